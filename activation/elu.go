@@ -0,0 +1,50 @@
+package activation
+
+import (
+	"math"
+
+	. "github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
+)
+
+// ELU, or Exponential Linear Unit, is a smooth variation of ReLU, which saturates
+// to -α for the large negative values.
+//
+//	ELU(x) = x if x >= 0 else α*(exp(x) - 1)
+//	dELU/dx = 1 if x >= 0 else α*exp(x)
+//
+// If Alpha is not set, it is initialized to 1.
+type ELU[T Float] struct {
+	Alpha float64
+}
+
+func (e ELU[T]) alpha() float64 {
+	if e.Alpha == 0 {
+		return 1
+	}
+	return e.Alpha
+}
+
+func (e ELU[T]) Apply(x T) T {
+	if x >= 0 {
+		return x
+	}
+	return T(e.alpha() * math.Expm1(float64(x)))
+}
+
+func (e ELU[T]) ApplyMatrix(M Matrix[T]) {
+	ApplyByElement(M, e.Apply)
+}
+
+func (e ELU[T]) Derivative(x T) T {
+	if x >= 0 {
+		return 1
+	}
+	return T(e.alpha() * math.Exp(float64(x)))
+}
+
+func (e ELU[T]) DerivativeMatrix(M Matrix[T]) Matrix[T] {
+	result := M.DeepCopy()
+	ApplyByElement(result, e.Derivative)
+	return result
+}
@@ -0,0 +1,40 @@
+package activation
+
+import (
+	"math"
+
+	. "github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
+)
+
+// GELU, or Gaussian Error Linear Unit, weights the input by the probability of
+// the standard normal variable being less than it. Used mainly in the Transformers.
+//
+//	GELU(x) = x * Φ(x), where Φ(x) = (1 + erf(x / sqrt(2))) / 2
+//	dGELU/dx = Φ(x) + x * φ(x), where φ(x) = exp(-x^2 / 2) / sqrt(2π)
+type GELU[T Float] struct{}
+
+func (g GELU[T]) Apply(x T) T {
+	v := float64(x)
+	return T(v * normalCDF(v))
+}
+
+func (g GELU[T]) ApplyMatrix(M Matrix[T]) {
+	ApplyByElement(M, g.Apply)
+}
+
+func (g GELU[T]) Derivative(x T) T {
+	v := float64(x)
+	return T(normalCDF(v) + v*math.Exp(-v*v/2)/math.Sqrt(2*math.Pi))
+}
+
+func (g GELU[T]) DerivativeMatrix(M Matrix[T]) Matrix[T] {
+	result := M.DeepCopy()
+	ApplyByElement(result, g.Derivative)
+	return result
+}
+
+// normalCDF is the cumulative distribution function of the standard normal distribution.
+func normalCDF(x float64) float64 {
+	return (1 + math.Erf(x/math.Sqrt2)) / 2
+}
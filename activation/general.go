@@ -8,7 +8,9 @@ package activation
 import (
 	"fmt"
 
+	"github.com/Hukyl/mlgo/internal/registry"
 	. "github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
 )
 
 // ActivationFunction is the interface for the output for the entire layer in
@@ -28,29 +30,142 @@ import (
 //
 // DerivativeMatrix produces a derivative matrix. As some
 // activation functions are vector functions, this function may use the whole matrix.
-type ActivationFunction interface {
-	Apply(float64) float64
-	ApplyMatrix(Matrix[float64])
+type ActivationFunction[T Float] interface {
+	Apply(T) T
+	ApplyMatrix(Matrix[T])
 
-	Derivative(float64) float64
-	DerivativeMatrix(Matrix[float64]) Matrix[float64]
+	Derivative(T) T
+	DerivativeMatrix(Matrix[T]) Matrix[T]
 }
 
-var activationMap = map[string]func() ActivationFunction{
-	"Linear":         func() ActivationFunction { return Linear{} },
-	"Sigmoid":        func() ActivationFunction { return Sigmoid{} },
-	"ReLU":           func() ActivationFunction { return ReLU{} },
-	"SELU":           func() ActivationFunction { return SELU{} },
-	"Softmax":        func() ActivationFunction { return Softmax{} },
-	"SoftmaxWithCCE": func() ActivationFunction { return SoftmaxWithCCE{} },
+// VectorActivation is implemented by the vector activation functions (e.g. Softmax),
+// the derivative of which is a Jacobian matrix for each sample, rather than
+// a single value for each element.
+//
+// JacobianProduct propagates the gradient dA of the output A = f(Z) to the input Z,
+// i.e. multiplies the Jacobian of each column of A by the corresponding column of dA.
+type VectorActivation[T Float] interface {
+	ActivationFunction[T]
+
+	JacobianProduct(Z, A, dA Matrix[T]) Matrix[T]
+}
+
+// Backward propagates the gradient dA of the output A = f(Z) of the activation function
+// to its input Z. The Jacobian product is used for the vector functions, while for
+// the rest the gradient is multiplied elementwise by DerivativeMatrix(Z).
+func Backward[T Float](f ActivationFunction[T], Z, A, dA Matrix[T]) Matrix[T] {
+	if v, ok := f.(VectorActivation[T]); ok {
+		return v.JacobianProduct(Z, A, dA)
+	}
+	result, _ := dA.MultiplyElementwise(f.DerivativeMatrix(Z))
+	return result
+}
+
+// LearnableActivation is implemented by the activation functions with trainable
+// parameters (e.g. PReLU), which are updated by the layer together with its weights.
+//
+// Parameters returns the pointers to the parameters, so that the optimizer can replace them.
+//
+// ParameterGradients produces the gradients of the parameters in the same order, given
+// the input Z and the gradient dA of the output. The gradients are summed over the samples.
+type LearnableActivation[T Float] interface {
+	ActivationFunction[T]
+
+	Parameters() []*Matrix[T]
+	ParameterGradients(Z, dA Matrix[T]) []Matrix[T]
 }
 
-// DynamicActivation returns the activation function based on the name.
+// DynamicActivation returns the activation function based on the name, which is either
+// one of the functions of the package, or registered by RegisterActivation.
 // Identical to importing and initializing the activation function directly.
-func DynamicActivation(activationName string) (ActivationFunction, error) {
-	f, ok := activationMap[activationName]
+func DynamicActivation[T Float](activationName string) (ActivationFunction[T], error) {
+	if f := builtinActivation[T](activationName); f != nil {
+		return f, nil
+	}
+	factory, ok := registry.Lookup[T, func() ActivationFunction[T]](&registeredActivations, activationName)
 	if !ok {
 		return nil, fmt.Errorf("unknown activation function: %s", activationName)
 	}
-	return f(), nil
+	return factory(), nil
+}
+
+// builtinActivation returns the activation function of the package by its name,
+// or nil if there is no such function.
+func builtinActivation[T Float](activationName string) ActivationFunction[T] {
+	var f ActivationFunction[T]
+	switch activationName {
+	case "Linear":
+		f = Linear[T]{}
+	case "Sigmoid":
+		f = Sigmoid[T]{}
+	case "ReLU":
+		f = ReLU[T]{}
+	case "SELU":
+		f = SELU[T]{}
+	case "Softmax":
+		f = Softmax[T]{}
+	case "SoftmaxWithCCE":
+		f = SoftmaxWithCCE[T]{}
+	case "Tanh":
+		f = Tanh[T]{}
+	case "LeakyReLU":
+		f = LeakyReLU[T]{}
+	case "ELU":
+		f = ELU[T]{}
+	case "GELU":
+		f = GELU[T]{}
+	case "Swish", "SiLU":
+		f = Swish[T]{}
+	case "Mish":
+		f = Mish[T]{}
+	case "Softplus":
+		f = Softplus[T]{}
+	case "Softsign":
+		f = Softsign[T]{}
+	case "HardSigmoid":
+		f = HardSigmoid[T]{}
+	case "PReLU":
+		f = NewPReLU[T](1, 0)
+	}
+	return f
+}
+
+// registeredActivations contains the activation functions, registered by RegisterActivation.
+var registeredActivations registry.Registry
+
+// RegisterActivation makes an activation function, defined outside the package, available
+// by its name in DynamicActivation and UnmarshalActivation, so that a model with it can be
+// loaded from JSON. The name has to be the name of the type (without the type arguments),
+// as returned by Name. The factory produces the function with the default parameters,
+// which are then replaced by the ones decoded from JSON.
+//
+// The function is registered only for the float type T. Panics if the name is already
+// used for T, or the factory is nil.
+func RegisterActivation[T Float](name string, factory func() ActivationFunction[T]) {
+	if factory == nil {
+		panic("activation: RegisterActivation factory is nil")
+	}
+	if builtinActivation[T](name) != nil || !registry.Register[T](&registeredActivations, name, factory) {
+		panic(fmt.Sprintf("activation: RegisterActivation called twice for %s", name))
+	}
+}
+
+// Name returns the name of the activation function, by which DynamicActivation
+// produces it, e.g. "ReLU" for ReLU[float32].
+func Name[T Float](f ActivationFunction[T]) string {
+	return registry.Name(f)
+}
+
+// MarshalActivation encodes the activation function to JSON. The functions without
+// parameters are encoded just by their name, while the rest (e.g. LeakyReLU) are
+// encoded as an object with the name in the "Type" field, and the parameters.
+func MarshalActivation[T Float](f ActivationFunction[T]) ([]byte, error) {
+	return registry.Marshal(f)
+}
+
+// UnmarshalActivation decodes the activation function, encoded by MarshalActivation.
+//
+// Returns error if the function is unknown, or its parameters are invalid.
+func UnmarshalActivation[T Float](data []byte) (ActivationFunction[T], error) {
+	return registry.Unmarshal(data, DynamicActivation[T])
 }
@@ -0,0 +1,173 @@
+package activation_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Hukyl/mlgo/activation"
+	"github.com/Hukyl/mlgo/matrix"
+)
+
+func TestApply(t *testing.T) {
+	testCases := []struct {
+		desc string
+		f    activation.ActivationFunction[float64]
+		x    float64
+		want float64
+	}{
+		{desc: "tanh", f: activation.Tanh[float64]{}, x: 1, want: math.Tanh(1)},
+		{desc: "leaky-relu-default", f: activation.LeakyReLU[float64]{}, x: -2, want: -0.02},
+		{desc: "leaky-relu-alpha", f: activation.LeakyReLU[float64]{Alpha: 0.3}, x: -2, want: -0.6},
+		{desc: "elu", f: activation.ELU[float64]{Alpha: 2}, x: -1, want: 2 * (math.Exp(-1) - 1)},
+		{desc: "gelu-zero", f: activation.GELU[float64]{}, x: 0, want: 0},
+		{desc: "gelu", f: activation.GELU[float64]{}, x: 1, want: 0.8413447460685429},
+		{desc: "swish", f: activation.Swish[float64]{}, x: 1, want: 1 / (1 + math.Exp(-1))},
+		{desc: "mish", f: activation.Mish[float64]{}, x: 1, want: math.Tanh(math.Log(1 + math.E))},
+		{desc: "softplus-large", f: activation.Softplus[float64]{}, x: 1000, want: 1000},
+		{desc: "softplus", f: activation.Softplus[float64]{}, x: 0, want: math.Ln2},
+		{desc: "softsign", f: activation.Softsign[float64]{}, x: -3, want: -0.75},
+		{desc: "hard-sigmoid-saturated", f: activation.HardSigmoid[float64]{}, x: 4, want: 1},
+		{desc: "hard-sigmoid", f: activation.HardSigmoid[float64]{}, x: 1.5, want: 0.75},
+		{desc: "prelu", f: activation.NewPReLU[float64](1, 0), x: -2, want: -0.5},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Act
+			got := tC.f.Apply(tC.x)
+
+			// Assert
+			if math.Abs(got-tC.want) > 1e-10 {
+				t.Errorf("Apply(%v) = %v, want %v", tC.x, got, tC.want)
+			}
+		})
+	}
+}
+
+func TestDynamicActivation_NewNames(t *testing.T) {
+	names := []string{
+		"Tanh", "LeakyReLU", "ELU", "GELU", "Swish", "SiLU",
+		"Mish", "Softplus", "Softsign", "HardSigmoid", "PReLU",
+	}
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			// Act
+			f, err := activation.DynamicActivation[float64](name)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("DynamicActivation(%q) error = %v", name, err)
+			}
+			if got := activation.Name(f); got != name && name != "SiLU" {
+				t.Errorf("Name() = %q, want %q", got, name)
+			}
+		})
+	}
+}
+
+func TestMarshalActivation(t *testing.T) {
+	alpha, _ := matrix.NewMatrix([][]float64{{0.1}, {0.2}})
+	testCases := []struct {
+		desc     string
+		f        activation.ActivationFunction[float64]
+		wantJSON string
+	}{
+		{desc: "no-parameters", f: activation.ReLU[float64]{}, wantJSON: `"ReLU"`},
+		{desc: "leaky-relu", f: activation.LeakyReLU[float64]{Alpha: 0.3}, wantJSON: `{"Alpha":0.3,"Type":"LeakyReLU"}`},
+		{desc: "swish", f: activation.Swish[float64]{Beta: 2}, wantJSON: `{"Beta":2,"Type":"Swish"}`},
+		{desc: "prelu", f: &activation.PReLU[float64]{Alpha: alpha}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Act
+			data, err := activation.MarshalActivation(tC.f)
+			if err != nil {
+				t.Fatalf("MarshalActivation() error = %v", err)
+			}
+			got, err := activation.UnmarshalActivation[float64](data)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("UnmarshalActivation(%s) error = %v", data, err)
+			}
+			if tC.wantJSON != "" && string(data) != tC.wantJSON {
+				t.Errorf("MarshalActivation() = %s, want %s", data, tC.wantJSON)
+			}
+			Z, _ := matrix.NewMatrix([][]float64{{-1, 2}, {-3, 0}})
+			want, gotA := Z.DeepCopy(), Z.DeepCopy()
+			tC.f.ApplyMatrix(want)
+			got.ApplyMatrix(gotA)
+			if !gotA.Equals(want) {
+				t.Errorf("decoded ApplyMatrix() = %v, want %v", gotA, want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalActivation_Invalid(t *testing.T) {
+	testCases := []struct {
+		desc string
+		data string
+	}{
+		{desc: "unknown-name", data: `"Unknown"`},
+		{desc: "unknown-type", data: `{"Type":"Unknown"}`},
+		{desc: "invalid-parameters", data: `{"Type":"LeakyReLU","Alpha":"x"}`},
+		{desc: "prelu-without-alpha", data: `{"Type":"PReLU"}`},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Act
+			_, err := activation.UnmarshalActivation[float64]([]byte(tC.data))
+
+			// Assert
+			if err == nil {
+				t.Errorf("UnmarshalActivation(%s) error = nil, want error", tC.data)
+			}
+		})
+	}
+}
+
+func TestPReLU_ParameterGradients(t *testing.T) {
+	// Arrange
+	p := activation.NewPReLU[float64](2, 0)
+	shared := activation.NewPReLU[float64](1, 0)
+	Z, _ := matrix.NewMatrix([][]float64{{-1, 2}, {-3, -2}})
+	dA, _ := matrix.NewMatrix([][]float64{{2, 5}, {1, 3}})
+
+	// Act
+	got := p.ParameterGradients(Z, dA)[0]
+	gotShared := shared.ParameterGradients(Z, dA)[0]
+
+	// Assert
+	want, _ := matrix.NewMatrix([][]float64{{-2}, {-9}})
+	wantShared, _ := matrix.NewMatrix([][]float64{{-11}})
+	if !got.Equals(want) {
+		t.Errorf("ParameterGradients() = %v, want %v", got, want)
+	}
+	if !gotShared.Equals(wantShared) {
+		t.Errorf("shared ParameterGradients() = %v, want %v", gotShared, wantShared)
+	}
+}
+
+func TestRegisterActivation_Duplicate(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		name    string
+		factory func() activation.ActivationFunction[float64]
+	}{
+		{desc: "built-in", name: "ReLU", factory: func() activation.ActivationFunction[float64] { return activation.ReLU[float64]{} }},
+		{desc: "nil-factory", name: "Nil", factory: nil},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Assert
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RegisterActivation(%q) did not panic", tC.name)
+				}
+			}()
+
+			// Act
+			activation.RegisterActivation(tC.name, tC.factory)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package activation
+
+import (
+	. "github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
+)
+
+// HardSigmoid is a piecewise linear approximation of Sigmoid, which is cheaper to compute.
+//
+//	HardSigmoid(x) = 0 if x <= -3, 1 if x >= 3, else x/6 + 1/2
+//	dHardSigmoid/dx = 1/6 if -3 < x < 3 else 0
+type HardSigmoid[T Float] struct{}
+
+func (h HardSigmoid[T]) Apply(x T) T {
+	return min(max(x/6+0.5, 0), 1)
+}
+
+func (h HardSigmoid[T]) ApplyMatrix(M Matrix[T]) {
+	ApplyByElement(M, h.Apply)
+}
+
+func (h HardSigmoid[T]) Derivative(x T) T {
+	if x > -3 && x < 3 {
+		return T(1) / 6
+	}
+	return 0
+}
+
+func (h HardSigmoid[T]) DerivativeMatrix(M Matrix[T]) Matrix[T] {
+	result := M.DeepCopy()
+	ApplyByElement(result, h.Derivative)
+	return result
+}
@@ -0,0 +1,50 @@
+package activation
+
+import (
+	. "github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
+)
+
+const defaultLeakyReLUAlpha = 0.01
+
+// LeakyReLU is a variation of ReLU, which propagates a small fraction of the negative
+// values, so that the neurons do not "die" with the zero gradient.
+//
+//	LeakyReLU(x) = x if x >= 0 else α*x
+//	dLeakyReLU/dx = 1 if x >= 0 else α
+//
+// If Alpha is not set, it is initialized to 0.01.
+type LeakyReLU[T Float] struct {
+	Alpha float64
+}
+
+func (r LeakyReLU[T]) alpha() T {
+	if r.Alpha == 0 {
+		return defaultLeakyReLUAlpha
+	}
+	return T(r.Alpha)
+}
+
+func (r LeakyReLU[T]) Apply(x T) T {
+	if x >= 0 {
+		return x
+	}
+	return r.alpha() * x
+}
+
+func (r LeakyReLU[T]) ApplyMatrix(M Matrix[T]) {
+	ApplyByElement(M, r.Apply)
+}
+
+func (r LeakyReLU[T]) Derivative(x T) T {
+	if x >= 0 {
+		return 1
+	}
+	return r.alpha()
+}
+
+func (r LeakyReLU[T]) DerivativeMatrix(M Matrix[T]) Matrix[T] {
+	result := M.DeepCopy()
+	ApplyByElement(result, r.Derivative)
+	return result
+}
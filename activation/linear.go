@@ -2,24 +2,25 @@ package activation
 
 import (
 	. "github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
 )
 
 // Linear is a linear activation function, which does not modify the input.
 //
 //	Linear(x) = x
 //	dLinear/dx = 1
-type Linear struct{}
+type Linear[T Float] struct{}
 
-func (l Linear) Apply(x float64) float64 {
+func (l Linear[T]) Apply(x T) T {
 	return x
 }
 
-func (l Linear) ApplyMatrix(M Matrix[float64]) {}
+func (l Linear[T]) ApplyMatrix(M Matrix[T]) {}
 
-func (l Linear) Derivative(x float64) float64 {
+func (l Linear[T]) Derivative(x T) T {
 	return 1.0
 }
 
-func (l Linear) DerivativeMatrix(m Matrix[float64]) Matrix[float64] {
-	return NewOnesMatrix(m.RowCount(), m.ColumnCount())
+func (l Linear[T]) DerivativeMatrix(m Matrix[T]) Matrix[T] {
+	return NewOnesMatrix[T](m.RowCount(), m.ColumnCount())
 }
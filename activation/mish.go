@@ -0,0 +1,35 @@
+package activation
+
+import (
+	"math"
+
+	. "github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
+)
+
+// Mish is a smooth non-monotonic activation function, similar to Swish.
+//
+//	Mish(x) = x * Tanh(Softplus(x))
+//	dMish/dx = Tanh(Softplus(x)) + x * (1 - Tanh(Softplus(x))^2) * Sigmoid(x)
+type Mish[T Float] struct{}
+
+func (m Mish[T]) Apply(x T) T {
+	v := float64(x)
+	return T(v * math.Tanh(softplus(v)))
+}
+
+func (m Mish[T]) ApplyMatrix(M Matrix[T]) {
+	ApplyByElement(M, m.Apply)
+}
+
+func (m Mish[T]) Derivative(x T) T {
+	v := float64(x)
+	tanh := math.Tanh(softplus(v))
+	return T(tanh + v*(1-tanh*tanh)/(1+math.Exp(-v)))
+}
+
+func (m Mish[T]) DerivativeMatrix(M Matrix[T]) Matrix[T] {
+	result := M.DeepCopy()
+	ApplyByElement(result, m.Derivative)
+	return result
+}
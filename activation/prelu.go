@@ -0,0 +1,126 @@
+package activation
+
+import (
+	"encoding/json"
+	"errors"
+
+	. "github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
+)
+
+const defaultPReLUAlpha = 0.25
+
+// PReLU, or Parametric ReLU, is a variation of LeakyReLU, the slope α of which for
+// the negative values is learned together with the weights of the layer.
+//
+//	PReLU(x_i) = x_i if x_i >= 0 else α_i*x_i
+//	dPReLU/dx_i = 1 if x_i >= 0 else α_i
+//	dPReLU/dα_i = 0 if x_i >= 0 else x_i
+//
+// Alpha is a column with either a single slope, shared between all the neurons,
+// or a separate slope for each neuron (i.e. row of the input matrix).
+//
+// Apply() and Derivative() use the first slope, as the neuron of a scalar is unknown.
+type PReLU[T Float] struct {
+	Alpha Matrix[T]
+}
+
+// NewPReLU produces a PReLU with the slopes for the given number of neurons, initialized
+// to alpha. If size is 1, the slope is shared between all the neurons. If alpha is not set,
+// it is initialized to 0.25.
+func NewPReLU[T Float](size int, alpha float64) *PReLU[T] {
+	if alpha == 0 {
+		alpha = defaultPReLUAlpha
+	}
+	return &PReLU[T]{Alpha: NewOnesMatrix[T](max(size, 1), 1).MultiplyByScalar(T(alpha))}
+}
+
+// alphaAt returns the slope for the i-th neuron.
+func (p *PReLU[T]) alphaAt(i int) T {
+	if p.Alpha.RowCount() == 1 {
+		i = 0
+	}
+	a, _ := p.Alpha.At(i, 0)
+	return a
+}
+
+func (p *PReLU[T]) Apply(x T) T {
+	if x >= 0 {
+		return x
+	}
+	return p.alphaAt(0) * x
+}
+
+func (p *PReLU[T]) ApplyMatrix(M Matrix[T]) {
+	for i := 0; i < M.RowCount(); i++ {
+		a := p.alphaAt(i)
+		for j := 0; j < M.ColumnCount(); j++ {
+			if x, _ := M.At(i, j); x < 0 {
+				M.Set(i, j, a*x)
+			}
+		}
+	}
+}
+
+func (p *PReLU[T]) Derivative(x T) T {
+	if x >= 0 {
+		return 1
+	}
+	return p.alphaAt(0)
+}
+
+func (p *PReLU[T]) DerivativeMatrix(M Matrix[T]) Matrix[T] {
+	result := M.DeepCopy()
+	for i := 0; i < result.RowCount(); i++ {
+		a := p.alphaAt(i)
+		for j := 0; j < result.ColumnCount(); j++ {
+			if x, _ := result.At(i, j); x < 0 {
+				result.Set(i, j, a)
+			} else {
+				result.Set(i, j, 1)
+			}
+		}
+	}
+	return result
+}
+
+func (p *PReLU[T]) Parameters() []*Matrix[T] {
+	return []*Matrix[T]{&p.Alpha}
+}
+
+// ParameterGradients produces the gradient of the slopes, summed over the samples
+// (and over the neurons, if the slope is shared).
+func (p *PReLU[T]) ParameterGradients(Z, dA Matrix[T]) []Matrix[T] {
+	result := NewZeroMatrix[T](p.Alpha.RowCount(), 1)
+	for i := 0; i < Z.RowCount(); i++ {
+		row := i
+		if p.Alpha.RowCount() == 1 {
+			row = 0
+		}
+		sum, _ := result.At(row, 0)
+		for j := 0; j < Z.ColumnCount(); j++ {
+			if z, _ := Z.At(i, j); z < 0 {
+				g, _ := dA.At(i, j)
+				sum += g * z
+			}
+		}
+		result.Set(row, 0, sum)
+	}
+	return []Matrix[T]{result}
+}
+
+func (p *PReLU[T]) UnmarshalJSON(data []byte) error {
+	var v map[string]json.RawMessage
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	a, _ := NewMatrix([][]T{{}})
+	if err := a.UnmarshalJSON(v["Alpha"]); err != nil {
+		return errors.Join(errors.New("invalid alpha initializing"), err)
+	}
+	if a.ColumnCount() != 1 {
+		return errors.New("alpha must be a column")
+	}
+	p.Alpha = a
+	return nil
+}
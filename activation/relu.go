@@ -1,9 +1,8 @@
 package activation
 
 import (
-	"math"
-
 	. "github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
 )
 
 // ReLU, or Rectified Linear Unit, is an activation function which works similarly to
@@ -15,24 +14,24 @@ import (
 // Computation of derivative in such manner is mathematically incorrect, as ReLU is
 // not differentiable at x = 0, but in practice either 1 or 0 (1 in case of this implementation)
 // is used.
-type ReLU struct{}
+type ReLU[T Float] struct{}
 
-func (r ReLU) Apply(x float64) float64 {
-	return math.Max(x, 0)
+func (r ReLU[T]) Apply(x T) T {
+	return max(x, 0)
 }
 
-func (r ReLU) ApplyMatrix(M Matrix[float64]) {
+func (r ReLU[T]) ApplyMatrix(M Matrix[T]) {
 	ApplyByElement(M, r.Apply)
 }
 
-func (r ReLU) Derivative(x float64) float64 {
+func (r ReLU[T]) Derivative(x T) T {
 	if x >= 0 {
 		return 1.0
 	}
 	return 0.0
 }
 
-func (r ReLU) DerivativeMatrix(M Matrix[float64]) Matrix[float64] {
+func (r ReLU[T]) DerivativeMatrix(M Matrix[T]) Matrix[T] {
 	result := M.DeepCopy()
 	ApplyByElement(result, r.Derivative)
 	return result
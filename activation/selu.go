@@ -4,6 +4,7 @@ import (
 	"math"
 
 	. "github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
 )
 
 const lambda = 1.0507
@@ -18,28 +19,28 @@ const alpha = 1.6733
 // Where:
 //
 //	λ ≈ 1.0507, α ≈ 1.6733
-type SELU struct{}
+type SELU[T Float] struct{}
 
-func (s SELU) Apply(x float64) float64 {
+func (s SELU[T]) Apply(x T) T {
 	if x >= 0 {
 		return lambda * x
 	} else {
-		return lambda * alpha * (math.Exp(x) - 1)
+		return lambda * alpha * T(math.Exp(float64(x))-1)
 	}
 }
 
-func (s SELU) ApplyMatrix(m Matrix[float64]) {
+func (s SELU[T]) ApplyMatrix(m Matrix[T]) {
 	ApplyByElement(m, s.Apply)
 }
 
-func (s SELU) Derivative(x float64) float64 {
+func (s SELU[T]) Derivative(x T) T {
 	if x >= 0 {
 		return lambda
 	}
-	return lambda * alpha * math.Exp(x)
+	return lambda * alpha * T(math.Exp(float64(x)))
 }
 
-func (s SELU) DerivativeMatrix(m Matrix[float64]) Matrix[float64] {
+func (s SELU[T]) DerivativeMatrix(m Matrix[T]) Matrix[T] {
 	result := m.DeepCopy()
 	ApplyByElement(result, s.Derivative)
 	return result
@@ -4,6 +4,7 @@ import (
 	"math"
 
 	"github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
 )
 
 // Sigmoid is a continuous non-linear activation function which maps
@@ -11,22 +12,22 @@ import (
 //
 //	Sigmoid(x) = 1 / (1 + exp(-x))
 //	dSigmoid/dx = Sigmoid(x) * (1 - Sigmoid(x))
-type Sigmoid struct{}
+type Sigmoid[T Float] struct{}
 
-func (s Sigmoid) Apply(z float64) float64 {
-	return 1 / (1 + math.Exp(-z))
+func (s Sigmoid[T]) Apply(z T) T {
+	return T(1 / (1 + math.Exp(-float64(z))))
 }
 
-func (s Sigmoid) ApplyMatrix(M matrix.Matrix[float64]) {
+func (s Sigmoid[T]) ApplyMatrix(M matrix.Matrix[T]) {
 	matrix.ApplyByElement(M, s.Apply)
 }
 
-func (s Sigmoid) Derivative(x float64) float64 {
+func (s Sigmoid[T]) Derivative(x T) T {
 	sigm := s.Apply(x)
 	return sigm * (1 - sigm)
 }
 
-func (s Sigmoid) DerivativeMatrix(M matrix.Matrix[float64]) matrix.Matrix[float64] {
+func (s Sigmoid[T]) DerivativeMatrix(M matrix.Matrix[T]) matrix.Matrix[T] {
 	result := M.DeepCopy()
 	matrix.ApplyByElement(result, s.Derivative)
 	return result
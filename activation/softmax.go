@@ -4,6 +4,7 @@ import (
 	"math"
 
 	. "github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
 )
 
 // Softmax is a probability-generative vector activation function, i.e.
@@ -15,45 +16,63 @@ import (
 //
 // As Softmax is a *vector* function, Apply() and Derivative() methods return NaN.
 //
-// Due to implementation of the matrix class, and the fact that dSoftmax/dx returns
-// a Jacobian matrix (i.e. a 3D-tensor for a list of vecotrs),
-// the DerivativeMatrix() is not implemented and has to be overriden.
-type Softmax struct{}
+// As dSoftmax/dx is a Jacobian matrix (i.e. a 3D-tensor for a list of vectors),
+// the DerivativeMatrix() returns only its diagonal, and the gradient is propagated by
+// JacobianProduct() instead. Therefore, Softmax can be used with any loss function.
+type Softmax[T Float] struct{}
 
-func (s Softmax) Apply(z float64) float64 {
-	return math.NaN()
+func (s Softmax[T]) Apply(z T) T {
+	return T(math.NaN())
 }
 
-func (s Softmax) ApplyMatrix(M Matrix[float64]) {
+func (s Softmax[T]) ApplyMatrix(M Matrix[T]) {
 	for j := 0; j < M.ColumnCount(); j++ {
-		// Find column max for numerical stability
-		maxVal := math.Inf(-1)
+		column, _ := M.Col(j)
+		// Subtract column max for numerical stability
+		maxValue, _ := Max(column, AllElements).At(0, 0)
+		exponents := Exp(column.AddScalar(-maxValue))
+		sumExponents, _ := Sum(exponents, AllElements).At(0, 0)
 		for i := 0; i < M.RowCount(); i++ {
-			z, _ := M.At(i, j)
-			if z > maxVal {
-				maxVal = z
-			}
-		}
-
-		exponents := make([]float64, M.RowCount())
-		sumExponents := float64(0.0)
-		for i := 0; i < M.RowCount(); i++ {
-			z, _ := M.At(i, j)
-			exponents[i] = math.Exp(z - maxVal)
-			sumExponents += exponents[i]
-		}
-		for i := 0; i < M.RowCount(); i++ {
-			M.Set(i, j, exponents[i]/sumExponents)
+			e, _ := exponents.At(i, 0)
+			M.Set(i, j, e/sumExponents)
 		}
 	}
 }
 
-func (s Softmax) Derivative(x float64) float64 {
-	return math.NaN()
+func (s Softmax[T]) Derivative(x T) T {
+	return T(math.NaN())
 }
 
-func (s Softmax) DerivativeMatrix(M Matrix[float64]) Matrix[float64] {
-	panic("not implemented")
+// DerivativeMatrix returns the diagonal of the Jacobian for each column of M,
+//
+//	dSoftmax(x)_j/dx_j = A_j * (1 - A_j), where A = Softmax(x)
+//
+// This is only a partial derivative, as the off-diagonal elements -A_i * A_j
+// are omitted, so it must not be used for the backpropagation (see JacobianProduct).
+func (s Softmax[T]) DerivativeMatrix(M Matrix[T]) Matrix[T] {
+	A := M.DeepCopy()
+	s.ApplyMatrix(A)
+	return Map(A, func(a T) T { return a * (1 - a) })
+}
+
+// JacobianProduct propagates the gradient dA of the output A = Softmax(Z) as
+//
+//	dZ = A * (dA - sum(A * dA) over the column)
+//
+// Z is not used, as the Jacobian depends only on the output.
+func (s Softmax[T]) JacobianProduct(Z, A, dA Matrix[T]) Matrix[T] {
+	product, _ := A.MultiplyElementwise(dA)
+	dots := Sum(product, PerColumn)
+	result := dA.DeepCopy()
+	for j := 0; j < result.ColumnCount(); j++ {
+		dot, _ := dots.At(0, j)
+		for i := 0; i < result.RowCount(); i++ {
+			a, _ := A.At(i, j)
+			g, _ := result.At(i, j)
+			result.Set(i, j, a*(g-dot))
+		}
+	}
+	return result
 }
 
 // SoftmaxWithCCE is an activation function, which is used only together with categorical
@@ -61,13 +80,20 @@ func (s Softmax) DerivativeMatrix(M Matrix[float64]) Matrix[float64] {
 // it does not produce a proper derivative, but instead relies fully on
 // the derivative of categorical cross-entropy loss function.
 //
-// IMPORTANT: should be only used with CategoricalCrossEntropyLossWithSoftmax loss function!
+// IMPORTANT: should be only used with CCELossWithSoftmax loss function! The pairing
+// is checked when the neural network is created.
 //
 // As SoftmaxWithCCE is a *vector* function, Apply() and Derivative() methods return NaN.
-type SoftmaxWithCCE struct {
-	Softmax
+type SoftmaxWithCCE[T Float] struct {
+	Softmax[T]
+}
+
+func (s SoftmaxWithCCE[T]) DerivativeMatrix(M Matrix[T]) Matrix[T] {
+	return NewOnesMatrix[T](M.RowCount(), M.ColumnCount())
 }
 
-func (s SoftmaxWithCCE) DerivativeMatrix(M Matrix[float64]) Matrix[float64] {
-	return NewOnesMatrix(M.RowCount(), M.ColumnCount())
+// JacobianProduct passes the gradient through, as it is already the gradient
+// with respect to the input of Softmax, computed by CCELossWithSoftmax.
+func (s SoftmaxWithCCE[T]) JacobianProduct(Z, A, dA Matrix[T]) Matrix[T] {
+	return dA.DeepCopy()
 }
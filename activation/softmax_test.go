@@ -40,7 +40,7 @@ func TestSoftmaxApplyMatrix(t *testing.T) {
 			wantM, _ := matrix.NewMatrix(tC.want)
 
 			// Act
-			activation.Softmax{}.ApplyMatrix(m)
+			activation.Softmax[float64]{}.ApplyMatrix(m)
 
 			// Assert
 			for i := 0; i < m.RowCount(); i++ {
@@ -62,7 +62,7 @@ func TestSoftmaxApplyMatrix_SumsToOne(t *testing.T) {
 	m, _ := matrix.NewMatrix(input)
 
 	// Act
-	activation.Softmax{}.ApplyMatrix(m)
+	activation.Softmax[float64]{}.ApplyMatrix(m)
 
 	// Assert
 	for j := 0; j < m.ColumnCount(); j++ {
@@ -83,7 +83,7 @@ func TestSoftmaxApplyMatrix_LargeValues(t *testing.T) {
 	m, _ := matrix.NewMatrix(input)
 
 	// Act
-	activation.Softmax{}.ApplyMatrix(m)
+	activation.Softmax[float64]{}.ApplyMatrix(m)
 
 	// Assert
 	sum := 0.0
@@ -108,7 +108,7 @@ func TestSoftmaxApplyMatrix_NegativeLargeValues(t *testing.T) {
 	m, _ := matrix.NewMatrix(input)
 
 	// Act
-	activation.Softmax{}.ApplyMatrix(m)
+	activation.Softmax[float64]{}.ApplyMatrix(m)
 
 	// Assert
 	sum := 0.0
@@ -130,7 +130,7 @@ func TestSoftmaxWithCCEApplyMatrix_LargeValues(t *testing.T) {
 	m, _ := matrix.NewMatrix(input)
 
 	// Act
-	activation.SoftmaxWithCCE{}.ApplyMatrix(m)
+	activation.SoftmaxWithCCE[float64]{}.ApplyMatrix(m)
 
 	// Assert
 	for i := 0; i < m.RowCount(); i++ {
@@ -140,3 +140,71 @@ func TestSoftmaxWithCCEApplyMatrix_LargeValues(t *testing.T) {
 		}
 	}
 }
+
+func TestSoftmaxJacobianProduct(t *testing.T) {
+	// Arrange
+	Z, _ := matrix.NewMatrix([][]float64{{1.0, -2.0}, {2.0, 0.5}, {3.0, 1.0}})
+	dA, _ := matrix.NewMatrix([][]float64{{0.5, -1.0}, {-0.3, 2.0}, {1.0, 0.1}})
+	A := Z.DeepCopy()
+	activation.Softmax[float64]{}.ApplyMatrix(A)
+
+	// Act
+	got := activation.Backward[float64](activation.Softmax[float64]{}, Z, A, dA)
+
+	// Assert - dZ_i = sum(J_ki * dA_k, k), where J_ki = A_k * (δ_ki - A_i)
+	for j := 0; j < A.ColumnCount(); j++ {
+		for i := 0; i < A.RowCount(); i++ {
+			want := 0.0
+			ai, _ := A.At(i, j)
+			for k := 0; k < A.RowCount(); k++ {
+				ak, _ := A.At(k, j)
+				g, _ := dA.At(k, j)
+				delta := 0.0
+				if k == i {
+					delta = 1
+				}
+				want += ak * (delta - ai) * g
+			}
+			if v, _ := got.At(i, j); math.Abs(v-want) > 1e-12 {
+				t.Errorf("At(%d,%d): got %v, want %v", i, j, v, want)
+			}
+		}
+	}
+}
+
+func TestSoftmaxWithCCEJacobianProduct(t *testing.T) {
+	// Arrange
+	Z, _ := matrix.NewMatrix([][]float64{{1.0}, {2.0}})
+	dA, _ := matrix.NewMatrix([][]float64{{0.25}, {-0.25}})
+
+	// Act
+	got := activation.Backward[float64](activation.SoftmaxWithCCE[float64]{}, Z, Z, dA)
+
+	// Assert - the gradient of CCELossWithSoftmax is passed through
+	if !got.Equals(dA) {
+		t.Errorf("got %v, want %v", got, dA)
+	}
+}
+
+func TestSoftmaxDerivativeMatrix_JacobianDiagonal(t *testing.T) {
+	// Arrange
+	Z, _ := matrix.NewMatrix([][]float64{{1.0, -2.0}, {2.0, 0.5}, {3.0, 1.0}})
+	A := Z.DeepCopy()
+	activation.Softmax[float64]{}.ApplyMatrix(A)
+
+	// Act
+	got := activation.Softmax[float64]{}.DerivativeMatrix(Z)
+
+	// Assert
+	for i := 0; i < Z.RowCount(); i++ {
+		for j := 0; j < Z.ColumnCount(); j++ {
+			a, _ := A.At(i, j)
+			if v, _ := got.At(i, j); math.Abs(v-a*(1-a)) > 1e-12 {
+				t.Errorf("At(%d,%d): got %v, want %v", i, j, v, a*(1-a))
+			}
+		}
+	}
+	if v, _ := Z.At(0, 0); v != 1.0 {
+		t.Errorf("input modified: At(0,0) = %v, want 1", v)
+	}
+}
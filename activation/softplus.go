@@ -0,0 +1,38 @@
+package activation
+
+import (
+	"math"
+
+	. "github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
+)
+
+// Softplus is a smooth approximation of ReLU, which maps the rational numbers
+// to (0;+inf) range.
+//
+//	Softplus(x) = log(1 + exp(x))
+//	dSoftplus/dx = Sigmoid(x)
+type Softplus[T Float] struct{}
+
+func (s Softplus[T]) Apply(x T) T {
+	return T(softplus(float64(x)))
+}
+
+func (s Softplus[T]) ApplyMatrix(M Matrix[T]) {
+	ApplyByElement(M, s.Apply)
+}
+
+func (s Softplus[T]) Derivative(x T) T {
+	return Sigmoid[T]{}.Apply(x)
+}
+
+func (s Softplus[T]) DerivativeMatrix(M Matrix[T]) Matrix[T] {
+	result := M.DeepCopy()
+	ApplyByElement(result, s.Derivative)
+	return result
+}
+
+// softplus computes log(1 + exp(x)) without overflowing for the large x.
+func softplus(x float64) float64 {
+	return math.Max(x, 0) + math.Log1p(math.Exp(-math.Abs(x)))
+}
@@ -0,0 +1,32 @@
+package activation
+
+import (
+	. "github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
+)
+
+// Softsign is an alternative to Tanh, which maps the rational numbers to (-1;1)
+// range, approaching the limits polynomially rather than exponentially.
+//
+//	Softsign(x) = x / (1 + |x|)
+//	dSoftsign/dx = 1 / (1 + |x|)^2
+type Softsign[T Float] struct{}
+
+func (s Softsign[T]) Apply(x T) T {
+	return x / (1 + max(x, -x))
+}
+
+func (s Softsign[T]) ApplyMatrix(M Matrix[T]) {
+	ApplyByElement(M, s.Apply)
+}
+
+func (s Softsign[T]) Derivative(x T) T {
+	d := 1 + max(x, -x)
+	return 1 / (d * d)
+}
+
+func (s Softsign[T]) DerivativeMatrix(M Matrix[T]) Matrix[T] {
+	result := M.DeepCopy()
+	ApplyByElement(result, s.Derivative)
+	return result
+}
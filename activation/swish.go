@@ -0,0 +1,44 @@
+package activation
+
+import (
+	. "github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
+)
+
+// Swish is a smooth non-monotonic activation function, which is the input weighted
+// by its sigmoid. With β = 1 it is also known as SiLU (Sigmoid Linear Unit).
+//
+//	Swish(x) = x * Sigmoid(β*x)
+//	dSwish/dx = Sigmoid(β*x) + β*x * Sigmoid(β*x) * (1 - Sigmoid(β*x))
+//
+// If Beta is not set, it is initialized to 1.
+type Swish[T Float] struct {
+	Beta float64
+}
+
+func (s Swish[T]) beta() T {
+	if s.Beta == 0 {
+		return 1
+	}
+	return T(s.Beta)
+}
+
+func (s Swish[T]) Apply(x T) T {
+	return x * Sigmoid[T]{}.Apply(s.beta()*x)
+}
+
+func (s Swish[T]) ApplyMatrix(M Matrix[T]) {
+	ApplyByElement(M, s.Apply)
+}
+
+func (s Swish[T]) Derivative(x T) T {
+	beta := s.beta()
+	sigm := Sigmoid[T]{}.Apply(beta * x)
+	return sigm + beta*x*sigm*(1-sigm)
+}
+
+func (s Swish[T]) DerivativeMatrix(M Matrix[T]) Matrix[T] {
+	result := M.DeepCopy()
+	ApplyByElement(result, s.Derivative)
+	return result
+}
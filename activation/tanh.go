@@ -0,0 +1,34 @@
+package activation
+
+import (
+	"math"
+
+	. "github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
+)
+
+// Tanh is the hyperbolic tangent, which maps the rational numbers to (-1;1) range.
+// Unlike Sigmoid, its output is centered around zero.
+//
+//	Tanh(x) = (exp(x) - exp(-x)) / (exp(x) + exp(-x))
+//	dTanh/dx = 1 - Tanh(x)^2
+type Tanh[T Float] struct{}
+
+func (t Tanh[T]) Apply(x T) T {
+	return T(math.Tanh(float64(x)))
+}
+
+func (t Tanh[T]) ApplyMatrix(M Matrix[T]) {
+	ApplyByElement(M, t.Apply)
+}
+
+func (t Tanh[T]) Derivative(x T) T {
+	tanh := t.Apply(x)
+	return 1 - tanh*tanh
+}
+
+func (t Tanh[T]) DerivativeMatrix(M Matrix[T]) Matrix[T] {
+	result := M.DeepCopy()
+	ApplyByElement(result, t.Derivative)
+	return result
+}
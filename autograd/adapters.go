@@ -0,0 +1,98 @@
+package autograd
+
+import (
+	. "github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
+)
+
+// Activation is an activation function, defined as a composition of the operations on
+// variables, the derivative of which is computed automatically. It implements
+// activation.ActivationFunction, and therefore can be used in any layer, e.g.
+//
+//	swish := autograd.Activation[float32]{Function: func(z *autograd.Variable) *autograd.Variable {
+//		return z.Mul(z.Sigmoid())
+//	}}
+//
+// As the layers multiply the result of DerivativeMatrix elementwise, the function
+// has to be applied to each element separately, i.e. the output element (i, j) has
+// to depend only on the input element (i, j).
+//
+// As the variables are float64, the matrices are converted at the boundary of the function.
+type Activation[T Float] struct {
+	Function func(Z *Variable) *Variable
+}
+
+func (a Activation[T]) Apply(x T) T {
+	v, _ := a.Function(Scalar(float64(x))).Value().At(0, 0)
+	return T(v)
+}
+
+func (a Activation[T]) ApplyMatrix(M Matrix[T]) {
+	result := a.Function(Constant(Convert[float64](M))).Value()
+	for i := 0; i < M.RowCount(); i++ {
+		for j := 0; j < M.ColumnCount(); j++ {
+			v, _ := result.At(i, j)
+			M.Set(i, j, T(v))
+		}
+	}
+}
+
+func (a Activation[T]) Derivative(x T) T {
+	M := NewZeroMatrix[T](1, 1)
+	M.Set(0, 0, x)
+	v, _ := a.DerivativeMatrix(M).At(0, 0)
+	return v
+}
+
+func (a Activation[T]) DerivativeMatrix(M Matrix[T]) Matrix[T] {
+	Z := NewVariable(Convert[float64](M))
+	a.Function(Z).Backward()
+	if Z.Gradient() == nil {
+		return NewZeroMatrix[T](M.RowCount(), M.ColumnCount())
+	}
+	return Convert[T](Z.Gradient())
+}
+
+// Loss is a loss function, defined as a composition of the operations on variables,
+// the derivative of which is computed automatically. It implements loss.LossFunction,
+// and therefore can be used in any neural network, e.g.
+//
+//	absolute := autograd.Loss[float32]{Function: func(y, yHat *autograd.Variable) *autograd.Variable {
+//		return yHat.Sub(y).Abs()
+//	}}
+//
+// Function receives the label and prediction matrices, and returns the losses, e.g.
+// the loss of each element, or the 1xN matrix with the loss of each sample.
+//
+// As the variables are float64, the matrices are converted at the boundary of the function.
+type Loss[T Float] struct {
+	Function func(y, yHat *Variable) *Variable
+}
+
+func (l Loss[T]) Apply(y, yHat T) T {
+	v, _ := l.Function(Scalar(float64(y)), Scalar(float64(yHat))).Value().At(0, 0)
+	return T(v)
+}
+
+func (l Loss[T]) ApplyMatrix(y Matrix[T], yHat Matrix[T]) Matrix[T] {
+	return Convert[T](l.Function(Constant(Convert[float64](y)), Constant(Convert[float64](yHat))).Value())
+}
+
+func (l Loss[T]) ApplyDerivative(y, yHat T) T {
+	Y, YHat := NewZeroMatrix[T](1, 1), NewZeroMatrix[T](1, 1)
+	Y.Set(0, 0, y)
+	YHat.Set(0, 0, yHat)
+	v, _ := l.ApplyDerivativeMatrix(Y, YHat).At(0, 0)
+	return v
+}
+
+// ApplyDerivativeMatrix returns the gradient of the sum of the losses with respect
+// to the prediction.
+func (l Loss[T]) ApplyDerivativeMatrix(y Matrix[T], yHat Matrix[T]) Matrix[T] {
+	prediction := NewVariable(Convert[float64](yHat))
+	l.Function(Constant(Convert[float64](y)), prediction).Backward()
+	if prediction.Gradient() == nil {
+		return NewZeroMatrix[T](yHat.RowCount(), yHat.ColumnCount())
+	}
+	return Convert[T](prediction.Gradient())
+}
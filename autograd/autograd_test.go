@@ -0,0 +1,314 @@
+package autograd_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/Hukyl/mlgo/activation"
+	"github.com/Hukyl/mlgo/autograd"
+	"github.com/Hukyl/mlgo/loss"
+	"github.com/Hukyl/mlgo/matrix"
+)
+
+func randomMatrix(rows, columns int) matrix.Matrix[float64] {
+	m := matrix.NewZeroMatrix[float64](rows, columns)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < columns; j++ {
+			m.Set(i, j, rand.Float64()+0.5) // positive, so that Log, Sqrt and Div are defined
+		}
+	}
+	return m
+}
+
+func sum(m matrix.Matrix[float64]) float64 {
+	result := 0.0
+	for i := 0; i < m.RowCount(); i++ {
+		for j := 0; j < m.ColumnCount(); j++ {
+			v, _ := m.At(i, j)
+			result += v
+		}
+	}
+	return result
+}
+
+func assertClose(t *testing.T, got, want matrix.Matrix[float64], tolerance float64) {
+	t.Helper()
+	if !got.AreSameSize(want) {
+		t.Fatalf("size = %v, want %v", got.Size(), want.Size())
+	}
+	for i := 0; i < got.RowCount(); i++ {
+		for j := 0; j < got.ColumnCount(); j++ {
+			g, _ := got.At(i, j)
+			w, _ := want.At(i, j)
+			if math.Abs(g-w) > tolerance {
+				t.Errorf("(%d,%d) = %v, want %v", i, j, g, w)
+			}
+		}
+	}
+}
+
+func TestVariable_Backward(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		sizes  [][2]int
+		result func(v []*autograd.Variable) *autograd.Variable
+	}{
+		{
+			desc:   "add-broadcast-column",
+			sizes:  [][2]int{{3, 4}, {3, 1}},
+			result: func(v []*autograd.Variable) *autograd.Variable { return v[0].Add(v[1]) },
+		},
+		{
+			desc:   "sub-broadcast-row",
+			sizes:  [][2]int{{3, 4}, {1, 4}},
+			result: func(v []*autograd.Variable) *autograd.Variable { return v[0].Sub(v[1]) },
+		},
+		{
+			desc:   "mul-broadcast-scalar",
+			sizes:  [][2]int{{1, 1}, {3, 4}},
+			result: func(v []*autograd.Variable) *autograd.Variable { return v[0].Mul(v[1]) },
+		},
+		{
+			desc:   "div",
+			sizes:  [][2]int{{3, 4}, {3, 4}},
+			result: func(v []*autograd.Variable) *autograd.Variable { return v[0].Div(v[1]) },
+		},
+		{
+			desc:  "dense-layer",
+			sizes: [][2]int{{2, 3}, {3, 4}, {2, 1}},
+			result: func(v []*autograd.Variable) *autograd.Variable {
+				return v[0].MatMul(v[1]).Add(v[2]).Tanh()
+			},
+		},
+		{
+			desc:  "transpose-scale",
+			sizes: [][2]int{{2, 3}},
+			result: func(v []*autograd.Variable) *autograd.Variable {
+				return v[0].T().Scale(3).AddScalar(1).Neg().Pow(2)
+			},
+		},
+		{
+			desc:  "reductions",
+			sizes: [][2]int{{3, 4}},
+			result: func(v []*autograd.Variable) *autograd.Variable {
+				return v[0].RowSums().Exp().Sum().Add(v[0].ColumnSums().Log().Mean())
+			},
+		},
+		{
+			desc:  "elementwise-functions",
+			sizes: [][2]int{{3, 4}},
+			result: func(v []*autograd.Variable) *autograd.Variable {
+				return v[0].Sqrt().Sigmoid().Add(v[0].AddScalar(-1).Abs()).Add(v[0].AddScalar(-1).ReLU())
+			},
+		},
+		{
+			desc:  "softmax",
+			sizes: [][2]int{{4, 3}, {4, 3}},
+			result: func(v []*autograd.Variable) *autograd.Variable {
+				return v[0].Softmax().Mul(v[1]).Sum()
+			},
+		},
+		{
+			desc:  "log-softmax",
+			sizes: [][2]int{{4, 3}, {4, 3}},
+			result: func(v []*autograd.Variable) *autograd.Variable {
+				return v[0].LogSoftmax().Mul(v[1]).Sum()
+			},
+		},
+		{
+			desc:  "reused-variable",
+			sizes: [][2]int{{3, 3}},
+			result: func(v []*autograd.Variable) *autograd.Variable {
+				square := v[0].MatMul(v[0])
+				return square.Mul(square).Add(v[0])
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			values := make([]matrix.Matrix[float64], len(tC.sizes))
+			variables := make([]*autograd.Variable, len(tC.sizes))
+			for k, size := range tC.sizes {
+				values[k] = randomMatrix(size[0], size[1])
+				variables[k] = autograd.NewVariable(values[k])
+			}
+			evaluate := func() float64 {
+				constants := make([]*autograd.Variable, len(values))
+				for k, v := range values {
+					constants[k] = autograd.Constant(v)
+				}
+				return sum(tC.result(constants).Value())
+			}
+
+			// Act
+			tC.result(variables).Backward()
+
+			// Assert
+			const h = 1e-6
+			for k, V := range values {
+				numerical := matrix.NewZeroMatrix[float64](V.RowCount(), V.ColumnCount())
+				for i := 0; i < V.RowCount(); i++ {
+					for j := 0; j < V.ColumnCount(); j++ {
+						v, _ := V.At(i, j)
+						V.Set(i, j, v+h)
+						plus := evaluate()
+						V.Set(i, j, v-h)
+						minus := evaluate()
+						V.Set(i, j, v)
+						numerical.Set(i, j, (plus-minus)/(2*h))
+					}
+				}
+				assertClose(t, variables[k].Gradient(), numerical, 1e-5)
+			}
+		})
+	}
+}
+
+func TestVariable_BackwardWith(t *testing.T) {
+	// Arrange
+	W := autograd.NewVariable(randomMatrix(2, 3))
+	X := autograd.Constant(randomMatrix(3, 4))
+	gradient := randomMatrix(2, 4)
+
+	// Act
+	err := W.MatMul(X).BackwardWith(gradient)
+	wrongErr := W.MatMul(X).BackwardWith(randomMatrix(4, 2))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("BackwardWith() error = %v", err)
+	}
+	if wrongErr == nil {
+		t.Error("BackwardWith() with a wrong size gradient error = nil")
+	}
+	want, _ := gradient.Multiply(X.Value().T())
+	assertClose(t, W.Gradient(), want, 1e-12)
+	if X.Gradient() != nil {
+		t.Error("constant has a gradient")
+	}
+}
+
+func TestVariable_GradientAccumulation(t *testing.T) {
+	// Arrange
+	x := autograd.NewVariable(randomMatrix(2, 2))
+
+	// Act
+	x.Scale(2).Backward()
+	x.Scale(3).Backward()
+	accumulated := x.Gradient().DeepCopy()
+	x.ZeroGrad()
+	x.Scale(4).Backward()
+
+	// Assert
+	assertClose(t, accumulated, matrix.NewOnesMatrix[float64](2, 2).MultiplyByScalar(5), 1e-12)
+	assertClose(t, x.Gradient(), matrix.NewOnesMatrix[float64](2, 2).MultiplyByScalar(4), 1e-12)
+}
+
+func TestVariable_NonConformable(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Add() of non-conformable matrices did not panic")
+		}
+	}()
+	autograd.Constant(randomMatrix(2, 3)).Add(autograd.Constant(randomMatrix(3, 2)))
+}
+
+func TestActivation(t *testing.T) {
+	// Arrange
+	sigmoid := autograd.Activation[float64]{Function: func(Z *autograd.Variable) *autograd.Variable {
+		return Z.Neg().Exp().AddScalar(1).Pow(-1)
+	}}
+	Z := randomMatrix(3, 4).AddScalar(-1)
+
+	// Act
+	got := Z.DeepCopy()
+	sigmoid.ApplyMatrix(got)
+	derivative := sigmoid.DerivativeMatrix(Z)
+
+	// Assert
+	want := Z.DeepCopy()
+	activation.Sigmoid[float64]{}.ApplyMatrix(want)
+	assertClose(t, got, want, 1e-12)
+	assertClose(t, derivative, activation.Sigmoid[float64]{}.DerivativeMatrix(Z), 1e-12)
+	if v := sigmoid.Derivative(0); math.Abs(v-0.25) > 1e-12 {
+		t.Errorf("Derivative(0) = %v, want 0.25", v)
+	}
+}
+
+func TestActivation_Float32(t *testing.T) {
+	// Arrange
+	var sigmoid activation.ActivationFunction[float32] = autograd.Activation[float32]{
+		Function: func(Z *autograd.Variable) *autograd.Variable {
+			return Z.Sigmoid()
+		},
+	}
+	Z := matrix.Convert[float32](randomMatrix(3, 4).AddScalar(-1))
+
+	// Act
+	got := Z.DeepCopy()
+	sigmoid.ApplyMatrix(got)
+	derivative := sigmoid.DerivativeMatrix(Z)
+
+	// Assert
+	want := Z.DeepCopy()
+	activation.Sigmoid[float32]{}.ApplyMatrix(want)
+	assertClose(t, matrix.Convert[float64](got), matrix.Convert[float64](want), 1e-6)
+	assertClose(
+		t,
+		matrix.Convert[float64](derivative),
+		matrix.Convert[float64](activation.Sigmoid[float32]{}.DerivativeMatrix(Z)),
+		1e-6,
+	)
+}
+
+func TestLoss(t *testing.T) {
+	// Arrange
+	square := autograd.Loss[float64]{Function: func(y, yHat *autograd.Variable) *autograd.Variable {
+		return y.Sub(yHat).Pow(2).Scale(0.5)
+	}}
+	y, yHat := randomMatrix(3, 4), randomMatrix(3, 4)
+
+	// Act
+	got := square.ApplyMatrix(y, yHat)
+	derivative := square.ApplyDerivativeMatrix(y, yHat)
+
+	// Assert
+	assertClose(t, got, loss.SquareLoss[float64]{}.ApplyMatrix(y, yHat), 1e-12)
+	assertClose(t, derivative, loss.SquareLoss[float64]{}.ApplyDerivativeMatrix(y, yHat), 1e-12)
+	if v := square.ApplyDerivative(1, 3); math.Abs(v-2) > 1e-12 {
+		t.Errorf("ApplyDerivative(1, 3) = %v, want 2", v)
+	}
+}
+
+func TestLoss_Float32(t *testing.T) {
+	// Arrange
+	var square loss.LossFunction[float32] = autograd.Loss[float32]{
+		Function: func(y, yHat *autograd.Variable) *autograd.Variable {
+			return y.Sub(yHat).Pow(2).Scale(0.5)
+		},
+	}
+	y, yHat := matrix.Convert[float32](randomMatrix(3, 4)), matrix.Convert[float32](randomMatrix(3, 4))
+
+	// Act
+	got := square.ApplyMatrix(y, yHat)
+	derivative := square.ApplyDerivativeMatrix(y, yHat)
+
+	// Assert
+	assertClose(
+		t,
+		matrix.Convert[float64](got),
+		matrix.Convert[float64](loss.SquareLoss[float32]{}.ApplyMatrix(y, yHat)),
+		1e-6,
+	)
+	assertClose(
+		t,
+		matrix.Convert[float64](derivative),
+		matrix.Convert[float64](loss.SquareLoss[float32]{}.ApplyDerivativeMatrix(y, yHat)),
+		1e-6,
+	)
+	if v := square.ApplyDerivative(1, 3); v != 2 {
+		t.Errorf("ApplyDerivative(1, 3) = %v, want 2", v)
+	}
+}
@@ -0,0 +1,141 @@
+package autograd
+
+import (
+	"math"
+
+	. "github.com/Hukyl/mlgo/matrix"
+)
+
+// Apply applies the scalar function to each element. derivative receives the input
+// and the output of the function, and returns the derivative of the function, e.g.
+//
+//	v.Apply(math.Sin, func(x, y float64) float64 { return math.Cos(x) })
+func (v *Variable) Apply(f func(x float64) float64, derivative func(x, y float64) float64) *Variable {
+	value := v.value.DeepCopy()
+	ApplyByElement(value, f)
+	return newResult(value, func(gradient Matrix[float64]) {
+		result := NewZeroMatrix[float64](value.RowCount(), value.ColumnCount())
+		for i := 0; i < value.RowCount(); i++ {
+			for j := 0; j < value.ColumnCount(); j++ {
+				x, _ := v.value.At(i, j)
+				y, _ := value.At(i, j)
+				g, _ := gradient.At(i, j)
+				result.Set(i, j, g*derivative(x, y))
+			}
+		}
+		v.accumulate(result)
+	}, v)
+}
+
+// Exp applies e^x to each element.
+func (v *Variable) Exp() *Variable {
+	return v.Apply(math.Exp, func(x, y float64) float64 { return y })
+}
+
+// Log applies the natural logarithm to each element.
+func (v *Variable) Log() *Variable {
+	return v.Apply(math.Log, func(x, y float64) float64 { return 1 / x })
+}
+
+// Sqrt applies the square root to each element.
+func (v *Variable) Sqrt() *Variable {
+	return v.Apply(math.Sqrt, func(x, y float64) float64 { return 0.5 / y })
+}
+
+// Pow raises each element to the power p.
+func (v *Variable) Pow(p float64) *Variable {
+	return v.Apply(
+		func(x float64) float64 { return math.Pow(x, p) },
+		func(x, y float64) float64 { return p * math.Pow(x, p-1) },
+	)
+}
+
+// Abs applies the absolute value to each element. The derivative at 0 is 0.
+func (v *Variable) Abs() *Variable {
+	return v.Apply(math.Abs, func(x, y float64) float64 {
+		switch {
+		case x > 0:
+			return 1
+		case x < 0:
+			return -1
+		}
+		return 0
+	})
+}
+
+// Tanh applies the hyperbolic tangent to each element.
+func (v *Variable) Tanh() *Variable {
+	return v.Apply(math.Tanh, func(x, y float64) float64 { return 1 - y*y })
+}
+
+// Sigmoid applies 1 / (1 + e^-x) to each element.
+func (v *Variable) Sigmoid() *Variable {
+	return v.Apply(
+		func(x float64) float64 { return 1 / (1 + math.Exp(-x)) },
+		func(x, y float64) float64 { return y * (1 - y) },
+	)
+}
+
+// ReLU applies max(0, x) to each element.
+func (v *Variable) ReLU() *Variable {
+	return v.Apply(
+		func(x float64) float64 { return math.Max(0, x) },
+		func(x, y float64) float64 {
+			if x > 0 {
+				return 1
+			}
+			return 0
+		},
+	)
+}
+
+// Softmax applies the softmax function to each column, which is treated as a separate sample.
+//
+//	Softmax(x)_i = exp(x_i) / sum(exp(x_j))
+func (v *Variable) Softmax() *Variable {
+	return v.LogSoftmax().Exp()
+}
+
+// LogSoftmax applies the logarithm of the softmax function to each column,
+// which is numerically stable even for large inputs.
+//
+//	LogSoftmax(x)_i = x_i - log(sum(exp(x_j)))
+func (v *Variable) LogSoftmax() *Variable {
+	rows, columns := v.value.RowCount(), v.value.ColumnCount()
+	value := NewZeroMatrix[float64](rows, columns)
+	for j := 0; j < columns; j++ {
+		maxValue := math.Inf(-1)
+		for i := 0; i < rows; i++ {
+			x, _ := v.value.At(i, j)
+			maxValue = math.Max(maxValue, x)
+		}
+		sum := 0.0
+		for i := 0; i < rows; i++ {
+			x, _ := v.value.At(i, j)
+			sum += math.Exp(x - maxValue)
+		}
+		logSum := maxValue + math.Log(sum)
+		for i := 0; i < rows; i++ {
+			x, _ := v.value.At(i, j)
+			value.Set(i, j, x-logSum)
+		}
+	}
+
+	// d/dx_k LogSoftmax(x)_i = [i == k] - Softmax(x)_k
+	return newResult(value, func(gradient Matrix[float64]) {
+		result := NewZeroMatrix[float64](rows, columns)
+		for j := 0; j < columns; j++ {
+			sum := 0.0
+			for i := 0; i < rows; i++ {
+				g, _ := gradient.At(i, j)
+				sum += g
+			}
+			for i := 0; i < rows; i++ {
+				g, _ := gradient.At(i, j)
+				y, _ := value.At(i, j)
+				result.Set(i, j, g-math.Exp(y)*sum)
+			}
+		}
+		v.accumulate(result)
+	}, v)
+}
@@ -0,0 +1,208 @@
+package autograd
+
+import (
+	"fmt"
+
+	. "github.com/Hukyl/mlgo/matrix"
+)
+
+// Add adds the matrices elementwise. Dimensions of size 1 are broadcasted, e.g.
+// a bias column can be added to a batch of samples.
+func (v *Variable) Add(other *Variable) *Variable {
+	return binary(v, other,
+		func(x, y float64) float64 { return x + y },
+		func(x, y, z float64) float64 { return 1 },
+		func(x, y, z float64) float64 { return 1 },
+	)
+}
+
+// Sub subtracts the matrices elementwise, broadcasting as Add.
+func (v *Variable) Sub(other *Variable) *Variable {
+	return binary(v, other,
+		func(x, y float64) float64 { return x - y },
+		func(x, y, z float64) float64 { return 1 },
+		func(x, y, z float64) float64 { return -1 },
+	)
+}
+
+// Mul multiplies the matrices elementwise, broadcasting as Add.
+func (v *Variable) Mul(other *Variable) *Variable {
+	return binary(v, other,
+		func(x, y float64) float64 { return x * y },
+		func(x, y, z float64) float64 { return y },
+		func(x, y, z float64) float64 { return x },
+	)
+}
+
+// Div divides the matrices elementwise, broadcasting as Add.
+func (v *Variable) Div(other *Variable) *Variable {
+	return binary(v, other,
+		func(x, y float64) float64 { return x / y },
+		func(x, y, z float64) float64 { return 1 / y },
+		func(x, y, z float64) float64 { return -x / (y * y) },
+	)
+}
+
+// MatMul multiplies the matrices in the math manner.
+func (v *Variable) MatMul(other *Variable) *Variable {
+	value, err := v.value.Multiply(other.value)
+	if err != nil {
+		panic(fmt.Sprintf("autograd: MatMul of %v and %v matrices", v.value.Size(), other.value.Size()))
+	}
+	return newResult(value, func(gradient Matrix[float64]) {
+		if v.requiresGrad {
+			g, _ := gradient.Multiply(other.value.T())
+			v.accumulate(g)
+		}
+		if other.requiresGrad {
+			g, _ := v.value.T().Multiply(gradient)
+			other.accumulate(g)
+		}
+	}, v, other)
+}
+
+// T transposes the matrix.
+func (v *Variable) T() *Variable {
+	return newResult(v.value.T(), func(gradient Matrix[float64]) {
+		v.accumulate(gradient.T())
+	}, v)
+}
+
+// Scale multiplies each element by the scalar.
+func (v *Variable) Scale(k float64) *Variable {
+	return newResult(v.value.MultiplyByScalar(k), func(gradient Matrix[float64]) {
+		v.accumulate(gradient.MultiplyByScalar(k))
+	}, v)
+}
+
+// Neg negates each element.
+func (v *Variable) Neg() *Variable {
+	return v.Scale(-1)
+}
+
+// AddScalar adds the scalar to each element.
+func (v *Variable) AddScalar(k float64) *Variable {
+	return newResult(v.value.AddScalar(k), func(gradient Matrix[float64]) {
+		v.accumulate(gradient)
+	}, v)
+}
+
+// Sum sums all the elements into a 1x1 matrix.
+func (v *Variable) Sum() *Variable {
+	return v.RowSums().ColumnSums()
+}
+
+// Mean averages all the elements into a 1x1 matrix.
+func (v *Variable) Mean() *Variable {
+	return v.Sum().Scale(1 / float64(v.value.RowCount()*v.value.ColumnCount()))
+}
+
+// ColumnSums sums each column, producing a 1xN matrix, e.g. the per-sample losses.
+func (v *Variable) ColumnSums() *Variable {
+	value, _ := NewOnesMatrix[float64](1, v.value.RowCount()).Multiply(v.value)
+	return newResult(value, func(gradient Matrix[float64]) {
+		v.accumulate(broadcast(gradient, v.value.Size()))
+	}, v)
+}
+
+// RowSums sums each row, producing a Mx1 matrix, e.g. the gradient of a bias.
+func (v *Variable) RowSums() *Variable {
+	value, _ := v.value.Multiply(NewOnesMatrix[float64](v.value.ColumnCount(), 1))
+	return newResult(value, func(gradient Matrix[float64]) {
+		v.accumulate(broadcast(gradient, v.value.Size()))
+	}, v)
+}
+
+/****************************************************************************/
+
+// broadcastSize returns the size of the result of an elementwise operation on the
+// matrices of the given sizes. Panics if the sizes are non-conformable.
+func broadcastSize(a, b [2]int) [2]int {
+	var result [2]int
+	for i := range result {
+		switch {
+		case a[i] == b[i] || b[i] == 1:
+			result[i] = a[i]
+		case a[i] == 1:
+			result[i] = b[i]
+		default:
+			panic(fmt.Sprintf("autograd: elementwise operation on %v and %v matrices", a, b))
+		}
+	}
+	return result
+}
+
+// broadcastAt returns the element of the matrix for the index of a broadcasted matrix.
+func broadcastAt(M Matrix[float64], i, j int) float64 {
+	if M.RowCount() == 1 {
+		i = 0
+	}
+	if M.ColumnCount() == 1 {
+		j = 0
+	}
+	v, _ := M.At(i, j)
+	return v
+}
+
+// broadcast repeats the matrix along its dimensions of size 1 to the given size.
+func broadcast(M Matrix[float64], size [2]int) Matrix[float64] {
+	result := NewZeroMatrix[float64](size[0], size[1])
+	for i := 0; i < size[0]; i++ {
+		for j := 0; j < size[1]; j++ {
+			result.Set(i, j, broadcastAt(M, i, j))
+		}
+	}
+	return result
+}
+
+// reduce is the adjoint of broadcast, i.e. sums the gradient along the dimensions,
+// along which the matrix of the given size was broadcasted.
+func reduce(gradient Matrix[float64], size [2]int) Matrix[float64] {
+	if gradient.Size() == size {
+		return gradient
+	}
+	result := NewZeroMatrix[float64](size[0], size[1])
+	for i := 0; i < gradient.RowCount(); i++ {
+		for j := 0; j < gradient.ColumnCount(); j++ {
+			ri, rj := i, j
+			if size[0] == 1 {
+				ri = 0
+			}
+			if size[1] == 1 {
+				rj = 0
+			}
+			current, _ := result.At(ri, rj)
+			g, _ := gradient.At(i, j)
+			result.Set(ri, rj, current+g)
+		}
+	}
+	return result
+}
+
+// binary applies the function elementwise with broadcasting. da and db are the partial
+// derivatives of z = f(x, y) with respect to x and y.
+func binary(a, b *Variable, f func(x, y float64) float64, da, db func(x, y, z float64) float64) *Variable {
+	size := broadcastSize(a.value.Size(), b.value.Size())
+	value := NewZeroMatrix[float64](size[0], size[1])
+	for i := 0; i < size[0]; i++ {
+		for j := 0; j < size[1]; j++ {
+			value.Set(i, j, f(broadcastAt(a.value, i, j), broadcastAt(b.value, i, j)))
+		}
+	}
+
+	return newResult(value, func(gradient Matrix[float64]) {
+		ga := NewZeroMatrix[float64](size[0], size[1])
+		gb := NewZeroMatrix[float64](size[0], size[1])
+		for i := 0; i < size[0]; i++ {
+			for j := 0; j < size[1]; j++ {
+				x, y := broadcastAt(a.value, i, j), broadcastAt(b.value, i, j)
+				z, _ := value.At(i, j)
+				g, _ := gradient.At(i, j)
+				ga.Set(i, j, g*da(x, y, z))
+				gb.Set(i, j, g*db(x, y, z))
+			}
+		}
+		a.accumulate(reduce(ga, a.value.Size()))
+		b.accumulate(reduce(gb, b.value.Size()))
+	}, a, b)
+}
@@ -0,0 +1,156 @@
+// Package autograd contains a reverse-mode automatic differentiation engine.
+//
+// Operations on variables are recorded into a computation graph, which is then
+// traversed backwards to compute the gradients of the result with respect to
+// every variable it depends on. This allows to obtain the derivatives of arbitrary
+// compositions of the supported operations, without deriving them by hand:
+//
+//	W := autograd.NewVariable(weights)
+//	b := autograd.NewVariable(bias)
+//	X := autograd.Constant(input)
+//	cost := W.MatMul(X).Add(b).Sigmoid().Sum()
+//	cost.Backward()
+//	fmt.Println(W.Gradient(), b.Gradient())
+//
+// The operations panic on non-conformable matrices, the same way as indexing a slice
+// out of range does, as the shapes are defined by the code building the graph.
+package autograd
+
+import (
+	"errors"
+
+	. "github.com/Hukyl/mlgo/matrix"
+)
+
+// Variable is a node of the computation graph, which holds a matrix value, and
+// the gradient of the last differentiated result with respect to it.
+//
+// Variables are created either as leaves (NewVariable and Constant), or as
+// results of the operations on other variables.
+type Variable struct {
+	value        Matrix[float64]
+	gradient     Matrix[float64]
+	requiresGrad bool
+
+	parents  []*Variable
+	backward func(gradient Matrix[float64])
+}
+
+// NewVariable creates a leaf variable, the gradient of which is computed, e.g. a parameter.
+func NewVariable(value Matrix[float64]) *Variable {
+	return &Variable{value: value, requiresGrad: true}
+}
+
+// Constant creates a leaf variable, the gradient of which is not computed, e.g. the labels.
+func Constant(value Matrix[float64]) *Variable {
+	return &Variable{value: value}
+}
+
+// Scalar creates a 1x1 constant.
+func Scalar(value float64) *Variable {
+	m := NewZeroMatrix[float64](1, 1)
+	m.Set(0, 0, value)
+	return Constant(m)
+}
+
+// newResult creates a variable as the result of an operation on the parents. backward
+// receives the gradient with respect to the result, and accumulates the gradients
+// of the parents using accumulate.
+func newResult(value Matrix[float64], backward func(gradient Matrix[float64]), parents ...*Variable) *Variable {
+	v := &Variable{value: value, parents: parents, backward: backward}
+	for _, p := range parents {
+		v.requiresGrad = v.requiresGrad || p.requiresGrad
+	}
+	return v
+}
+
+// Value returns the value of the variable.
+func (v *Variable) Value() Matrix[float64] {
+	return v.value
+}
+
+// Gradient returns the gradient of the differentiated result with respect to the variable.
+// If the variable does not require the gradient or was not differentiated, returns nil.
+func (v *Variable) Gradient() Matrix[float64] {
+	return v.gradient
+}
+
+// RequiresGrad returns whether the gradient with respect to the variable is computed.
+func (v *Variable) RequiresGrad() bool {
+	return v.requiresGrad
+}
+
+// ZeroGrad resets the gradient of the variable. The gradients of the leaves are
+// accumulated between the calls of Backward, so it has to be used before reusing them.
+func (v *Variable) ZeroGrad() {
+	v.gradient = nil
+}
+
+// accumulate adds the gradient to the gradient of the variable.
+func (v *Variable) accumulate(gradient Matrix[float64]) {
+	if !v.requiresGrad {
+		return
+	}
+	if v.gradient == nil {
+		v.gradient = gradient.DeepCopy()
+		return
+	}
+	v.gradient, _ = v.gradient.Add(gradient)
+}
+
+// Backward computes the gradients of the variable with respect to all the variables
+// it depends on. If the variable is not a scalar, the gradients of the sum of its
+// elements are computed.
+func (v *Variable) Backward() {
+	v.BackwardWith(NewOnesMatrix[float64](v.value.RowCount(), v.value.ColumnCount()))
+}
+
+// BackwardWith works as Backward, starting from the given gradient with respect to
+// the variable, i.e. computes vector-Jacobian products. This allows to continue the
+// backpropagation from the gradient of the next layer.
+//
+// Returns error if the gradient is not of the variable size.
+func (v *Variable) BackwardWith(gradient Matrix[float64]) error {
+	if !gradient.AreSameSize(v.value) {
+		return errors.New("gradient size does not correspond to the variable")
+	}
+	if !v.requiresGrad {
+		return nil
+	}
+
+	order := v.topologicalOrder()
+	for _, node := range order {
+		if node.backward != nil {
+			node.gradient = nil // intermediate gradients are not accumulated
+		}
+	}
+	v.accumulate(gradient)
+	for i := len(order) - 1; i >= 0; i-- {
+		node := order[i]
+		if node.backward != nil && node.gradient != nil {
+			node.backward(node.gradient)
+		}
+	}
+	return nil
+}
+
+// topologicalOrder returns the variables, which require the gradient and the variable
+// depends on, so that each variable comes after all its parents.
+func (v *Variable) topologicalOrder() []*Variable {
+	var order []*Variable
+	visited := make(map[*Variable]bool)
+
+	var visit func(node *Variable)
+	visit = func(node *Variable) {
+		if visited[node] || !node.requiresGrad {
+			return
+		}
+		visited[node] = true
+		for _, p := range node.parents {
+			visit(p)
+		}
+		order = append(order, node)
+	}
+	visit(v)
+	return order
+}
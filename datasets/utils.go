@@ -3,25 +3,44 @@
 package datasets
 
 import (
+	"errors"
+
 	"github.com/Hukyl/mlgo/matrix"
 	. "golang.org/x/exp/constraints"
 )
 
 // BatchMatrix accepts a slice of inputs to the neural network, and produces
-// a slice of matrices with row count equal to batchSize.
-func BatchMatrix[T Signed | Float](input [][]T, batchSize int) []matrix.Matrix[T] {
-	var result []matrix.Matrix[T]
+// a slice of matrices with row count equal to batchSize. The last batch contains
+// the rest of the rows, if their count is not divisible by batchSize.
+//
+// The input is copied into a single matrix once, and the batches are views of its rows,
+// i.e. the batches share one buffer, which is released only when none of them is used.
+//
+// Returns error if batchSize is not positive, or the rows of the input are of
+// different lengths.
+func BatchMatrix[T Signed | Float](input [][]T, batchSize int) ([]matrix.Matrix[T], error) {
+	if batchSize <= 0 {
+		return nil, errors.New("batch size must be positive")
+	}
+	if len(input) == 0 {
+		return nil, nil
+	}
+	m, err := matrix.NewMatrix(input)
+	if err != nil {
+		return nil, errors.Join(errors.New("invalid input"), err)
+	}
 
+	var result []matrix.Matrix[T]
 	for i := 0; i < len(input); i += batchSize {
 		end := i + batchSize
 		if end > len(input) {
 			end = len(input)
 		}
-		m, _ := matrix.NewMatrix(input[i:end])
-		result = append(result, m)
+		batch, _ := m.Slice(i, end, 0, m.ColumnCount())
+		result = append(result, batch)
 	}
 
-	return result
+	return result, nil
 }
 
 // OneHotEncode accepts a slice of labels, and produces slices of
@@ -39,3 +58,48 @@ func OneHotEncode(labels []float64, classCount int) [][]float64 {
 	}
 	return output
 }
+
+// BatchSparseMatrix splits the rows of a (sparse) matrix into batches with row count
+// equal to batchSize, e.g. the bag-of-words of the documents, which would not fit
+// into the memory as [][]T. The matrix is converted into the CSR format, if it is not
+// already, so that the batches are sliced in O(non-zeros in the batch) time.
+// As BatchMatrix, the last batch contains the rest of the rows.
+//
+// Returns error if batchSize is not positive.
+func BatchSparseMatrix[T Signed | Float](input matrix.Matrix[T], batchSize int) ([]matrix.Matrix[T], error) {
+	if batchSize <= 0 {
+		return nil, errors.New("batch size must be positive")
+	}
+
+	var result []matrix.Matrix[T]
+	m := matrix.NewCSR(input)
+	for i := 0; i < m.RowCount(); i += batchSize {
+		end := i + batchSize
+		if end > m.RowCount() {
+			end = m.RowCount()
+		}
+		batch, _ := m.Slice(i, end, 0, m.ColumnCount())
+		result = append(result, batch)
+	}
+
+	return result, nil
+}
+
+// OneHotEncodeSparse is the same as OneHotEncode, but produces a sparse matrix with
+// a row for each label, which stores only the ones.
+//
+//	labels := []float64{0, 2, 1}
+//	encoded := OneHotEncodeSparse(labels, 3) // [ [1, 0, 0], [0, 0, 1], [0, 1, 0] ]
+func OneHotEncodeSparse(labels []float64, classCount int) matrix.Matrix[float64] {
+	rows := make([]int, len(labels))
+	columns := make([]int, len(labels))
+	values := make([]float64, len(labels))
+	for i, v := range labels {
+		rows[i], columns[i], values[i] = i, int(v), 1
+	}
+	result, err := matrix.NewSparseMatrix(len(labels), classCount, rows, columns, values)
+	if err != nil {
+		panic(err) // a label is not in [0, classCount), as in OneHotEncode
+	}
+	return result
+}
@@ -0,0 +1,168 @@
+package datasets_test
+
+import (
+	"testing"
+
+	"github.com/Hukyl/mlgo/datasets"
+	"github.com/Hukyl/mlgo/matrix"
+)
+
+func TestBatchMatrix(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		input     [][]float64
+		batchSize int
+		wantRows  []int
+		wantErr   bool
+	}{
+		{desc: "exact-batches", input: [][]float64{{1, 2}, {3, 4}, {5, 6}, {7, 8}}, batchSize: 2, wantRows: []int{2, 2}},
+		{desc: "last-partial-batch", input: [][]float64{{1, 2}, {3, 4}, {5, 6}}, batchSize: 2, wantRows: []int{2, 1}},
+		{desc: "single-batch", input: [][]float64{{1, 2}, {3, 4}}, batchSize: 5, wantRows: []int{2}},
+		{desc: "empty-input", input: nil, batchSize: 2, wantRows: nil},
+		{desc: "ragged-rows", input: [][]float64{{1, 2}, {3}}, batchSize: 1, wantErr: true},
+		{desc: "zero-batch-size", input: [][]float64{{1, 2}}, batchSize: 0, wantErr: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Act
+			got, err := datasets.BatchMatrix(tC.input, tC.batchSize)
+
+			// Assert
+			if (err != nil) != tC.wantErr {
+				t.Fatalf("BatchMatrix() error = %v, wantErr %v", err, tC.wantErr)
+			}
+			if len(got) != len(tC.wantRows) {
+				t.Fatalf("BatchMatrix() produced %d batches, want %d", len(got), len(tC.wantRows))
+			}
+			row := 0
+			for k, batch := range got {
+				if batch.RowCount() != tC.wantRows[k] {
+					t.Errorf("batch #%d row count = %d, want %d", k, batch.RowCount(), tC.wantRows[k])
+				}
+				for i := 0; i < batch.RowCount(); i++ {
+					for j := 0; j < batch.ColumnCount(); j++ {
+						if v, _ := batch.At(i, j); v != tC.input[row][j] {
+							t.Errorf("batch #%d At(%d,%d) = %v, want %v", k, i, j, v, tC.input[row][j])
+						}
+					}
+					row++
+				}
+			}
+		})
+	}
+}
+
+func TestBatchMatrix_CopiesInput(t *testing.T) {
+	// Arrange
+	input := [][]float64{{1, 2}, {3, 4}, {5, 6}}
+	batches, _ := datasets.BatchMatrix(input, 2)
+	whole, _ := datasets.BatchMatrix(input, 3)
+
+	// Act
+	batches[1].Set(0, 0, -5)
+
+	// Assert
+	if input[2][0] != 5 {
+		t.Errorf("input modified: %v", input[2][0])
+	}
+	if v, _ := batches[1].At(0, 0); v != -5 {
+		t.Errorf("batch At(0,0) = %v, want -5", v)
+	}
+	if v, _ := whole[0].At(2, 0); v != 5 {
+		t.Errorf("separate BatchMatrix call shares elements: At(2,0) = %v, want 5", v)
+	}
+}
+
+func TestBatchSparseMatrix(t *testing.T) {
+	input := [][]float64{{1, 0, 0}, {0, 0, 2}, {0, 0, 0}, {3, 0, 4}, {0, 5, 0}}
+	testCases := []struct {
+		desc      string
+		batchSize int
+		wantRows  []int
+		wantErr   bool
+	}{
+		{desc: "single-row-batches", batchSize: 1, wantRows: []int{1, 1, 1, 1, 1}},
+		{desc: "last-partial-batch", batchSize: 2, wantRows: []int{2, 2, 1}},
+		{desc: "exact-batches", batchSize: 5, wantRows: []int{5}},
+		{desc: "batch-larger-than-input", batchSize: 10, wantRows: []int{5}},
+		{desc: "zero-batch-size", batchSize: 0, wantErr: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			dense, _ := matrix.NewMatrix(input)
+
+			// Act
+			got, err := datasets.BatchSparseMatrix(matrix.NewCSC(dense), tC.batchSize)
+
+			// Assert
+			if (err != nil) != tC.wantErr {
+				t.Fatalf("BatchSparseMatrix() error = %v, wantErr %v", err, tC.wantErr)
+			}
+			if len(got) != len(tC.wantRows) {
+				t.Fatalf("BatchSparseMatrix() produced %d batches, want %d", len(got), len(tC.wantRows))
+			}
+			row := 0
+			for k, batch := range got {
+				if !matrix.IsSparse(batch) {
+					t.Errorf("batch #%d is dense", k)
+				}
+				if batch.RowCount() != tC.wantRows[k] || batch.ColumnCount() != len(input[0]) {
+					t.Errorf("batch #%d size = %v, want [%d %d]", k, batch.Size(), tC.wantRows[k], len(input[0]))
+				}
+				for i := 0; i < batch.RowCount(); i++ {
+					for j := 0; j < batch.ColumnCount(); j++ {
+						if v, _ := batch.At(i, j); v != input[row][j] {
+							t.Errorf("batch #%d At(%d,%d) = %v, want %v", k, i, j, v, input[row][j])
+						}
+					}
+					row++
+				}
+			}
+		})
+	}
+}
+
+func TestOneHotEncodeSparse(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		labels     []float64
+		classCount int
+		wantPanic  bool
+	}{
+		{desc: "all-classes", labels: []float64{0, 2, 1}, classCount: 3},
+		{desc: "repeated-class", labels: []float64{1, 1, 0, 1}, classCount: 2},
+		{desc: "boundary-classes", labels: []float64{0, 4}, classCount: 5},
+		{desc: "no-labels", labels: []float64{}, classCount: 3},
+		{desc: "label-too-large", labels: []float64{0, 3}, classCount: 3, wantPanic: true},
+		{desc: "negative-label", labels: []float64{-1}, classCount: 3, wantPanic: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			defer func() {
+				if r := recover(); (r != nil) != tC.wantPanic {
+					t.Errorf("OneHotEncodeSparse() panic = %v, wantPanic %v", r, tC.wantPanic)
+				}
+			}()
+
+			// Act
+			got := datasets.OneHotEncodeSparse(tC.labels, tC.classCount)
+
+			// Assert
+			want := datasets.OneHotEncode(tC.labels, tC.classCount)
+			if !matrix.IsSparse(got) {
+				t.Error("OneHotEncodeSparse() is dense")
+			}
+			if got.RowCount() != len(tC.labels) || got.ColumnCount() != tC.classCount {
+				t.Fatalf("size = %v, want [%d %d]", got.Size(), len(tC.labels), tC.classCount)
+			}
+			for i := range want {
+				for j := range want[i] {
+					if v, _ := got.At(i, j); v != want[i][j] {
+						t.Errorf("At(%d,%d) = %v, want %v", i, j, v, want[i][j])
+					}
+				}
+			}
+		})
+	}
+}
@@ -2,4 +2,4 @@ module github.com/Hukyl/mlgo
 
 go 1.21
 
-require golang.org/x/exp v0.0.0-20240119083558-1b970713d09a // indirect
+require golang.org/x/exp v0.0.0-20240119083558-1b970713d09a
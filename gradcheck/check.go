@@ -0,0 +1,151 @@
+package gradcheck
+
+import (
+	"fmt"
+
+	"github.com/Hukyl/mlgo/activation"
+	"github.com/Hukyl/mlgo/loss"
+	. "github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/nn"
+	"github.com/Hukyl/mlgo/nn/layers"
+)
+
+// Layer checks the gradients of the layer for the input X. The checked function is
+// sum(R * layer(X)) for a fixed random matrix R, which is given to BackPropagate as
+// the gradient of the next layer.
+//
+// The report contains the gradient with respect to the input (named "input"), and
+// the gradients of all the parameters the layer updates, averaged over the samples.
+// The parameters are named "weights" and "bias", if they are returned by Weights()
+// and Bias() respectively, and "parameter #k" otherwise, in the order of the updates.
+// The parameters are not changed by the check.
+//
+// If epsilon is not set, DefaultEpsilon is used. Returns error if the layer fails to
+// propagate the input.
+func Layer(layer layers.Layer[float64], X Matrix[float64], epsilon float64) (report Report, err error) {
+	epsilon = valueOrDefault(epsilon, DefaultEpsilon)
+	X = X.DeepCopy()
+	A, err := layer.ForwardPropagate(X)
+	if err != nil {
+		return nil, err
+	}
+	R := randomWeights(A[1].RowCount(), A[1].ColumnCount())
+	cost := func() (float64, error) {
+		Y, err := layer.ForwardPropagate(X)
+		if err != nil {
+			return 0, err
+		}
+		return weightedSum(R, Y[1])
+	}
+
+	r := &recorder{}
+	dX := layer.BackPropagate(R, X, A, r.trainingParameters())
+	numerical, err := numericalGradient(X, cost, epsilon)
+	if err != nil {
+		return nil, err
+	}
+	report = append(report, newResult("input", dX, numerical))
+
+	samples := float64(X.ColumnCount())
+	for k, p := range r.parameters {
+		numerical, err := numericalGradient(*p, cost, epsilon)
+		if err != nil {
+			return nil, err
+		}
+		name := fmt.Sprintf("parameter #%d", k+1)
+		switch *p {
+		case layer.Weights():
+			name = "weights"
+		case layer.Bias():
+			name = "bias"
+		}
+		report = append(report, newResult(name, r.gradients[k], numerical.MultiplyByScalar(1/samples)))
+	}
+	return report, nil
+}
+
+// Activation checks the derivative of the activation function at Z, as it is used by
+// the layers, i.e. multiplied elementwise by the gradient of the next layer. The checked
+// function is sum(R * f(Z)) for a fixed random matrix R. The report contains a single
+// result, named "input".
+//
+// For the vector functions (e.g. Softmax), the Jacobian product is checked instead.
+//
+// If epsilon is not set, DefaultEpsilon is used. Returns error if the derivative panics,
+// e.g. is not implemented.
+func Activation(f activation.ActivationFunction[float64], Z Matrix[float64], epsilon float64) (report Report, err error) {
+	epsilon = valueOrDefault(epsilon, DefaultEpsilon)
+	defer func() {
+		if r := recover(); r != nil {
+			report, err = nil, fmt.Errorf("activation function panicked: %v", r)
+		}
+	}()
+	Z = Z.DeepCopy()
+	R := randomWeights(Z.RowCount(), Z.ColumnCount())
+	A := Z.DeepCopy()
+	f.ApplyMatrix(A)
+	analytic := activation.Backward(f, Z, A, R)
+	numerical, err := numericalGradient(Z, func() (float64, error) {
+		A := Z.DeepCopy()
+		f.ApplyMatrix(A)
+		return weightedSum(R, A)
+	}, epsilon)
+	if err != nil {
+		return nil, err
+	}
+	return Report{newResult("input", analytic, numerical)}, nil
+}
+
+// Loss checks the derivative of the loss function with respect to the prediction yHat,
+// i.e. the gradient of the sum of the losses. The report contains a single result,
+// named "prediction".
+//
+// If epsilon is not set, DefaultEpsilon is used.
+func Loss(l loss.LossFunction[float64], y, yHat Matrix[float64], epsilon float64) (Report, error) {
+	epsilon = valueOrDefault(epsilon, DefaultEpsilon)
+	yHat = yHat.DeepCopy()
+	analytic := l.ApplyDerivativeMatrix(y, yHat)
+	numerical, err := numericalGradient(yHat, func() (float64, error) {
+		losses := l.ApplyMatrix(y, yHat)
+		return weightedSum(NewOnesMatrix[float64](losses.RowCount(), losses.ColumnCount()), losses)
+	}, epsilon)
+	if err != nil {
+		return nil, err
+	}
+	return Report{newResult("prediction", analytic, numerical)}, nil
+}
+
+// Network checks the gradients of the cost of the neural network (as computed by ComputeCost)
+// for the input X and the labels Y, with respect to all the parameters of its layers.
+// The parameters are named "parameter #k" in the order of the updates, i.e. starting
+// from the last layer. The parameters are not changed by the check.
+//
+// If epsilon is not set, DefaultEpsilon is used.
+func Network(n nn.NeuralNetwork[float64], X, Y Matrix[float64], epsilon float64) (report Report, err error) {
+	epsilon = valueOrDefault(epsilon, DefaultEpsilon)
+	cost := func() (float64, error) {
+		cache := n.ForwardPropagate(X)
+		return n.ComputeCost(cache[len(cache)-1][1], Y), nil
+	}
+
+	r := &recorder{}
+	n.BackPropagate(Y, n.ForwardPropagate(X), r.trainingParameters())
+	for k, p := range r.parameters {
+		numerical, err := numericalGradient(*p, cost, epsilon)
+		if err != nil {
+			return nil, err
+		}
+		report = append(report, newResult(fmt.Sprintf("parameter #%d", k+1), r.gradients[k], numerical))
+	}
+	return report, nil
+}
+
+/****************************************************************************/
+
+func valueOrDefault[T comparable](value, defaultValue T) T {
+	var zero T
+	if value == zero {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,188 @@
+// Package gradcheck verifies the hand-derived gradients of the layers, activation
+// functions, loss functions and whole neural networks by comparing them with
+// the numerical gradients, obtained using central finite differences:
+//
+//	df/dx ≈ (f(x + epsilon) - f(x - epsilon)) / (2 * epsilon)
+//
+// The checks are meant to be used in tests, e.g.
+//
+//	report, err := gradcheck.Layer(layer, X, 0)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	if err := report.Check(1e-6); err != nil {
+//		t.Error(err)
+//	}
+//
+// As the numerical gradients are computed by evaluating the component repeatedly,
+// it has to be deterministic, e.g. dropout layers have to be excluded.
+package gradcheck
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+
+	. "github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/utils"
+)
+
+// DefaultEpsilon is the step of the finite differences, used if epsilon is not set.
+const DefaultEpsilon = 1e-6
+
+// Result is the comparison of the analytic and the numerical gradient with respect
+// to a single input or parameter.
+//
+// RelativeError is computed using the Frobenius norms of the gradients:
+//
+//	RelativeError = ||analytic - numerical|| / (||analytic|| + ||numerical||)
+//
+// and is 0 if both gradients are zero.
+type Result struct {
+	Name          string
+	Analytic      Matrix[float64]
+	Numerical     Matrix[float64]
+	RelativeError float64
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("%s: relative error %g", r.Name, r.RelativeError)
+}
+
+// Report contains the results of a gradient check for each checked input and parameter.
+type Report []Result
+
+// MaxRelativeError returns the largest relative error of the report.
+func (r Report) MaxRelativeError() float64 {
+	result := 0.0
+	for _, res := range r {
+		result = math.Max(result, res.RelativeError)
+	}
+	return result
+}
+
+// Check returns error, listing the results with the relative error above the tolerance.
+// Usually, correct gradients have relative error below 1e-6.
+func (r Report) Check(tolerance float64) error {
+	var failed []string
+	for _, res := range r {
+		if !(res.RelativeError <= tolerance) { // NaN fails as well
+			failed = append(failed, res.String())
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("gradient check failed: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+/****************************************************************************/
+
+// newResult compares the gradients.
+func newResult(name string, analytic, numerical Matrix[float64]) Result {
+	result := Result{Name: name, Analytic: analytic, Numerical: numerical}
+	difference, err := analytic.Add(numerical.MultiplyByScalar(-1))
+	if err != nil {
+		result.RelativeError = math.Inf(1)
+		return result
+	}
+	denominator := norm(analytic) + norm(numerical)
+	if denominator > 0 {
+		result.RelativeError = norm(difference) / denominator
+	}
+	return result
+}
+
+// norm returns the Frobenius norm of the matrix.
+func norm(M Matrix[float64]) float64 {
+	sum := 0.0
+	for i := 0; i < M.RowCount(); i++ {
+		for j := 0; j < M.ColumnCount(); j++ {
+			v, _ := M.At(i, j)
+			sum += v * v
+		}
+	}
+	return math.Sqrt(sum)
+}
+
+// numericalGradient computes the gradient of f with respect to M using central finite
+// differences, perturbing M in place. M is restored after each evaluation.
+func numericalGradient(M Matrix[float64], f func() (float64, error), epsilon float64) (Matrix[float64], error) {
+	result := NewZeroMatrix[float64](M.RowCount(), M.ColumnCount())
+	for i := 0; i < M.RowCount(); i++ {
+		for j := 0; j < M.ColumnCount(); j++ {
+			v, _ := M.At(i, j)
+			M.Set(i, j, v+epsilon)
+			plus, err := f()
+			M.Set(i, j, v-epsilon)
+			minus, err2 := f()
+			M.Set(i, j, v)
+			if err = errors.Join(err, err2); err != nil {
+				return nil, err
+			}
+			result.Set(i, j, (plus-minus)/(2*epsilon))
+		}
+	}
+	return result, nil
+}
+
+// weightedSum returns sum(R * M), i.e. a scalar function of M with the gradient R.
+func weightedSum(R, M Matrix[float64]) (float64, error) {
+	product, err := R.MultiplyElementwise(M)
+	if err != nil {
+		return 0, err
+	}
+	sum := 0.0
+	for i := 0; i < product.RowCount(); i++ {
+		for j := 0; j < product.ColumnCount(); j++ {
+			v, _ := product.At(i, j)
+			sum += v
+		}
+	}
+	return sum, nil
+}
+
+// randomWeights returns a matrix of the given size with the elements in [-1, 1),
+// generated from a fixed seed, so that the checks are reproducible.
+func randomWeights(rows, columns int) Matrix[float64] {
+	r := rand.New(rand.NewSource(1))
+	result := NewZeroMatrix[float64](rows, columns)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < columns; j++ {
+			result.Set(i, j, 2*r.Float64()-1)
+		}
+	}
+	return result
+}
+
+/****************************************************************************/
+
+// recorder is an optimizer, which records the gradients of the parameters
+// instead of updating them. As the optimizers, it identifies the parameters by the pointers.
+type recorder struct {
+	parameters []*Matrix[float64]
+	gradients  []Matrix[float64]
+}
+
+func (r *recorder) Update(parameter *Matrix[float64], gradient Matrix[float64], _, _ float64) {
+	for k, p := range r.parameters {
+		if p == parameter {
+			r.gradients[k], _ = r.gradients[k].Add(gradient)
+			return
+		}
+	}
+	r.parameters = append(r.parameters, parameter)
+	r.gradients = append(r.gradients, gradient.DeepCopy())
+}
+
+// trainingParameters returns the parameters, which make the layers report their
+// gradients to the optimizer, without clipping them.
+func (r *recorder) trainingParameters() utils.NeuralNetworkParameters[float64] {
+	return utils.NeuralNetworkParameters[float64]{
+		InitialLearningRate: 1,
+		Optimizer:           r,
+		ClipValue:           math.Inf(1),
+	}
+}
@@ -0,0 +1,263 @@
+package gradcheck_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/Hukyl/mlgo/activation"
+	"github.com/Hukyl/mlgo/autograd"
+	"github.com/Hukyl/mlgo/gradcheck"
+	"github.com/Hukyl/mlgo/loss"
+	"github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/nn"
+	"github.com/Hukyl/mlgo/nn/layers"
+	"github.com/Hukyl/mlgo/utils"
+)
+
+const tolerance = 1e-6
+
+func randomMatrix(rows, columns int, min, max float64) matrix.Matrix[float64] {
+	m := matrix.NewZeroMatrix[float64](rows, columns)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < columns; j++ {
+			m.Set(i, j, min+(max-min)*rand.Float64())
+		}
+	}
+	return m
+}
+
+// brokenLayer doubles the gradient with respect to the input.
+type brokenLayer struct {
+	layers.Layer[float64]
+}
+
+func (b brokenLayer) BackPropagate(next, X matrix.Matrix[float64], A [2]matrix.Matrix[float64], p utils.NeuralNetworkParameters[float64]) matrix.Matrix[float64] {
+	return b.Layer.BackPropagate(next, X, A, p).MultiplyByScalar(2)
+}
+
+func TestLayer(t *testing.T) {
+	lstm, _ := layers.NewLSTM[float64](
+		layers.RecurrentParameters{TimeSteps: 3, InputSize: 2, HiddenSize: 2},
+		layers.XavierUniformInitialization{},
+	)
+	conv, _ := layers.NewConv2D(
+		layers.Shape{Channels: 2, Height: 4, Width: 4},
+		layers.Conv2DParameters{Filters: 2, KernelSize: [2]int{3, 3}},
+		activation.Sigmoid[float64]{},
+		layers.XavierUniformInitialization{},
+	)
+	custom, _ := layers.NewCustom("Scaled", 3, 2,
+		func(X *autograd.Variable, p []*autograd.Variable) *autograd.Variable {
+			return p[0].MatMul(X).Add(p[1]).Tanh().Mul(p[2])
+		},
+		randomMatrix(2, 3, -1, 1), randomMatrix(2, 1, -1, 1), randomMatrix(2, 1, -1, 1),
+	)
+	testCases := []struct {
+		desc  string
+		layer layers.Layer[float64]
+		input matrix.Matrix[float64]
+		names []string
+	}{
+		{
+			desc:  "dense",
+			layer: layers.NewRandomDense([2]int{3, 2}, activation.Sigmoid[float64]{}, layers.XavierUniformInitialization{}),
+			input: randomMatrix(3, 4, -1, 1),
+			names: []string{"input", "weights", "bias"},
+		},
+		{
+			desc:  "conv2d",
+			layer: conv,
+			input: randomMatrix(32, 2, -1, 1),
+			names: []string{"input", "weights", "bias"},
+		},
+		{
+			desc:  "lstm",
+			layer: lstm,
+			input: randomMatrix(6, 3, -1, 1),
+			names: []string{"input", "weights", "bias"},
+		},
+		{
+			desc:  "batch-norm",
+			layer: layers.NewBatchNorm[float64](3, 0, 0),
+			input: randomMatrix(3, 5, -1, 1),
+			names: []string{"input", "weights", "bias"},
+		},
+		{
+			desc:  "custom",
+			layer: custom,
+			input: randomMatrix(3, 4, -1, 1),
+			names: []string{"input", "weights", "bias", "parameter #3"},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			before := tC.layer.Weights()
+			if before != nil {
+				before = before.DeepCopy()
+			}
+
+			// Act
+			report, err := gradcheck.Layer(tC.layer, tC.input, 0)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Layer() error = %v", err)
+			}
+			if err := report.Check(tolerance); err != nil {
+				t.Error(err)
+			}
+			if len(report) != len(tC.names) {
+				t.Fatalf("len(report) = %d, want %d", len(report), len(tC.names))
+			}
+			for k, name := range tC.names {
+				if report[k].Name != name {
+					t.Errorf("report[%d].Name = %s, want %s", k, report[k].Name, name)
+				}
+			}
+			if before != nil && !before.Equals(tC.layer.Weights()) {
+				t.Error("weights were changed by the check")
+			}
+		})
+	}
+}
+
+func TestLayer_WrongGradient(t *testing.T) {
+	// Arrange
+	layer := brokenLayer{layers.NewRandomDense([2]int{3, 2}, activation.Sigmoid[float64]{}, layers.XavierUniformInitialization{})}
+
+	// Act
+	report, err := gradcheck.Layer(layer, randomMatrix(3, 4, -1, 1), 0)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Layer() error = %v", err)
+	}
+	if report.Check(tolerance) == nil {
+		t.Error("Check() error = nil")
+	}
+	if e := report[0].RelativeError; e < 0.3 || e > 0.4 { // |2g - g| / (|2g| + |g|) = 1/3
+		t.Errorf("input relative error = %v, want 1/3", e)
+	}
+}
+
+func TestActivation(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		f       activation.ActivationFunction[float64]
+		wantErr bool
+		wantOK  bool
+	}{
+		{desc: "sigmoid", f: activation.Sigmoid[float64]{}, wantOK: true},
+		{desc: "relu", f: activation.ReLU[float64]{}, wantOK: true},
+		{desc: "selu", f: activation.SELU[float64]{}, wantOK: true},
+		{desc: "softmax", f: activation.Softmax[float64]{}, wantOK: true},
+		{desc: "tanh", f: activation.Tanh[float64]{}, wantOK: true},
+		{desc: "leaky-relu", f: activation.LeakyReLU[float64]{Alpha: 0.2}, wantOK: true},
+		{desc: "elu", f: activation.ELU[float64]{}, wantOK: true},
+		{desc: "gelu", f: activation.GELU[float64]{}, wantOK: true},
+		{desc: "swish", f: activation.Swish[float64]{Beta: 1.5}, wantOK: true},
+		{desc: "mish", f: activation.Mish[float64]{}, wantOK: true},
+		{desc: "softplus", f: activation.Softplus[float64]{}, wantOK: true},
+		{desc: "softsign", f: activation.Softsign[float64]{}, wantOK: true},
+		{desc: "hard-sigmoid", f: activation.HardSigmoid[float64]{}, wantOK: true},
+		{desc: "prelu", f: activation.NewPReLU[float64](3, 0.1), wantOK: true},
+		{desc: "softmax-with-cce", f: activation.SoftmaxWithCCE[float64]{}, wantOK: false},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			Z := randomMatrix(3, 4, 0.1, 1)
+			Z.Set(0, 0, -0.5) // away from the kink of ReLU
+
+			// Act
+			report, err := gradcheck.Activation(tC.f, Z, 0)
+
+			// Assert
+			if (err != nil) != tC.wantErr {
+				t.Fatalf("Activation() error = %v, wantErr %v", err, tC.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if ok := report.Check(tolerance) == nil; ok != tC.wantOK {
+				t.Errorf("Check() passed = %v, want %v: %v", ok, tC.wantOK, report)
+			}
+		})
+	}
+}
+
+// mustLoss returns the loss function produced by a constructor, panicking on its error.
+func mustLoss(l loss.LossFunction[float64], err error) loss.LossFunction[float64] {
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
+func TestLoss(t *testing.T) {
+	testCases := []struct {
+		desc string
+		l    loss.LossFunction[float64]
+	}{
+		{desc: "square", l: loss.SquareLoss[float64]{}},
+		{desc: "log", l: loss.LogLoss[float64]{}},
+		{desc: "categorical-cross-entropy", l: loss.CategoricalCrossEntropyLoss[float64]{}},
+		{desc: "mean-absolute-error", l: loss.MeanAbsoluteError[float64]{}},
+		{desc: "mean-absolute-percentage-error", l: loss.MeanAbsolutePercentageError[float64]{}},
+		{desc: "huber", l: mustLoss(loss.NewHuberLoss[float64](0.2))},
+		{desc: "log-cosh", l: loss.LogCoshLoss[float64]{}},
+		{desc: "quantile", l: mustLoss(loss.NewQuantileLoss[float64](0.9))},
+		{desc: "poisson", l: loss.PoissonLoss[float64]{}},
+		{desc: "binary-cross-entropy-with-logits", l: loss.BinaryCrossEntropyWithLogits[float64]{}},
+		{desc: "focal", l: loss.NewFocalLoss[float64](1.5, 0.4)},
+		{desc: "hinge", l: loss.HingeLoss[float64]{}},
+		{desc: "squared-hinge", l: loss.SquaredHingeLoss[float64]{}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			y := randomMatrix(3, 4, 0, 1)
+			yHat := randomMatrix(3, 4, 0.1, 0.9)
+
+			// Act
+			report, err := gradcheck.Loss(tC.l, y, yHat, 0)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Loss() error = %v", err)
+			}
+			if err := report.Check(tolerance); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestNetwork(t *testing.T) {
+	// Arrange
+	model, _ := nn.NewNeuralNetwork(
+		[]layers.Layer[float64]{
+			layers.NewRandomDense([2]int{3, 4}, activation.Sigmoid[float64]{}, layers.XavierUniformInitialization{}),
+			layers.NewLayerNorm[float64](4, 0),
+			layers.NewRandomDense([2]int{4, 2}, activation.Sigmoid[float64]{}, layers.XavierUniformInitialization{}),
+		},
+		loss.LogLoss[float64]{},
+	)
+	X := randomMatrix(3, 5, -1, 1)
+	Y := randomMatrix(2, 5, 0, 1)
+
+	// Act
+	report, err := gradcheck.Network(model, X, Y, 0)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Network() error = %v", err)
+	}
+	if len(report) != 6 {
+		t.Errorf("len(report) = %d, want 6", len(report))
+	}
+	if err := report.Check(tolerance); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,62 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Name returns the name of the type of the component without the type arguments,
+// e.g. "ReLU" for ReLU[float32] or *ReLU[float32].
+func Name(component any) string {
+	t := reflect.TypeOf(component)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	name, _, _ := strings.Cut(t.Name(), "[")
+	return name
+}
+
+// Marshal encodes the component to JSON. The components without parameters are
+// encoded just by their name, while the rest are encoded as an object with the name
+// in the "Type" field, and the parameters.
+func Marshal(component any) ([]byte, error) {
+	data, err := json.Marshal(component)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil || len(fields) == 0 {
+		return json.Marshal(Name(component))
+	}
+	fields["Type"], _ = json.Marshal(Name(component))
+	return json.Marshal(fields)
+}
+
+// Unmarshal decodes the component, encoded by Marshal. The component is produced by its
+// name using dynamic, and the parameters (if any) are decoded into a new value of the
+// same type.
+//
+// Returns error if dynamic fails, or the parameters are invalid.
+func Unmarshal[C any](data []byte, dynamic func(name string) (C, error)) (C, error) {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		return dynamic(name)
+	}
+
+	var zero C
+	var v struct{ Type string }
+	if err := json.Unmarshal(data, &v); err != nil {
+		return zero, err
+	}
+	component, err := dynamic(v.Type)
+	if err != nil {
+		return zero, err
+	}
+	p := reflect.New(reflect.TypeOf(component))
+	if err := json.Unmarshal(data, p.Interface()); err != nil {
+		return zero, fmt.Errorf("invalid %s parameters: %w", v.Type, err)
+	}
+	return p.Elem().Interface().(C), nil
+}
@@ -0,0 +1,48 @@
+// Package registry contains the helpers, shared by the packages, the components of which
+// (e.g. activation functions or layers) can be produced and encoded to JSON by their name.
+package registry
+
+import (
+	"fmt"
+	"sync"
+
+	. "golang.org/x/exp/constraints"
+)
+
+// Registry maps the names to the factories of the components, defined outside the package
+// they belong to. As the factories depend on the float type, the key contains both.
+// The zero value is ready to use, and is safe for concurrent use.
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]any
+}
+
+// Register adds the factory of the component by the name for the float type T.
+// Returns false if the name is already registered for T, in which case the registry
+// is left intact.
+func Register[T Float, F any](r *Registry, name string, factory F) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k := key[T](name)
+	if _, ok := r.factories[k]; ok {
+		return false
+	}
+	if r.factories == nil {
+		r.factories = make(map[string]any)
+	}
+	r.factories[k] = factory
+	return true
+}
+
+// Lookup returns the factory, registered by the name for the float type T.
+func Lookup[T Float, F any](r *Registry, name string) (F, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	factory, ok := r.factories[key[T](name)].(F)
+	return factory, ok
+}
+
+// key returns the key of the name for the float type T, e.g. "Custom[float64]".
+func key[T Float](name string) string {
+	return fmt.Sprintf("%s[%T]", name, T(0))
+}
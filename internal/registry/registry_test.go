@@ -0,0 +1,39 @@
+package registry_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Hukyl/mlgo/internal/registry"
+)
+
+func TestRegister_Concurrent(t *testing.T) {
+	// Arrange
+	var r registry.Registry
+	var registered atomic.Int32
+	var wg sync.WaitGroup
+
+	// Act
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if registry.Register[float64](&r, "Custom", func() int { return 1 }) {
+				registered.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Assert
+	if got := registered.Load(); got != 1 {
+		t.Errorf("registered %d times, want 1", got)
+	}
+	if _, ok := registry.Lookup[float32, func() int](&r, "Custom"); ok {
+		t.Error("Lookup() found the name registered for another float type")
+	}
+	if factory, ok := registry.Lookup[float64, func() int](&r, "Custom"); !ok || factory() != 1 {
+		t.Error("Lookup() did not find the registered factory")
+	}
+}
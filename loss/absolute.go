@@ -0,0 +1,76 @@
+package loss
+
+import (
+	"math"
+
+	. "github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
+)
+
+const defaultPercentageEpsilon = 1e-7
+
+// MeanAbsoluteError is a MAE loss function, which is less sensitive to the outliers
+// than SquareLoss.
+//
+//	MAE(pred, label) = |pred - label|
+//	dMAE/dpred = sign(pred - label)
+//
+// MAE is not differentiable at pred = label, where 0 is used as the derivative.
+type MeanAbsoluteError[T Float] struct{}
+
+func (m MeanAbsoluteError[T]) Apply(y, yHat T) T {
+	return T(math.Abs(float64(yHat - y)))
+}
+
+func (m MeanAbsoluteError[T]) ApplyMatrix(y Matrix[T], yHat Matrix[T]) Matrix[T] {
+	diff, _ := yHat.Add(y.MultiplyByScalar(-1))
+	return Abs(diff)
+}
+
+func (m MeanAbsoluteError[T]) ApplyDerivative(y, yHat T) T {
+	return sign(yHat - y)
+}
+
+func (m MeanAbsoluteError[T]) ApplyDerivativeMatrix(y Matrix[T], yHat Matrix[T]) Matrix[T] {
+	diff, _ := yHat.Add(y.MultiplyByScalar(-1))
+	return Sign(diff)
+}
+
+// MeanAbsolutePercentageError is a MAPE loss function, i.e. the absolute error
+// relative to the label, in percents.
+//
+//	MAPE(pred, label) = 100 * |pred - label| / max(|label|, ε)
+//	dMAPE/dpred = 100 * sign(pred - label) / max(|label|, ε)
+//
+// Epsilon prevents the division by zero for the zero labels. If not set,
+// it is initialized to 1e-7.
+type MeanAbsolutePercentageError[T Float] struct {
+	Epsilon float64
+}
+
+// scale returns 100 / max(|label|, ε).
+func (m MeanAbsolutePercentageError[T]) scale(y T) T {
+	epsilon := m.Epsilon
+	if epsilon == 0 {
+		epsilon = defaultPercentageEpsilon
+	}
+	return T(100 / math.Max(math.Abs(float64(y)), epsilon))
+}
+
+func (m MeanAbsolutePercentageError[T]) Apply(y, yHat T) T {
+	return m.scale(y) * T(math.Abs(float64(yHat-y)))
+}
+
+func (m MeanAbsolutePercentageError[T]) ApplyMatrix(y Matrix[T], yHat Matrix[T]) Matrix[T] {
+	result, _ := Map2(y, yHat, m.Apply)
+	return result
+}
+
+func (m MeanAbsolutePercentageError[T]) ApplyDerivative(y, yHat T) T {
+	return m.scale(y) * sign(yHat-y)
+}
+
+func (m MeanAbsolutePercentageError[T]) ApplyDerivativeMatrix(y Matrix[T], yHat Matrix[T]) Matrix[T] {
+	result, _ := Map2(y, yHat, m.ApplyDerivative)
+	return result
+}
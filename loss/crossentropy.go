@@ -19,23 +19,11 @@ func (l CategoricalCrossEntropyLoss[T]) Apply(y, yHat T) T {
 }
 
 func (l CategoricalCrossEntropyLoss[T]) ApplyMatrix(y Matrix[T], yHat Matrix[T]) Matrix[T] {
-	result := NewZeroMatrix[T](1, y.ColumnCount())
-	for j := 0; j < y.ColumnCount(); j++ {
-		sum := T(0)
-
-		for i := 0; i < y.RowCount(); i++ {
-			yValue, _ := y.At(i, j)
-			yHatValue, _ := yHat.At(i, j)
-			// In case yHatValue is close to 0
-			sum += -yValue * T(math.Log(math.Max(
-				float64(yHatValue),
-				l.Epsilon/float64(y.ColumnCount()*10),
-			)))
-		}
-
-		result.Set(0, j, sum)
-	}
-	return result
+	// In case yHat is close to 0
+	floor := T(l.Epsilon / float64(y.ColumnCount()*10))
+	logarithms := Log(Clip(yHat, floor, T(math.Inf(1))))
+	result, _ := y.MultiplyElementwise(logarithms)
+	return Sum(result, PerColumn).MultiplyByScalar(-1)
 }
 
 func (l CategoricalCrossEntropyLoss[T]) ApplyDerivative(y, yHat T) T {
@@ -48,9 +36,7 @@ func (l CategoricalCrossEntropyLoss[T]) ApplyDerivativeMatrix(y Matrix[T], yHat
 		T(l.Epsilon)/T(y.ColumnCount()),
 		1-T(l.Epsilon),
 	)
-	denominator := yHat.MultiplyByScalar(-1)
-	ApplyByElement(denominator, func(x T) T { return 1 / x })
-	result, _ := smoothedLabel.MultiplyElementwise(denominator)
+	result, _ := smoothedLabel.MultiplyElementwise(Pow(yHat, -1).MultiplyByScalar(-1))
 	return result
 }
 
@@ -74,3 +60,92 @@ func (l CCELossWithSoftmax[T]) ApplyDerivativeMatrix(y Matrix[T], yHat Matrix[T]
 	result, _ := yHat.Add(smoothedLabel.MultiplyByScalar(-1))
 	return result
 }
+
+// SparseCategoricalCrossEntropy is CategoricalCrossEntropyLoss for the labels, given
+// as the class indices (i.e. a 1xN matrix) instead of the one-hot vectors, e.g. to be
+// used with Softmax activation.
+//
+//	SparseCCE(pred, label) = -Log(pred[label])
+//	dSparseCCE/dpred[i] = -1/pred[i] if i = label else 0
+//
+// The probabilities are floored by Epsilon, so that the loss stays finite. If not set,
+// it is initialized to 1e-7. The loss is NaN for the labels, which are not valid classes.
+//
+// As the label is the index, Apply() and ApplyDerivative() take the probability
+// of the labelled class as the prediction.
+type SparseCategoricalCrossEntropy[T Float] struct {
+	Epsilon float64
+}
+
+func (l SparseCategoricalCrossEntropy[T]) floor() T {
+	if l.Epsilon == 0 {
+		return probabilityEpsilon
+	}
+	return T(l.Epsilon)
+}
+
+func (l SparseCategoricalCrossEntropy[T]) Apply(_, yHat T) T {
+	return -T(math.Log(float64(max(yHat, l.floor()))))
+}
+
+func (l SparseCategoricalCrossEntropy[T]) ApplyMatrix(y Matrix[T], yHat Matrix[T]) Matrix[T] {
+	result := NewZeroMatrix[T](1, yHat.ColumnCount())
+	for j := 0; j < yHat.ColumnCount(); j++ {
+		class, ok := classIndex(y, yHat.RowCount(), j)
+		if !ok {
+			result.Set(0, j, T(math.NaN()))
+			continue
+		}
+		p, _ := yHat.At(class, j)
+		result.Set(0, j, l.Apply(1, p))
+	}
+	return result
+}
+
+func (l SparseCategoricalCrossEntropy[T]) ApplyDerivative(_, yHat T) T {
+	return -1 / max(yHat, l.floor())
+}
+
+func (l SparseCategoricalCrossEntropy[T]) ApplyDerivativeMatrix(y Matrix[T], yHat Matrix[T]) Matrix[T] {
+	result := NewZeroMatrix[T](yHat.RowCount(), yHat.ColumnCount())
+	for j := 0; j < yHat.ColumnCount(); j++ {
+		class, ok := classIndex(y, yHat.RowCount(), j)
+		if !ok {
+			for i := 0; i < result.RowCount(); i++ {
+				result.Set(i, j, T(math.NaN()))
+			}
+			continue
+		}
+		p, _ := yHat.At(class, j)
+		result.Set(class, j, l.ApplyDerivative(1, p))
+	}
+	return result
+}
+
+// LabelRows returns 1, as the labels are the class indices.
+func (l SparseCategoricalCrossEntropy[T]) LabelRows(int) int {
+	return 1
+}
+
+// DenseLabels converts the class indices to the one-hot vectors of the given number
+// of classes. The vectors of the labels, which are not valid classes, are zero.
+func (l SparseCategoricalCrossEntropy[T]) DenseLabels(y Matrix[T], outputRows int) Matrix[T] {
+	result := NewZeroMatrix[T](outputRows, y.ColumnCount())
+	for j := 0; j < y.ColumnCount(); j++ {
+		if class, ok := classIndex(y, outputRows, j); ok {
+			result.Set(class, j, 1)
+		}
+	}
+	return result
+}
+
+// classIndex returns the class index of the j-th sample, and whether it is a valid
+// class for the given class count.
+func classIndex[T Float](y Matrix[T], classCount, j int) (int, bool) {
+	label, err := y.At(0, j)
+	class := int(label)
+	if err != nil || T(class) != label || class < 0 || class >= classCount {
+		return 0, false
+	}
+	return class, true
+}
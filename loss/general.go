@@ -5,6 +5,7 @@ package loss
 import (
 	"fmt"
 
+	"github.com/Hukyl/mlgo/internal/registry"
 	. "github.com/Hukyl/mlgo/matrix"
 	. "golang.org/x/exp/constraints"
 )
@@ -29,9 +30,36 @@ type LossFunction[T Float] interface {
 	ApplyDerivativeMatrix(y Matrix[T], yHat Matrix[T]) Matrix[T]
 }
 
-// DynamicLoss returns a loss function by fully corresponding name.
+// SparseLabels is implemented by the loss functions, the labels of which are not in
+// the layout of the prediction, e.g. the class indices of SparseCategoricalCrossEntropy.
+//
+// LabelRows returns the number of rows of the labels for the prediction with
+// the given number of rows.
+//
+// DenseLabels converts the labels to the layout of the prediction with the given number
+// of rows (e.g. to the one-hot vectors), so that they can be compared by the metrics.
+type SparseLabels[T Float] interface {
+	LabelRows(outputRows int) int
+	DenseLabels(y Matrix[T], outputRows int) Matrix[T]
+}
+
+// DynamicLoss returns a loss function by fully corresponding name, which is either
+// one of the functions of the package, or registered by RegisterLoss.
 // Identical to importing and initializing the function directly.
 func DynamicLoss[T Float](lossName string) (LossFunction[T], error) {
+	if f := builtinLoss[T](lossName); f != nil {
+		return f, nil
+	}
+	factory, ok := registry.Lookup[T, func() LossFunction[T]](&registeredLosses, lossName)
+	if !ok {
+		return nil, fmt.Errorf("unknown loss function: %s", lossName)
+	}
+	return factory(), nil
+}
+
+// builtinLoss returns the loss function of the package by its name, or nil if there
+// is no such function.
+func builtinLoss[T Float](lossName string) LossFunction[T] {
 	var f LossFunction[T]
 	switch lossName {
 	case "SquareLoss":
@@ -42,8 +70,91 @@ func DynamicLoss[T Float](lossName string) (LossFunction[T], error) {
 		f = CategoricalCrossEntropyLoss[T]{}
 	case "CCELossWithSoftmax":
 		f = CCELossWithSoftmax[T]{}
-	default:
-		return nil, fmt.Errorf("unknown activation function: %s", lossName)
+	case "BinaryCrossEntropyWithLogits":
+		f = BinaryCrossEntropyWithLogits[T]{}
+	case "FocalLoss":
+		f = FocalLoss[T]{}
+	case "SparseCategoricalCrossEntropy":
+		f = SparseCategoricalCrossEntropy[T]{}
+	case "HingeLoss":
+		f = HingeLoss[T]{}
+	case "SquaredHingeLoss":
+		f = SquaredHingeLoss[T]{}
+	case "MeanAbsoluteError":
+		f = MeanAbsoluteError[T]{}
+	case "MeanAbsolutePercentageError":
+		f = MeanAbsolutePercentageError[T]{}
+	case "HuberLoss":
+		f = HuberLoss[T]{}
+	case "LogCoshLoss":
+		f = LogCoshLoss[T]{}
+	case "QuantileLoss":
+		f = QuantileLoss[T]{}
+	case "PoissonLoss":
+		f = PoissonLoss[T]{}
+	}
+	return f
+}
+
+// registeredLosses contains the loss functions, registered by RegisterLoss.
+var registeredLosses registry.Registry
+
+// RegisterLoss makes a loss function, defined outside the package, available by
+// its name in DynamicLoss, so that a model with it can be loaded from JSON.
+// The name has to be the name of the type (without the type arguments), as returned by Name.
+//
+// The function is registered only for the float type T. Panics if the name is already
+// used for T, or the factory is nil.
+func RegisterLoss[T Float](name string, factory func() LossFunction[T]) {
+	if factory == nil {
+		panic("loss: RegisterLoss factory is nil")
+	}
+	if builtinLoss[T](name) != nil || !registry.Register[T](&registeredLosses, name, factory) {
+		panic(fmt.Sprintf("loss: RegisterLoss called twice for %s", name))
+	}
+}
+
+// Name returns the name of the loss function, by which DynamicLoss produces it,
+// e.g. "SquareLoss" for SquareLoss[float32].
+func Name[T Float](f LossFunction[T]) string {
+	return registry.Name(f)
+}
+
+// MarshalLoss encodes the loss function to JSON. The functions without parameters
+// are encoded just by their name, while the rest (e.g. HuberLoss) are encoded
+// as an object with the name in the "Type" field, and the parameters.
+func MarshalLoss[T Float](f LossFunction[T]) ([]byte, error) {
+	return registry.Marshal(f)
+}
+
+// UnmarshalLoss decodes the loss function, encoded by MarshalLoss.
+//
+// Returns error if the function is unknown, or its parameters are invalid.
+func UnmarshalLoss[T Float](data []byte) (LossFunction[T], error) {
+	f, err := registry.Unmarshal(data, DynamicLoss[T])
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := f.(validator); ok {
+		if err := v.validate(); err != nil {
+			return nil, err
+		}
 	}
 	return f, nil
 }
+
+// validator is implemented by the loss functions, the parameters of which have
+// to be checked when the function is decoded, e.g. QuantileLoss.
+type validator interface {
+	validate() error
+}
+
+func sign[T Float](x T) T {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+	return 0
+}
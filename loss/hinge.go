@@ -0,0 +1,61 @@
+package loss
+
+import (
+	. "github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
+)
+
+// HingeLoss is a maximum-margin loss function, used for the binary classification
+// (e.g. SVM). The labels have to be -1 or 1, and the prediction is the raw score,
+// i.e. the last layer should use Linear activation.
+//
+//	Hinge(pred, label) = max(0, 1 - label*pred)
+//	dHinge/dpred = -label if label*pred < 1 else 0
+type HingeLoss[T Float] struct{}
+
+func (h HingeLoss[T]) Apply(y, yHat T) T {
+	return max(0, 1-y*yHat)
+}
+
+func (h HingeLoss[T]) ApplyMatrix(y Matrix[T], yHat Matrix[T]) Matrix[T] {
+	result, _ := Map2(y, yHat, h.Apply)
+	return result
+}
+
+func (h HingeLoss[T]) ApplyDerivative(y, yHat T) T {
+	if y*yHat < 1 {
+		return -y
+	}
+	return 0
+}
+
+func (h HingeLoss[T]) ApplyDerivativeMatrix(y Matrix[T], yHat Matrix[T]) Matrix[T] {
+	result, _ := Map2(y, yHat, h.ApplyDerivative)
+	return result
+}
+
+// SquaredHingeLoss is a smooth variation of HingeLoss, which penalizes the margin
+// violations quadratically. The labels have to be -1 or 1.
+//
+//	SquaredHinge(pred, label) = max(0, 1 - label*pred)**2
+//	dSquaredHinge/dpred = -2*label*max(0, 1 - label*pred)
+type SquaredHingeLoss[T Float] struct{}
+
+func (h SquaredHingeLoss[T]) Apply(y, yHat T) T {
+	margin := max(0, 1-y*yHat)
+	return margin * margin
+}
+
+func (h SquaredHingeLoss[T]) ApplyMatrix(y Matrix[T], yHat Matrix[T]) Matrix[T] {
+	result, _ := Map2(y, yHat, h.Apply)
+	return result
+}
+
+func (h SquaredHingeLoss[T]) ApplyDerivative(y, yHat T) T {
+	return -2 * y * max(0, 1-y*yHat)
+}
+
+func (h SquaredHingeLoss[T]) ApplyDerivativeMatrix(y Matrix[T], yHat Matrix[T]) Matrix[T] {
+	result, _ := Map2(y, yHat, h.ApplyDerivative)
+	return result
+}
@@ -0,0 +1,92 @@
+package loss
+
+import (
+	"fmt"
+	"math"
+
+	. "github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
+)
+
+// HuberLoss is a robust loss function, which is quadratic for the small errors
+// (as SquareLoss) and linear for the large ones (as MeanAbsoluteError).
+//
+//	Huber(pred, label) = 0.5 * d**2 if |d| <= δ else δ * (|d| - 0.5*δ), where d = pred - label
+//	dHuber/dpred = clip(d, -δ, δ)
+//
+// Delta has to be non-negative. If nil, it is initialized to 1.
+type HuberLoss[T Float] struct {
+	Delta *float64
+}
+
+// NewHuberLoss produces a Huber loss with the given δ.
+//
+// Returns error if δ is negative.
+func NewHuberLoss[T Float](delta float64) (HuberLoss[T], error) {
+	h := HuberLoss[T]{Delta: &delta}
+	return h, h.validate()
+}
+
+func (h HuberLoss[T]) validate() error {
+	if h.Delta != nil && !(*h.Delta >= 0) {
+		return fmt.Errorf("huber delta must be non-negative, got %v", *h.Delta)
+	}
+	return nil
+}
+
+func (h HuberLoss[T]) delta() T {
+	if h.Delta == nil {
+		return 1
+	}
+	return T(*h.Delta)
+}
+
+func (h HuberLoss[T]) Apply(y, yHat T) T {
+	d, delta := T(math.Abs(float64(yHat-y))), h.delta()
+	if d <= delta {
+		return d * d / 2
+	}
+	return delta * (d - delta/2)
+}
+
+func (h HuberLoss[T]) ApplyMatrix(y Matrix[T], yHat Matrix[T]) Matrix[T] {
+	result, _ := Map2(y, yHat, h.Apply)
+	return result
+}
+
+func (h HuberLoss[T]) ApplyDerivative(y, yHat T) T {
+	delta := h.delta()
+	return min(max(yHat-y, -delta), delta)
+}
+
+func (h HuberLoss[T]) ApplyDerivativeMatrix(y Matrix[T], yHat Matrix[T]) Matrix[T] {
+	diff, _ := yHat.Add(y.MultiplyByScalar(-1))
+	return Clip(diff, -h.delta(), h.delta())
+}
+
+// LogCoshLoss is a smooth robust loss function, which behaves as SquareLoss for
+// the small errors and as MeanAbsoluteError for the large ones.
+//
+//	LogCosh(pred, label) = log(cosh(pred - label))
+//	dLogCosh/dpred = tanh(pred - label)
+type LogCoshLoss[T Float] struct{}
+
+func (l LogCoshLoss[T]) Apply(y, yHat T) T {
+	// log(cosh(d)) = |d| + log(1 + exp(-2|d|)) - log(2), which does not overflow
+	d := math.Abs(float64(yHat - y))
+	return T(d + math.Log1p(math.Exp(-2*d)) - math.Ln2)
+}
+
+func (l LogCoshLoss[T]) ApplyMatrix(y Matrix[T], yHat Matrix[T]) Matrix[T] {
+	result, _ := Map2(y, yHat, l.Apply)
+	return result
+}
+
+func (l LogCoshLoss[T]) ApplyDerivative(y, yHat T) T {
+	return T(math.Tanh(float64(yHat - y)))
+}
+
+func (l LogCoshLoss[T]) ApplyDerivativeMatrix(y Matrix[T], yHat Matrix[T]) Matrix[T] {
+	result, _ := Map2(y, yHat, l.ApplyDerivative)
+	return result
+}
@@ -7,42 +7,134 @@ import (
 	. "golang.org/x/exp/constraints"
 )
 
+// probabilityEpsilon is the distance, by which the predicted probabilities are kept
+// from 0 and 1, so that the logarithms and the derivatives stay finite.
+const probabilityEpsilon = 1e-7
+
 // LogLoss is a logarithmic loss function, used for probability prediction.
 //
 //	LogLoss(pred, label) = -label*Log(pred) - (1-label)*Log(1 - pred)
 //	dLogLoss/dpred = (pred - label) / (pred - pred*pred)
+//
+// The prediction is clipped to [1e-7, 1 - 1e-7], so that the loss does not become
+// NaN for the saturated predictions. For the best numerical stability, prefer
+// BinaryCrossEntropyWithLogits.
 type LogLoss[T Float] struct{}
 
 func (l LogLoss[T]) Apply(y, yHat T) T {
+	yHat = clipProbability(yHat)
 	return -y*T(math.Log(float64(yHat))) - (1-y)*T(math.Log(1-float64(yHat)))
 }
 
 func (l LogLoss[T]) ApplyMatrix(y Matrix[T], yHat Matrix[T]) Matrix[T] {
-	ln_yHat := yHat.DeepCopy() // Log(yHat)
-	ApplyByElement(ln_yHat, func(t T) T { return T(math.Log(float64(t))) })
-
-	ln_1_yHat := yHat.DeepCopy() // Log(1 - yHat)
-	ApplyByElement(ln_1_yHat, func(t T) T { return T(math.Log(1 - float64(t))) })
-
-	p1, _ := y.MultiplyElementwise(ln_yHat) // y * Log(yHat)
-	p2 := y.DeepCopy()
-	ApplyByElement(p2, func(t T) T { return 1 - t })
-	p2, _ = p2.MultiplyElementwise(ln_1_yHat) // (1-y) * Log(1 - yHat)
+	yHat = Clip(yHat, probabilityEpsilon, 1-probabilityEpsilon)
+	p1, _ := y.MultiplyElementwise(Log(yHat)) // y * Log(yHat)
+	oneMinus := func(M Matrix[T]) Matrix[T] { return M.MultiplyByScalar(-1).AddScalar(1) }
+	p2, _ := oneMinus(y).MultiplyElementwise(Log(oneMinus(yHat))) // (1-y) * Log(1 - yHat)
 
 	result, _ := p1.Add(p2)
 	return result.MultiplyByScalar(-1) // -y*Log(yHat) - (1-y)*Log(1-yHat)
-
 }
 
 func (l LogLoss[T]) ApplyDerivative(y, yHat T) T {
+	yHat = clipProbability(yHat)
 	return (yHat - y) / (yHat - yHat*yHat)
 }
 
 func (l LogLoss[T]) ApplyDerivativeMatrix(y Matrix[T], yHat Matrix[T]) Matrix[T] {
-	yHatSquared, _ := yHat.MultiplyElementwise(yHat)
-	denominator, _ := yHat.Add(yHatSquared.MultiplyByScalar(-1))
-	ApplyByElement(denominator, func(x T) T { return 1 / x })
+	yHat = Clip(yHat, probabilityEpsilon, 1-probabilityEpsilon)
+	denominator, _ := yHat.Add(Pow(yHat, 2).MultiplyByScalar(-1))
+	denominator = Pow(denominator, -1)
 	numerator, _ := yHat.Add(y.MultiplyByScalar(-1))
 	result, _ := numerator.MultiplyElementwise(denominator)
 	return result // (yHat - y) / (yHat - yHat*yHat)
 }
+
+// BinaryCrossEntropyWithLogits is LogLoss, computed from the logits (i.e. the input
+// of Sigmoid) rather than from the probabilities, which keeps it numerically stable
+// even for the saturated predictions. Therefore, the last layer should use Linear
+// activation, and Sigmoid should be applied to its predictions to obtain the probabilities.
+//
+//	BCE(z, label) = max(z, 0) - z*label + Log(1 + exp(-|z|))
+//	dBCE/dz = Sigmoid(z) - label
+type BinaryCrossEntropyWithLogits[T Float] struct{}
+
+func (b BinaryCrossEntropyWithLogits[T]) Apply(y, z T) T {
+	v := float64(z)
+	return T(math.Max(v, 0) - v*float64(y) + math.Log1p(math.Exp(-math.Abs(v))))
+}
+
+func (b BinaryCrossEntropyWithLogits[T]) ApplyMatrix(y Matrix[T], z Matrix[T]) Matrix[T] {
+	result, _ := Map2(y, z, b.Apply)
+	return result
+}
+
+func (b BinaryCrossEntropyWithLogits[T]) ApplyDerivative(y, z T) T {
+	return T(1/(1+math.Exp(-float64(z)))) - y
+}
+
+func (b BinaryCrossEntropyWithLogits[T]) ApplyDerivativeMatrix(y Matrix[T], z Matrix[T]) Matrix[T] {
+	result, _ := Map2(y, z, b.ApplyDerivative)
+	return result
+}
+
+// FocalLoss is a variation of LogLoss for the imbalanced data, which down-weights
+// the well-classified samples by the factor (1 - p)^γ, so that the training focuses
+// on the hard ones, and weights the positive class by α (and the negative by 1 - α).
+//
+//	Focal(pred, label) = -α*label*(1 - pred)^γ*Log(pred) - (1-α)*(1-label)*pred^γ*Log(1 - pred)
+//	dFocal/dpred = α*label*(γ*(1 - pred)^(γ-1)*Log(pred) - (1 - pred)^γ/pred)
+//		- (1-α)*(1-label)*(γ*pred^(γ-1)*Log(1 - pred) - pred^γ/(1 - pred))
+//
+// If Gamma is nil, it is initialized to 2. If Alpha is nil, it is initialized to 0.25.
+// As LogLoss, the prediction is clipped to [1e-7, 1 - 1e-7].
+type FocalLoss[T Float] struct {
+	Gamma *float64
+	Alpha *float64
+}
+
+// NewFocalLoss produces a focal loss with the given γ and α.
+func NewFocalLoss[T Float](gamma, alpha float64) FocalLoss[T] {
+	return FocalLoss[T]{Gamma: &gamma, Alpha: &alpha}
+}
+
+func (f FocalLoss[T]) parameters() (gamma, alpha float64) {
+	gamma, alpha = 2, 0.25
+	if f.Gamma != nil {
+		gamma = *f.Gamma
+	}
+	if f.Alpha != nil {
+		alpha = *f.Alpha
+	}
+	return gamma, alpha
+}
+
+func (f FocalLoss[T]) Apply(y, yHat T) T {
+	gamma, alpha := f.parameters()
+	label, p := float64(y), float64(clipProbability(yHat))
+	positive := -alpha * label * math.Pow(1-p, gamma) * math.Log(p)
+	negative := -(1 - alpha) * (1 - label) * math.Pow(p, gamma) * math.Log(1-p)
+	return T(positive + negative)
+}
+
+func (f FocalLoss[T]) ApplyMatrix(y Matrix[T], yHat Matrix[T]) Matrix[T] {
+	result, _ := Map2(y, yHat, f.Apply)
+	return result
+}
+
+func (f FocalLoss[T]) ApplyDerivative(y, yHat T) T {
+	gamma, alpha := f.parameters()
+	label, p := float64(y), float64(clipProbability(yHat))
+	positive := alpha * label * (gamma*math.Pow(1-p, gamma-1)*math.Log(p) - math.Pow(1-p, gamma)/p)
+	negative := (1 - alpha) * (1 - label) * (gamma*math.Pow(p, gamma-1)*math.Log(1-p) - math.Pow(p, gamma)/(1-p))
+	return T(positive - negative)
+}
+
+func (f FocalLoss[T]) ApplyDerivativeMatrix(y Matrix[T], yHat Matrix[T]) Matrix[T] {
+	result, _ := Map2(y, yHat, f.ApplyDerivative)
+	return result
+}
+
+func clipProbability[T Float](p T) T {
+	return min(max(p, probabilityEpsilon), 1-probabilityEpsilon)
+}
@@ -0,0 +1,193 @@
+package loss_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Hukyl/mlgo/loss"
+	"github.com/Hukyl/mlgo/matrix"
+)
+
+func TestSaturatedPredictions(t *testing.T) {
+	testCases := []struct {
+		desc string
+		l    loss.LossFunction[float64]
+		yHat [][]float64
+	}{
+		{desc: "log-loss", l: loss.LogLoss[float64]{}, yHat: [][]float64{{1, 0}}},
+		{desc: "focal", l: loss.FocalLoss[float64]{}, yHat: [][]float64{{1, 0}}},
+		{desc: "binary-cross-entropy-with-logits", l: loss.BinaryCrossEntropyWithLogits[float64]{}, yHat: [][]float64{{1000, -1000}}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			y, _ := matrix.NewMatrix([][]float64{{0, 1}})
+			yHat, _ := matrix.NewMatrix(tC.yHat)
+
+			// Act
+			losses := tC.l.ApplyMatrix(y, yHat)
+			derivatives := tC.l.ApplyDerivativeMatrix(y, yHat)
+
+			// Assert
+			for j := 0; j < y.ColumnCount(); j++ {
+				l, _ := losses.At(0, j)
+				d, _ := derivatives.At(0, j)
+				if math.IsNaN(l) || math.IsInf(l, 0) || math.IsNaN(d) || math.IsInf(d, 0) {
+					t.Errorf("sample #%d: loss = %v, derivative = %v, want finite", j, l, d)
+				}
+			}
+		})
+	}
+}
+
+func TestBinaryCrossEntropyWithLogits(t *testing.T) {
+	// Arrange
+	y, _ := matrix.NewMatrix([][]float64{{0, 1, 1}})
+	z, _ := matrix.NewMatrix([][]float64{{-2, 0.5, 3}})
+	probabilities := matrix.Map(z, func(v float64) float64 { return 1 / (1 + math.Exp(-v)) })
+
+	// Act
+	got := loss.BinaryCrossEntropyWithLogits[float64]{}.ApplyMatrix(y, z)
+	want := loss.LogLoss[float64]{}.ApplyMatrix(y, probabilities)
+
+	// Assert
+	for j := 0; j < y.ColumnCount(); j++ {
+		g, _ := got.At(0, j)
+		w, _ := want.At(0, j)
+		if math.Abs(g-w) > 1e-10 {
+			t.Errorf("loss #%d = %v, want %v", j, g, w)
+		}
+	}
+}
+
+func TestFocalLoss_ZeroGamma(t *testing.T) {
+	// Arrange
+	y, _ := matrix.NewMatrix([][]float64{{0, 1, 1}})
+	yHat, _ := matrix.NewMatrix([][]float64{{0.2, 0.6, 0.9}})
+
+	// Act - without the focusing, the focal loss is LogLoss weighted by α
+	got := loss.NewFocalLoss[float64](0, 0.5).ApplyMatrix(y, yHat)
+	want := loss.LogLoss[float64]{}.ApplyMatrix(y, yHat).MultiplyByScalar(0.5)
+
+	// Assert
+	for j := 0; j < y.ColumnCount(); j++ {
+		g, _ := got.At(0, j)
+		w, _ := want.At(0, j)
+		if math.Abs(g-w) > 1e-10 {
+			t.Errorf("loss #%d = %v, want %v", j, g, w)
+		}
+	}
+}
+
+func TestQuantileLoss_ZeroQuantile(t *testing.T) {
+	// Arrange
+	y, _ := matrix.NewMatrix([][]float64{{1, 0}})
+	yHat, _ := matrix.NewMatrix([][]float64{{0, 1}})
+	l, err := loss.NewQuantileLoss[float64](0)
+
+	// Act
+	got := l.ApplyMatrix(y, yHat)
+
+	// Assert - only the overestimation is penalized
+	if err != nil {
+		t.Fatalf("NewQuantileLoss() error = %v", err)
+	}
+	want, _ := matrix.NewMatrix([][]float64{{0, 1}})
+	if !got.Equals(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestUnmarshalLoss_InvalidParameters(t *testing.T) {
+	testCases := []struct {
+		desc string
+		data string
+	}{
+		{desc: "negative-quantile", data: `{"Type":"QuantileLoss","Quantile":-0.1}`},
+		{desc: "quantile-above-one", data: `{"Type":"QuantileLoss","Quantile":1.5}`},
+		{desc: "negative-huber-delta", data: `{"Type":"HuberLoss","Delta":-1}`},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Act
+			_, err := loss.UnmarshalLoss[float64]([]byte(tC.data))
+
+			// Assert
+			if err == nil {
+				t.Error("UnmarshalLoss() error = nil")
+			}
+		})
+	}
+	if _, err := loss.NewQuantileLoss[float64](2); err == nil {
+		t.Error("NewQuantileLoss(2) error = nil")
+	}
+	if _, err := loss.NewHuberLoss[float64](-1); err == nil {
+		t.Error("NewHuberLoss(-1) error = nil")
+	}
+}
+
+func TestSparseCategoricalCrossEntropy(t *testing.T) {
+	// Arrange
+	labels, _ := matrix.NewMatrix([][]float64{{2, 0, 1}})
+	oneHot, _ := matrix.NewMatrix([][]float64{{0, 1, 0}, {0, 0, 1}, {1, 0, 0}})
+	yHat, _ := matrix.NewMatrix([][]float64{{0.2, 0.7, 0.1}, {0.3, 0.2, 0.6}, {0.5, 0.1, 0.3}})
+	sparse := loss.SparseCategoricalCrossEntropy[float64]{}
+	dense := loss.CategoricalCrossEntropyLoss[float64]{}
+
+	// Act
+	gotLoss := sparse.ApplyMatrix(labels, yHat)
+	gotDerivative := sparse.ApplyDerivativeMatrix(labels, yHat)
+
+	// Assert
+	wantLoss := dense.ApplyMatrix(oneHot, yHat)
+	wantDerivative := dense.ApplyDerivativeMatrix(oneHot, yHat)
+	for j := 0; j < yHat.ColumnCount(); j++ {
+		g, _ := gotLoss.At(0, j)
+		w, _ := wantLoss.At(0, j)
+		if math.Abs(g-w) > 1e-10 {
+			t.Errorf("loss #%d = %v, want %v", j, g, w)
+		}
+		for i := 0; i < yHat.RowCount(); i++ {
+			g, _ := gotDerivative.At(i, j)
+			w, _ := wantDerivative.At(i, j)
+			if math.Abs(g-w) > 1e-10 {
+				t.Errorf("derivative At(%d,%d) = %v, want %v", i, j, g, w)
+			}
+		}
+	}
+}
+
+func TestSparseCategoricalCrossEntropy_InvalidLabels(t *testing.T) {
+	// Arrange
+	labels, _ := matrix.NewMatrix([][]float64{{3, -1, 0.5}})
+	yHat := matrix.NewOnesMatrix[float64](3, 3).MultiplyByScalar(1.0 / 3)
+
+	// Act
+	losses := loss.SparseCategoricalCrossEntropy[float64]{}.ApplyMatrix(labels, yHat)
+
+	// Assert
+	for j := 0; j < losses.ColumnCount(); j++ {
+		if l, _ := losses.At(0, j); !math.IsNaN(l) {
+			t.Errorf("loss #%d = %v, want NaN", j, l)
+		}
+	}
+}
+
+func TestSparseCategoricalCrossEntropy_DenseLabels(t *testing.T) {
+	// Arrange
+	y, _ := matrix.NewMatrix([][]float64{{2, 0, 5}})
+	want, _ := matrix.NewMatrix([][]float64{{0, 1, 0}, {0, 0, 0}, {1, 0, 0}})
+	var l loss.SparseLabels[float64] = loss.SparseCategoricalCrossEntropy[float64]{}
+
+	// Act
+	rows := l.LabelRows(3)
+	got := l.DenseLabels(y, 3)
+
+	// Assert
+	if rows != 1 {
+		t.Errorf("LabelRows() = %d, want 1", rows)
+	}
+	if !got.Equals(want) {
+		t.Errorf("DenseLabels() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,35 @@
+package loss
+
+import (
+	"math"
+
+	. "github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
+)
+
+// PoissonLoss is the negative log-likelihood of the Poisson distribution (without
+// the constant term), used for predicting the counts. The prediction is the rate,
+// so it has to be positive, e.g. produced by Softplus activation.
+//
+//	Poisson(pred, label) = pred - label*Log(pred)
+//	dPoisson/dpred = 1 - label/pred
+type PoissonLoss[T Float] struct{}
+
+func (p PoissonLoss[T]) Apply(y, yHat T) T {
+	return yHat - y*T(math.Log(float64(yHat)))
+}
+
+func (p PoissonLoss[T]) ApplyMatrix(y Matrix[T], yHat Matrix[T]) Matrix[T] {
+	logarithms, _ := y.MultiplyElementwise(Log(yHat))
+	result, _ := yHat.Add(logarithms.MultiplyByScalar(-1))
+	return result // yHat - y*Log(yHat)
+}
+
+func (p PoissonLoss[T]) ApplyDerivative(y, yHat T) T {
+	return 1 - y/yHat
+}
+
+func (p PoissonLoss[T]) ApplyDerivativeMatrix(y Matrix[T], yHat Matrix[T]) Matrix[T] {
+	ratio, _ := y.MultiplyElementwise(Pow(yHat, -1))
+	return ratio.MultiplyByScalar(-1).AddScalar(1) // 1 - y/yHat
+}
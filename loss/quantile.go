@@ -0,0 +1,70 @@
+package loss
+
+import (
+	"fmt"
+
+	. "github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
+)
+
+const defaultQuantile = 0.5
+
+// QuantileLoss, or pinball loss, is minimized by the given quantile q of the label
+// distribution, e.g. to predict the bounds of the prediction interval with
+// q = 0.05 and q = 0.95.
+//
+//	Quantile(pred, label) = q * d if d >= 0 else (q - 1) * d, where d = label - pred
+//	dQuantile/dpred = -q if d > 0 else 1 - q
+//
+// Quantile has to be in range [0;1]. If nil, it is initialized to 0.5, i.e. the median
+// is predicted.
+type QuantileLoss[T Float] struct {
+	Quantile *float64
+}
+
+// NewQuantileLoss produces a quantile loss for the quantile q.
+//
+// Returns error if q is not in range [0;1].
+func NewQuantileLoss[T Float](q float64) (QuantileLoss[T], error) {
+	l := QuantileLoss[T]{Quantile: &q}
+	return l, l.validate()
+}
+
+func (q QuantileLoss[T]) validate() error {
+	if q.Quantile != nil && !(*q.Quantile >= 0 && *q.Quantile <= 1) {
+		return fmt.Errorf("quantile must be in range [0;1], got %v", *q.Quantile)
+	}
+	return nil
+}
+
+func (q QuantileLoss[T]) quantile() T {
+	if q.Quantile == nil {
+		return defaultQuantile
+	}
+	return T(*q.Quantile)
+}
+
+func (q QuantileLoss[T]) Apply(y, yHat T) T {
+	d, quantile := y-yHat, q.quantile()
+	if d >= 0 {
+		return quantile * d
+	}
+	return (quantile - 1) * d
+}
+
+func (q QuantileLoss[T]) ApplyMatrix(y Matrix[T], yHat Matrix[T]) Matrix[T] {
+	result, _ := Map2(y, yHat, q.Apply)
+	return result
+}
+
+func (q QuantileLoss[T]) ApplyDerivative(y, yHat T) T {
+	if y > yHat {
+		return -q.quantile()
+	}
+	return 1 - q.quantile()
+}
+
+func (q QuantileLoss[T]) ApplyDerivativeMatrix(y Matrix[T], yHat Matrix[T]) Matrix[T] {
+	result, _ := Map2(y, yHat, q.ApplyDerivative)
+	return result
+}
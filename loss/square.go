@@ -19,8 +19,7 @@ func (s SquareLoss[T]) Apply(y, yHat T) T {
 
 func (s SquareLoss[T]) ApplyMatrix(y Matrix[T], yHat Matrix[T]) Matrix[T] {
 	diff, _ := y.Add(yHat.MultiplyByScalar(-1))
-	ApplyByElement(diff, func(t T) T { return t * t / 2 })
-	return diff
+	return Pow(diff, 2).MultiplyByScalar(0.5)
 }
 
 func (s SquareLoss[T]) ApplyDerivative(y, yHat T) T {
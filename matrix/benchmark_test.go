@@ -0,0 +1,79 @@
+package matrix_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	m "github.com/Hukyl/mlgo/matrix"
+)
+
+func randomMatrix(rows, columns int) m.Matrix[float64] {
+	result := m.NewZeroMatrix[float64](rows, columns)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < columns; j++ {
+			result.Set(i, j, rand.Float64())
+		}
+	}
+	return result
+}
+
+// mnistBatchSizes are the batch sizes of the 784xN MNIST inputs.
+var mnistBatchSizes = []int{32, 128, 512}
+
+// BenchmarkMultiply multiplies the weights of a 784 -> 128 dense layer by a MNIST batch.
+func BenchmarkMultiply(b *testing.B) {
+	for _, n := range mnistBatchSizes {
+		W, X := randomMatrix(128, 784), randomMatrix(784, n)
+		b.Run(fmt.Sprintf("128x784x%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				W.Multiply(X)
+			}
+		})
+	}
+}
+
+// BenchmarkMultiply_Transposed computes the weight gradient dZ * X.T() of the same layer.
+func BenchmarkMultiply_Transposed(b *testing.B) {
+	for _, n := range mnistBatchSizes {
+		dZ, X := randomMatrix(128, n), randomMatrix(784, n)
+		b.Run(fmt.Sprintf("128x%dx784", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				dZ.Multiply(X.T())
+			}
+		})
+	}
+}
+
+func BenchmarkT(b *testing.B) {
+	for _, n := range mnistBatchSizes {
+		X := randomMatrix(784, n)
+		b.Run(fmt.Sprintf("784x%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				X.T()
+			}
+		})
+	}
+}
+
+func BenchmarkDeepCopy(b *testing.B) {
+	for _, n := range mnistBatchSizes {
+		X := randomMatrix(784, n)
+		b.Run(fmt.Sprintf("784x%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				X.DeepCopy()
+			}
+		})
+	}
+}
+
+func BenchmarkAdd(b *testing.B) {
+	for _, n := range mnistBatchSizes {
+		X, Y := randomMatrix(784, n), randomMatrix(784, n)
+		b.Run(fmt.Sprintf("784x%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				X.Add(Y)
+			}
+		})
+	}
+}
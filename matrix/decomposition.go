@@ -0,0 +1,485 @@
+package matrix
+
+import (
+	"errors"
+	"math"
+
+	. "golang.org/x/exp/constraints"
+)
+
+// The decompositions are computed in float64 regardless of T, so that Determinant
+// and Inverse of the Signed matrices are not affected by the integer division.
+
+// toFloat64 copies the elements of the matrix into a row-major float64 buffer.
+func toFloat64[T Signed | Float](M Matrix[T]) []float64 {
+	m := dense(M)
+	result := make([]float64, 0, m.rows*m.columns)
+	for i := 0; i < m.rows; i++ {
+		for _, v := range m.row(i) {
+			result = append(result, float64(v))
+		}
+	}
+	return result
+}
+
+// fromFloat64 creates a matrix from a row-major float64 buffer.
+func fromFloat64[T Signed | Float](data []float64, rows, columns int) *matrix[T] {
+	result := newMatrix[T](rows, columns)
+	for k, v := range data {
+		result.data[k] = T(v)
+	}
+	return result
+}
+
+// tolerance returns the value, below which the pivots of an n x n matrix
+// with the largest absolute element maxAbs are considered to be zero.
+func tolerance(data []float64, n int) float64 {
+	maxAbs := 0.0
+	for _, v := range data {
+		maxAbs = math.Max(maxAbs, math.Abs(v))
+	}
+	return float64(n) * maxAbs * 0x1p-52
+}
+
+/****************************************************************************/
+
+// lu stores the LU decomposition with partial pivoting of an n x n matrix.
+// The unit lower triangle L is stored below the diagonal, and U on and above it.
+type lu struct {
+	data     []float64
+	n        int
+	pivot    []int   // row i of P*A is the row pivot[i] of A
+	sign     float64 // determinant of P
+	singular bool
+}
+
+func factorLU(data []float64, n int) *lu {
+	d := &lu{data: data, n: n, pivot: make([]int, n), sign: 1}
+	for i := range d.pivot {
+		d.pivot[i] = i
+	}
+	eps := tolerance(data, n)
+	for k := 0; k < n; k++ {
+		p := k
+		for i := k + 1; i < n; i++ {
+			if math.Abs(data[i*n+k]) > math.Abs(data[p*n+k]) {
+				p = i
+			}
+		}
+		if p != k {
+			for j := 0; j < n; j++ {
+				data[k*n+j], data[p*n+j] = data[p*n+j], data[k*n+j]
+			}
+			d.pivot[k], d.pivot[p] = d.pivot[p], d.pivot[k]
+			d.sign = -d.sign
+		}
+		pivot := data[k*n+k]
+		if math.Abs(pivot) <= eps {
+			d.singular = true
+			continue
+		}
+		for i := k + 1; i < n; i++ {
+			factor := data[i*n+k] / pivot
+			data[i*n+k] = factor
+			if factor == 0 {
+				continue
+			}
+			for j := k + 1; j < n; j++ {
+				data[i*n+j] -= factor * data[k*n+j]
+			}
+		}
+	}
+	return d
+}
+
+func (d *lu) determinant() float64 {
+	if d.singular {
+		return 0
+	}
+	det := d.sign
+	for k := 0; k < d.n; k++ {
+		det *= d.data[k*d.n+k]
+	}
+	return det
+}
+
+// solve solves A*X = B for the row-major n x columns buffer b, replacing it with X.
+func (d *lu) solve(b []float64, columns int) {
+	n := d.n
+	x := make([]float64, len(b))
+	for i, p := range d.pivot {
+		copy(x[i*columns:(i+1)*columns], b[p*columns:(p+1)*columns])
+	}
+	for i := 0; i < n; i++ { // L*Y = P*B
+		for k := 0; k < i; k++ {
+			if factor := d.data[i*n+k]; factor != 0 {
+				for j := 0; j < columns; j++ {
+					x[i*columns+j] -= factor * x[k*columns+j]
+				}
+			}
+		}
+	}
+	for i := n - 1; i >= 0; i-- { // U*X = Y
+		for k := i + 1; k < n; k++ {
+			if factor := d.data[i*n+k]; factor != 0 {
+				for j := 0; j < columns; j++ {
+					x[i*columns+j] -= factor * x[k*columns+j]
+				}
+			}
+		}
+		pivot := d.data[i*n+i]
+		for j := 0; j < columns; j++ {
+			x[i*columns+j] /= pivot
+		}
+	}
+	copy(b, x)
+}
+
+func (d *lu) inverse() []float64 {
+	result := make([]float64, d.n*d.n)
+	for i := 0; i < d.n; i++ {
+		result[i*d.n+i] = 1
+	}
+	d.solve(result, d.n)
+	return result
+}
+
+// LUDecomposition is the decomposition P*A = L*U of a square matrix A, where P is
+// a permutation matrix, L is a lower triangular matrix with ones on the diagonal
+// and U is an upper triangular matrix. The rows are pivoted by the largest
+// absolute value in the column (partial pivoting).
+type LUDecomposition[T Float] struct {
+	lu *lu
+}
+
+// LU computes the LU decomposition of the matrix.
+//
+// Returns error if the matrix is not square. A singular matrix is decomposed
+// without an error, but can not be used to solve the systems.
+func LU[T Float](A Matrix[T]) (*LUDecomposition[T], error) {
+	if A.RowCount() != A.ColumnCount() {
+		return nil, errors.New("matrix is not square (n x n)")
+	}
+	return &LUDecomposition[T]{lu: factorLU(toFloat64(A), A.RowCount())}, nil
+}
+
+// L returns the lower triangular factor with ones on the diagonal.
+func (d *LUDecomposition[T]) L() Matrix[T] {
+	n := d.lu.n
+	result := newMatrix[T](n, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < i; j++ {
+			result.data[i*n+j] = T(d.lu.data[i*n+j])
+		}
+		result.data[i*n+i] = 1
+	}
+	return result
+}
+
+// U returns the upper triangular factor.
+func (d *LUDecomposition[T]) U() Matrix[T] {
+	n := d.lu.n
+	result := newMatrix[T](n, n)
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			result.data[i*n+j] = T(d.lu.data[i*n+j])
+		}
+	}
+	return result
+}
+
+// P returns the permutation matrix.
+func (d *LUDecomposition[T]) P() Matrix[T] {
+	n := d.lu.n
+	result := newMatrix[T](n, n)
+	for i, p := range d.lu.pivot {
+		result.data[i*n+p] = 1
+	}
+	return result
+}
+
+// IsSingular reports whether the decomposed matrix is (numerically) singular.
+func (d *LUDecomposition[T]) IsSingular() bool {
+	return d.lu.singular
+}
+
+// Determinant returns the determinant of the decomposed matrix.
+func (d *LUDecomposition[T]) Determinant() T {
+	return T(d.lu.determinant())
+}
+
+// Solve solves the system A*X = B, where every column of B is a separate right side.
+//
+// Returns error if the matrix is singular or B has a different row count.
+func (d *LUDecomposition[T]) Solve(B Matrix[T]) (Matrix[T], error) {
+	if B.RowCount() != d.lu.n {
+		return nil, errors.New("right side row count is not equal to matrix size")
+	}
+	if d.lu.singular {
+		return nil, errors.New("matrix is singular")
+	}
+	x := toFloat64(B)
+	d.lu.solve(x, B.ColumnCount())
+	return fromFloat64[T](x, B.RowCount(), B.ColumnCount()), nil
+}
+
+// Inverse returns the inverse of the decomposed matrix.
+//
+// Returns error if the matrix is singular.
+func (d *LUDecomposition[T]) Inverse() (Matrix[T], error) {
+	if d.lu.singular {
+		return nil, errors.New("matrix is singular")
+	}
+	return fromFloat64[T](d.lu.inverse(), d.lu.n, d.lu.n), nil
+}
+
+/****************************************************************************/
+
+// QRDecomposition is the (thin) decomposition A = Q*R of an m x n matrix A with
+// m >= n, where Q is an m x n matrix with orthonormal columns and R is an n x n
+// upper triangular matrix. It is computed by Householder reflections.
+type QRDecomposition[T Float] struct {
+	q, r       []float64
+	rows, cols int
+}
+
+// QR computes the QR decomposition of the matrix.
+//
+// Returns error if the matrix has less rows than columns.
+func QR[T Float](A Matrix[T]) (*QRDecomposition[T], error) {
+	m, n := A.RowCount(), A.ColumnCount()
+	if m < n {
+		return nil, errors.New("matrix has less rows than columns")
+	}
+	a := toFloat64(A)
+	reflections := make([][]float64, n)
+	for k := 0; k < n; k++ {
+		// v = x - alpha*e1, where x is the k-th column below the diagonal
+		v := make([]float64, m-k)
+		norm := 0.0
+		for i := k; i < m; i++ {
+			v[i-k] = a[i*n+k]
+			norm = math.Hypot(norm, v[i-k])
+		}
+		if norm == 0 {
+			continue
+		}
+		alpha := -math.Copysign(norm, v[0])
+		v[0] -= alpha
+		vNorm := 0.0
+		for _, x := range v {
+			vNorm = math.Hypot(vNorm, x)
+		}
+		for i := range v {
+			v[i] /= vNorm
+		}
+		reflections[k] = v
+		// A = (I - 2*v*v.T) * A
+		for j := k; j < n; j++ {
+			dot := 0.0
+			for i := k; i < m; i++ {
+				dot += v[i-k] * a[i*n+j]
+			}
+			for i := k; i < m; i++ {
+				a[i*n+j] -= 2 * dot * v[i-k]
+			}
+		}
+	}
+
+	r := make([]float64, n*n)
+	for i := 0; i < n; i++ {
+		copy(r[i*n+i:(i+1)*n], a[i*n+i:(i+1)*n])
+	}
+	// Q = H_0 * H_1 * ... * H_{n-1} * I[:, :n]
+	q := make([]float64, m*n)
+	for i := 0; i < n; i++ {
+		q[i*n+i] = 1
+	}
+	for k := n - 1; k >= 0; k-- {
+		v := reflections[k]
+		if v == nil {
+			continue
+		}
+		for j := 0; j < n; j++ {
+			dot := 0.0
+			for i := k; i < m; i++ {
+				dot += v[i-k] * q[i*n+j]
+			}
+			for i := k; i < m; i++ {
+				q[i*n+j] -= 2 * dot * v[i-k]
+			}
+		}
+	}
+	return &QRDecomposition[T]{q: q, r: r, rows: m, cols: n}, nil
+}
+
+// Q returns the m x n factor with orthonormal columns.
+func (d *QRDecomposition[T]) Q() Matrix[T] {
+	return fromFloat64[T](d.q, d.rows, d.cols)
+}
+
+// R returns the n x n upper triangular factor.
+func (d *QRDecomposition[T]) R() Matrix[T] {
+	return fromFloat64[T](d.r, d.cols, d.cols)
+}
+
+// IsFullRank reports whether the columns of the decomposed matrix are linearly independent.
+func (d *QRDecomposition[T]) IsFullRank() bool {
+	eps := tolerance(d.r, max(d.rows, d.cols))
+	for k := 0; k < d.cols; k++ {
+		if math.Abs(d.r[k*d.cols+k]) <= eps {
+			return false
+		}
+	}
+	return true
+}
+
+// Solve finds X, which minimizes ||A*X - B|| for every column of B, i.e. solves
+// the system in the least-squares sense.
+//
+// Returns error if the matrix is not full rank or B has a different row count.
+func (d *QRDecomposition[T]) Solve(B Matrix[T]) (Matrix[T], error) {
+	if B.RowCount() != d.rows {
+		return nil, errors.New("right side row count is not equal to matrix row count")
+	}
+	if !d.IsFullRank() {
+		return nil, errors.New("matrix is not full rank")
+	}
+	m, n, columns := d.rows, d.cols, B.ColumnCount()
+	b := toFloat64(B)
+	x := make([]float64, n*columns) // Q.T * B
+	for i := 0; i < m; i++ {
+		for k := 0; k < n; k++ {
+			if q := d.q[i*n+k]; q != 0 {
+				for j := 0; j < columns; j++ {
+					x[k*columns+j] += q * b[i*columns+j]
+				}
+			}
+		}
+	}
+	backSubstitute(d.r, n, x, columns)
+	return fromFloat64[T](x, n, columns), nil
+}
+
+// backSubstitute solves U*X = B for the upper triangular n x n buffer u,
+// replacing the n x columns buffer b with X.
+func backSubstitute(u []float64, n int, b []float64, columns int) {
+	for i := n - 1; i >= 0; i-- {
+		for k := i + 1; k < n; k++ {
+			for j := 0; j < columns; j++ {
+				b[i*columns+j] -= u[i*n+k] * b[k*columns+j]
+			}
+		}
+		for j := 0; j < columns; j++ {
+			b[i*columns+j] /= u[i*n+i]
+		}
+	}
+}
+
+// forwardSubstitute solves L*X = B for the lower triangular n x n buffer l,
+// replacing the n x columns buffer b with X.
+func forwardSubstitute(l []float64, n int, b []float64, columns int) {
+	for i := 0; i < n; i++ {
+		for k := 0; k < i; k++ {
+			for j := 0; j < columns; j++ {
+				b[i*columns+j] -= l[i*n+k] * b[k*columns+j]
+			}
+		}
+		for j := 0; j < columns; j++ {
+			b[i*columns+j] /= l[i*n+i]
+		}
+	}
+}
+
+/****************************************************************************/
+
+// CholeskyDecomposition is the decomposition A = L*L.T of a symmetric positive
+// definite matrix A, where L is a lower triangular matrix with positive diagonal.
+// It is about twice as fast as LU, e.g. for the covariance matrices.
+type CholeskyDecomposition[T Float] struct {
+	l []float64
+	n int
+}
+
+// Cholesky computes the Cholesky decomposition of the matrix.
+//
+// Returns error if the matrix is not square, not symmetric or not positive definite.
+func Cholesky[T Float](A Matrix[T]) (*CholeskyDecomposition[T], error) {
+	if A.RowCount() != A.ColumnCount() {
+		return nil, errors.New("matrix is not square (n x n)")
+	}
+	n := A.RowCount()
+	a := toFloat64(A)
+	eps := tolerance(a, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < i; j++ {
+			if math.Abs(a[i*n+j]-a[j*n+i]) > eps {
+				return nil, errors.New("matrix is not symmetric")
+			}
+		}
+	}
+
+	l := make([]float64, n*n)
+	for j := 0; j < n; j++ {
+		sum := a[j*n+j]
+		for k := 0; k < j; k++ {
+			sum -= l[j*n+k] * l[j*n+k]
+		}
+		if sum <= eps {
+			return nil, errors.New("matrix is not positive definite")
+		}
+		l[j*n+j] = math.Sqrt(sum)
+		for i := j + 1; i < n; i++ {
+			sum := a[i*n+j]
+			for k := 0; k < j; k++ {
+				sum -= l[i*n+k] * l[j*n+k]
+			}
+			l[i*n+j] = sum / l[j*n+j]
+		}
+	}
+	return &CholeskyDecomposition[T]{l: l, n: n}, nil
+}
+
+// L returns the lower triangular factor.
+func (d *CholeskyDecomposition[T]) L() Matrix[T] {
+	return fromFloat64[T](d.l, d.n, d.n)
+}
+
+// Determinant returns the determinant of the decomposed matrix.
+func (d *CholeskyDecomposition[T]) Determinant() T {
+	det := 1.0
+	for k := 0; k < d.n; k++ {
+		det *= d.l[k*d.n+k] * d.l[k*d.n+k]
+	}
+	return T(det)
+}
+
+// LogDeterminant returns the natural logarithm of the determinant, which does not
+// overflow for large matrices (e.g. in the log-likelihood of a Gaussian process).
+func (d *CholeskyDecomposition[T]) LogDeterminant() T {
+	sum := 0.0
+	for k := 0; k < d.n; k++ {
+		sum += 2 * math.Log(d.l[k*d.n+k])
+	}
+	return T(sum)
+}
+
+// Solve solves the system A*X = B, where every column of B is a separate right side.
+//
+// Returns error if B has a different row count.
+func (d *CholeskyDecomposition[T]) Solve(B Matrix[T]) (Matrix[T], error) {
+	if B.RowCount() != d.n {
+		return nil, errors.New("right side row count is not equal to matrix size")
+	}
+	n, columns := d.n, B.ColumnCount()
+	x := toFloat64(B)
+	forwardSubstitute(d.l, n, x, columns) // L*Y = B
+	lT := make([]float64, n*n)
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i; j++ {
+			lT[j*n+i] = d.l[i*n+j]
+		}
+	}
+	backSubstitute(lT, n, x, columns) // L.T*X = Y
+	return fromFloat64[T](x, n, columns), nil
+}
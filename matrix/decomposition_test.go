@@ -0,0 +1,332 @@
+package matrix_test
+
+import (
+	"math"
+	"testing"
+
+	m "github.com/Hukyl/mlgo/matrix"
+)
+
+// hilbert returns the n x n Hilbert matrix, which is badly conditioned.
+func hilbert(n int) m.Matrix[float64] {
+	result := m.NewZeroMatrix[float64](n, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			result.Set(i, j, 1/float64(i+j+1))
+		}
+	}
+	return result
+}
+
+func TestLU(t *testing.T) {
+	// Arrange
+	A, _ := m.NewMatrix([][]float64{{1, 2, 3}, {4, 5, 6}, {7, 8, 10}})
+
+	// Act
+	d, err := m.LU(A)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("LU() error = %v", err)
+	}
+	PA, _ := d.P().Multiply(A)
+	LU, _ := d.L().Multiply(d.U())
+	if !approxEquals(PA, LU) {
+		t.Errorf("P*A = %v, L*U = %v", PA, LU)
+	}
+	if det := d.Determinant(); math.Abs(det+3) > 1e-9 {
+		t.Errorf("Determinant() = %v, want -3", det)
+	}
+}
+
+func TestQR(t *testing.T) {
+	// Arrange
+	A, _ := m.NewMatrix([][]float64{{12, -51, 4}, {6, 167, -68}, {-4, 24, -41}, {1, 1, 1}})
+
+	// Act
+	d, err := m.QR(A)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("QR() error = %v", err)
+	}
+	QR, _ := d.Q().Multiply(d.R())
+	if !approxEquals(QR, A) {
+		t.Errorf("Q*R = %v, want %v", QR, A)
+	}
+	QtQ, _ := d.Q().T().Multiply(d.Q())
+	if !approxEquals(QtQ, m.IdentityMatrix[float64](3)) {
+		t.Errorf("Q.T*Q = %v, want identity", QtQ)
+	}
+	for i := 1; i < 3; i++ {
+		for j := 0; j < i; j++ {
+			if v, _ := d.R().At(i, j); v != 0 {
+				t.Errorf("R[%d][%d] = %v, want 0", i, j, v)
+			}
+		}
+	}
+	if _, err := m.QR(A.T()); err == nil {
+		t.Error("QR() didn't produce error for a wide matrix")
+	}
+}
+
+func TestCholesky(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		data    [][]float64
+		wantErr bool
+	}{
+		{desc: "positive-definite", data: [][]float64{{4, 12, -16}, {12, 37, -43}, {-16, -43, 98}}},
+		{desc: "not-symmetric", data: [][]float64{{4, 1}, {2, 4}}, wantErr: true},
+		{desc: "not-positive-definite", data: [][]float64{{1, 2}, {2, 1}}, wantErr: true},
+		{desc: "not-square", data: [][]float64{{1, 2}}, wantErr: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			A, _ := m.NewMatrix(tC.data)
+
+			// Act
+			d, err := m.Cholesky(A)
+
+			// Assert
+			if (err != nil) != tC.wantErr {
+				t.Fatalf("Cholesky() error = %v, wantErr %v", err, tC.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			LLt, _ := d.L().Multiply(d.L().T())
+			if !approxEquals(LLt, A) {
+				t.Errorf("L*L.T = %v, want %v", LLt, A)
+			}
+			if det, _ := A.Determinant(); math.Abs(d.Determinant()-det) > 1e-9 {
+				t.Errorf("Determinant() = %v, want %v", d.Determinant(), det)
+			}
+			if logDet := d.LogDeterminant(); math.Abs(logDet-math.Log(d.Determinant())) > 1e-9 {
+				t.Errorf("LogDeterminant() = %v, want %v", logDet, math.Log(d.Determinant()))
+			}
+		})
+	}
+}
+
+func TestSolve(t *testing.T) {
+	spd, _ := m.NewMatrix([][]float64{{4, 12, -16}, {12, 37, -43}, {-16, -43, 98}})
+	testCases := []struct {
+		desc    string
+		A       m.Matrix[float64]
+		solve   func(A, B m.Matrix[float64]) (m.Matrix[float64], error)
+		wantErr bool
+	}{
+		{desc: "lu", A: spd, solve: m.Solve[float64]},
+		{desc: "lu-large", A: must(hilbert(6).Add(m.IdentityMatrix[float64](6))), solve: m.Solve[float64]},
+		{
+			desc: "cholesky",
+			A:    spd,
+			solve: func(A, B m.Matrix[float64]) (m.Matrix[float64], error) {
+				d, err := m.Cholesky(A)
+				if err != nil {
+					return nil, err
+				}
+				return d.Solve(B)
+			},
+		},
+		{desc: "least-squares-square", A: spd, solve: m.LeastSquares[float64]},
+		{
+			desc:    "singular",
+			A:       m.NewOnesMatrix[float64](3, 3),
+			solve:   m.Solve[float64],
+			wantErr: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			n := tC.A.RowCount()
+			want := randomMatrix(n, 2)
+			B, _ := tC.A.Multiply(want)
+
+			// Act
+			got, err := tC.solve(tC.A, B)
+
+			// Assert
+			if (err != nil) != tC.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tC.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !approxEquals(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestLeastSquares(t *testing.T) {
+	t.Run("overdetermined", func(t *testing.T) {
+		// Arrange: the points around y = 1.8x + 1.3
+		A, _ := m.NewMatrix([][]float64{{1, 0}, {1, 1}, {1, 2}, {1, 3}})
+		B, _ := m.NewMatrix([][]float64{{1.5}, {2.5}, {5.5}, {6.5}})
+		want, _ := m.NewMatrix([][]float64{{1.3}, {1.8}})
+
+		// Act
+		got, err := m.LeastSquares(A, B)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("LeastSquares() error = %v", err)
+		}
+		if !approxEquals(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+	t.Run("underdetermined", func(t *testing.T) {
+		// Arrange: x + y = 2 has the smallest-norm solution x = y = 1
+		A, _ := m.NewMatrix([][]float64{{1, 1}})
+		B, _ := m.NewMatrix([][]float64{{2}})
+		want, _ := m.NewMatrix([][]float64{{1}, {1}})
+
+		// Act
+		got, err := m.LeastSquares(A, B)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("LeastSquares() error = %v", err)
+		}
+		if !approxEquals(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+	t.Run("rank-deficient", func(t *testing.T) {
+		// Arrange
+		A, _ := m.NewMatrix([][]float64{{1, 2}, {2, 4}, {3, 6}})
+
+		// Act
+		_, err := m.LeastSquares(A, m.NewOnesMatrix[float64](3, 1))
+
+		// Assert
+		if err == nil {
+			t.Error("LeastSquares() didn't produce rank error")
+		}
+	})
+}
+
+func TestConditionNumber(t *testing.T) {
+	testCases := []struct {
+		desc string
+		A    m.Matrix[float64]
+		want float64
+	}{
+		{desc: "identity", A: m.IdentityMatrix[float64](4), want: 1},
+		{desc: "diagonal", A: must(m.NewMatrix([][]float64{{2, 0}, {0, 0.5}})), want: 4},
+		{desc: "hilbert", A: hilbert(4), want: 28375},
+		{desc: "singular", A: m.NewOnesMatrix[float64](2, 2), want: math.Inf(1)},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Act
+			got, err := m.ConditionNumber(tC.A)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("ConditionNumber() error = %v", err)
+			}
+			if got != tC.want && math.Abs(got-tC.want) > 1e-6*tC.want {
+				t.Errorf("ConditionNumber() = %v, want %v", got, tC.want)
+			}
+		})
+	}
+}
+
+func TestDeterminant(t *testing.T) {
+	testCases := []struct {
+		desc string
+		data [][]int
+		want int
+	}{
+		{desc: "1x1", data: [][]int{{-7}}, want: -7},
+		{desc: "3x3", data: [][]int{{2, -3, 1}, {2, 0, -1}, {1, 4, 5}}, want: 49},
+		{desc: "singular", data: [][]int{{1, 2}, {2, 4}}, want: 0},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			M, _ := m.NewMatrix(tC.data)
+
+			// Act
+			got, err := M.Determinant()
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Determinant() error = %v", err)
+			}
+			if got != tC.want {
+				t.Errorf("Determinant() = %v, want %v", got, tC.want)
+			}
+		})
+	}
+}
+
+func TestInverse(t *testing.T) {
+	t.Run("float", func(t *testing.T) {
+		// Arrange
+		A := must(hilbert(12).Add(m.IdentityMatrix[float64](12)))
+
+		// Act
+		inverse, err := A.Inverse()
+
+		// Assert
+		if err != nil {
+			t.Fatalf("Inverse() error = %v", err)
+		}
+		product, _ := A.Multiply(inverse)
+		if !approxEquals(product, m.IdentityMatrix[float64](12)) {
+			t.Errorf("A*A^-1 = %v, want identity", product)
+		}
+	})
+	t.Run("integral", func(t *testing.T) {
+		// Arrange
+		A, _ := m.NewMatrix([][]int{{2, 1}, {1, 1}})
+		want, _ := m.NewMatrix([][]int{{1, -1}, {-1, 2}})
+
+		// Act
+		got, err := A.Inverse()
+
+		// Assert
+		if err != nil {
+			t.Fatalf("Inverse() error = %v", err)
+		}
+		if !got.Equals(want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+	t.Run("not-integral", func(t *testing.T) {
+		// Arrange
+		A, _ := m.NewMatrix([][]int{{2, 0}, {0, 1}})
+
+		// Act
+		_, err := A.Inverse()
+
+		// Assert
+		if err == nil {
+			t.Error("Inverse() didn't produce error")
+		}
+	})
+	t.Run("singular", func(t *testing.T) {
+		// Act
+		_, err := m.NewOnesMatrix[float64](3, 3).Inverse()
+
+		// Assert
+		if err == nil {
+			t.Error("Inverse() didn't produce error")
+		}
+	})
+}
+
+func must(M m.Matrix[float64], err error) m.Matrix[float64] {
+	if err != nil {
+		panic(err)
+	}
+	return M
+}
@@ -0,0 +1,120 @@
+package matrix
+
+import (
+	"errors"
+	"math"
+
+	. "golang.org/x/exp/constraints"
+)
+
+// Map produces a new matrix by applying some function elementwise to the matrix.
+// Unlike ApplyByElement, the matrix is not changed.
+func Map[T Signed | Float](M Matrix[T], f func(T) T) Matrix[T] {
+	return dense(M).elementwise(nil, func(result, row, _ []T) {
+		for j, v := range row {
+			result[j] = f(v)
+		}
+	})
+}
+
+// Map2 produces a new matrix by applying some function to each pair of the corresponding
+// elements of the matrices, e.g. of the labels and the predictions.
+//
+// Returns error if the matrices are not the same size.
+func Map2[T Signed | Float](M1, M2 Matrix[T], f func(T, T) T) (Matrix[T], error) {
+	if !M1.AreSameSize(M2) {
+		return nil, errors.New("matrices are not the same size")
+	}
+	return dense(M1).elementwise(dense(M2), func(result, row, otherRow []T) {
+		for j, v := range row {
+			result[j] = f(v, otherRow[j])
+		}
+	}), nil
+}
+
+// Exp applies e^x to each element.
+func Exp[T Float](M Matrix[T]) Matrix[T] {
+	return Map(M, func(v T) T { return T(math.Exp(float64(v))) })
+}
+
+// Log applies the natural logarithm to each element.
+func Log[T Float](M Matrix[T]) Matrix[T] {
+	return Map(M, func(v T) T { return T(math.Log(float64(v))) })
+}
+
+// Sqrt applies the square root to each element.
+func Sqrt[T Float](M Matrix[T]) Matrix[T] {
+	return Map(M, func(v T) T { return T(math.Sqrt(float64(v))) })
+}
+
+// Pow raises each element to the power p.
+func Pow[T Float](M Matrix[T], p T) Matrix[T] {
+	switch p {
+	case 1:
+		return M.DeepCopy()
+	case 2:
+		return Map(M, func(v T) T { return v * v })
+	case -1:
+		return Map(M, func(v T) T { return 1 / v })
+	}
+	return Map(M, func(v T) T { return T(math.Pow(float64(v), float64(p))) })
+}
+
+// Abs applies the absolute value to each element.
+func Abs[T Signed | Float](M Matrix[T]) Matrix[T] {
+	return Map(M, func(v T) T {
+		if v < 0 {
+			return -v
+		}
+		return v
+	})
+}
+
+// Sign replaces each element with -1, 0 or 1 depending on its sign. NaN is kept as is.
+func Sign[T Signed | Float](M Matrix[T]) Matrix[T] {
+	return Map(M, func(v T) T {
+		switch {
+		case v > 0:
+			return 1
+		case v < 0:
+			return -1
+		}
+		return v
+	})
+}
+
+// Mask produces a matrix with 1 for the elements, which satisfy the predicate, and 0
+// for the rest, e.g. the mask of positive elements:
+//
+//	positive := Mask(M, func(v float64) bool { return v > 0 })
+func Mask[T Signed | Float](M Matrix[T], predicate func(T) bool) Matrix[T] {
+	return Map(M, func(v T) T {
+		if predicate(v) {
+			return 1
+		}
+		return 0
+	})
+}
+
+// Where produces a matrix with the elements of a, where the condition (e.g. a mask)
+// is non-zero, and the elements of b elsewhere.
+//
+// Returns error if the matrices are not the same size.
+func Where[T Signed | Float](condition, a, b Matrix[T]) (Matrix[T], error) {
+	if !condition.AreSameSize(a) || !condition.AreSameSize(b) {
+		return nil, errors.New("matrices are not the same size")
+	}
+	first, second := dense(a), dense(b)
+	result := newMatrix[T](condition.RowCount(), condition.ColumnCount())
+	mask := dense(condition)
+	for i := 0; i < result.rows; i++ {
+		row, firstRow := result.row(i), first.row(i)
+		copy(row, second.row(i))
+		for j, c := range mask.row(i) {
+			if c != 0 {
+				row[j] = firstRow[j]
+			}
+		}
+	}
+	return result, nil
+}
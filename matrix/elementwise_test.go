@@ -0,0 +1,128 @@
+package matrix_test
+
+import (
+	"math"
+	"testing"
+
+	m "github.com/Hukyl/mlgo/matrix"
+)
+
+func TestElementwise(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		apply func(M m.Matrix[float64]) m.Matrix[float64]
+		want  [][]float64
+	}{
+		{
+			desc:  "exp",
+			apply: m.Exp[float64],
+			want:  [][]float64{{math.Exp(-4), 1}, {math.E, math.Exp(4)}},
+		},
+		{
+			desc:  "log",
+			apply: func(M m.Matrix[float64]) m.Matrix[float64] { return m.Log(m.Abs(M)) },
+			want:  [][]float64{{math.Log(4), math.Inf(-1)}, {0, math.Log(4)}},
+		},
+		{
+			desc:  "sqrt",
+			apply: func(M m.Matrix[float64]) m.Matrix[float64] { return m.Sqrt(m.Abs(M)) },
+			want:  [][]float64{{2, 0}, {1, 2}},
+		},
+		{
+			desc:  "square",
+			apply: func(M m.Matrix[float64]) m.Matrix[float64] { return m.Pow(M, 2) },
+			want:  [][]float64{{16, 0}, {1, 16}},
+		},
+		{
+			desc:  "cube",
+			apply: func(M m.Matrix[float64]) m.Matrix[float64] { return m.Pow(M, 3) },
+			want:  [][]float64{{-64, 0}, {1, 64}},
+		},
+		{
+			desc:  "reciprocal",
+			apply: func(M m.Matrix[float64]) m.Matrix[float64] { return m.Pow(M, -1) },
+			want:  [][]float64{{-0.25, math.Inf(1)}, {1, 0.25}},
+		},
+		{
+			desc:  "sign",
+			apply: m.Sign[float64],
+			want:  [][]float64{{-1, 0}, {1, 1}},
+		},
+		{
+			desc:  "clip",
+			apply: func(M m.Matrix[float64]) m.Matrix[float64] { return m.Clip(M, -1, 2) },
+			want:  [][]float64{{-1, 0}, {1, 2}},
+		},
+		{
+			desc: "mask",
+			apply: func(M m.Matrix[float64]) m.Matrix[float64] {
+				return m.Mask(M, func(v float64) bool { return v > 0 })
+			},
+			want: [][]float64{{0, 0}, {1, 1}},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			M, _ := m.NewMatrix([][]float64{{-4, 0}, {1, 4}})
+			before := M.DeepCopy()
+			want, _ := m.NewMatrix(tC.want)
+
+			// Act
+			got := tC.apply(M)
+
+			// Assert
+			if !got.Equals(want) && !approxEquals(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+			if !M.Equals(before) {
+				t.Error("the input matrix was changed")
+			}
+		})
+	}
+}
+
+func TestMap2(t *testing.T) {
+	// Arrange
+	a, _ := m.NewMatrix([][]int{{1, 2}, {3, 4}})
+	b, _ := m.NewMatrix([][]int{{5, 6}, {7, 8}})
+	want, _ := m.NewMatrix([][]int{{-4, -4}, {-4, -4}})
+
+	// Act
+	got, err := m.Map2(a, b, func(x, y int) int { return x - y })
+	_, sizeErr := m.Map2(a, m.NewZeroMatrix[int](1, 2), func(x, y int) int { return x - y })
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Map2() error = %v", err)
+	}
+	if !got.Equals(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if sizeErr == nil {
+		t.Error("Map2() didn't produce size error")
+	}
+}
+
+func TestWhere(t *testing.T) {
+	// Arrange
+	a, _ := m.NewMatrix([][]int{{1, 2}, {3, 4}})
+	b, _ := m.NewMatrix([][]int{{-1, -2}, {-3, -4}})
+	condition := m.Mask(a, func(v int) bool { return v%2 == 0 })
+	want, _ := m.NewMatrix([][]int{{-1, 2}, {-3, 4}})
+
+	// Act
+	got, err := m.Where(condition, a, b)
+	_, sizeErr := m.Where(condition, a, m.NewZeroMatrix[int](1, 2))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Where() error = %v", err)
+	}
+	if !got.Equals(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if sizeErr == nil {
+		t.Error("Where() didn't produce size error")
+	}
+}
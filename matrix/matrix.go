@@ -8,7 +8,6 @@ import (
 	"fmt"
 	"math"
 	"slices"
-	"sync"
 
 	. "golang.org/x/exp/constraints"
 )
@@ -60,11 +59,24 @@ import (
 // Minor returns the minor from a certain element, returning
 // (rows-1)x(columns-1) matrix. Returns error if invalid indices.
 //
-// Determinant returns the determinant of the matrix.
+// Determinant returns the determinant of the matrix, computed by the LU
+// decomposition. For Signed types, it is rounded to the nearest integer.
 //
-// Inverse returns the inverse matrix for a given matrix.
-// Only possible for square matrices. If the determinant is equal
-// to zero, returns an error.
+// Inverse returns the inverse matrix for a given matrix, computed by the LU
+// decomposition. Only possible for square matrices. If the matrix is singular,
+// returns an error. For Signed types, returns an error if the inverse has
+// non-integer elements (i.e. the determinant is not ±1).
+//
+// Slice returns the block of the rows [r0, r1) and the columns [c0, c1). The block
+// shares the elements with the matrix, so setting them changes the matrix as well.
+// Returns error if the indices are not in range.
+//
+// Row and Col return the i-th row as a 1xColumns matrix and the j-th column as
+// a Rowsx1 matrix, sharing the elements the same way as Slice does.
+//
+// Reshape returns the matrix with the same elements in the row-major order and
+// the given dimensions. The elements are shared if they are stored contiguously,
+// otherwise they are copied. Returns error if the number of elements differs.
 //
 // Copy and Deepcopy copy the matrix to a new location.
 type Matrix[T Signed | Float] interface {
@@ -91,6 +103,11 @@ type Matrix[T Signed | Float] interface {
 
 	T() Matrix[T]
 
+	Slice(r0, r1, c0, c1 int) (Matrix[T], error)
+	Row(int) (Matrix[T], error)
+	Col(int) (Matrix[T], error)
+	Reshape(rows, columns int) (Matrix[T], error)
+
 	Minor(int, int) (Matrix[T], error)
 	Determinant() (T, error)
 	Inverse() (Matrix[T], error)
@@ -99,21 +116,51 @@ type Matrix[T Signed | Float] interface {
 	DeepCopy() Matrix[T]
 }
 
+// matrix stores the elements in a single contiguous row-major buffer. The element (i, j)
+// is located at data[i*stride+j], so that the rows may be a part of a larger buffer.
 type matrix[T Signed | Float] struct {
-	data [][]T
+	data    []T
+	rows    int
+	columns int
+	stride  int
 }
 
-/************************************************************************/
+// newMatrix allocates a zero matrix with a contiguous buffer.
+func newMatrix[T Signed | Float](rows, columns int) *matrix[T] {
+	return &matrix[T]{data: make([]T, rows*columns), rows: rows, columns: columns, stride: columns}
+}
 
-func (m *matrix[T]) RowCount() int { return len(m.data) }
+// row returns the i-th row, sharing the buffer with the matrix.
+func (m *matrix[T]) row(i int) []T {
+	start := i * m.stride
+	return m.data[start : start+m.columns : start+m.columns]
+}
 
-func (m *matrix[T]) ColumnCount() int {
-	if len(m.data) > 0 {
-		return len(m.data[0])
+// dense returns the matrix as the buffer-backed implementation, copying it if
+// it is a different implementation of Matrix.
+func dense[T Signed | Float](M Matrix[T]) *matrix[T] {
+	switch m := M.(type) {
+	case *matrix[T]:
+		return m
+	case *sparse[T]:
+		return m.toDense()
+	}
+	result := newMatrix[T](M.RowCount(), M.ColumnCount())
+	for i := 0; i < result.rows; i++ {
+		row := result.row(i)
+		for j := range row {
+			row[j], _ = M.At(i, j)
+		}
 	}
-	return 0
+	return result
 }
 
+/************************************************************************/
+
+func (m *matrix[T]) RowCount() int { return m.rows }
+
+func (m *matrix[T]) ColumnCount() int { return m.columns }
+
 func (m1 *matrix[T]) Size() [2]int {
 	return [2]int{m1.RowCount(), m1.ColumnCount()}
 }
@@ -133,21 +180,15 @@ func (m *matrix[T]) Broadcast(newRows, newColumns int) error {
 	if newRows < rows || newColumns < columns || newRows%rows != 0 || newColumns%columns != 0 {
 		return errors.New("invalid broadcast size (must be scalable by a positive factor)")
 	}
-	for i := 0; i < rows; i++ {
-		m.data[i] = slices.Grow(m.data[i], newColumns-columns)
-		for j := 0; j < newColumns/columns-1; j++ {
-			m.data[i] = append(m.data[i], m.data[i][:columns]...)
-		}
-	}
-	m.data = slices.Grow(m.data, newRows-rows)
-	for i := 0; i < newRows-rows; i++ {
-		m.data = append(m.data, make([]T, newColumns))
-	}
-	for i := rows; i < newRows; i += rows {
-		for j := 0; j < rows; j++ {
-			copy(m.data[i+j], m.data[j])
+	result := newMatrix[T](newRows, newColumns)
+	for i := 0; i < newRows; i++ {
+		source := m.row(i % rows)
+		row := result.row(i)
+		for j := 0; j < newColumns; j += columns {
+			copy(row[j:j+columns], source)
 		}
 	}
+	*m = *result
 	return nil
 }
 
@@ -157,13 +198,10 @@ func (m1 *matrix[T]) Equals(m2 Matrix[T]) bool {
 	if !m1.AreSameSize(m2) {
 		return false
 	}
-	for i := 0; i < m1.RowCount(); i++ {
-		for j := 0; j < m1.ColumnCount(); j++ {
-			m1Item, _ := m1.At(i, j)
-			m2Item, _ := m2.At(i, j)
-			if m1Item != m2Item {
-				return false
-			}
+	other := dense(m2)
+	for i := 0; i < m1.rows; i++ {
+		if !slices.Equal(m1.row(i), other.row(i)) {
+			return false
 		}
 	}
 	return true
@@ -175,150 +213,170 @@ func (m1 *matrix[T]) At(i, j int) (T, error) {
 	if !m1.inRange(i, j) {
 		return 0, errors.New("indices are not in range")
 	}
-	return m1.data[i][j], nil
+	return m1.data[i*m1.stride+j], nil
 }
 
 func (m1 *matrix[T]) Set(i, j int, value T) error {
 	if !m1.inRange(i, j) {
 		return errors.New("indices are not in range")
 	}
-	m1.data[i][j] = value
+	m1.data[i*m1.stride+j] = value
 	return nil
 }
 
 /************************************************************************/
 
+// elementwise produces a new matrix by applying f to the corresponding rows
+// of the matrix and other (if given), splitting the rows between the workers.
+func (m1 *matrix[T]) elementwise(other *matrix[T], f func(result, row, otherRow []T)) *matrix[T] {
+	m3 := newMatrix[T](m1.rows, m1.columns)
+	parallelFor(m1.rows, m1.columns, func(start, end int) {
+		for i := start; i < end; i++ {
+			var otherRow []T
+			if other != nil {
+				otherRow = other.row(i)
+			}
+			f(m3.row(i), m1.row(i), otherRow)
+		}
+	})
+	return m3
+}
+
 func (m1 *matrix[T]) Add(m2 Matrix[T]) (Matrix[T], error) {
 	if !m1.AreSameSize(m2) {
 		return nil, errors.New("matrices are not the same size")
 	}
-	m3 := NewZeroMatrix[T](m1.RowCount(), m1.ColumnCount())
-
-	wg := sync.WaitGroup{}
-	wg.Add(m3.ColumnCount())
-	for j := 0; j < m3.ColumnCount(); j++ {
-		go func(j int) {
-			defer wg.Done()
-			for i := 0; i < m3.RowCount(); i++ {
-				m1ItemValue, _ := m1.At(i, j)
-				m2ItemValue, _ := m2.At(i, j)
-				m3.Set(i, j, m1ItemValue+m2ItemValue)
-			}
-		}(j)
-	}
-	wg.Wait()
-
-	return m3, nil
+	return m1.elementwise(dense(m2), func(result, row, otherRow []T) {
+		for j, v := range row {
+			result[j] = v + otherRow[j]
+		}
+	}), nil
 }
 
 func (m1 *matrix[T]) AddScalar(k T) Matrix[T] {
-	m3 := NewZeroMatrix[T](m1.RowCount(), m1.ColumnCount())
-
-	wg := sync.WaitGroup{}
-	wg.Add(m3.ColumnCount())
-	for j := 0; j < m3.ColumnCount(); j++ {
-		go func(j int) {
-			defer wg.Done()
-			for i := 0; i < m3.RowCount(); i++ {
-				item, _ := m1.At(i, j)
-				m3.Set(i, j, item+k)
-			}
-		}(j)
-	}
-	wg.Wait()
-
-	return m3
+	return m1.elementwise(nil, func(result, row, _ []T) {
+		for j, v := range row {
+			result[j] = v + k
+		}
+	})
 }
 
+// Multiply uses a blocked kernel, so that the blocks of both matrices stay in the cache
+// while being reused, and the rows of the result are split between the workers.
+// A sparse matrix is multiplied without converting it into the dense format.
 func (m1 *matrix[T]) Multiply(m2 Matrix[T]) (Matrix[T], error) {
 	otherSize := m2.Size()
 	if m1.ColumnCount() != otherSize[0] {
 		return nil, errors.New("matrices are not conformable under multiplication")
 	}
-	m3 := NewZeroMatrix[T](m1.RowCount(), otherSize[1])
-
-	wg := sync.WaitGroup{}
-	wg.Add(m3.ColumnCount())
-	for j := 0; j < m3.ColumnCount(); j++ {
-		go func(j int) {
-			defer wg.Done()
-			for i := 0; i < m3.RowCount(); i++ {
-				value := T(0)
-				for k := 0; k < m1.ColumnCount(); k++ {
-					m1Value, _ := m1.At(i, k)
-					m2Value, _ := m2.At(k, j)
-					value += m1Value * m2Value
-				}
-				m3.Set(i, j, value)
-			}
-		}(j)
+	if s, ok := m2.(*sparse[T]); ok {
+		b := s.asFormat(false)
+		m3 := newMatrix[T](m1.rows, b.columns)
+		parallelFor(m1.rows, len(b.values)+1, func(start, end int) {
+			multiplyDenseSparse(m1, b, m3, start, end)
+		})
+		return m3, nil
 	}
-	wg.Wait()
-
+	other := dense(m2)
+	m3 := newMatrix[T](m1.rows, other.columns)
+	parallelFor(m1.rows, m1.columns*other.columns, func(start, end int) {
+		multiplyBlock(m1, other, m3, start, end)
+	})
 	return m3, nil
 }
 
 func (m1 *matrix[T]) MultiplyByScalar(k T) Matrix[T] {
-	m3 := NewZeroMatrix[T](m1.RowCount(), m1.ColumnCount())
-
-	wg := sync.WaitGroup{}
-	wg.Add(m3.ColumnCount())
-	for j := 0; j < m3.ColumnCount(); j++ {
-		go func(j int) {
-			defer wg.Done()
-			for i := 0; i < m3.RowCount(); i++ {
-				item, _ := m1.At(i, j)
-				m3.Set(i, j, item*k)
-			}
-		}(j)
-	}
-	wg.Wait()
-
-	return m3
+	return m1.elementwise(nil, func(result, row, _ []T) {
+		for j, v := range row {
+			result[j] = v * k
+		}
+	})
 }
 
 func (m1 *matrix[T]) MultiplyElementwise(m2 Matrix[T]) (Matrix[T], error) {
 	if !m1.AreSameSize(m2) {
 		return nil, errors.New("matrices are not the same size")
 	}
-	m3 := NewZeroMatrix[T](m1.RowCount(), m1.ColumnCount())
-
-	wg := sync.WaitGroup{}
-	wg.Add(m3.ColumnCount())
-	for j := 0; j < m3.ColumnCount(); j++ {
-		go func(j int) {
-			defer wg.Done()
-			for i := 0; i < m3.RowCount(); i++ {
-				m1Value, _ := m1.At(i, j)
-				m2Value, _ := m2.At(i, j)
-				m3.Set(i, j, m1Value*m2Value)
-			}
-		}(j)
-	}
-	wg.Wait()
-
-	return m3, nil
+	return m1.elementwise(dense(m2), func(result, row, otherRow []T) {
+		for j, v := range row {
+			result[j] = v * otherRow[j]
+		}
+	}), nil
 }
 
 /************************************************************************/
 
+// T transposes the matrix by blocks, so that both the reads and the writes
+// stay within a few cache lines.
 func (m *matrix[T]) T() Matrix[T] {
-	result := NewZeroMatrix[T](m.ColumnCount(), m.RowCount())
-
-	wg := sync.WaitGroup{}
-	wg.Add(m.ColumnCount())
-	for j := 0; j < m.ColumnCount(); j++ {
-		go func(j int) {
-			defer wg.Done()
-			for i := 0; i < m.RowCount(); i++ {
-				item, _ := m.At(i, j)
-				result.Set(j, i, item)
+	result := newMatrix[T](m.columns, m.rows)
+	parallelFor(m.columns, m.rows, func(start, end int) {
+		for jj := start; jj < end; jj += blockSize {
+			jEnd := min(jj+blockSize, end)
+			for ii := 0; ii < m.rows; ii += blockSize {
+				iEnd := min(ii+blockSize, m.rows)
+				for j := jj; j < jEnd; j++ {
+					row := result.row(j)
+					for i := ii; i < iEnd; i++ {
+						row[i] = m.data[i*m.stride+j]
+					}
+				}
 			}
-		}(j)
+		}
+	})
+	return result
+}
+
+/************************************************************************/
+
+// view returns the block of the matrix, sharing the buffer with it. The indices
+// have to be in range.
+func (m *matrix[T]) view(r0, r1, c0, c1 int) *matrix[T] {
+	rows, columns := r1-r0, c1-c0
+	if rows == 0 || columns == 0 {
+		return newMatrix[T](rows, columns)
+	}
+	return &matrix[T]{
+		data:    m.data[r0*m.stride+c0 : (r1-1)*m.stride+c1],
+		rows:    rows,
+		columns: columns,
+		stride:  m.stride,
+	}
+}
+
+func (m *matrix[T]) Slice(r0, r1, c0, c1 int) (Matrix[T], error) {
+	if r0 < 0 || r1 < r0 || r1 > m.rows || c0 < 0 || c1 < c0 || c1 > m.columns {
+		return nil, errors.New("indices are not in range")
 	}
-	wg.Wait()
+	return m.view(r0, r1, c0, c1), nil
+}
 
-	return result
+func (m *matrix[T]) Row(i int) (Matrix[T], error) {
+	if i < 0 || i >= m.rows {
+		return nil, errors.New("index is not in range")
+	}
+	return m.view(i, i+1, 0, m.columns), nil
+}
+
+func (m *matrix[T]) Col(j int) (Matrix[T], error) {
+	if j < 0 || j >= m.columns {
+		return nil, errors.New("index is not in range")
+	}
+	return m.view(0, m.rows, j, j+1), nil
+}
+
+func (m *matrix[T]) Reshape(rows, columns int) (Matrix[T], error) {
+	if rows < 0 || columns < 0 || rows*columns != m.rows*m.columns {
+		return nil, fmt.Errorf("cannot reshape %dx%d matrix into %dx%d", m.rows, m.columns, rows, columns)
+	}
+	source := m
+	if m.stride != m.columns && m.rows > 1 {
+		source = m.DeepCopy().(*matrix[T])
+	}
+	if rows == 0 || columns == 0 {
+		return newMatrix[T](rows, columns), nil
+	}
+	return &matrix[T]{data: source.data[:rows*columns], rows: rows, columns: columns, stride: columns}, nil
 }
 
 /************************************************************************/
@@ -354,86 +412,88 @@ func (m *matrix[T]) Determinant() (T, error) {
 	if m.RowCount() != m.ColumnCount() {
 		return 0, errors.New("matrix is not square (n x n)")
 	}
-	if m.RowCount() == 1 {
-		return m.At(0, 0)
-	} else if m.RowCount() == 2 {
-		a, _ := m.At(0, 0)
-		b, _ := m.At(0, 1)
-		c, _ := m.At(1, 0)
-		d, _ := m.At(1, 1)
-		return a*d - b*c, nil
-	} else {
-		det := T(0)
-		for column := 0; column < m.ColumnCount(); column++ {
-			minor, _ := m.Minor(0, column)
-			minorDeterminant, _ := minor.Determinant()
-			value, _ := m.At(0, column)
-			det += T(math.Pow(-1, float64(column))) * value * minorDeterminant
-		}
-		return det, nil
+	det := factorLU(toFloat64[T](m), m.rows).determinant()
+	if isIntegral[T]() {
+		det = math.Round(det)
 	}
+	return T(det), nil
 }
 
 func (m *matrix[T]) Inverse() (Matrix[T], error) {
 	if m.RowCount() != m.ColumnCount() {
 		return nil, errors.New("matrix is not square (n x n)")
 	}
-	result := NewZeroMatrix[T](m.RowCount(), m.ColumnCount())
-	var determinant T // calculate it here to reduce computations
-	for i := 0; i < m.RowCount(); i++ {
-		determinant = 0
-		for j := 0; j < m.ColumnCount(); j++ {
-			minor, _ := m.Minor(i, j)
-			minorDeterminant, _ := minor.Determinant()
-			result.Set(i, j, T(math.Pow(-1, float64(i+j)))*minorDeterminant)
-
-			value, _ := m.At(i, j)
-			determinant += T(math.Pow(-1, float64(i+j))) * value * minorDeterminant
-		}
-	}
-	if determinant == 0 {
+	d := factorLU(toFloat64[T](m), m.rows)
+	if d.singular {
 		return nil, errors.New("matrix is singular")
 	}
-	return result.T().MultiplyByScalar(1 / determinant), nil
+	inverse := d.inverse()
+	if isIntegral[T]() {
+		for k, v := range inverse {
+			rounded := math.Round(v)
+			if math.Abs(v-rounded) > 1e-9*math.Max(1, math.Abs(v)) {
+				return nil, errors.New("inverse is not integral")
+			}
+			inverse[k] = rounded
+		}
+	}
+	return fromFloat64[T](inverse, m.rows, m.columns), nil
+}
+
+// isIntegral reports whether T is a Signed type.
+func isIntegral[T Signed | Float]() bool {
+	return T(1)/T(2) == 0
 }
 
 /************************************************************************/
 
-func (m matrix[T]) String() string {
-	return fmt.Sprint(m.data)
+// rowSlices returns the rows of the matrix, sharing the buffer with it.
+func (m *matrix[T]) rowSlices() [][]T {
+	result := make([][]T, m.rows)
+	for i := range result {
+		result[i] = m.row(i)
+	}
+	return result
 }
 
-func (m matrix[T]) Copy() Matrix[T] {
-	result, _ := NewMatrix[T](m.data)
-	return result
+func (m *matrix[T]) String() string {
+	return fmt.Sprint(m.rowSlices())
 }
 
-func (m matrix[T]) DeepCopy() Matrix[T] {
-	result := NewZeroMatrix[T](m.RowCount(), m.ColumnCount())
+// Copy returns a matrix, which shares the elements with the original one.
+func (m *matrix[T]) Copy() Matrix[T] {
+	result := *m
+	return &result
+}
 
-	wg := sync.WaitGroup{}
-	wg.Add(result.ColumnCount())
-	for j := 0; j < m.ColumnCount(); j++ {
-		go func(j int) {
-			defer wg.Done()
-			for i := 0; i < m.RowCount(); i++ {
-				item, _ := m.At(i, j)
-				result.Set(i, j, item)
-			}
-		}(j)
+func (m *matrix[T]) DeepCopy() Matrix[T] {
+	result := newMatrix[T](m.rows, m.columns)
+	if m.stride == m.columns {
+		copy(result.data, m.data[:m.rows*m.columns])
+		return result
+	}
+	for i := 0; i < m.rows; i++ {
+		copy(result.row(i), m.row(i))
 	}
-	wg.Wait()
-
 	return result
 }
 
+// MarshalJSON stores the matrix as a list of rows.
 func (m *matrix[T]) MarshalJSON() ([]byte, error) {
-	return json.Marshal(m.data)
+	return json.Marshal(m.rowSlices())
 }
 
+// UnmarshalJSON loads the matrix from a list of rows. Returns error if
+// the rows have different lengths.
 func (m *matrix[T]) UnmarshalJSON(data []byte) error {
-	if err := json.Unmarshal(data, &m.data); err != nil {
+	var rows [][]T
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return err
+	}
+	result, err := newMatrixFromRows(rows)
+	if err != nil {
 		return err
 	}
+	*m = *result
 	return nil
 }
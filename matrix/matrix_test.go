@@ -1,6 +1,8 @@
 package matrix_test
 
 import (
+	"math"
+	"runtime"
 	"testing"
 
 	m "github.com/Hukyl/mlgo/matrix"
@@ -35,3 +37,275 @@ func TestBroadcast(t *testing.T) {
 		t.Error("invalid broadcasting result")
 	}
 }
+
+// naiveMultiply multiplies the matrices by the definition, using only the interface.
+func naiveMultiply(a, b m.Matrix[float64]) m.Matrix[float64] {
+	result := m.NewZeroMatrix[float64](a.RowCount(), b.ColumnCount())
+	for i := 0; i < a.RowCount(); i++ {
+		for j := 0; j < b.ColumnCount(); j++ {
+			sum := 0.0
+			for k := 0; k < a.ColumnCount(); k++ {
+				x, _ := a.At(i, k)
+				y, _ := b.At(k, j)
+				sum += x * y
+			}
+			result.Set(i, j, sum)
+		}
+	}
+	return result
+}
+
+func TestMultiply(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4)) // exercise the workers on any machine
+	testCases := []struct {
+		desc string
+		size [3]int
+	}{
+		{desc: "small", size: [3]int{2, 3, 4}},
+		{desc: "vector", size: [3]int{1, 100, 1}},
+		{desc: "partial-blocks", size: [3]int{70, 130, 65}},
+		{desc: "parallel", size: [3]int{300, 200, 100}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			a := randomMatrix(tC.size[0], tC.size[1])
+			b := randomMatrix(tC.size[1], tC.size[2])
+			want := naiveMultiply(a, b)
+
+			// Act
+			got, err := a.Multiply(b)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Multiply() error = %v", err)
+			}
+			diff, _ := got.Add(want.MultiplyByScalar(-1))
+			for i := 0; i < diff.RowCount(); i++ {
+				for j := 0; j < diff.ColumnCount(); j++ {
+					if v, _ := diff.At(i, j); math.Abs(v) > 1e-9 {
+						t.Fatalf("(%d,%d) differs by %v", i, j, v)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestT(t *testing.T) {
+	// Arrange
+	M := randomMatrix(130, 70)
+
+	// Act
+	got := M.T()
+
+	// Assert
+	if got.Size() != [2]int{70, 130} {
+		t.Fatalf("Size() = %v, want [70 130]", got.Size())
+	}
+	for i := 0; i < M.RowCount(); i++ {
+		for j := 0; j < M.ColumnCount(); j++ {
+			want, _ := M.At(i, j)
+			if v, _ := got.At(j, i); v != want {
+				t.Fatalf("(%d,%d) = %v, want %v", j, i, v, want)
+			}
+		}
+	}
+}
+
+func TestNewMatrix_CopiesData(t *testing.T) {
+	// Arrange
+	data := [][]int{{1, 2}, {3, 4}}
+	M, _ := m.NewMatrix(data)
+
+	// Act
+	data[0][0] = 5
+	copied := M.Copy()
+	copied.Set(1, 1, 6)
+
+	// Assert
+	if v, _ := M.At(0, 0); v != 1 {
+		t.Errorf("At(0, 0) = %d, want 1", v)
+	}
+	if v, _ := M.At(1, 1); v != 6 {
+		t.Errorf("At(1, 1) = %d, want 6, as Copy() shares the elements", v)
+	}
+}
+
+func TestMatrix_JSON(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		data    string
+		want    [][]float64
+		wantErr bool
+	}{
+		{desc: "rows", data: "[[1,2,3],[4,5,6]]", want: [][]float64{{1, 2, 3}, {4, 5, 6}}},
+		{desc: "empty-row", data: "[[]]", want: [][]float64{{}}},
+		{desc: "inconsistent-rows", data: "[[1,2],[3]]", wantErr: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			M := m.NewZeroMatrix[float64](1, 1)
+
+			// Act
+			err := M.UnmarshalJSON([]byte(tC.data))
+
+			// Assert
+			if (err != nil) != tC.wantErr {
+				t.Fatalf("UnmarshalJSON() error = %v, wantErr %v", err, tC.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			want, _ := m.NewMatrix(tC.want)
+			if !M.Equals(want) {
+				t.Errorf("UnmarshalJSON() = %v, want %v", M, want)
+			}
+			if data, _ := M.MarshalJSON(); string(data) != tC.data {
+				t.Errorf("MarshalJSON() = %s, want %s", data, tC.data)
+			}
+		})
+	}
+}
+
+func TestSlice(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		get     func(M m.Matrix[int]) (m.Matrix[int], error)
+		want    [][]int
+		wantErr bool
+	}{
+		{
+			desc: "block",
+			get:  func(M m.Matrix[int]) (m.Matrix[int], error) { return M.Slice(1, 3, 1, 3) },
+			want: [][]int{{5, 6}, {8, 9}},
+		},
+		{
+			desc: "row",
+			get:  func(M m.Matrix[int]) (m.Matrix[int], error) { return M.Row(2) },
+			want: [][]int{{7, 8, 9}},
+		},
+		{
+			desc: "column",
+			get:  func(M m.Matrix[int]) (m.Matrix[int], error) { return M.Col(0) },
+			want: [][]int{{1}, {4}, {7}},
+		},
+		{
+			desc: "slice-of-slice",
+			get: func(M m.Matrix[int]) (m.Matrix[int], error) {
+				block, _ := M.Slice(1, 3, 0, 3)
+				return block.Col(2)
+			},
+			want: [][]int{{6}, {9}},
+		},
+		{
+			desc: "reshape",
+			get:  func(M m.Matrix[int]) (m.Matrix[int], error) { return M.Reshape(1, 9) },
+			want: [][]int{{1, 2, 3, 4, 5, 6, 7, 8, 9}},
+		},
+		{
+			desc: "reshape-non-contiguous",
+			get: func(M m.Matrix[int]) (m.Matrix[int], error) {
+				block, _ := M.Slice(0, 3, 1, 3)
+				return block.Reshape(2, 3)
+			},
+			want: [][]int{{2, 3, 5}, {6, 8, 9}},
+		},
+		{
+			desc:    "slice-out-of-range",
+			get:     func(M m.Matrix[int]) (m.Matrix[int], error) { return M.Slice(0, 4, 0, 1) },
+			wantErr: true,
+		},
+		{
+			desc:    "column-out-of-range",
+			get:     func(M m.Matrix[int]) (m.Matrix[int], error) { return M.Col(3) },
+			wantErr: true,
+		},
+		{
+			desc:    "reshape-invalid-size",
+			get:     func(M m.Matrix[int]) (m.Matrix[int], error) { return M.Reshape(2, 4) },
+			wantErr: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			M, _ := m.NewMatrix([][]int{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}})
+
+			// Act
+			got, err := tC.get(M)
+
+			// Assert
+			if (err != nil) != tC.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tC.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			want, _ := m.NewMatrix(tC.want)
+			if !got.Equals(want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestSlice_SharesElements(t *testing.T) {
+	// Arrange
+	M, _ := m.NewMatrix([][]int{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}})
+	block, _ := M.Slice(1, 3, 1, 3)
+	column, _ := M.Col(1)
+
+	// Act
+	block.Set(0, 0, 50)
+	column.Set(2, 0, 80)
+	sum, _ := block.Add(block)
+
+	// Assert
+	want, _ := m.NewMatrix([][]int{{1, 2, 3}, {4, 50, 6}, {7, 80, 9}})
+	if !M.Equals(want) {
+		t.Errorf("matrix = %v, want %v", M, want)
+	}
+	wantSum, _ := m.NewMatrix([][]int{{100, 12}, {160, 18}})
+	if !sum.Equals(wantSum) {
+		t.Errorf("Add() = %v, want %v", sum, wantSum)
+	}
+}
+
+func TestStack(t *testing.T) {
+	a, _ := m.NewMatrix([][]int{{1, 2}, {3, 4}})
+	b, _ := m.NewMatrix([][]int{{5, 6}})
+	c, _ := m.NewMatrix([][]int{{7}, {8}})
+	testCases := []struct {
+		desc    string
+		stack   func(...m.Matrix[int]) (m.Matrix[int], error)
+		ms      []m.Matrix[int]
+		want    [][]int
+		wantErr bool
+	}{
+		{desc: "vstack", stack: m.VStack[int], ms: []m.Matrix[int]{a, b}, want: [][]int{{1, 2}, {3, 4}, {5, 6}}},
+		{desc: "hstack", stack: m.HStack[int], ms: []m.Matrix[int]{a, c, a}, want: [][]int{{1, 2, 7, 1, 2}, {3, 4, 8, 3, 4}}},
+		{desc: "vstack-inconsistent", stack: m.VStack[int], ms: []m.Matrix[int]{a, c}, wantErr: true},
+		{desc: "hstack-inconsistent", stack: m.HStack[int], ms: []m.Matrix[int]{a, b}, wantErr: true},
+		{desc: "empty", stack: m.VStack[int], wantErr: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Act
+			got, err := tC.stack(tC.ms...)
+
+			// Assert
+			if (err != nil) != tC.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tC.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			want, _ := m.NewMatrix(tC.want)
+			if !got.Equals(want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
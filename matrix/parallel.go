@@ -0,0 +1,70 @@
+package matrix
+
+import (
+	"runtime"
+	"sync"
+
+	. "golang.org/x/exp/constraints"
+)
+
+// blockSize is the side of the square blocks, processed by the matmul and transpose
+// kernels at once. 64x64 blocks of float64 take 32KiB, i.e. fit into the L1/L2 cache.
+const blockSize = 64
+
+// minParallelWork is the amount of work (roughly, the number of multiplications),
+// below which the operation is performed by a single goroutine, as starting
+// the workers would take longer than the operation itself.
+const minParallelWork = 1 << 15
+
+// parallelFor calls f for the consecutive ranges of [0, n), splitting them between
+// at most GOMAXPROCS workers. workPerItem is the approximate amount of work for each
+// of n items, used to decide whether to run the workers at all.
+func parallelFor(n, workPerItem int, f func(start, end int)) {
+	workers := runtime.GOMAXPROCS(0)
+	if limit := n * workPerItem / minParallelWork; limit < workers {
+		workers = limit
+	}
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		f(0, n)
+		return
+	}
+
+	chunk := (n + workers - 1) / workers
+	wg := sync.WaitGroup{}
+	for start := 0; start < n; start += chunk {
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			f(start, end)
+		}(start, min(start+chunk, n))
+	}
+	wg.Wait()
+}
+
+// multiplyBlock computes the rows [start, end) of c = a*b, where c is zeroed.
+//
+// The inner dimension and the columns of b are processed by blocks, and the innermost
+// loop runs along the rows of both b and c, so that the memory is accessed sequentially.
+func multiplyBlock[T Signed | Float](a, b, c *matrix[T], start, end int) {
+	inner, columns := a.columns, b.columns
+	for kk := 0; kk < inner; kk += blockSize {
+		kEnd := min(kk+blockSize, inner)
+		for jj := 0; jj < columns; jj += blockSize {
+			jEnd := min(jj+blockSize, columns)
+			for i := start; i < end; i++ {
+				aRow := a.row(i)
+				cRow := c.row(i)[jj:jEnd]
+				for k := kk; k < kEnd; k++ {
+					v := aRow[k]
+					bRow := b.row(k)[jj:jEnd]
+					for j, w := range bRow {
+						cRow[j] += v * w
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package matrix
+
+import (
+	"math"
+
+	. "golang.org/x/exp/constraints"
+)
+
+// Axis defines the elements, which are reduced into a single value.
+//
+// As the samples of a batch are stored as columns, PerColumn produces a value for
+// each sample (e.g. the loss of each sample), while PerRow produces a value for each
+// feature (e.g. the gradient of a bias).
+type Axis int
+
+const (
+	// AllElements reduces all the elements into a 1x1 matrix.
+	AllElements Axis = iota
+	// PerColumn reduces each column into a single value, producing a 1xColumns matrix.
+	PerColumn
+	// PerRow reduces each row into a single value, producing a Rowsx1 matrix.
+	PerRow
+)
+
+// Sum sums the elements along the axis.
+func Sum[T Signed | Float](M Matrix[T], axis Axis) Matrix[T] {
+	return reduce(M, axis, func(values []T) T {
+		var sum T
+		for _, v := range values {
+			sum += v
+		}
+		return sum
+	})
+}
+
+// Mean averages the elements along the axis.
+func Mean[T Float](M Matrix[T], axis Axis) Matrix[T] {
+	return reduce(M, axis, mean[T])
+}
+
+// Max returns the largest elements along the axis.
+func Max[T Signed | Float](M Matrix[T], axis Axis) Matrix[T] {
+	return reduce(M, axis, func(values []T) T { return extremum(values, 1) })
+}
+
+// Min returns the smallest elements along the axis.
+func Min[T Signed | Float](M Matrix[T], axis Axis) Matrix[T] {
+	return reduce(M, axis, func(values []T) T { return extremum(values, -1) })
+}
+
+// Var returns the (population) variance of the elements along the axis.
+//
+//	Var(x) = mean((x - mean(x))^2)
+func Var[T Float](M Matrix[T], axis Axis) Matrix[T] {
+	return reduce(M, axis, variance[T])
+}
+
+// Std returns the (population) standard deviation of the elements along the axis.
+func Std[T Float](M Matrix[T], axis Axis) Matrix[T] {
+	return reduce(M, axis, func(values []T) T { return T(math.Sqrt(float64(variance(values)))) })
+}
+
+// ArgMax returns the indices of the largest elements along the axis. If there are
+// several largest elements, the first one is used. For PerColumn, the indices are
+// the rows, and for PerRow - the columns. For AllElements, the only index is
+// the position in the row-major order, i.e. i*ColumnCount() + j.
+func ArgMax[T Signed | Float](M Matrix[T], axis Axis) []int {
+	return reduceIndex(M, axis, func(values []T) int { return argExtremum(values, 1) })
+}
+
+// ArgMin returns the indices of the smallest elements along the axis.
+// See ArgMax for the details.
+func ArgMin[T Signed | Float](M Matrix[T], axis Axis) []int {
+	return reduceIndex(M, axis, func(values []T) int { return argExtremum(values, -1) })
+}
+
+/****************************************************************************/
+
+// groups returns the elements of the matrix, which are reduced together along the axis.
+func groups[T Signed | Float](M Matrix[T], axis Axis) [][]T {
+	m := dense(M)
+	switch axis {
+	case PerRow:
+		result := make([][]T, m.rows)
+		for i := range result {
+			result[i] = m.row(i)
+		}
+		return result
+	case PerColumn:
+		result := make([][]T, m.columns)
+		for j := range result {
+			result[j] = make([]T, m.rows)
+			for i := 0; i < m.rows; i++ {
+				result[j][i] = m.data[i*m.stride+j]
+			}
+		}
+		return result
+	default:
+		all := make([]T, 0, m.rows*m.columns)
+		for i := 0; i < m.rows; i++ {
+			all = append(all, m.row(i)...)
+		}
+		return [][]T{all}
+	}
+}
+
+// reduce applies f to each group of the elements along the axis.
+func reduce[T Signed | Float](M Matrix[T], axis Axis, f func(values []T) T) Matrix[T] {
+	values := groups(M, axis)
+	var result *matrix[T]
+	switch axis {
+	case PerRow:
+		result = newMatrix[T](len(values), 1)
+	default:
+		result = newMatrix[T](1, len(values))
+	}
+	for k, v := range values {
+		result.data[k] = f(v)
+	}
+	return result
+}
+
+// reduceIndex applies f to each group of the elements along the axis.
+func reduceIndex[T Signed | Float](M Matrix[T], axis Axis, f func(values []T) int) []int {
+	values := groups(M, axis)
+	result := make([]int, len(values))
+	for k, v := range values {
+		result[k] = f(v)
+	}
+	return result
+}
+
+// argExtremum returns the index of the first largest value if sign is positive,
+// or the first smallest one if sign is negative. NaN values are ignored, unless
+// all the values are NaN. Returns -1 if there are no values.
+func argExtremum[T Signed | Float](values []T, sign int) int {
+	if len(values) == 0 {
+		return -1
+	}
+	best := 0
+	for k, v := range values {
+		if values[best] != values[best] || (sign > 0 && v > values[best]) || (sign < 0 && v < values[best]) {
+			best = k
+		}
+	}
+	return best
+}
+
+// extremum returns the value at argExtremum, or 0 if there are no values.
+func extremum[T Signed | Float](values []T, sign int) T {
+	if k := argExtremum(values, sign); k >= 0 {
+		return values[k]
+	}
+	return 0
+}
+
+func mean[T Float](values []T) T {
+	var sum T
+	for _, v := range values {
+		sum += v
+	}
+	return sum / T(len(values))
+}
+
+func variance[T Float](values []T) T {
+	mu := mean(values)
+	var sum T
+	for _, v := range values {
+		sum += (v - mu) * (v - mu)
+	}
+	return sum / T(len(values))
+}
@@ -0,0 +1,134 @@
+package matrix_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	m "github.com/Hukyl/mlgo/matrix"
+)
+
+// approxEquals compares the matrices elementwise with the absolute tolerance of 1e-9.
+func approxEquals(a, b m.Matrix[float64]) bool {
+	if !a.AreSameSize(b) {
+		return false
+	}
+	for i := 0; i < a.RowCount(); i++ {
+		for j := 0; j < a.ColumnCount(); j++ {
+			x, _ := a.At(i, j)
+			y, _ := b.At(i, j)
+			if math.Abs(x-y) > 1e-9 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestReductions(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		reduce func(M m.Matrix[float64], axis m.Axis) m.Matrix[float64]
+		axis   m.Axis
+		want   [][]float64
+	}{
+		{desc: "sum-all", reduce: m.Sum[float64], axis: m.AllElements, want: [][]float64{{24}}},
+		{desc: "sum-per-column", reduce: m.Sum[float64], axis: m.PerColumn, want: [][]float64{{5, 8, 11}}},
+		{desc: "sum-per-row", reduce: m.Sum[float64], axis: m.PerRow, want: [][]float64{{6}, {18}}},
+		{desc: "mean-all", reduce: m.Mean[float64], axis: m.AllElements, want: [][]float64{{4}}},
+		{desc: "mean-per-column", reduce: m.Mean[float64], axis: m.PerColumn, want: [][]float64{{2.5, 4, 5.5}}},
+		{desc: "max-per-column", reduce: m.Max[float64], axis: m.PerColumn, want: [][]float64{{4, 6, 8}}},
+		{desc: "max-per-row", reduce: m.Max[float64], axis: m.PerRow, want: [][]float64{{3}, {8}}},
+		{desc: "min-all", reduce: m.Min[float64], axis: m.AllElements, want: [][]float64{{1}}},
+		{desc: "var-per-row", reduce: m.Var[float64], axis: m.PerRow, want: [][]float64{{2. / 3}, {8. / 3}}},
+		{desc: "var-per-column", reduce: m.Var[float64], axis: m.PerColumn, want: [][]float64{{2.25, 4, 6.25}}},
+		{desc: "std-per-column", reduce: m.Std[float64], axis: m.PerColumn, want: [][]float64{{1.5, 2, 2.5}}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			M, _ := m.NewMatrix([][]float64{{1, 2, 3}, {4, 6, 8}})
+			want, _ := m.NewMatrix(tC.want)
+
+			// Act
+			got := tC.reduce(M, tC.axis)
+
+			// Assert
+			if !approxEquals(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestReductions_View(t *testing.T) {
+	// Arrange
+	M, _ := m.NewMatrix([][]int{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}})
+	block, _ := M.Slice(1, 3, 1, 3)
+	want, _ := m.NewMatrix([][]int{{13, 15}})
+
+	// Act
+	got := m.Sum(block, m.PerColumn)
+
+	// Assert
+	if !got.Equals(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestArgMax(t *testing.T) {
+	nan := math.NaN()
+	testCases := []struct {
+		desc    string
+		data    [][]float64
+		axis    m.Axis
+		wantMax []int
+		wantMin []int
+	}{
+		{
+			desc:    "per-column",
+			data:    [][]float64{{1, 5, 3}, {4, 2, 3}},
+			axis:    m.PerColumn,
+			wantMax: []int{1, 0, 0},
+			wantMin: []int{0, 1, 0},
+		},
+		{
+			desc:    "per-row",
+			data:    [][]float64{{1, 5, 3}, {4, 2, 3}},
+			axis:    m.PerRow,
+			wantMax: []int{1, 0},
+			wantMin: []int{0, 1},
+		},
+		{
+			desc:    "all-elements-row-major",
+			data:    [][]float64{{1, 5, 3}, {4, 2, 6}},
+			axis:    m.AllElements,
+			wantMax: []int{5},
+			wantMin: []int{0},
+		},
+		{
+			desc:    "nan-ignored",
+			data:    [][]float64{{nan}, {2}, {1}},
+			axis:    m.PerColumn,
+			wantMax: []int{1},
+			wantMin: []int{2},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			M, _ := m.NewMatrix(tC.data)
+
+			// Act
+			gotMax, gotMin := m.ArgMax(M, tC.axis), m.ArgMin(M, tC.axis)
+
+			// Assert
+			if !reflect.DeepEqual(gotMax, tC.wantMax) {
+				t.Errorf("ArgMax() = %v, want %v", gotMax, tC.wantMax)
+			}
+			if !reflect.DeepEqual(gotMin, tC.wantMin) {
+				t.Errorf("ArgMin() = %v, want %v", gotMin, tC.wantMin)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package matrix
+
+import (
+	"errors"
+	"math"
+
+	. "golang.org/x/exp/constraints"
+)
+
+// Solve solves the system A*X = B for a square matrix A by the LU decomposition.
+// Every column of B is a separate right side.
+//
+// Returns error if A is not square or singular, or B has a different row count.
+func Solve[T Float](A, B Matrix[T]) (Matrix[T], error) {
+	d, err := LU(A)
+	if err != nil {
+		return nil, err
+	}
+	return d.Solve(B)
+}
+
+// LeastSquares finds X, which minimizes ||A*X - B|| for every column of B, by
+// the QR decomposition. It is the numerically stable alternative to the normal
+// equation X = (A.T*A)^-1 * A.T*B, e.g. for the linear regression.
+//
+// If A has less rows than columns (the system is underdetermined), the solution
+// with the smallest norm is returned.
+//
+// Returns error if A is not full rank or B has a different row count.
+func LeastSquares[T Float](A, B Matrix[T]) (Matrix[T], error) {
+	if A.RowCount() != B.RowCount() {
+		return nil, errors.New("right side row count is not equal to matrix row count")
+	}
+	if A.RowCount() >= A.ColumnCount() {
+		d, _ := QR(A)
+		return d.Solve(B)
+	}
+
+	// A.T = Q*R, so A*X = B is R.T*Q.T*X = B, and X = Q*(R.T)^-1*B has the smallest norm
+	d, _ := QR(A.T())
+	if !d.IsFullRank() {
+		return nil, errors.New("matrix is not full rank")
+	}
+	n, columns := d.cols, B.ColumnCount()
+	y := toFloat64(B)
+	rT := make([]float64, n*n)
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			rT[j*n+i] = d.r[i*n+j]
+		}
+	}
+	forwardSubstitute(rT, n, y, columns)
+	return d.Q().Multiply(fromFloat64[T](y, n, columns))
+}
+
+// ConditionNumber returns the condition number of a square matrix in the 1-norm,
+//
+//	cond(A) = ||A|| * ||A^-1||, where ||A|| = max(sum(|A_ij|, i), j)
+//
+// i.e. the bound of how much the relative error in B may be amplified in the
+// solution of A*X = B. Returns +Inf for a singular matrix.
+//
+// Returns error if the matrix is not square.
+func ConditionNumber[T Float](A Matrix[T]) (T, error) {
+	d, err := LU(A)
+	if err != nil {
+		return 0, err
+	}
+	if d.IsSingular() {
+		return T(math.Inf(1)), nil
+	}
+	n := A.RowCount()
+	return T(oneNorm(toFloat64(A), n) * oneNorm(d.lu.inverse(), n)), nil
+}
+
+// oneNorm returns the largest absolute column sum of an n x n buffer.
+func oneNorm(data []float64, n int) float64 {
+	result := 0.0
+	for j := 0; j < n; j++ {
+		sum := 0.0
+		for i := 0; i < n; i++ {
+			sum += math.Abs(data[i*n+j])
+		}
+		result = math.Max(result, sum)
+	}
+	return result
+}
@@ -0,0 +1,552 @@
+package matrix
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	. "golang.org/x/exp/constraints"
+)
+
+// sparse stores only the non-zero elements in the compressed sparse row (CSR)
+// format, or in the compressed sparse column (CSC) format if columnMajor is set.
+//
+// The elements of the major line k (a row for CSR, a column for CSC) are stored
+// at [indptr[k], indptr[k+1]) of indices (the minor indices, sorted ascending)
+// and values.
+type sparse[T Signed | Float] struct {
+	rows, columns int
+	columnMajor   bool
+	indptr        []int
+	indices       []int
+	values        []T
+}
+
+// NewCSR converts the matrix into the compressed sparse row format, which is
+// efficient for the row slicing and for the multiplication by a dense matrix.
+func NewCSR[T Signed | Float](M Matrix[T]) Matrix[T] {
+	return toSparse(M, false)
+}
+
+// NewCSC converts the matrix into the compressed sparse column format, which is
+// efficient for the column slicing. A transposed CSR matrix is a CSC one and vice versa.
+func NewCSC[T Signed | Float](M Matrix[T]) Matrix[T] {
+	return toSparse(M, true)
+}
+
+// NewSparseMatrix creates a CSR matrix of the given size from the coordinates
+// of the non-zero elements, e.g. the word counts of a bag-of-words:
+//
+//	// 2 documents, 5 words in the vocabulary
+//	X, _ := NewSparseMatrix(2, 5, []int{0, 0, 1}, []int{1, 4, 1}, []float64{2, 1, 3})
+//
+// The values with the same coordinates are summed.
+//
+// Returns error if the slices have different lengths or the indices are not in range.
+func NewSparseMatrix[T Signed | Float](rows, columns int, rowIndices, columnIndices []int, values []T) (Matrix[T], error) {
+	if len(rowIndices) != len(values) || len(columnIndices) != len(values) {
+		return nil, errors.New("indices and values have different lengths")
+	}
+	for k := range values {
+		if rowIndices[k] < 0 || rowIndices[k] >= rows || columnIndices[k] < 0 || columnIndices[k] >= columns {
+			return nil, errors.New("indices are not in range")
+		}
+	}
+	return newSparseFromTriplets(rows, columns, false, rowIndices, columnIndices, values), nil
+}
+
+// ToDense converts the matrix into the dense format. A dense matrix is returned as is.
+func ToDense[T Signed | Float](M Matrix[T]) Matrix[T] {
+	return dense(M)
+}
+
+// IsSparse reports whether the matrix is stored in a sparse format.
+func IsSparse[T Signed | Float](M Matrix[T]) bool {
+	_, ok := M.(*sparse[T])
+	return ok
+}
+
+/************************************************************************/
+
+func toSparse[T Signed | Float](M Matrix[T], columnMajor bool) *sparse[T] {
+	if s, ok := M.(*sparse[T]); ok {
+		return s.asFormat(columnMajor)
+	}
+	m := dense(M)
+	s := &sparse[T]{rows: m.rows, columns: m.columns, columnMajor: columnMajor}
+	s.indptr = make([]int, 1, s.majorCount()+1)
+	for major := 0; major < s.majorCount(); major++ {
+		for minor := 0; minor < s.minorCount(); minor++ {
+			i, j := s.coordinates(major, minor)
+			if v := m.data[i*m.stride+j]; v != 0 {
+				s.indices = append(s.indices, minor)
+				s.values = append(s.values, v)
+			}
+		}
+		s.indptr = append(s.indptr, len(s.values))
+	}
+	return s
+}
+
+// newSparseFromTriplets creates a sparse matrix from the coordinates of the elements,
+// summing the duplicates and dropping the zeros. The indices have to be in range.
+func newSparseFromTriplets[T Signed | Float](rows, columns int, columnMajor bool, rowIndices, columnIndices []int, values []T) *sparse[T] {
+	s := &sparse[T]{rows: rows, columns: columns, columnMajor: columnMajor}
+	majors, minors := rowIndices, columnIndices
+	if columnMajor {
+		majors, minors = columnIndices, rowIndices
+	}
+	order := make([]int, len(values))
+	for k := range order {
+		order[k] = k
+	}
+	sort.Slice(order, func(a, b int) bool {
+		if majors[order[a]] != majors[order[b]] {
+			return majors[order[a]] < majors[order[b]]
+		}
+		return minors[order[a]] < minors[order[b]]
+	})
+
+	s.indptr = make([]int, s.majorCount()+1)
+	last := -1
+	for _, k := range order {
+		major, minor := majors[k], minors[k]
+		if n := len(s.values); n > 0 && last == major && s.indices[n-1] == minor {
+			s.values[n-1] += values[k]
+			continue
+		}
+		s.indices = append(s.indices, minor)
+		s.values = append(s.values, values[k])
+		s.indptr[major+1]++
+		last = major
+	}
+	for k := 1; k < len(s.indptr); k++ {
+		s.indptr[k] += s.indptr[k-1]
+	}
+	return s.withoutZeros()
+}
+
+// withoutZeros removes the explicitly stored zeros.
+func (s *sparse[T]) withoutZeros() *sparse[T] {
+	if !slices.Contains(s.values, 0) {
+		return s
+	}
+	result := &sparse[T]{rows: s.rows, columns: s.columns, columnMajor: s.columnMajor}
+	result.indptr = make([]int, 1, len(s.indptr))
+	for major := 0; major < s.majorCount(); major++ {
+		for k := s.indptr[major]; k < s.indptr[major+1]; k++ {
+			if s.values[k] != 0 {
+				result.indices = append(result.indices, s.indices[k])
+				result.values = append(result.values, s.values[k])
+			}
+		}
+		result.indptr = append(result.indptr, len(result.values))
+	}
+	return result
+}
+
+func (s *sparse[T]) majorCount() int {
+	if s.columnMajor {
+		return s.columns
+	}
+	return s.rows
+}
+
+func (s *sparse[T]) minorCount() int {
+	if s.columnMajor {
+		return s.rows
+	}
+	return s.columns
+}
+
+// coordinates converts the (major, minor) position into the (row, column) one and back.
+func (s *sparse[T]) coordinates(major, minor int) (int, int) {
+	if s.columnMajor {
+		return minor, major
+	}
+	return major, minor
+}
+
+// find returns the position of the element in indices and values, and whether it is stored.
+func (s *sparse[T]) find(i, j int) (int, bool) {
+	major, minor := s.coordinates(i, j)
+	start, end := s.indptr[major], s.indptr[major+1]
+	k, found := slices.BinarySearch(s.indices[start:end], minor)
+	return start + k, found
+}
+
+// forEach calls f for each stored element in the storage order.
+func (s *sparse[T]) forEach(f func(i, j int, v T)) {
+	for major := 0; major < s.majorCount(); major++ {
+		for k := s.indptr[major]; k < s.indptr[major+1]; k++ {
+			i, j := s.coordinates(major, s.indices[k])
+			f(i, j, s.values[k])
+		}
+	}
+}
+
+// triplets returns the coordinates and the values of the stored elements.
+func (s *sparse[T]) triplets() (rowIndices, columnIndices []int, values []T) {
+	rowIndices = make([]int, 0, len(s.values))
+	columnIndices = make([]int, 0, len(s.values))
+	values = make([]T, 0, len(s.values))
+	s.forEach(func(i, j int, v T) {
+		rowIndices = append(rowIndices, i)
+		columnIndices = append(columnIndices, j)
+		values = append(values, v)
+	})
+	return rowIndices, columnIndices, values
+}
+
+// asFormat returns the matrix in CSC format if columnMajor is set, or in CSR otherwise.
+// The matrix is returned as is if it is already in that format.
+func (s *sparse[T]) asFormat(columnMajor bool) *sparse[T] {
+	if s.columnMajor == columnMajor {
+		return s
+	}
+	rowIndices, columnIndices, values := s.triplets()
+	return newSparseFromTriplets(s.rows, s.columns, columnMajor, rowIndices, columnIndices, values)
+}
+
+func (s *sparse[T]) toDense() *matrix[T] {
+	result := newMatrix[T](s.rows, s.columns)
+	s.forEach(func(i, j int, v T) { result.data[i*result.stride+j] = v })
+	return result
+}
+
+/************************************************************************/
+
+func (s *sparse[T]) RowCount() int { return s.rows }
+
+func (s *sparse[T]) ColumnCount() int { return s.columns }
+
+func (s *sparse[T]) Size() [2]int { return [2]int{s.rows, s.columns} }
+
+func (s *sparse[T]) AreSameSize(M Matrix[T]) bool {
+	return s.Size() == M.Size()
+}
+
+func (s *sparse[T]) inRange(i, j int) bool {
+	return 0 <= i && i < s.rows && 0 <= j && j < s.columns
+}
+
+func (s *sparse[T]) Broadcast(newRows, newColumns int) error {
+	m := s.toDense()
+	if err := m.Broadcast(newRows, newColumns); err != nil {
+		return err
+	}
+	*s = *toSparse[T](m, s.columnMajor)
+	return nil
+}
+
+func (s *sparse[T]) Equals(M Matrix[T]) bool {
+	return s.AreSameSize(M) && s.toDense().Equals(M)
+}
+
+/************************************************************************/
+
+func (s *sparse[T]) At(i, j int) (T, error) {
+	if !s.inRange(i, j) {
+		return 0, errors.New("indices are not in range")
+	}
+	if k, found := s.find(i, j); found {
+		return s.values[k], nil
+	}
+	return 0, nil
+}
+
+// Set changes the stored value in place. Setting a new non-zero element or zeroing
+// a stored one changes the structure of the matrix, which takes O(non-zeros) time,
+// and the matrix stops sharing the elements with its copies.
+func (s *sparse[T]) Set(i, j int, value T) error {
+	if !s.inRange(i, j) {
+		return errors.New("indices are not in range")
+	}
+	k, found := s.find(i, j)
+	switch {
+	case found && value != 0:
+		s.values[k] = value
+		return nil
+	case !found && value == 0:
+		return nil
+	}
+
+	major, minor := s.coordinates(i, j)
+	delta := 1
+	if found {
+		s.indices = slices.Delete(slices.Clone(s.indices), k, k+1)
+		s.values = slices.Delete(slices.Clone(s.values), k, k+1)
+		delta = -1
+	} else {
+		s.indices = slices.Insert(slices.Clone(s.indices), k, minor)
+		s.values = slices.Insert(slices.Clone(s.values), k, value)
+	}
+	s.indptr = slices.Clone(s.indptr)
+	for m := major + 1; m < len(s.indptr); m++ {
+		s.indptr[m] += delta
+	}
+	return nil
+}
+
+/************************************************************************/
+
+// Add produces a sparse matrix if both matrices are sparse, and a dense one otherwise.
+func (s *sparse[T]) Add(M Matrix[T]) (Matrix[T], error) {
+	if !s.AreSameSize(M) {
+		return nil, errors.New("matrices are not the same size")
+	}
+	other, ok := M.(*sparse[T])
+	if !ok {
+		return dense(M).Add(s)
+	}
+	rowIndices, columnIndices, values := s.triplets()
+	otherRows, otherColumns, otherValues := other.triplets()
+	return newSparseFromTriplets(
+		s.rows, s.columns, s.columnMajor,
+		append(rowIndices, otherRows...),
+		append(columnIndices, otherColumns...),
+		append(values, otherValues...),
+	), nil
+}
+
+// AddScalar produces a dense matrix, as the zeros stop being zeros.
+func (s *sparse[T]) AddScalar(k T) Matrix[T] {
+	return s.toDense().AddScalar(k)
+}
+
+// Multiply always produces a dense matrix. Only the stored elements are multiplied,
+// so NaN and Inf in the other matrix may be lost, if they are multiplied by zeros.
+func (s *sparse[T]) Multiply(M Matrix[T]) (Matrix[T], error) {
+	if s.columns != M.RowCount() {
+		return nil, errors.New("matrices are not conformable under multiplication")
+	}
+	a := s.asFormat(false)
+	result := newMatrix[T](s.rows, M.ColumnCount())
+	if other, ok := M.(*sparse[T]); ok {
+		b := other.asFormat(false)
+		parallelFor(a.rows, (len(a.values)/max(a.rows, 1)+1)*result.columns, func(start, end int) {
+			for i := start; i < end; i++ {
+				row := result.row(i)
+				for k := a.indptr[i]; k < a.indptr[i+1]; k++ {
+					v, bRow := a.values[k], a.indices[k]
+					for l := b.indptr[bRow]; l < b.indptr[bRow+1]; l++ {
+						row[b.indices[l]] += v * b.values[l]
+					}
+				}
+			}
+		})
+		return result, nil
+	}
+
+	b := dense(M)
+	parallelFor(a.rows, (len(a.values)/max(a.rows, 1)+1)*result.columns, func(start, end int) {
+		for i := start; i < end; i++ {
+			row := result.row(i)
+			for k := a.indptr[i]; k < a.indptr[i+1]; k++ {
+				v := a.values[k]
+				for j, w := range b.row(a.indices[k]) {
+					row[j] += v * w
+				}
+			}
+		}
+	})
+	return result, nil
+}
+
+// multiplyDenseSparse computes the rows [start, end) of c = a*b, where c is zeroed.
+func multiplyDenseSparse[T Signed | Float](a *matrix[T], b *sparse[T], c *matrix[T], start, end int) {
+	for i := start; i < end; i++ {
+		row := c.row(i)
+		for k, v := range a.row(i) {
+			if v == 0 {
+				continue
+			}
+			for l := b.indptr[k]; l < b.indptr[k+1]; l++ {
+				row[b.indices[l]] += v * b.values[l]
+			}
+		}
+	}
+}
+
+func (s *sparse[T]) MultiplyByScalar(k T) Matrix[T] {
+	result := s.DeepCopy().(*sparse[T])
+	for l := range result.values {
+		result.values[l] *= k
+	}
+	return result.withoutZeros()
+}
+
+// MultiplyElementwise produces a sparse matrix, as the zeros stay zeros.
+func (s *sparse[T]) MultiplyElementwise(M Matrix[T]) (Matrix[T], error) {
+	if !s.AreSameSize(M) {
+		return nil, errors.New("matrices are not the same size")
+	}
+	result := s.DeepCopy().(*sparse[T])
+	other := M
+	if _, ok := M.(*sparse[T]); !ok {
+		other = dense(M)
+	}
+	for major := 0; major < s.majorCount(); major++ {
+		for k := s.indptr[major]; k < s.indptr[major+1]; k++ {
+			v, _ := other.At(s.coordinates(major, s.indices[k]))
+			result.values[k] *= v
+		}
+	}
+	return result.withoutZeros(), nil
+}
+
+/************************************************************************/
+
+// T swaps the format (CSR <-> CSC), sharing the elements with the matrix.
+func (s *sparse[T]) T() Matrix[T] {
+	return &sparse[T]{
+		rows:        s.columns,
+		columns:     s.rows,
+		columnMajor: !s.columnMajor,
+		indptr:      s.indptr,
+		indices:     s.indices,
+		values:      s.values,
+	}
+}
+
+// Slice copies the elements of the block, unlike the dense matrix. Slicing the rows
+// of a CSR matrix (or the columns of a CSC one) takes O(non-zeros in the block) time.
+func (s *sparse[T]) Slice(r0, r1, c0, c1 int) (Matrix[T], error) {
+	if r0 < 0 || r1 < r0 || r1 > s.rows || c0 < 0 || c1 < c0 || c1 > s.columns {
+		return nil, errors.New("indices are not in range")
+	}
+	major0, minor0 := s.coordinates(r0, c0)
+	major1, minor1 := s.coordinates(r1, c1)
+	result := &sparse[T]{rows: r1 - r0, columns: c1 - c0, columnMajor: s.columnMajor}
+	result.indptr = make([]int, 1, major1-major0+1)
+	for major := major0; major < major1; major++ {
+		for k := s.indptr[major]; k < s.indptr[major+1]; k++ {
+			if minor := s.indices[k]; minor0 <= minor && minor < minor1 {
+				result.indices = append(result.indices, minor-minor0)
+				result.values = append(result.values, s.values[k])
+			}
+		}
+		result.indptr = append(result.indptr, len(result.values))
+	}
+	return result, nil
+}
+
+func (s *sparse[T]) Row(i int) (Matrix[T], error) {
+	if i < 0 || i >= s.rows {
+		return nil, errors.New("index is not in range")
+	}
+	return s.Slice(i, i+1, 0, s.columns)
+}
+
+func (s *sparse[T]) Col(j int) (Matrix[T], error) {
+	if j < 0 || j >= s.columns {
+		return nil, errors.New("index is not in range")
+	}
+	return s.Slice(0, s.rows, j, j+1)
+}
+
+func (s *sparse[T]) Reshape(rows, columns int) (Matrix[T], error) {
+	if rows < 0 || columns < 0 || rows*columns != s.rows*s.columns {
+		return nil, fmt.Errorf("cannot reshape %dx%d matrix into %dx%d", s.rows, s.columns, rows, columns)
+	}
+	rowIndices, columnIndices, values := s.triplets()
+	for k := range values {
+		position := rowIndices[k]*s.columns + columnIndices[k]
+		rowIndices[k], columnIndices[k] = position/columns, position%columns
+	}
+	return newSparseFromTriplets(rows, columns, s.columnMajor, rowIndices, columnIndices, values), nil
+}
+
+/************************************************************************/
+
+func (s *sparse[T]) Minor(i, j int) (Matrix[T], error) {
+	return s.toDense().Minor(i, j)
+}
+
+func (s *sparse[T]) Determinant() (T, error) {
+	return s.toDense().Determinant()
+}
+
+func (s *sparse[T]) Inverse() (Matrix[T], error) {
+	return s.toDense().Inverse()
+}
+
+/************************************************************************/
+
+func (s *sparse[T]) String() string {
+	return s.toDense().String()
+}
+
+// Copy returns a matrix, which shares the elements with the original one.
+func (s *sparse[T]) Copy() Matrix[T] {
+	result := *s
+	return &result
+}
+
+func (s *sparse[T]) DeepCopy() Matrix[T] {
+	return &sparse[T]{
+		rows:        s.rows,
+		columns:     s.columns,
+		columnMajor: s.columnMajor,
+		indptr:      slices.Clone(s.indptr),
+		indices:     slices.Clone(s.indices),
+		values:      slices.Clone(s.values),
+	}
+}
+
+// sparseJSON is the stored form of a sparse matrix.
+type sparseJSON[T Signed | Float] struct {
+	Format  string
+	Rows    int
+	Columns int
+	Indptr  []int
+	Indices []int
+	Values  []T
+}
+
+// MarshalJSON stores the format ("CSR" or "CSC"), the size and the compressed arrays.
+func (s *sparse[T]) MarshalJSON() ([]byte, error) {
+	format := "CSR"
+	if s.columnMajor {
+		format = "CSC"
+	}
+	return json.Marshal(sparseJSON[T]{
+		Format: format, Rows: s.rows, Columns: s.columns,
+		Indptr: s.indptr, Indices: s.indices, Values: s.values,
+	})
+}
+
+// UnmarshalJSON loads the matrix, stored by MarshalJSON. Returns error if the format
+// is unknown or the arrays are inconsistent.
+func (s *sparse[T]) UnmarshalJSON(data []byte) error {
+	var stored sparseJSON[T]
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return err
+	}
+	if stored.Format != "CSR" && stored.Format != "CSC" {
+		return fmt.Errorf("unknown sparse format %q", stored.Format)
+	}
+	result := &sparse[T]{
+		rows: stored.Rows, columns: stored.Columns, columnMajor: stored.Format == "CSC",
+		indptr: stored.Indptr, indices: stored.Indices, values: stored.Values,
+	}
+	if len(result.indptr) != result.majorCount()+1 || len(result.indices) != len(result.values) ||
+		result.indptr[0] != 0 || result.indptr[len(result.indptr)-1] != len(result.values) {
+		return errors.New("inconsistent sparse matrix")
+	}
+	for major := 0; major < result.majorCount(); major++ {
+		start, end := result.indptr[major], result.indptr[major+1]
+		if start > end {
+			return errors.New("inconsistent sparse matrix")
+		}
+		for k := start; k < end; k++ {
+			if result.indices[k] < 0 || result.indices[k] >= result.minorCount() || (k > start && result.indices[k] <= result.indices[k-1]) {
+				return errors.New("inconsistent sparse matrix")
+			}
+		}
+	}
+	*s = *result
+	return nil
+}
@@ -0,0 +1,175 @@
+package matrix_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	m "github.com/Hukyl/mlgo/matrix"
+)
+
+// sparseSample returns a 3x4 matrix, which is mostly zeros.
+func sparseSample() m.Matrix[float64] {
+	M, _ := m.NewMatrix([][]float64{
+		{0, 2, 0, 0},
+		{1, 0, 0, 3},
+		{0, 0, 0, 0},
+	})
+	return M
+}
+
+func TestSparse_Conversion(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		convert func(M m.Matrix[float64]) m.Matrix[float64]
+	}{
+		{desc: "csr", convert: m.NewCSR[float64]},
+		{desc: "csc", convert: m.NewCSC[float64]},
+		{desc: "csr-to-csc", convert: func(M m.Matrix[float64]) m.Matrix[float64] { return m.NewCSC(m.NewCSR(M)) }},
+		{
+			desc: "triplets",
+			convert: func(M m.Matrix[float64]) m.Matrix[float64] {
+				// The duplicates are summed and the zeros are dropped
+				S, _ := m.NewSparseMatrix(3, 4, []int{1, 0, 1, 1, 2}, []int{3, 1, 0, 3, 2}, []float64{1, 2, 1, 2, 0})
+				return S
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			M := sparseSample()
+
+			// Act
+			S := tC.convert(M)
+
+			// Assert
+			if !m.IsSparse(S) {
+				t.Error("IsSparse() = false")
+			}
+			if !S.Equals(M) || !M.Equals(S) {
+				t.Errorf("got %v, want %v", S, M)
+			}
+			if D := m.ToDense(S); m.IsSparse(D) || !D.Equals(M) {
+				t.Errorf("ToDense() = %v, want %v", D, M)
+			}
+		})
+	}
+}
+
+func TestSparse_Operations(t *testing.T) {
+	other := randomMatrix(3, 4)
+	testCases := []struct {
+		desc       string
+		apply      func(M m.Matrix[float64]) (m.Matrix[float64], error)
+		wantSparse bool
+	}{
+		{desc: "multiply", apply: func(M m.Matrix[float64]) (m.Matrix[float64], error) { return M.Multiply(other.T()) }},
+		{desc: "multiply-dense-by-sparse", apply: func(M m.Matrix[float64]) (m.Matrix[float64], error) { return other.T().Multiply(M) }},
+		{desc: "multiply-by-transposed", apply: func(M m.Matrix[float64]) (m.Matrix[float64], error) { return other.Multiply(M.T()) }},
+		{desc: "multiply-sparse", apply: func(M m.Matrix[float64]) (m.Matrix[float64], error) { return M.Multiply(M.T()) }},
+		{desc: "add-dense", apply: func(M m.Matrix[float64]) (m.Matrix[float64], error) { return M.Add(other) }},
+		{desc: "add-sparse", apply: func(M m.Matrix[float64]) (m.Matrix[float64], error) { return M.Add(M) }, wantSparse: true},
+		{desc: "add-scalar", apply: func(M m.Matrix[float64]) (m.Matrix[float64], error) { return M.AddScalar(1), nil }},
+		{
+			desc:       "multiply-by-scalar",
+			apply:      func(M m.Matrix[float64]) (m.Matrix[float64], error) { return M.MultiplyByScalar(-2), nil },
+			wantSparse: true,
+		},
+		{
+			desc:       "multiply-elementwise",
+			apply:      func(M m.Matrix[float64]) (m.Matrix[float64], error) { return M.MultiplyElementwise(other) },
+			wantSparse: true,
+		},
+		{desc: "transpose", apply: func(M m.Matrix[float64]) (m.Matrix[float64], error) { return M.T(), nil }, wantSparse: true},
+		{desc: "slice", apply: func(M m.Matrix[float64]) (m.Matrix[float64], error) { return M.Slice(1, 3, 0, 4) }, wantSparse: true},
+		{desc: "slice-block", apply: func(M m.Matrix[float64]) (m.Matrix[float64], error) { return M.Slice(0, 2, 1, 4) }, wantSparse: true},
+		{desc: "column", apply: func(M m.Matrix[float64]) (m.Matrix[float64], error) { return M.Col(3) }, wantSparse: true},
+		{desc: "reshape", apply: func(M m.Matrix[float64]) (m.Matrix[float64], error) { return M.Reshape(6, 2) }, wantSparse: true},
+		{desc: "sum", apply: func(M m.Matrix[float64]) (m.Matrix[float64], error) { return m.Sum(M, m.PerRow), nil }},
+	}
+	for _, tC := range testCases {
+		for _, format := range []struct {
+			name    string
+			convert func(M m.Matrix[float64]) m.Matrix[float64]
+		}{{"csr", m.NewCSR[float64]}, {"csc", m.NewCSC[float64]}} {
+			t.Run(tC.desc+"-"+format.name, func(t *testing.T) {
+				// Arrange
+				M := sparseSample()
+				S := format.convert(M)
+				want, _ := tC.apply(M)
+
+				// Act
+				got, err := tC.apply(S)
+
+				// Assert
+				if err != nil {
+					t.Fatalf("error = %v", err)
+				}
+				if !approxEquals(got, want) {
+					t.Errorf("got %v, want %v", got, want)
+				}
+				if m.IsSparse(got) != tC.wantSparse {
+					t.Errorf("IsSparse() = %v, want %v", m.IsSparse(got), tC.wantSparse)
+				}
+			})
+		}
+	}
+}
+
+func TestSparse_Set(t *testing.T) {
+	// Arrange
+	S := m.NewCSR(sparseSample())
+	shared := S.Copy()
+	want, _ := m.NewMatrix([][]float64{
+		{0, 5, 0, 0},
+		{0, 0, 4, 3},
+		{0, 0, 0, 0},
+	})
+
+	// Act
+	S.Set(0, 1, 5) // stored
+	S.Set(1, 0, 0) // removed
+	S.Set(1, 2, 4) // inserted
+	S.Set(2, 2, 0) // not stored
+
+	// Assert
+	if !S.Equals(want) {
+		t.Errorf("got %v, want %v", S, want)
+	}
+	// The stored values are shared, but the structure changes are not
+	wantShared, _ := m.NewMatrix([][]float64{
+		{0, 5, 0, 0},
+		{1, 0, 0, 3},
+		{0, 0, 0, 0},
+	})
+	if !shared.Equals(wantShared) {
+		t.Errorf("copy = %v, want %v", shared, wantShared)
+	}
+	if err := S.Set(3, 0, 1); err == nil {
+		t.Error("Set() didn't produce range error")
+	}
+}
+
+func TestSparse_JSON(t *testing.T) {
+	// Arrange
+	S := m.NewCSC(sparseSample())
+	loaded := m.NewCSR(m.NewZeroMatrix[float64](0, 0))
+
+	// Act
+	data, err := json.Marshal(S)
+	if err != nil {
+		t.Fatalf("MarshalJSON() error = %v", err)
+	}
+	err = json.Unmarshal(data, loaded)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("UnmarshalJSON() error = %v", err)
+	}
+	if !loaded.Equals(S) {
+		t.Errorf("got %v, want %v", loaded, S)
+	}
+	if json.Unmarshal([]byte(`{"Format":"CSR","Rows":1,"Columns":2,"Indptr":[0,1],"Indices":[2],"Values":[1]}`), loaded) == nil {
+		t.Error("UnmarshalJSON() didn't produce error for an inconsistent matrix")
+	}
+}
@@ -0,0 +1,330 @@
+package matrix
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	. "golang.org/x/exp/constraints"
+)
+
+// maxSweeps is the number of the Jacobi sweeps, after which the algorithm is
+// considered to not converge. Usually, it converges in less than 10 sweeps.
+const maxSweeps = 100
+
+// EigenDecomposition is the decomposition A = V*diag(values)*V.T of a symmetric
+// matrix A, where the columns of V are the orthonormal eigenvectors.
+type EigenDecomposition[T Float] struct {
+	values  []float64
+	vectors []float64
+	n       int
+}
+
+// SymmetricEigen computes the eigenvalues and the eigenvectors of a symmetric matrix
+// by the cyclic Jacobi algorithm. The eigenvalues are sorted in the descending order.
+//
+// Returns error if the matrix is not square or not symmetric.
+func SymmetricEigen[T Float](A Matrix[T]) (*EigenDecomposition[T], error) {
+	if A.RowCount() != A.ColumnCount() {
+		return nil, errors.New("matrix is not square (n x n)")
+	}
+	n := A.RowCount()
+	a := toFloat64(A)
+	eps := tolerance(a, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < i; j++ {
+			if math.Abs(a[i*n+j]-a[j*n+i]) > eps {
+				return nil, errors.New("matrix is not symmetric")
+			}
+		}
+	}
+
+	// The elements below negligible are considered to be zero
+	negligible := 0x1p-52 * frobeniusNorm(a)
+	v := make([]float64, n*n)
+	for i := 0; i < n; i++ {
+		v[i*n+i] = 1
+	}
+	converged := false
+	for sweep := 0; sweep < maxSweeps && !converged; sweep++ {
+		converged = true
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				apq := math.Abs(a[p*n+q])
+				if apq <= negligible || apq <= 0x1p-52*math.Sqrt(math.Abs(a[p*n+p]*a[q*n+q])) {
+					continue
+				}
+				converged = false
+				// The rotation, which zeroes a[p][q]
+				theta := (a[q*n+q] - a[p*n+p]) / (2 * a[p*n+q])
+				t := math.Copysign(1, theta) / (math.Abs(theta) + math.Hypot(1, theta))
+				c := 1 / math.Hypot(1, t)
+				s := c * t
+				for k := 0; k < n; k++ { // A = A*J
+					akp, akq := a[k*n+p], a[k*n+q]
+					a[k*n+p], a[k*n+q] = c*akp-s*akq, s*akp+c*akq
+				}
+				for k := 0; k < n; k++ { // A = J.T*A
+					apk, aqk := a[p*n+k], a[q*n+k]
+					a[p*n+k], a[q*n+k] = c*apk-s*aqk, s*apk+c*aqk
+				}
+				for k := 0; k < n; k++ { // V = V*J
+					vkp, vkq := v[k*n+p], v[k*n+q]
+					v[k*n+p], v[k*n+q] = c*vkp-s*vkq, s*vkp+c*vkq
+				}
+			}
+		}
+	}
+	if !converged {
+		return nil, errors.New("eigendecomposition did not converge")
+	}
+
+	values := make([]float64, n)
+	for k := range values {
+		values[k] = a[k*n+k]
+	}
+	order := descendingOrder(values)
+	return &EigenDecomposition[T]{
+		values:  permute(values, order),
+		vectors: permuteColumns(v, n, n, order),
+		n:       n,
+	}, nil
+}
+
+// Values returns the eigenvalues in the descending order.
+func (d *EigenDecomposition[T]) Values() []T {
+	return convertSlice[T](d.values)
+}
+
+// Vectors returns the matrix, whose columns are the eigenvectors in the order of Values.
+func (d *EigenDecomposition[T]) Vectors() Matrix[T] {
+	return fromFloat64[T](d.vectors, d.n, d.n)
+}
+
+/****************************************************************************/
+
+// SVDDecomposition is the (thin) singular value decomposition A = U*diag(S)*V.T of
+// an m x n matrix A, where k = min(m, n), U is an m x k matrix and V is an n x k matrix
+// with orthonormal columns, and S are k non-negative singular values.
+type SVDDecomposition[T Float] struct {
+	u, s, v       []float64
+	rows, columns int
+}
+
+// SVD computes the singular value decomposition by the one-sided Jacobi algorithm.
+// The singular values are sorted in the descending order.
+//
+// Returns error if the algorithm does not converge.
+func SVD[T Float](A Matrix[T]) (*SVDDecomposition[T], error) {
+	m, n := A.RowCount(), A.ColumnCount()
+	if m < n {
+		// A.T = U*S*V.T, so A = V*S*U.T
+		d, err := SVD(A.T())
+		if err != nil {
+			return nil, err
+		}
+		return &SVDDecomposition[T]{u: d.v, s: d.s, v: d.u, rows: m, columns: n}, nil
+	}
+
+	// The columns of U = A*V are rotated until they are orthogonal
+	u := toFloat64(A)
+	// The columns with the norm below negligible are considered to be zero
+	negligible := 0x1p-52 * frobeniusNorm(u)
+	v := make([]float64, n*n)
+	for i := 0; i < n; i++ {
+		v[i*n+i] = 1
+	}
+	converged := false
+	for sweep := 0; sweep < maxSweeps && !converged; sweep++ {
+		converged = true
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				var alpha, beta, gamma float64
+				for i := 0; i < m; i++ {
+					up, uq := u[i*n+p], u[i*n+q]
+					alpha += up * up
+					beta += uq * uq
+					gamma += up * uq
+				}
+				if math.Min(alpha, beta) <= negligible*negligible || math.Abs(gamma) <= 0x1p-52*math.Sqrt(alpha*beta) {
+					continue
+				}
+				converged = false
+				zeta := (beta - alpha) / (2 * gamma)
+				t := math.Copysign(1, zeta) / (math.Abs(zeta) + math.Hypot(1, zeta))
+				c := 1 / math.Hypot(1, t)
+				s := c * t
+				for i := 0; i < m; i++ {
+					up, uq := u[i*n+p], u[i*n+q]
+					u[i*n+p], u[i*n+q] = c*up-s*uq, s*up+c*uq
+				}
+				for i := 0; i < n; i++ {
+					vp, vq := v[i*n+p], v[i*n+q]
+					v[i*n+p], v[i*n+q] = c*vp-s*vq, s*vp+c*vq
+				}
+			}
+		}
+	}
+	if !converged {
+		return nil, errors.New("singular value decomposition did not converge")
+	}
+
+	s := make([]float64, n)
+	for j := 0; j < n; j++ {
+		for i := 0; i < m; i++ {
+			s[j] = math.Hypot(s[j], u[i*n+j])
+		}
+	}
+	order := descendingOrder(s)
+	s = permute(s, order)
+	u = permuteColumns(u, m, n, order)
+	v = permuteColumns(v, n, n, order)
+
+	eps := 0x1p-52 * float64(m)
+	if len(s) > 0 {
+		eps *= s[0]
+	}
+	for j := 0; j < n; j++ {
+		if s[j] <= eps {
+			// The column is (numerically) zero and is replaced with an orthonormal one
+			s[j] = 0
+			completeColumn(u, m, n, j)
+			continue
+		}
+		for i := 0; i < m; i++ {
+			u[i*n+j] /= s[j]
+		}
+	}
+	return &SVDDecomposition[T]{u: u, s: s, v: v, rows: m, columns: n}, nil
+}
+
+// U returns the m x k matrix of the left singular vectors.
+func (d *SVDDecomposition[T]) U() Matrix[T] {
+	return fromFloat64[T](d.u, d.rows, len(d.s))
+}
+
+// S returns the singular values in the descending order.
+func (d *SVDDecomposition[T]) S() []T {
+	return convertSlice[T](d.s)
+}
+
+// V returns the n x k matrix of the right singular vectors.
+func (d *SVDDecomposition[T]) V() Matrix[T] {
+	return fromFloat64[T](d.v, d.columns, len(d.s))
+}
+
+// Rank returns the number of the singular values, which are not (numerically) zero.
+func (d *SVDDecomposition[T]) Rank() int {
+	threshold := d.threshold()
+	rank := 0
+	for _, s := range d.s {
+		if s > threshold {
+			rank++
+		}
+	}
+	return rank
+}
+
+// threshold returns the value, below which the singular values are considered to be zero.
+func (d *SVDDecomposition[T]) threshold() float64 {
+	if len(d.s) == 0 {
+		return 0
+	}
+	return float64(max(d.rows, d.columns)) * d.s[0] * 0x1p-52
+}
+
+// PseudoInverse returns the Moore-Penrose pseudo-inverse A^+ = V*diag(S^+)*U.T, where
+// the reciprocals of the (numerically) zero singular values are replaced by zeros.
+// For a full rank matrix, A^+ * B is the least-squares solution of A*X = B.
+//
+// Returns error if the singular value decomposition does not converge.
+func PseudoInverse[T Float](A Matrix[T]) (Matrix[T], error) {
+	d, err := SVD(A)
+	if err != nil {
+		return nil, err
+	}
+	k, threshold := len(d.s), d.threshold()
+	m, n := d.rows, d.columns
+	result := make([]float64, n*m)
+	for j, s := range d.s {
+		if s <= threshold {
+			continue
+		}
+		for i := 0; i < n; i++ {
+			vij := d.v[i*k+j] / s
+			for l := 0; l < m; l++ {
+				result[i*m+l] += vij * d.u[l*k+j]
+			}
+		}
+	}
+	return fromFloat64[T](result, n, m), nil
+}
+
+/****************************************************************************/
+
+// descendingOrder returns the indices of the values, sorted by the values in the descending order.
+func descendingOrder(values []float64) []int {
+	order := make([]int, len(values))
+	for k := range order {
+		order[k] = k
+	}
+	sort.SliceStable(order, func(a, b int) bool { return values[order[a]] > values[order[b]] })
+	return order
+}
+
+func permute(values []float64, order []int) []float64 {
+	result := make([]float64, len(values))
+	for k, o := range order {
+		result[k] = values[o]
+	}
+	return result
+}
+
+// permuteColumns reorders the columns of the row-major rows x columns buffer.
+func permuteColumns(data []float64, rows, columns int, order []int) []float64 {
+	result := make([]float64, len(data))
+	for i := 0; i < rows; i++ {
+		for k, o := range order {
+			result[i*columns+k] = data[i*columns+o]
+		}
+	}
+	return result
+}
+
+// completeColumn replaces the j-th column of the row-major rows x columns buffer by
+// a unit vector, orthogonal to the previous columns (which are orthonormal), using
+// the Gram-Schmidt process on the standard basis vectors.
+func completeColumn(data []float64, rows, columns, j int) {
+	for e := 0; e < rows; e++ {
+		candidate := make([]float64, rows)
+		candidate[e] = 1
+		for k := 0; k < j; k++ {
+			dot := data[e*columns+k]
+			for i := 0; i < rows; i++ {
+				candidate[i] -= dot * data[i*columns+k]
+			}
+		}
+		if norm := frobeniusNorm(candidate); norm > 1e-8 {
+			for i := 0; i < rows; i++ {
+				data[i*columns+j] = candidate[i] / frobeniusNorm(candidate)
+			}
+			return
+		}
+	}
+}
+
+func frobeniusNorm(data []float64) float64 {
+	norm := 0.0
+	for _, x := range data {
+		norm = math.Hypot(norm, x)
+	}
+	return norm
+}
+
+func convertSlice[T Float](values []float64) []T {
+	result := make([]T, len(values))
+	for k, v := range values {
+		result[k] = T(v)
+	}
+	return result
+}
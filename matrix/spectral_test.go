@@ -0,0 +1,174 @@
+package matrix_test
+
+import (
+	"math"
+	"testing"
+
+	m "github.com/Hukyl/mlgo/matrix"
+)
+
+// diagonal returns the square matrix with the values on the main diagonal.
+func diagonal(values []float64) m.Matrix[float64] {
+	result := m.NewZeroMatrix[float64](len(values), len(values))
+	for k, v := range values {
+		result.Set(k, k, v)
+	}
+	return result
+}
+
+func isOrthonormal(M m.Matrix[float64]) bool {
+	product, _ := M.T().Multiply(M)
+	return approxEquals(product, m.IdentityMatrix[float64](M.ColumnCount()))
+}
+
+func TestSymmetricEigen(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		data       [][]float64
+		wantValues []float64
+		wantErr    bool
+	}{
+		{desc: "2x2", data: [][]float64{{2, 1}, {1, 2}}, wantValues: []float64{3, 1}},
+		{desc: "zero-diagonal", data: [][]float64{{0, 1}, {1, 0}}, wantValues: []float64{1, -1}},
+		{desc: "singular", data: [][]float64{{1, 1, 0}, {1, 1, 0}, {0, 0, 0}}, wantValues: []float64{2, 0, 0}},
+		{
+			desc:       "3x3",
+			data:       [][]float64{{2, -1, 0}, {-1, 2, -1}, {0, -1, 2}},
+			wantValues: []float64{2 + math.Sqrt2, 2, 2 - math.Sqrt2},
+		},
+		{desc: "not-symmetric", data: [][]float64{{1, 2}, {3, 4}}, wantErr: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			A, _ := m.NewMatrix(tC.data)
+
+			// Act
+			d, err := m.SymmetricEigen(A)
+
+			// Assert
+			if (err != nil) != tC.wantErr {
+				t.Fatalf("SymmetricEigen() error = %v, wantErr %v", err, tC.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			values := d.Values()
+			for k, want := range tC.wantValues {
+				if math.Abs(values[k]-want) > 1e-9 {
+					t.Errorf("Values() = %v, want %v", values, tC.wantValues)
+					break
+				}
+			}
+			V := d.Vectors()
+			if !isOrthonormal(V) {
+				t.Errorf("Vectors() = %v are not orthonormal", V)
+			}
+			VD, _ := V.Multiply(diagonal(values))
+			restored, _ := VD.Multiply(V.T())
+			if !approxEquals(restored, A) {
+				t.Errorf("V*D*V.T = %v, want %v", restored, A)
+			}
+		})
+	}
+}
+
+func TestSVD(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		A        m.Matrix[float64]
+		wantS    []float64
+		wantRank int
+	}{
+		{
+			desc:     "tall",
+			A:        must(m.NewMatrix([][]float64{{3, 0}, {4, 5}, {0, 0}})),
+			wantS:    []float64{3 * math.Sqrt(5), math.Sqrt(5)},
+			wantRank: 2,
+		},
+		{
+			desc:     "wide",
+			A:        must(m.NewMatrix([][]float64{{3, 4, 0}, {0, 5, 0}})),
+			wantS:    []float64{3 * math.Sqrt(5), math.Sqrt(5)},
+			wantRank: 2,
+		},
+		{
+			desc:     "rank-deficient",
+			A:        must(m.NewMatrix([][]float64{{1, 2}, {2, 4}, {3, 6}})),
+			wantS:    []float64{math.Sqrt(70), 0},
+			wantRank: 1,
+		},
+		{desc: "random", A: randomMatrix(7, 5), wantRank: 5},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Act
+			d, err := m.SVD(tC.A)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("SVD() error = %v", err)
+			}
+			S := d.S()
+			for k, want := range tC.wantS {
+				if math.Abs(S[k]-want) > 1e-9 {
+					t.Errorf("S() = %v, want %v", S, tC.wantS)
+					break
+				}
+			}
+			for k := 1; k < len(S); k++ {
+				if S[k] > S[k-1] {
+					t.Errorf("S() = %v are not sorted", S)
+				}
+			}
+			if rank := d.Rank(); rank != tC.wantRank {
+				t.Errorf("Rank() = %d, want %d", rank, tC.wantRank)
+			}
+			if !isOrthonormal(d.U()) || !isOrthonormal(d.V()) {
+				t.Errorf("U() = %v or V() = %v are not orthonormal", d.U(), d.V())
+			}
+			US, _ := d.U().Multiply(diagonal(S))
+			restored, _ := US.Multiply(d.V().T())
+			if !approxEquals(restored, tC.A) {
+				t.Errorf("U*S*V.T = %v, want %v", restored, tC.A)
+			}
+		})
+	}
+}
+
+func TestPseudoInverse(t *testing.T) {
+	testCases := []struct {
+		desc string
+		A    m.Matrix[float64]
+	}{
+		{desc: "invertible", A: must(m.NewMatrix([][]float64{{4, 7}, {2, 6}}))},
+		{desc: "tall", A: randomMatrix(5, 3)},
+		{desc: "wide", A: randomMatrix(2, 4)},
+		{desc: "rank-deficient", A: must(m.NewMatrix([][]float64{{1, 2}, {2, 4}, {3, 6}}))},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Act
+			P, err := m.PseudoInverse(tC.A)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("PseudoInverse() error = %v", err)
+			}
+			// The Moore-Penrose conditions: A*P*A = A, P*A*P = P, A*P and P*A are symmetric
+			AP, _ := tC.A.Multiply(P)
+			PA, _ := P.Multiply(tC.A)
+			APA, _ := AP.Multiply(tC.A)
+			PAP, _ := PA.Multiply(P)
+			if !approxEquals(APA, tC.A) {
+				t.Errorf("A*P*A = %v, want %v", APA, tC.A)
+			}
+			if !approxEquals(PAP, P) {
+				t.Errorf("P*A*P = %v, want %v", PAP, P)
+			}
+			if !approxEquals(AP, AP.T()) || !approxEquals(PA, PA.T()) {
+				t.Error("A*P or P*A is not symmetric")
+			}
+		})
+	}
+}
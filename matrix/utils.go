@@ -2,58 +2,145 @@ package matrix
 
 import (
 	"errors"
-	"sync"
 
 	. "golang.org/x/exp/constraints"
 )
 
-// NewMatrix returns a matrix implementation using the slice data.
+// NewMatrix returns a matrix implementation with the values of the slice data.
+// The values are copied, so the matrix does not share the memory with the slice.
 //
 // if the data is empty, or column count is incosistnent, returns an error,
 func NewMatrix[T Signed | Float](data [][]T) (Matrix[T], error) {
 	if len(data) == 0 {
 		return nil, errors.New("at least one row")
 	}
-	for i := 1; i < len(data); i++ {
-		if len(data[i]) != len(data[0]) {
+	return newMatrixFromRows(data)
+}
+
+// newMatrixFromRows copies the rows into a contiguous matrix.
+func newMatrixFromRows[T Signed | Float](data [][]T) (*matrix[T], error) {
+	columns := 0
+	if len(data) > 0 {
+		columns = len(data[0])
+	}
+	m := newMatrix[T](len(data), columns)
+	for i, row := range data {
+		if len(row) != columns {
 			return nil, errors.New("incosistent column count")
 		}
+		copy(m.row(i), row)
 	}
-	m := new(matrix[T])
-	m.data = data
 	return m, nil
 }
 
 // NewZeroMatrix returns a matrix implementation filled with zeros with required size.
 func NewZeroMatrix[T Signed | Float](rowCount int, columnCount int) Matrix[T] {
-	m := new(matrix[T])
-	m.data = make([][]T, rowCount)
-	for i := 0; i < rowCount; i++ {
-		m.data[i] = make([]T, columnCount)
-	}
-	return m
+	return newMatrix[T](rowCount, columnCount)
 }
 
 // NewOnesMatrix returns a matrix implementation filled with ones with required size.
-func NewOnesMatrix(rowCount int, columnCount int) Matrix[float64] {
-	m := NewZeroMatrix[float64](rowCount, columnCount)
-	for i := 0; i < rowCount; i++ {
-		for j := 0; j < columnCount; j++ {
-			m.Set(i, j, 1)
-		}
+func NewOnesMatrix[T Signed | Float](rowCount int, columnCount int) Matrix[T] {
+	m := newMatrix[T](rowCount, columnCount)
+	for i := range m.data {
+		m.data[i] = 1
 	}
 	return m
 }
 
 // IdentityMatrix returns a sqaure identity matrix implementation.
-func IdentityMatrix(rowCount int) Matrix[float64] {
-	m := NewZeroMatrix[float64](rowCount, rowCount)
+func IdentityMatrix[T Signed | Float](rowCount int) Matrix[T] {
+	m := NewZeroMatrix[T](rowCount, rowCount)
 	for i := 0; i < rowCount; i++ {
 		m.Set(i, i, 1)
 	}
 	return m
 }
 
+// VStack concatenates the matrices vertically, i.e. the rows of each next matrix
+// follow the rows of the previous one. The elements are copied.
+//
+// Returns error if no matrices are given, or their column counts differ.
+func VStack[T Signed | Float](ms ...Matrix[T]) (Matrix[T], error) {
+	if len(ms) == 0 {
+		return nil, errors.New("at least one matrix")
+	}
+	rows := 0
+	for _, M := range ms {
+		if M.ColumnCount() != ms[0].ColumnCount() {
+			return nil, errors.New("incosistent column count")
+		}
+		rows += M.RowCount()
+	}
+	result := newMatrix[T](rows, ms[0].ColumnCount())
+	rows = 0
+	for _, M := range ms {
+		m := dense(M)
+		for i := 0; i < m.rows; i++ {
+			copy(result.row(rows+i), m.row(i))
+		}
+		rows += m.rows
+	}
+	return result, nil
+}
+
+// HStack concatenates the matrices horizontally, i.e. the columns of each next matrix
+// follow the columns of the previous one. The elements are copied.
+//
+// Returns error if no matrices are given, or their row counts differ.
+func HStack[T Signed | Float](ms ...Matrix[T]) (Matrix[T], error) {
+	if len(ms) == 0 {
+		return nil, errors.New("at least one matrix")
+	}
+	columns := 0
+	for _, M := range ms {
+		if M.RowCount() != ms[0].RowCount() {
+			return nil, errors.New("incosistent row count")
+		}
+		columns += M.ColumnCount()
+	}
+	result := newMatrix[T](ms[0].RowCount(), columns)
+	columns = 0
+	for _, M := range ms {
+		m := dense(M)
+		for i := 0; i < m.rows; i++ {
+			copy(result.row(i)[columns:], m.row(i))
+		}
+		columns += m.columns
+	}
+	return result, nil
+}
+
+// Convert converts the elements of the matrix into another type, e.g. from float64
+// into float32 to halve the memory use. The matrix is returned as is, if it is already
+// of that type. A sparse matrix stays sparse.
+//
+//	M32 := Convert[float32](M)
+func Convert[U, T Signed | Float](M Matrix[T]) Matrix[U] {
+	if result, ok := any(M).(Matrix[U]); ok {
+		return result
+	}
+	if s, ok := M.(*sparse[T]); ok {
+		values := make([]U, len(s.values))
+		for k, v := range s.values {
+			values[k] = U(v)
+		}
+		result := &sparse[U]{
+			rows: s.rows, columns: s.columns, columnMajor: s.columnMajor,
+			indptr: s.indptr, indices: s.indices, values: values,
+		}
+		return result.withoutZeros()
+	}
+	m := dense(M)
+	result := newMatrix[U](m.rows, m.columns)
+	for i := 0; i < m.rows; i++ {
+		row := result.row(i)
+		for j, v := range m.row(i) {
+			row[j] = U(v)
+		}
+	}
+	return result
+}
+
 /****************************************************************************/
 
 // ApplyByElement applies some function elementwise to the matrix.
@@ -77,25 +164,14 @@ func ApplyByElement[T Signed | Float](M Matrix[T], f func(T) T) {
 //		value = lower
 //	 }
 func Clip[T Signed | Float](M Matrix[T], lower, upper T) Matrix[T] {
-	result := M.DeepCopy()
-
-	wg := sync.WaitGroup{}
-	wg.Add(result.ColumnCount())
-	for j := 0; j < M.ColumnCount(); j++ {
-		go func(j int) {
-			defer wg.Done()
-			for i := 0; i < M.RowCount(); i++ {
-				v, _ := result.At(i, j)
-				if v < lower {
-					v = lower
-				} else if v > upper {
-					v = upper
-				}
-				result.Set(i, j, v)
+	return dense(M).elementwise(nil, func(result, row, _ []T) {
+		for j, v := range row {
+			if v < lower {
+				v = lower
+			} else if v > upper {
+				v = upper
 			}
-		}(j)
-	}
-	wg.Wait()
-
-	return result
+			result[j] = v
+		}
+	})
 }
@@ -1,10 +1,8 @@
 package metric
 
 import (
-	"math"
-	"sync"
-
 	"github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
 )
 
 // Accuracy compares the prediction to the actual values.
@@ -18,36 +16,24 @@ import (
 //
 // Different columns are treated as different samples. The correct counter goes up
 // only if all the values per one prediction are equal to the label.
-type Accuracy struct {
+type Accuracy[T Float] struct {
 	Epsilon float64
 }
 
-func (a Accuracy) Calculate(yTrue, yHat matrix.Matrix[float64]) float64 {
+func (a Accuracy[T]) Calculate(yTrue, yHat matrix.Matrix[T]) float64 {
 	correct := 0
 	precision := a.Epsilon
 	if precision == 0.0 {
 		precision = DefaultEpsilon
 	}
 
-	m := sync.Mutex{}
-	wg := sync.WaitGroup{}
-	wg.Add(yTrue.ColumnCount())
-	for j := 0; j < yTrue.ColumnCount(); j++ {
-		go func(j int) {
-			defer wg.Done()
-			for i := 0; i < yTrue.RowCount(); i++ {
-				yTrueV, _ := yTrue.At(i, j)
-				yHatV, _ := yHat.At(i, j)
-				if math.Abs(yHatV-yTrueV) > precision {
-					return
-				}
-			}
-			m.Lock()
+	diff, _ := yHat.Add(yTrue.MultiplyByScalar(-1))
+	largestDiffs := matrix.Max(matrix.Abs(diff), matrix.PerColumn)
+	for j := 0; j < largestDiffs.ColumnCount(); j++ {
+		if d, _ := largestDiffs.At(0, j); float64(d) <= precision {
 			correct++
-			m.Unlock()
-		}(j)
+		}
 	}
-	wg.Wait()
 
 	return float64(correct) / float64(yTrue.ColumnCount())
 }
@@ -34,7 +34,7 @@ func TestAccuracy(t *testing.T) {
 		t.Run(tC.desc, func(t *testing.T) {
 			yTrueM, _ := matrix.NewMatrix(tC.yTrue)
 			yHatM, _ := matrix.NewMatrix(tC.yHat)
-			got := metric.Accuracy{Epsilon: tC.epsilon}.Calculate(yTrueM, yHatM)
+			got := metric.Accuracy[float64]{Epsilon: tC.epsilon}.Calculate(yTrueM, yHatM)
 			if tC.want != got {
 				t.Fail()
 			}
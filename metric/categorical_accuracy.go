@@ -1,35 +1,12 @@
 package metric
 
 import (
-	"sync"
-
 	"github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
 )
 
-func oneHotEncodingToValues(m matrix.Matrix[float64]) []int {
-	predictions := make([]int, m.ColumnCount())
-
-	wg := sync.WaitGroup{}
-	wg.Add(m.ColumnCount())
-	for j := 0; j < m.ColumnCount(); j++ {
-		go func(j int) {
-			defer wg.Done()
-			largestIndex := 0
-			largestValue, _ := m.At(0, j)
-
-			for i := 0; i < m.RowCount(); i++ {
-				value, _ := m.At(i, j)
-				if value > largestValue {
-					largestValue = value
-					largestIndex = i
-				}
-			}
-			predictions[j] = largestIndex
-		}(j)
-	}
-	wg.Wait()
-
-	return predictions
+func oneHotEncodingToValues[T Float](m matrix.Matrix[T]) []int {
+	return matrix.ArgMax(m, matrix.PerColumn)
 }
 
 // CategoriacalAccuracy is a probabilistic accuracy metric, used to compare most probable
@@ -38,15 +15,15 @@ func oneHotEncodingToValues(m matrix.Matrix[float64]) []int {
 //
 // Example:
 //
-//	ca := metrics.CategoricalAccuracy{}
+//	ca := metrics.CategoricalAccuracy[float64]{}
 //	yTrue, _ := matrix.NewMatrix([][]float64{{1, 0, 0}, {0, 1, 0}})
 //	yTrue = yTrue.T()
 //	yHat, _ := matrix.NewMatrix([][]float64{{0.78, 0.20, 0.02}, {0.10, 0.11, 0.79}})
 //	yHat = yHat.T()
 //	fmt.Println(ca.Calculate(yTrue, yHat)) // 0.5
-type CategoricalAccuracy struct{}
+type CategoricalAccuracy[T Float] struct{}
 
-func (c CategoricalAccuracy) Calculate(yTrue, yHat matrix.Matrix[float64]) float64 {
+func (c CategoricalAccuracy[T]) Calculate(yTrue, yHat matrix.Matrix[T]) float64 {
 	correct := 0
 
 	predictions := oneHotEncodingToValues(yHat)
@@ -67,14 +44,19 @@ func (c CategoricalAccuracy) Calculate(yTrue, yHat matrix.Matrix[float64]) float
 //
 // Example:
 //
-//	ca := metrics.SparseCategoricalAccuracy{}
+//	ca := metrics.SparseCategoricalAccuracy[float64]{}
 //	yTrue, _ := matrix.NewMatrix([][]float64{{0, 1}})
 //	yHat, _ := matrix.NewMatrix([][]float64{{0.78, 0.20, 0.02}, {0.10, 0.11, 0.79}})
 //	yHat = yHat.T()
 //	fmt.Println(ca.Calculate(yTrue, yHat)) // 0.5
-type SparseCategoricalAccuracy struct{}
+type SparseCategoricalAccuracy[T Float] struct{}
+
+// LabelRows returns 1, as the labels are the class indices.
+func (s SparseCategoricalAccuracy[T]) LabelRows(int) int {
+	return 1
+}
 
-func (s SparseCategoricalAccuracy) Calculate(yTrue, yHat matrix.Matrix[float64]) float64 {
+func (s SparseCategoricalAccuracy[T]) Calculate(yTrue, yHat matrix.Matrix[T]) float64 {
 	correct := 0
 
 	predictions := oneHotEncodingToValues(yHat)
@@ -33,7 +33,7 @@ func TestCategoricalAccuracy(t *testing.T) {
 			yTrueM = yTrueM.T()
 			yHatM, _ := matrix.NewMatrix(tC.yHat)
 			yHatM = yHatM.T()
-			got := metric.CategoricalAccuracy{}.Calculate(yTrueM, yHatM)
+			got := metric.CategoricalAccuracy[float64]{}.Calculate(yTrueM, yHatM)
 			if tC.want != got {
 				t.Fail()
 			}
@@ -66,7 +66,7 @@ func TestSparseCategoricalAccuracy(t *testing.T) {
 			yTrueM, _ := matrix.NewMatrix(tC.yTrue)
 			yHatM, _ := matrix.NewMatrix(tC.yHat)
 			yHatM = yHatM.T()
-			got := metric.SparseCategoricalAccuracy{}.Calculate(yTrueM, yHatM)
+			got := metric.SparseCategoricalAccuracy[float64]{}.Calculate(yTrueM, yHatM)
 			if tC.want != got {
 				t.Fail()
 			}
@@ -0,0 +1,156 @@
+package metric
+
+import (
+	"github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
+)
+
+const defaultThreshold = 0.5
+
+// classCounts contains the number of true positives, false positives and
+// false negatives for each of the classes.
+type classCounts struct {
+	truePositives, falsePositives, falseNegatives []float64
+}
+
+// countClasses computes the class counts for the prediction.
+//
+// If the output has a single row, the problem is treated as binary, with the
+// prediction being positive if it is not less than the threshold (0.5 if nil).
+// Otherwise labels must be one-hot encoded, and the most probable class is used
+// as the prediction.
+func countClasses[T Float](yTrue, yHat matrix.Matrix[T], thresholdValue *float64) classCounts {
+	var trueValues, predictions []int
+	classCount := yHat.RowCount()
+
+	if classCount == 1 {
+		threshold := defaultThreshold
+		if thresholdValue != nil {
+			threshold = *thresholdValue
+		}
+		trueValues = make([]int, yTrue.ColumnCount())
+		predictions = make([]int, yHat.ColumnCount())
+		for j := range predictions {
+			if v, _ := yTrue.At(0, j); v >= defaultThreshold {
+				trueValues[j] = 1
+			}
+			if v, _ := yHat.At(0, j); float64(v) >= threshold {
+				predictions[j] = 1
+			}
+		}
+		classCount = 2
+	} else {
+		trueValues = oneHotEncodingToValues(yTrue)
+		predictions = oneHotEncodingToValues(yHat)
+	}
+
+	counts := classCounts{
+		truePositives:  make([]float64, classCount),
+		falsePositives: make([]float64, classCount),
+		falseNegatives: make([]float64, classCount),
+	}
+	for j := range predictions {
+		if predictions[j] == trueValues[j] {
+			counts.truePositives[predictions[j]]++
+		} else {
+			counts.falsePositives[predictions[j]]++
+			counts.falseNegatives[trueValues[j]]++
+		}
+	}
+	if yHat.RowCount() == 1 {
+		// only the positive class is reported for binary problems
+		counts.truePositives = counts.truePositives[1:]
+		counts.falsePositives = counts.falsePositives[1:]
+		counts.falseNegatives = counts.falseNegatives[1:]
+	}
+	return counts
+}
+
+// macroAverage averages the score function over the classes, which are present
+// either in the labels or in the predictions.
+func macroAverage(counts classCounts, score func(tp, fp, fn float64) float64) float64 {
+	sum, classes := 0.0, 0
+	for i := range counts.truePositives {
+		tp, fp, fn := counts.truePositives[i], counts.falsePositives[i], counts.falseNegatives[i]
+		if tp+fp+fn == 0 {
+			continue
+		}
+		sum += score(tp, fp, fn)
+		classes++
+	}
+	if classes == 0 {
+		return 0
+	}
+	return sum / float64(classes)
+}
+
+func safeDivide(numerator, denominator float64) float64 {
+	if denominator == 0 {
+		return 0
+	}
+	return numerator / denominator
+}
+
+// Precision is a ratio of correct positive predictions to all positive predictions.
+//
+//	precision = TP / (TP + FP)
+//
+// For a single-row output, the problem is treated as binary, where the prediction is
+// positive if it is not less than Threshold (0.5 if nil). Otherwise, labels must
+// be one-hot encoded and the precision is macro-averaged over the classes.
+type Precision[T Float] struct {
+	Threshold *float64
+}
+
+// NewPrecision produces a precision metric with the given threshold for the binary outputs.
+func NewPrecision[T Float](threshold float64) Precision[T] {
+	return Precision[T]{Threshold: &threshold}
+}
+
+func (p Precision[T]) Calculate(yTrue, yHat matrix.Matrix[T]) float64 {
+	return macroAverage(countClasses(yTrue, yHat, p.Threshold), func(tp, fp, _ float64) float64 {
+		return safeDivide(tp, tp+fp)
+	})
+}
+
+// Recall is a ratio of correct positive predictions to all actual positives.
+//
+//	recall = TP / (TP + FN)
+//
+// Binary and multi-class outputs are treated the same as in Precision.
+type Recall[T Float] struct {
+	Threshold *float64
+}
+
+// NewRecall produces a recall metric with the given threshold for the binary outputs.
+func NewRecall[T Float](threshold float64) Recall[T] {
+	return Recall[T]{Threshold: &threshold}
+}
+
+func (r Recall[T]) Calculate(yTrue, yHat matrix.Matrix[T]) float64 {
+	return macroAverage(countClasses(yTrue, yHat, r.Threshold), func(tp, _, fn float64) float64 {
+		return safeDivide(tp, tp+fn)
+	})
+}
+
+// F1Score is a harmonic mean of precision and recall.
+//
+//	F1 = 2*TP / (2*TP + FP + FN)
+//
+// Binary and multi-class outputs are treated the same as in Precision. As the metric
+// is not additive, values averaged over the batches can differ from the one computed
+// on the whole dataset.
+type F1Score[T Float] struct {
+	Threshold *float64
+}
+
+// NewF1Score produces an F1 score metric with the given threshold for the binary outputs.
+func NewF1Score[T Float](threshold float64) F1Score[T] {
+	return F1Score[T]{Threshold: &threshold}
+}
+
+func (f F1Score[T]) Calculate(yTrue, yHat matrix.Matrix[T]) float64 {
+	return macroAverage(countClasses(yTrue, yHat, f.Threshold), func(tp, fp, fn float64) float64 {
+		return safeDivide(2*tp, 2*tp+fp+fn)
+	})
+}
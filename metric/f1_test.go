@@ -0,0 +1,76 @@
+package metric_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/metric"
+)
+
+func TestPrecisionRecallF1(t *testing.T) {
+	testCases := []struct {
+		yTrue     [][]float64
+		yHat      [][]float64
+		transpose bool
+		metric    metric.Metric[float64]
+		want      float64
+		desc      string
+	}{
+		{
+			// TP = 2, FP = 1, FN = 1
+			yTrue:  [][]float64{{1, 1, 1, 0, 0}},
+			yHat:   [][]float64{{0.9, 0.6, 0.2, 0.7, 0.1}},
+			metric: metric.Precision[float64]{},
+			want:   2.0 / 3.0,
+			desc:   "binary-precision",
+		},
+		{
+			yTrue:  [][]float64{{1, 1, 1, 0, 0}},
+			yHat:   [][]float64{{0.9, 0.6, 0.2, 0.7, 0.1}},
+			metric: metric.NewRecall[float64](0.65),
+			want:   1.0 / 3.0,
+			desc:   "binary-recall-threshold",
+		},
+		{
+			yTrue:  [][]float64{{1, 1, 1, 0, 0}},
+			yHat:   [][]float64{{0.9, 0.6, 0.2, 0.7, 0.1}},
+			metric: metric.F1Score[float64]{},
+			want:   2.0 / 3.0,
+			desc:   "binary-f1",
+		},
+		{
+			// class 0: TP = 1, FP = 1, FN = 0 -> F1 = 2/3
+			// class 1: TP = 1, FP = 0, FN = 1 -> F1 = 2/3
+			// class 2: TP = 0, FP = 0, FN = 0 -> not present
+			yTrue:     [][]float64{{1, 0, 0}, {0, 1, 0}, {0, 1, 0}},
+			yHat:      [][]float64{{0.8, 0.1, 0.1}, {0.6, 0.3, 0.1}, {0.1, 0.5, 0.4}},
+			transpose: true,
+			metric:    metric.F1Score[float64]{},
+			want:      2.0 / 3.0,
+			desc:      "multiclass-f1",
+		},
+		{
+			// every prediction is positive: TP = 3, FP = 2
+			yTrue:  [][]float64{{1, 1, 1, 0, 0}},
+			yHat:   [][]float64{{0.9, 0.6, 0.2, 0.7, 0.1}},
+			metric: metric.NewPrecision[float64](0),
+			want:   3.0 / 5.0,
+			desc:   "binary-precision-zero-threshold",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			yTrueM, _ := matrix.NewMatrix(tC.yTrue)
+			yHatM, _ := matrix.NewMatrix(tC.yHat)
+			if tC.transpose {
+				yTrueM = yTrueM.T()
+				yHatM = yHatM.T()
+			}
+			got := tC.metric.Calculate(yTrueM, yHatM)
+			if math.Abs(got-tC.want) > 1e-10 {
+				t.Errorf("got %v, want %v", got, tC.want)
+			}
+		})
+	}
+}
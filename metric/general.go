@@ -1,7 +1,10 @@
 // Package metric provides a set of metrics to calculate the accuracy of ANN predictions.
 package metric
 
-import "github.com/Hukyl/mlgo/matrix"
+import (
+	"github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
+)
 
 const DefaultEpsilon = 1e-5
 
@@ -16,8 +19,31 @@ const DefaultEpsilon = 1e-5
 //	yTrue = yTrue.T()
 //	yHat, _ := matrix.NewMatrix([][]float64{{1.8, 2.5}, {0, 1}})
 //	yHat = yHat.T()
-//	var metric Metric
+//	var metric Metric[float64] = Accuracy[float64]{Epsilon: 0.1}
 //	fmt.Println(metric.Calculate(yTrue, yHat)) // 0.5
-type Metric interface {
-	Calculate(yTrue, yHat matrix.Matrix[float64]) float64
+type Metric[T Float] interface {
+	Calculate(yTrue, yHat matrix.Matrix[T]) float64
+}
+
+// NamedMetric is a metric with the name, under which its value is reported
+// in the logs of the ANN.
+//
+// Example:
+//
+//	metrics := []metric.NamedMetric[float64]{
+//		{Name: "top5", Metric: metric.TopKCategoricalAccuracy[float64]{K: 5}},
+//		{Name: "f1", Metric: metric.F1Score[float64]{}},
+//	}
+type NamedMetric[T Float] struct {
+	Name string
+	Metric[T]
+}
+
+// SparseLabels is implemented by the metrics, the labels of which are not in the layout
+// of the prediction, e.g. the class indices of SparseCategoricalAccuracy.
+//
+// LabelRows returns the number of rows of the labels for the prediction with
+// the given number of rows.
+type SparseLabels interface {
+	LabelRows(outputRows int) int
 }
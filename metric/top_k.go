@@ -0,0 +1,51 @@
+package metric
+
+import (
+	"github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
+)
+
+const defaultK = 5
+
+// TopKCategoricalAccuracy is a probabilistic accuracy metric, which treats the prediction
+// as correct, if the true class is among the K most probable guesses of the neural network.
+// True labels must be presented as one-hot encoded values.
+//
+// If K is not set, it is initialized to 5.
+//
+// Example:
+//
+//	ca := metrics.TopKCategoricalAccuracy[float64]{K: 2}
+//	yTrue, _ := matrix.NewMatrix([][]float64{{1, 0, 0}, {0, 1, 0}})
+//	yTrue = yTrue.T()
+//	yHat, _ := matrix.NewMatrix([][]float64{{0.20, 0.78, 0.02}, {0.10, 0.11, 0.79}})
+//	yHat = yHat.T()
+//	fmt.Println(ca.Calculate(yTrue, yHat)) // 1
+type TopKCategoricalAccuracy[T Float] struct {
+	K int
+}
+
+func (t TopKCategoricalAccuracy[T]) Calculate(yTrue, yHat matrix.Matrix[T]) float64 {
+	k := t.K
+	if k == 0 {
+		k = defaultK
+	}
+	correct := 0
+
+	trueValues := oneHotEncodingToValues(yTrue)
+	for j, trueClass := range trueValues {
+		trueClassValue, _ := yHat.At(trueClass, j)
+		// the rank of the true class is the number of classes with greater probability
+		rank := 0
+		for i := 0; i < yHat.RowCount(); i++ {
+			if v, _ := yHat.At(i, j); v > trueClassValue {
+				rank++
+			}
+		}
+		if rank < k {
+			correct++
+		}
+	}
+
+	return float64(correct) / float64(len(trueValues))
+}
@@ -0,0 +1,52 @@
+package metric_test
+
+import (
+	"testing"
+
+	"github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/metric"
+)
+
+func TestTopKCategoricalAccuracy(t *testing.T) {
+	testCases := []struct {
+		yTrue [][]float64
+		yHat  [][]float64
+		k     int
+		want  float64
+		desc  string
+	}{
+		{
+			yTrue: [][]float64{{1, 0, 0}, {0, 1, 0}},
+			yHat:  [][]float64{{0.20, 0.78, 0.02}, {0.10, 0.11, 0.79}},
+			k:     1,
+			want:  0.0,
+			desc:  "top-1",
+		},
+		{
+			yTrue: [][]float64{{1, 0, 0}, {0, 1, 0}},
+			yHat:  [][]float64{{0.20, 0.78, 0.02}, {0.10, 0.11, 0.79}},
+			k:     2,
+			want:  1.0,
+			desc:  "top-2",
+		},
+		{
+			yTrue: [][]float64{{0, 0, 1, 0}, {1, 0, 0, 0}},
+			yHat:  [][]float64{{0.1, 0.2, 0.3, 0.4}, {0.1, 0.2, 0.3, 0.4}},
+			k:     2,
+			want:  0.5,
+			desc:  "quaternary",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			yTrueM, _ := matrix.NewMatrix(tC.yTrue)
+			yTrueM = yTrueM.T()
+			yHatM, _ := matrix.NewMatrix(tC.yHat)
+			yHatM = yHatM.T()
+			got := metric.TopKCategoricalAccuracy[float64]{K: tC.k}.Calculate(yTrueM, yHatM)
+			if tC.want != got {
+				t.Errorf("got %v, want %v", got, tC.want)
+			}
+		})
+	}
+}
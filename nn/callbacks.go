@@ -0,0 +1,103 @@
+package nn
+
+import "errors"
+
+// ErrStopTraining can be returned by any callback hook to stop the training
+// gracefully. Train does not return this error.
+var ErrStopTraining = errors.New("training stopped by callback")
+
+// Keys of the values in Logs, produced by the ANN during the training.
+const (
+	CostKey         = "cost"
+	AccuracyKey     = "accuracy"
+	LearningRateKey = "learning_rate"
+)
+
+// Logs contains named values, such as cost and accuracy, produced by the ANN
+// during the training for an epoch or a batch.
+type Logs map[string]float64
+
+// Callback is an interface for the objects, which are notified of the training
+// progress of the ANN and can influence it.
+//
+// Epochs are counted from 1, while batch is the index of the batch in the training data.
+//
+// OnTrainBegin and OnTrainEnd are called at the start and the end of the training.
+// OnTrainEnd receives the logs of the last epoch.
+//
+// OnEpochBegin and OnEpochEnd are called at the start and the end of each epoch.
+// OnEpochEnd receives the averaged logs for the epoch.
+//
+// OnBatchBegin and OnBatchEnd are called before and after each training batch.
+// OnBatchEnd receives the logs for the batch.
+//
+// If any of the hooks returns an error, the training is stopped and the error
+// is returned from Train, unless it is ErrStopTraining.
+type Callback interface {
+	OnTrainBegin(model Model) error
+	OnTrainEnd(model Model, logs Logs) error
+
+	OnEpochBegin(model Model, epoch int) error
+	OnEpochEnd(model Model, epoch int, logs Logs) error
+
+	OnBatchBegin(model Model, batch int) error
+	OnBatchEnd(model Model, batch int, logs Logs) error
+}
+
+// BaseCallback implements all the Callback hooks as no-ops. It is meant to be
+// embedded into the callbacks, which need only a few of the hooks.
+type BaseCallback struct{}
+
+func (BaseCallback) OnTrainBegin(Model) error          { return nil }
+func (BaseCallback) OnTrainEnd(Model, Logs) error      { return nil }
+func (BaseCallback) OnEpochBegin(Model, int) error     { return nil }
+func (BaseCallback) OnEpochEnd(Model, int, Logs) error { return nil }
+func (BaseCallback) OnBatchBegin(Model, int) error     { return nil }
+func (BaseCallback) OnBatchEnd(Model, int, Logs) error { return nil }
+
+/****************************************************************************/
+
+// callbackList calls the hooks of all the callbacks in order, stopping on the first error.
+type callbackList []Callback
+
+func (cl callbackList) call(f func(Callback) error) error {
+	for _, c := range cl {
+		if err := f(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cl callbackList) OnTrainBegin(model Model) error {
+	return cl.call(func(c Callback) error { return c.OnTrainBegin(model) })
+}
+
+func (cl callbackList) OnTrainEnd(model Model, logs Logs) error {
+	return cl.call(func(c Callback) error { return c.OnTrainEnd(model, logs) })
+}
+
+func (cl callbackList) OnEpochBegin(model Model, epoch int) error {
+	return cl.call(func(c Callback) error { return c.OnEpochBegin(model, epoch) })
+}
+
+func (cl callbackList) OnEpochEnd(model Model, epoch int, logs Logs) error {
+	return cl.call(func(c Callback) error { return c.OnEpochEnd(model, epoch, logs) })
+}
+
+func (cl callbackList) OnBatchBegin(model Model, batch int) error {
+	return cl.call(func(c Callback) error { return c.OnBatchBegin(model, batch) })
+}
+
+func (cl callbackList) OnBatchEnd(model Model, batch int, logs Logs) error {
+	return cl.call(func(c Callback) error { return c.OnBatchEnd(model, batch, logs) })
+}
+
+// isImprovement checks whether the value improved compared to the best one by
+// more than minDelta.
+func isImprovement(value, best, minDelta float64, maximize bool) bool {
+	if maximize {
+		return value > best+minDelta
+	}
+	return value < best-minDelta
+}
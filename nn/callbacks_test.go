@@ -0,0 +1,230 @@
+package nn_test
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Hukyl/mlgo/activation"
+	"github.com/Hukyl/mlgo/autograd"
+	"github.com/Hukyl/mlgo/loss"
+	"github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/metric"
+	"github.com/Hukyl/mlgo/nn"
+	"github.com/Hukyl/mlgo/nn/layers"
+	"github.com/Hukyl/mlgo/utils"
+)
+
+// countingCallback counts the calls of the hooks, and stops the training after stopAfter epochs.
+type countingCallback struct {
+	nn.BaseCallback
+	stopAfter int
+
+	trainBegin, trainEnd, epochEnd, batchEnd int
+}
+
+func (c *countingCallback) OnTrainBegin(nn.Model) error {
+	c.trainBegin++
+	return nil
+}
+
+func (c *countingCallback) OnTrainEnd(nn.Model, nn.Logs) error {
+	c.trainEnd++
+	return nil
+}
+
+func (c *countingCallback) OnEpochEnd(_ nn.Model, epoch int, _ nn.Logs) error {
+	c.epochEnd++
+	if epoch == c.stopAfter {
+		return nn.ErrStopTraining
+	}
+	return nil
+}
+
+func (c *countingCallback) OnBatchEnd(nn.Model, int, nn.Logs) error {
+	c.batchEnd++
+	return nil
+}
+
+func newLinearModel(t *testing.T, weight float64) nn.NeuralNetwork[float64] {
+	t.Helper()
+	W, _ := matrix.NewMatrix([][]float64{{weight}})
+	b := matrix.NewZeroMatrix[float64](1, 1)
+	layer, _ := layers.NewDense(W, b, activation.Linear[float64]{})
+	model, _ := nn.NewNeuralNetwork([]layers.Layer[float64]{layer}, loss.SquareLoss[float64]{})
+	return model
+}
+
+func linearData() ([]matrix.Matrix[float64], []matrix.Matrix[float64]) {
+	X1, _ := matrix.NewMatrix([][]float64{{1, 2}})
+	Y1, _ := matrix.NewMatrix([][]float64{{2, 4}})
+	X2, _ := matrix.NewMatrix([][]float64{{3, -1}})
+	Y2, _ := matrix.NewMatrix([][]float64{{6, -2}})
+	return []matrix.Matrix[float64]{X1, X2}, []matrix.Matrix[float64]{Y1, Y2}
+}
+
+func TestTrain_CallbacksStopTraining(t *testing.T) {
+	// Arrange
+	model := newLinearModel(t, 0.5)
+	X, Y := linearData()
+	callback := &countingCallback{stopAfter: 3}
+	parameters := utils.NeuralNetworkParameters[float64]{
+		EpochCount:     10,
+		AccuracyMetric: metric.Accuracy[float64]{},
+	}
+
+	// Act
+	_, err := model.Train(X, Y, parameters, callback)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Train error: %v", err)
+	}
+	if callback.trainBegin != 1 || callback.trainEnd != 1 {
+		t.Errorf("train hooks called %d/%d times, want 1/1", callback.trainBegin, callback.trainEnd)
+	}
+	if callback.epochEnd != 3 {
+		t.Errorf("OnEpochEnd called %d times, want 3", callback.epochEnd)
+	}
+	if callback.batchEnd != 6 {
+		t.Errorf("OnBatchEnd called %d times, want 6", callback.batchEnd)
+	}
+}
+
+func TestEarlyStopping(t *testing.T) {
+	// Arrange
+	W, _ := matrix.NewMatrix([][]float64{{1.0}})
+	layer, _ := layers.NewDense(W, matrix.NewZeroMatrix[float64](1, 1), activation.Linear[float64]{})
+	model, _ := nn.NewNeuralNetwork([]layers.Layer[float64]{layer}, loss.SquareLoss[float64]{})
+	es := &nn.EarlyStopping{Patience: 2, RestoreBestWeights: true}
+	costs := []float64{1.0, 0.5, 0.6, 0.7, 0.4}
+	es.OnTrainBegin(model)
+
+	// Act
+	var err error
+	stoppedAt := 0
+	for i, cost := range costs {
+		err = es.OnEpochEnd(model, i+1, nn.Logs{nn.CostKey: cost})
+		if err != nil {
+			stoppedAt = i + 1
+			break
+		}
+		// the weight of the model during the epoch k is equal to k
+		layer.Weights().Set(0, 0, float64(i+2))
+	}
+	restoreErr := es.OnTrainEnd(model, nil)
+
+	// Assert
+	if err != nn.ErrStopTraining || stoppedAt != 4 {
+		t.Fatalf("stopped at epoch %d with %v, want epoch 4 with ErrStopTraining", stoppedAt, err)
+	}
+	if es.BestEpoch() != 2 {
+		t.Errorf("BestEpoch = %d, want 2", es.BestEpoch())
+	}
+	if restoreErr != nil {
+		t.Fatalf("OnTrainEnd error: %v", restoreErr)
+	}
+	input, _ := matrix.NewMatrix([][]float64{{1.0}})
+	if got, _ := model.Predict(input).At(0, 0); got != 2.0 {
+		t.Errorf("restored prediction = %v, want 2.0", got)
+	}
+	// the weights are restored in place, so the layer held by the caller is up to date
+	if got, _ := layer.Weights().At(0, 0); got != 2.0 {
+		t.Errorf("restored weight of the layer = %v, want 2.0", got)
+	}
+}
+
+func TestEarlyStopping_RestoresUnregisteredLayer(t *testing.T) {
+	// Arrange - the custom layer is not registered, so the model cannot be loaded from JSON
+	W, _ := matrix.NewMatrix([][]float64{{0.5}})
+	layer, _ := layers.NewCustom("Scale", 1, 1,
+		func(X *autograd.Variable, p []*autograd.Variable) *autograd.Variable { return p[0].MatMul(X) },
+		W,
+	)
+	model, _ := nn.NewNeuralNetwork([]layers.Layer[float64]{layer}, loss.SquareLoss[float64]{})
+	X, Y := linearData()
+	es := &nn.EarlyStopping{Patience: 100, RestoreBestWeights: true}
+	parameters := utils.NeuralNetworkParameters[float64]{EpochCount: 5, InitialLearningRate: 0.01}
+
+	// Act
+	_, err := model.Train(X, Y, parameters, es)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Train error: %v", err)
+	}
+	if layer.Weights() == nil {
+		t.Error("the weights of the layer are lost")
+	}
+}
+
+func TestModelCheckpoint_SaveBestOnly(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	model := newLinearModel(t, 1.0)
+	mc := &nn.ModelCheckpoint{
+		Path:         filepath.Join(dir, "epoch_%d.json"),
+		SaveBestOnly: true,
+		Monitor:      nn.AccuracyKey,
+		Maximize:     true,
+	}
+	accuracies := []float64{0.5, 0.4, 0.7, 0.7}
+	mc.OnTrainBegin(model)
+
+	// Act
+	for i, accuracy := range accuracies {
+		if err := mc.OnEpochEnd(model, i+1, nn.Logs{nn.AccuracyKey: accuracy}); err != nil {
+			t.Fatalf("OnEpochEnd error: %v", err)
+		}
+	}
+
+	// Assert
+	for epoch, want := range map[int]bool{1: true, 2: false, 3: true, 4: false} {
+		_, err := os.Stat(filepath.Join(dir, fmt.Sprintf("epoch_%d.json", epoch)))
+		if got := err == nil; got != want {
+			t.Errorf("epoch %d dump exists = %v, want %v", epoch, got, want)
+		}
+	}
+	if _, err := nn.LoadNeuralNetwork[float64](filepath.Join(dir, "epoch_3.json")); err != nil {
+		t.Errorf("LoadNeuralNetwork error: %v", err)
+	}
+}
+
+func TestCSVLogger(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "history.csv")
+	model := newLinearModel(t, 0.5)
+	X, Y := linearData()
+	parameters := utils.NeuralNetworkParameters[float64]{
+		EpochCount:     3,
+		AccuracyMetric: metric.Accuracy[float64]{},
+	}
+
+	// Act
+	_, err := model.Train(X, Y, parameters, &nn.CSVLogger{Path: path})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Train error: %v", err)
+	}
+	f, _ := os.Open(path)
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("got %d records, want header and 3 epochs", len(records))
+	}
+	wantHeader := []string{"epoch", nn.AccuracyKey, nn.CostKey, nn.LearningRateKey}
+	for i, column := range wantHeader {
+		if records[0][i] != column {
+			t.Errorf("header[%d] = %s, want %s", i, records[0][i], column)
+		}
+	}
+	if records[3][0] != "3" {
+		t.Errorf("last epoch = %s, want 3", records[3][0])
+	}
+}
@@ -0,0 +1,56 @@
+package nn
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ModelCheckpoint dumps the ANN to a file at the end of the epochs.
+//
+// Path is the path of the dump. It may contain a single %d verb, which is
+// replaced with the epoch number (e.g. "backups/epoch_%d.json").
+//
+// If SaveBestOnly is set, the ANN is dumped only if the monitored value has improved.
+// Monitor, Maximize and MinDelta are the same as for EarlyStopping.
+//
+// As ModelCheckpoint is stateful, it has to be used by pointer.
+type ModelCheckpoint struct {
+	BaseCallback
+
+	Path         string
+	SaveBestOnly bool
+	Monitor      string
+	Maximize     bool
+	MinDelta     float64
+
+	best    float64
+	hasBest bool
+}
+
+func (mc *ModelCheckpoint) OnTrainBegin(Model) error {
+	mc.hasBest = false
+	return nil
+}
+
+func (mc *ModelCheckpoint) OnEpochEnd(model Model, epoch int, logs Logs) error {
+	if mc.SaveBestOnly {
+		monitor := mc.Monitor
+		if monitor == "" {
+			monitor = CostKey
+		}
+		value, ok := logs[monitor]
+		if !ok {
+			return fmt.Errorf("model checkpoint: unknown monitored value %q", monitor)
+		}
+		if mc.hasBest && !isImprovement(value, mc.best, mc.MinDelta, mc.Maximize) {
+			return nil
+		}
+		mc.best, mc.hasBest = value, true
+	}
+
+	path := mc.Path
+	if strings.Contains(path, "%d") {
+		path = fmt.Sprintf(path, epoch)
+	}
+	return DumpNeuralNetwork(model, path)
+}
@@ -0,0 +1,90 @@
+package nn
+
+import (
+	"errors"
+	"fmt"
+	"log"
+)
+
+// EarlyStopping stops the training, when the monitored value has stopped
+// improving for Patience epochs.
+//
+// Monitor is the key of the value in the epoch logs. If not set, CostKey is used.
+//
+// Maximize determines whether the monitored value is expected to increase
+// (e.g. accuracy) or decrease (e.g. cost).
+//
+// MinDelta is the minimal change of the monitored value to be treated as an improvement.
+//
+// If RestoreBestWeights is set, the weights of the ANN from the best epoch are restored
+// in place at the end of the training, i.e. the layers stay the same objects. Only the
+// layers, implementing layers.StatefulLayer, are restored.
+//
+// As EarlyStopping is stateful, it has to be used by pointer.
+type EarlyStopping struct {
+	BaseCallback
+
+	Monitor            string
+	Maximize           bool
+	MinDelta           float64
+	Patience           int
+	RestoreBestWeights bool
+
+	best        float64
+	hasBest     bool
+	wait        int
+	bestEpoch   int
+	restoreBest func()
+}
+
+// BestEpoch returns the epoch with the best monitored value during the last training.
+func (es *EarlyStopping) BestEpoch() int {
+	return es.bestEpoch
+}
+
+func (es *EarlyStopping) OnTrainBegin(Model) error {
+	es.hasBest = false
+	es.wait = 0
+	es.bestEpoch = 0
+	es.restoreBest = nil
+	return nil
+}
+
+func (es *EarlyStopping) OnEpochEnd(model Model, epoch int, logs Logs) error {
+	monitor := es.Monitor
+	if monitor == "" {
+		monitor = CostKey
+	}
+	value, ok := logs[monitor]
+	if !ok {
+		return fmt.Errorf("early stopping: unknown monitored value %q", monitor)
+	}
+
+	if !es.hasBest || isImprovement(value, es.best, es.MinDelta, es.Maximize) {
+		es.best, es.hasBest = value, true
+		es.bestEpoch = epoch
+		es.wait = 0
+		if es.RestoreBestWeights {
+			s, ok := model.(snapshotter)
+			if !ok {
+				return errors.New("early stopping: the weights of the model cannot be restored")
+			}
+			es.restoreBest = s.snapshot()
+		}
+		return nil
+	}
+
+	es.wait++
+	if es.wait >= es.Patience {
+		log.Printf("Early stopping at epoch %d, best epoch: %d\n", epoch, es.bestEpoch)
+		return ErrStopTraining
+	}
+	return nil
+}
+
+func (es *EarlyStopping) OnTrainEnd(model Model, _ Logs) error {
+	if es.RestoreBestWeights && es.restoreBest != nil {
+		es.restoreBest()
+	}
+	return nil
+}
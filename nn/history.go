@@ -0,0 +1,41 @@
+package nn
+
+// ValidationPrefix is prepended to the keys of the logs computed on the validation data,
+// e.g. "val_cost".
+const ValidationPrefix = "val_"
+
+// History contains the logs of each epoch of the training, returned by Train.
+//
+// Epochs contains the numbers of the epochs passed, starting at 1.
+//
+// Values maps the log keys (e.g. CostKey or ValidationPrefix+CostKey) to the values
+// for each of the epochs, so that Values[key][i] corresponds to Epochs[i].
+//
+// Example:
+//
+//	history, _ := model.Train(X, Y, parameters)
+//	trainingCost := history.Values[nn.CostKey]
+//	validationCost := history.Values[nn.ValidationPrefix+nn.CostKey]
+type History struct {
+	Epochs []int
+	Values map[string][]float64
+}
+
+// Logs returns the logs for the i-th recorded epoch.
+func (h History) Logs(i int) Logs {
+	logs := make(Logs, len(h.Values))
+	for key, values := range h.Values {
+		logs[key] = values[i]
+	}
+	return logs
+}
+
+func (h *History) append(epoch int, logs Logs) {
+	if h.Values == nil {
+		h.Values = make(map[string][]float64)
+	}
+	h.Epochs = append(h.Epochs, epoch)
+	for key, value := range logs {
+		h.Values[key] = append(h.Values[key], value)
+	}
+}
@@ -0,0 +1,87 @@
+package nn
+
+import (
+	"encoding/csv"
+	"os"
+	"slices"
+	"strconv"
+
+	"golang.org/x/exp/maps"
+)
+
+// CSVLogger writes the epoch logs into a CSV file given by Path. The first column
+// is the epoch number, and the rest are the log values sorted by their keys.
+//
+// The file is truncated at the beginning of the training, and a row is appended
+// at the end of each epoch, so the file can be inspected during the training.
+//
+// As CSVLogger is stateful, it has to be used by pointer.
+type CSVLogger struct {
+	BaseCallback
+
+	Path string
+
+	columns []string
+}
+
+func (cl *CSVLogger) OnTrainBegin(Model) error {
+	cl.columns = nil
+	f, err := os.Create(cl.Path)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func (cl *CSVLogger) OnEpochEnd(_ Model, epoch int, logs Logs) error {
+	f, err := os.OpenFile(cl.Path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+
+	if cl.columns == nil {
+		cl.columns = maps.Keys(logs)
+		slices.Sort(cl.columns)
+		if err := w.Write(append([]string{"epoch"}, cl.columns...)); err != nil {
+			return err
+		}
+	}
+	record := []string{strconv.Itoa(epoch)}
+	for _, column := range cl.columns {
+		record = append(record, strconv.FormatFloat(logs[column], 'g', -1, 64))
+	}
+	if err := w.Write(record); err != nil {
+		return err
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// JSONLogger writes the epoch logs into a JSON file given by Path, as a list
+// of objects, each of which contains the epoch number under the "epoch" key.
+//
+// The file is rewritten at the end of each epoch, so it can be inspected during
+// the training.
+//
+// As JSONLogger is stateful, it has to be used by pointer.
+type JSONLogger struct {
+	BaseCallback
+
+	Path string
+
+	history []map[string]float64
+}
+
+func (jl *JSONLogger) OnTrainBegin(Model) error {
+	jl.history = nil
+	return nil
+}
+
+func (jl *JSONLogger) OnEpochEnd(_ Model, epoch int, logs Logs) error {
+	entry := maps.Clone(logs)
+	entry["epoch"] = float64(epoch)
+	jl.history = append(jl.history, entry)
+	return jsonifyObject(jl.history, jl.Path)
+}
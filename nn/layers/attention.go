@@ -0,0 +1,320 @@
+package layers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/Hukyl/mlgo/activation"
+	. "github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/utils"
+	. "golang.org/x/exp/constraints"
+)
+
+// AttentionParameters contains the hyperparameters of a multi-head self-attention layer.
+//
+// Each sample is a sequence of TimeSteps vectors of ModelSize features, stored one
+// after another, i.e. the element d of the time step t is stored in the row
+//
+//	t*ModelSize + d
+//
+// Heads is the number of the attention heads. ModelSize has to be divisible by it.
+//
+// Causal defines whether each time step may attend only to itself and the previous ones.
+//
+// Mask is an optional TimeSteps x TimeSteps matrix, where Mask[i][j] defines whether
+// the time step i may attend to the time step j. If both Causal and Mask are set,
+// both of them are applied.
+type AttentionParameters struct {
+	TimeSteps int
+	ModelSize int
+	Heads     int
+	Causal    bool
+	Mask      [][]bool
+}
+
+// allowed returns whether the query time step may attend to the key time step.
+func (p AttentionParameters) allowed(query, key int) bool {
+	if p.Causal && key > query {
+		return false
+	}
+	return p.Mask == nil || p.Mask[query][key]
+}
+
+// validate returns error if the parameters are inconsistent, or some time step
+// may not attend to any of the time steps.
+func (p AttentionParameters) validate() error {
+	if p.TimeSteps <= 0 || p.ModelSize <= 0 || p.Heads <= 0 {
+		return errors.New("time steps, model size and heads must be positive")
+	}
+	if p.ModelSize%p.Heads != 0 {
+		return errors.New("model size must be divisible by the number of heads")
+	}
+	if p.Mask != nil {
+		if len(p.Mask) != p.TimeSteps {
+			return errors.New("invalid mask size")
+		}
+		for _, row := range p.Mask {
+			if len(row) != p.TimeSteps {
+				return errors.New("invalid mask size")
+			}
+		}
+	}
+	for query := 0; query < p.TimeSteps; query++ {
+		valid := false
+		for key := 0; key < p.TimeSteps && !valid; key++ {
+			valid = p.allowed(query, key)
+		}
+		if !valid {
+			return fmt.Errorf("time step %d may not attend to any time step", query)
+		}
+	}
+	return nil
+}
+
+// multiHeadAttention is a scaled dot-product self-attention with multiple heads.
+//
+// Within the layer, each sample is a ModelSize x TimeSteps matrix X, i.e. each time step
+// is a column. The projections are stored in a single weight matrix [Wq; Wk; Wv; Wo]
+// with the corresponding bias, and for each head h:
+//
+//	Q, K, V = Wq @ X + bq, Wk @ X + bk, Wv @ X + bv
+//	P_h = softmax(K_h.T() @ Q_h / sqrt(ModelSize/Heads) + mask)
+//	O_h = V_h @ P_h
+//	Y = Wo @ [O_1; ...; O_Heads] + bo
+//
+// where the softmax is applied to each column, i.e. the attention weights of each
+// query sum up to 1.
+type multiHeadAttention[T Float] struct {
+	parameters AttentionParameters
+	weights    Matrix[T] // (4*ModelSize) x ModelSize
+	bias       Matrix[T] // (4*ModelSize) x 1
+}
+
+func (m *multiHeadAttention[T]) InputSize() [2]int {
+	return [2]int{m.parameters.TimeSteps * m.parameters.ModelSize, 1}
+}
+
+func (m *multiHeadAttention[T]) OutputSize() [2]int {
+	return m.InputSize()
+}
+
+func (m *multiHeadAttention[T]) IsTraining() bool {
+	return false
+}
+
+func (m *multiHeadAttention[T]) Weights() Matrix[T] {
+	return m.weights
+}
+
+func (m *multiHeadAttention[T]) Bias() Matrix[T] {
+	return m.bias
+}
+
+func (m *multiHeadAttention[T]) Activation() activation.ActivationFunction[T] {
+	return nil
+}
+
+// State returns the projection weights and biases.
+func (m *multiHeadAttention[T]) State() []*Matrix[T] {
+	return []*Matrix[T]{&m.weights, &m.bias}
+}
+
+/****************************************************************************/
+
+// attentionCache contains the intermediate values of the attention for a single sample.
+type attentionCache[T Float] struct {
+	X, Q, K, V Matrix[T]
+	P          []Matrix[T] // attention weights of each head
+	O          Matrix[T]   // concatenated outputs of the heads
+}
+
+// attend computes the attention for a single sample X (ModelSize x TimeSteps).
+func (m *multiHeadAttention[T]) attend(X Matrix[T]) (Matrix[T], attentionCache[T]) {
+	size := m.parameters.ModelSize
+	headSize := size / m.parameters.Heads
+	scale := T(1 / math.Sqrt(float64(headSize)))
+
+	QKV := affine(rowRange(m.weights, 0, 3*size), rowRange(m.bias, 0, 3*size), X)
+	c := attentionCache[T]{
+		X: X,
+		Q: rowRange(QKV, 0, size),
+		K: rowRange(QKV, size, 2*size),
+		V: rowRange(QKV, 2*size, 3*size),
+		P: make([]Matrix[T], m.parameters.Heads),
+	}
+
+	outputs := make([]Matrix[T], m.parameters.Heads)
+	for h := range outputs {
+		Qh := rowRange(c.Q, h*headSize, (h+1)*headSize)
+		Kh := rowRange(c.K, h*headSize, (h+1)*headSize)
+		Vh := rowRange(c.V, h*headSize, (h+1)*headSize)
+
+		scores, _ := Kh.T().Multiply(Qh)
+		for key := 0; key < scores.RowCount(); key++ {
+			for query := 0; query < scores.ColumnCount(); query++ {
+				s, _ := scores.At(key, query)
+				if !m.parameters.allowed(query, key) {
+					s = T(math.Inf(-1))
+				}
+				scores.Set(key, query, s*scale)
+			}
+		}
+		activation.Softmax[T]{}.ApplyMatrix(scores)
+		c.P[h] = scores
+		outputs[h], _ = Vh.Multiply(scores)
+	}
+	c.O = stackRows(outputs...)
+
+	return affine(rowRange(m.weights, 3*size, 4*size), rowRange(m.bias, 3*size, 4*size), c.O), c
+}
+
+// attendBack propagates the gradient dY of a single sample, returning the gradients
+// with respect to the input, the weights and the bias.
+func (m *multiHeadAttention[T]) attendBack(c attentionCache[T], dY Matrix[T]) (dX, dW, db Matrix[T]) {
+	size := m.parameters.ModelSize
+	headSize := size / m.parameters.Heads
+	scale := T(1 / math.Sqrt(float64(headSize)))
+
+	dO, dWo, dbo := affineBack(rowRange(m.weights, 3*size, 4*size), c.O, dY)
+	dQ := make([]Matrix[T], m.parameters.Heads)
+	dK := make([]Matrix[T], m.parameters.Heads)
+	dV := make([]Matrix[T], m.parameters.Heads)
+	for h := range dQ {
+		Qh := rowRange(c.Q, h*headSize, (h+1)*headSize)
+		Kh := rowRange(c.K, h*headSize, (h+1)*headSize)
+		Vh := rowRange(c.V, h*headSize, (h+1)*headSize)
+		dOh := rowRange(dO, h*headSize, (h+1)*headSize)
+
+		dV[h], _ = dOh.Multiply(c.P[h].T())
+		dP, _ := Vh.T().Multiply(dOh)
+		dScores := activation.Softmax[T]{}.JacobianProduct(nil, c.P[h], dP).MultiplyByScalar(scale)
+		dQ[h], _ = Kh.Multiply(dScores)
+		dK[h], _ = Qh.Multiply(dScores.T())
+	}
+
+	dX, dWqkv, dbqkv := affineBack(
+		rowRange(m.weights, 0, 3*size),
+		c.X,
+		stackRows(stackRows(dQ...), stackRows(dK...), stackRows(dV...)),
+	)
+	return dX, stackRows(dWqkv, dWo), stackRows(dbqkv, dbo)
+}
+
+// ForwardPropagate computes the self-attention for each sample of X. As there is
+// no activation function, both output matrices are the same.
+//
+// Input has to be of m.InputSize() size, otherwise error is returned.
+func (m *multiHeadAttention[T]) ForwardPropagate(X Matrix[T]) (Y [2]Matrix[T], err error) {
+	if X.RowCount() != m.InputSize()[0] {
+		return Y, errors.New("invalid input size")
+	}
+	output := NewZeroMatrix[T](X.RowCount(), X.ColumnCount())
+	for n := 0; n < X.ColumnCount(); n++ {
+		sample, _ := m.attend(sequenceSample(X, n, m.parameters.ModelSize))
+		setSequenceSample(output, n, sample)
+	}
+	Y = [2]Matrix[T]{output, output}
+	return Y, nil
+}
+
+// BackPropagate propagates the gradient through the attention of each sample,
+// averaging the gradients of the weights and bias over the samples.
+func (m *multiHeadAttention[T]) BackPropagate(nextLayerPropagation, X Matrix[T], A [2]Matrix[T], parameters utils.NeuralNetworkParameters[T]) Matrix[T] {
+	result := NewZeroMatrix[T](X.RowCount(), X.ColumnCount())
+	dW := NewZeroMatrix[T](m.weights.RowCount(), m.weights.ColumnCount())
+	db := NewZeroMatrix[T](m.bias.RowCount(), 1)
+
+	for n := 0; n < X.ColumnCount(); n++ {
+		_, cache := m.attend(sequenceSample(X, n, m.parameters.ModelSize))
+		dX, dWn, dbn := m.attendBack(cache, sequenceSample(nextLayerPropagation, n, m.parameters.ModelSize))
+		setSequenceSample(result, n, dX)
+		dW, _ = dW.Add(dWn)
+		db, _ = db.Add(dbn)
+	}
+
+	samples := T(X.ColumnCount())
+	m.updateWeights(dW.MultiplyByScalar(1/samples), db.MultiplyByScalar(1/samples), parameters)
+	return result
+}
+
+func (m *multiHeadAttention[T]) updateWeights(dW, db Matrix[T], parameters utils.NeuralNetworkParameters[T]) {
+	updateParameter(&m.weights, dW, parameters)
+	updateParameter(&m.bias, db, parameters)
+}
+
+/****************************************************************************/
+
+func (m *multiHeadAttention[T]) String() string {
+	return fmt.Sprintf(
+		"MultiHeadAttention{%dx%d, heads: %d, causal: %t}",
+		m.parameters.TimeSteps,
+		m.parameters.ModelSize,
+		m.parameters.Heads,
+		m.parameters.Causal,
+	)
+}
+
+func (m *multiHeadAttention[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Parameters AttentionParameters
+		Weights    Matrix[T]
+		Bias       Matrix[T]
+		Type       string
+	}{
+		Parameters: m.parameters,
+		Weights:    m.weights,
+		Bias:       m.bias,
+		Type:       "MultiHeadAttention",
+	})
+}
+
+func (m *multiHeadAttention[T]) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Parameters AttentionParameters
+		Weights    json.RawMessage
+		Bias       json.RawMessage
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return errors.Join(errors.New("invalid attention layer"), err)
+	}
+	if err := v.Parameters.validate(); err != nil {
+		return errors.Join(errors.New("invalid attention parameters"), err)
+	}
+
+	w, _ := NewMatrix([][]T{{}})
+	if err := w.UnmarshalJSON(v.Weights); err != nil {
+		return errors.Join(errors.New("invalid weight initializing"), err)
+	}
+	b, _ := NewMatrix([][]T{{}})
+	if err := b.UnmarshalJSON(v.Bias); err != nil {
+		return errors.Join(errors.New("invalid bias initializing"), err)
+	}
+	size := v.Parameters.ModelSize
+	if w.RowCount() != 4*size || w.ColumnCount() != size || b.RowCount() != 4*size {
+		return errors.New("invalid weight or bias size")
+	}
+	m.parameters, m.weights, m.bias = v.Parameters, w, b
+	return nil
+}
+
+/****************************************************************************/
+
+// sequenceSample returns the sample n of the sequence batch X as a size x TimeSteps
+// matrix, i.e. each time step becomes a column.
+func sequenceSample[T Float](X Matrix[T], n, size int) Matrix[T] {
+	column, _ := X.Col(n)
+	steps, _ := column.Reshape(X.RowCount()/size, size)
+	return steps.T()
+}
+
+// setSequenceSample is the inverse of sequenceSample.
+func setSequenceSample[T Float](X Matrix[T], n int, sample Matrix[T]) {
+	for t := 0; t < sample.ColumnCount(); t++ {
+		for d := 0; d < sample.RowCount(); d++ {
+			v, _ := sample.At(d, t)
+			X.Set(t*sample.RowCount()+d, n, v)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package layers_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Hukyl/mlgo/activation"
+	"github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/nn/layers"
+)
+
+func TestMultiHeadAttention_BackPropagate(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		parameters layers.AttentionParameters
+	}{
+		{
+			desc:       "single-head",
+			parameters: layers.AttentionParameters{TimeSteps: 3, ModelSize: 2, Heads: 1},
+		},
+		{
+			desc:       "multi-head",
+			parameters: layers.AttentionParameters{TimeSteps: 3, ModelSize: 4, Heads: 2},
+		},
+		{
+			desc:       "causal",
+			parameters: layers.AttentionParameters{TimeSteps: 3, ModelSize: 4, Heads: 2, Causal: true},
+		},
+		{
+			desc: "mask",
+			parameters: layers.AttentionParameters{
+				TimeSteps: 3,
+				ModelSize: 2,
+				Heads:     2,
+				Mask:      [][]bool{{true, false, true}, {false, true, false}, {true, true, true}},
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			attention, err := layers.NewMultiHeadAttention[float64](tC.parameters, layers.XavierNormalInitialization{})
+			if err != nil {
+				t.Fatalf("NewMultiHeadAttention error: %v", err)
+			}
+			X := randomMatrix(attention.InputSize()[0], 2)
+
+			// Act & Assert
+			checkInputGradient(t, attention, X)
+			checkParameterGradients(t, attention, X)
+		})
+	}
+}
+
+func TestMultiHeadAttention_Causal(t *testing.T) {
+	// Arrange
+	parameters := layers.AttentionParameters{TimeSteps: 3, ModelSize: 2, Heads: 1, Causal: true}
+	attention, _ := layers.NewMultiHeadAttention[float64](parameters, layers.XavierNormalInitialization{})
+	X := randomMatrix(6, 1)
+	before, _ := attention.ForwardPropagate(X)
+
+	// Act - change the last time step
+	X.Set(4, 0, 10)
+	X.Set(5, 0, -10)
+	after, _ := attention.ForwardPropagate(X)
+
+	// Assert - the previous time steps must not attend to it
+	for i := 0; i < 4; i++ {
+		b, _ := before[1].At(i, 0)
+		a, _ := after[1].At(i, 0)
+		if math.Abs(a-b) > 1e-12 {
+			t.Errorf("output(%d) changed from %v to %v", i, b, a)
+		}
+	}
+}
+
+func TestNewMultiHeadAttention_InvalidParameters(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		parameters layers.AttentionParameters
+	}{
+		{
+			desc:       "indivisible-heads",
+			parameters: layers.AttentionParameters{TimeSteps: 2, ModelSize: 3, Heads: 2},
+		},
+		{
+			desc:       "invalid-mask-size",
+			parameters: layers.AttentionParameters{TimeSteps: 2, ModelSize: 2, Heads: 1, Mask: [][]bool{{true}}},
+		},
+		{
+			desc: "empty-mask-row",
+			parameters: layers.AttentionParameters{
+				TimeSteps: 2,
+				ModelSize: 2,
+				Heads:     1,
+				Causal:    true,
+				Mask:      [][]bool{{false, true}, {true, true}},
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Act
+			_, err := layers.NewMultiHeadAttention[float64](tC.parameters, layers.XavierNormalInitialization{})
+
+			// Assert
+			if err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestTransformerEncoderBlock_BackPropagate(t *testing.T) {
+	// Arrange
+	parameters := layers.AttentionParameters{TimeSteps: 3, ModelSize: 4, Heads: 2, Causal: true}
+	block, err := layers.NewTransformerEncoderBlock(parameters, 5, activation.Sigmoid[float64]{}, layers.XavierNormalInitialization{})
+	if err != nil {
+		t.Fatalf("NewTransformerEncoderBlock error: %v", err)
+	}
+	X := randomMatrix(block.InputSize()[0], 2)
+
+	// Act & Assert
+	checkInputGradient(t, block, X)
+}
+
+func TestPositionalEncoding(t *testing.T) {
+	// Arrange
+	encoding := layers.NewPositionalEncoding[float64](2, 4)
+	X := matrix.NewZeroMatrix[float64](8, 1)
+
+	// Act
+	output, err := encoding.ForwardPropagate(X)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("ForwardPropagate error: %v", err)
+	}
+	want := []float64{0, 1, 0, 1, math.Sin(1), math.Cos(1), math.Sin(0.01), math.Cos(0.01)}
+	for i, w := range want {
+		if got, _ := output[1].At(i, 0); math.Abs(got-w) > 1e-12 {
+			t.Errorf("PE(%d) = %v, want %v", i, got, w)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package layers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/Hukyl/mlgo/activation"
+	. "github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/utils"
+	. "golang.org/x/exp/constraints"
+)
+
+// batchNorm normalizes each feature over the batch. For spatial inputs, a feature
+// is a channel, which is normalized over the batch and all its positions.
+type batchNorm[T Float] struct {
+	inputShape      Shape
+	momentum        float64
+	epsilon         float64
+	gamma           Matrix[T] // Channels x 1
+	beta            Matrix[T] // Channels x 1
+	runningMean     Matrix[T] // Channels x 1
+	runningVariance Matrix[T] // Channels x 1
+}
+
+func (b *batchNorm[T]) InputShape() Shape {
+	return b.inputShape
+}
+
+func (b *batchNorm[T]) OutputShape() Shape {
+	return b.inputShape
+}
+
+func (b *batchNorm[T]) InputSize() [2]int {
+	return [2]int{b.inputShape.Size(), 1}
+}
+
+func (b *batchNorm[T]) OutputSize() [2]int {
+	return [2]int{b.inputShape.Size(), 1}
+}
+
+func (b *batchNorm[T]) IsTraining() bool {
+	return false
+}
+
+// Weights returns the scale (gamma) of the normalized features.
+func (b *batchNorm[T]) Weights() Matrix[T] {
+	return b.gamma
+}
+
+// Bias returns the shift (beta) of the normalized features.
+func (b *batchNorm[T]) Bias() Matrix[T] {
+	return b.beta
+}
+
+func (b *batchNorm[T]) Activation() activation.ActivationFunction[T] {
+	return nil
+}
+
+// State returns gamma, beta and the running statistics.
+func (b *batchNorm[T]) State() []*Matrix[T] {
+	return []*Matrix[T]{&b.gamma, &b.beta, &b.runningMean, &b.runningVariance}
+}
+
+/****************************************************************************/
+
+// gather returns the values of the feature f over all positions and samples.
+func (b *batchNorm[T]) gather(M Matrix[T], f int) []float64 {
+	positions := b.inputShape.Height * b.inputShape.Width
+	values := make([]float64, 0, positions*M.ColumnCount())
+	for l := 0; l < positions; l++ {
+		for n := 0; n < M.ColumnCount(); n++ {
+			v, _ := M.At(f*positions+l, n)
+			values = append(values, float64(v))
+		}
+	}
+	return values
+}
+
+// scatter is the inverse of gather.
+func (b *batchNorm[T]) scatter(M Matrix[T], f int, values []float64) {
+	positions := b.inputShape.Height * b.inputShape.Width
+	for l := 0; l < positions; l++ {
+		for n := 0; n < M.ColumnCount(); n++ {
+			M.Set(f*positions+l, n, T(values[l*M.ColumnCount()+n]))
+		}
+	}
+}
+
+// ForwardPropagate normalizes the features using the statistics of the batch,
+// which is the training behaviour of the layer. The running statistics are not
+// updated, so that the extra forward passes (e.g. for evaluation or gradient checking)
+// do not affect the inference. The result consists of:
+//   - The normalized input x̂
+//   - The actual output of the layer, which is gamma*x̂ + beta
+//
+// Input has to be of b.InputSize() size, otherwise error is returned.
+func (b *batchNorm[T]) ForwardPropagate(X Matrix[T]) (Y [2]Matrix[T], err error) {
+	if X.RowCount() != b.inputShape.Size() {
+		return Y, errors.New("invalid input size")
+	}
+	normalized := NewZeroMatrix[T](X.RowCount(), X.ColumnCount())
+	output := NewZeroMatrix[T](X.RowCount(), X.ColumnCount())
+
+	for f := 0; f < b.inputShape.Channels; f++ {
+		values := b.gather(X, f)
+		standardize(values, b.epsilon)
+		b.scatter(normalized, f, values)
+
+		gamma, _ := b.gamma.At(f, 0)
+		beta, _ := b.beta.At(f, 0)
+		for i, v := range values {
+			values[i] = float64(gamma)*v + float64(beta)
+		}
+		b.scatter(output, f, values)
+	}
+
+	Y = [2]Matrix[T]{normalized, output}
+	return Y, nil
+}
+
+// Infer normalizes the features using the running statistics, collected during
+// the training, i.e. the result for each sample does not depend on the rest of the batch.
+func (b *batchNorm[T]) Infer(X Matrix[T]) (Matrix[T], error) {
+	if X.RowCount() != b.inputShape.Size() {
+		return nil, errors.New("invalid input size")
+	}
+	output := NewZeroMatrix[T](X.RowCount(), X.ColumnCount())
+	for f := 0; f < b.inputShape.Channels; f++ {
+		mean, _ := b.runningMean.At(f, 0)
+		variance, _ := b.runningVariance.At(f, 0)
+		gamma, _ := b.gamma.At(f, 0)
+		beta, _ := b.beta.At(f, 0)
+		invStd := 1 / math.Sqrt(float64(variance)+b.epsilon)
+
+		values := b.gather(X, f)
+		for i, v := range values {
+			values[i] = float64(gamma)*(v-float64(mean))*invStd + float64(beta)
+		}
+		b.scatter(output, f, values)
+	}
+	return output, nil
+}
+
+// BackPropagate computes the gradients of gamma and beta, averaged over the samples
+//
+//	dLdGamma = sum(nextLayerPropagation * x̂) / N
+//	dLdBeta = sum(nextLayerPropagation) / N
+//
+// and propagates the gradient through the normalization, considering that the
+// batch statistics depend on every sample of the batch.
+//
+// As it is called only during the training, the running statistics are updated here as
+//
+//	running = momentum*running + (1-momentum)*batch
+//
+// with the unbiased variance of the batch.
+func (b *batchNorm[T]) BackPropagate(nextLayerPropagation, X Matrix[T], A [2]Matrix[T], parameters utils.NeuralNetworkParameters[T]) Matrix[T] {
+	result := NewZeroMatrix[T](X.RowCount(), X.ColumnCount())
+	dGamma := NewZeroMatrix[T](b.inputShape.Channels, 1)
+	dBeta := NewZeroMatrix[T](b.inputShape.Channels, 1)
+	samples := float64(X.ColumnCount())
+
+	for f := 0; f < b.inputShape.Channels; f++ {
+		xhat := b.gather(X, f)
+		mean, variance, invStd := standardize(xhat, b.epsilon)
+		b.updateRunningStatistics(f, mean, variance, len(xhat))
+		gradient := b.gather(nextLayerPropagation, f)
+		gamma, _ := b.gamma.At(f, 0)
+
+		sumGamma, sumBeta := 0.0, 0.0
+		for i := range gradient {
+			sumGamma += gradient[i] * xhat[i]
+			sumBeta += gradient[i]
+			gradient[i] *= float64(gamma)
+		}
+		dGamma.Set(f, 0, T(sumGamma/samples))
+		dBeta.Set(f, 0, T(sumBeta/samples))
+
+		standardizationGradient(gradient, xhat, invStd)
+		b.scatter(result, f, gradient)
+	}
+	b.updateWeights(dGamma, dBeta, parameters)
+
+	return result
+}
+
+// updateRunningStatistics updates the running statistics of the feature f with the mean
+// and the (biased) variance of its m values in the batch.
+func (b *batchNorm[T]) updateRunningStatistics(f int, mean, variance float64, m int) {
+	if m > 1 {
+		variance *= float64(m) / float64(m-1)
+	}
+	runningMean, _ := b.runningMean.At(f, 0)
+	runningVariance, _ := b.runningVariance.At(f, 0)
+	b.runningMean.Set(f, 0, T(b.momentum*float64(runningMean)+(1-b.momentum)*mean))
+	b.runningVariance.Set(f, 0, T(b.momentum*float64(runningVariance)+(1-b.momentum)*variance))
+}
+
+// updateWeights updates gamma and beta. Weight decay is not applied, as the scale
+// and the shift are not regularized.
+func (b *batchNorm[T]) updateWeights(dGamma, dBeta Matrix[T], parameters utils.NeuralNetworkParameters[T]) {
+	parameters.WeightDecay = 0
+	updateParameter(&b.gamma, dGamma, parameters)
+	updateParameter(&b.beta, dBeta, parameters)
+}
+
+/****************************************************************************/
+
+func (b *batchNorm[T]) String() string {
+	return fmt.Sprintf("BatchNorm{%s, momentum: %v}", b.inputShape, b.momentum)
+}
+
+func (b *batchNorm[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		InputShape      Shape
+		Momentum        float64
+		Epsilon         float64
+		Gamma           Matrix[T]
+		Beta            Matrix[T]
+		RunningMean     Matrix[T]
+		RunningVariance Matrix[T]
+		Type            string
+	}{
+		InputShape:      b.inputShape,
+		Momentum:        b.momentum,
+		Epsilon:         b.epsilon,
+		Gamma:           b.gamma,
+		Beta:            b.beta,
+		RunningMean:     b.runningMean,
+		RunningVariance: b.runningVariance,
+		Type:            "BatchNorm",
+	})
+}
+
+func (b *batchNorm[T]) UnmarshalJSON(data []byte) error {
+	var v map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(v["InputShape"], &b.inputShape); err != nil {
+		return errors.Join(errors.New("invalid input shape"), err)
+	}
+	if err := json.Unmarshal(v["Momentum"], &b.momentum); err != nil {
+		return errors.Join(errors.New("invalid momentum"), err)
+	}
+	if err := json.Unmarshal(v["Epsilon"], &b.epsilon); err != nil {
+		return errors.Join(errors.New("invalid epsilon"), err)
+	}
+
+	for _, p := range []struct {
+		key       string
+		parameter *Matrix[T]
+	}{
+		{"Gamma", &b.gamma},
+		{"Beta", &b.beta},
+		{"RunningMean", &b.runningMean},
+		{"RunningVariance", &b.runningVariance},
+	} {
+		m, _ := NewMatrix([][]T{{}})
+		if err := m.UnmarshalJSON(v[p.key]); err != nil {
+			return errors.Join(fmt.Errorf("invalid %s initializing", p.key), err)
+		}
+		if m.RowCount() != b.inputShape.Channels {
+			return fmt.Errorf("invalid %s size", p.key)
+		}
+		*p.parameter = m
+	}
+	return nil
+}
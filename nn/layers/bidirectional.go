@@ -0,0 +1,134 @@
+package layers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Hukyl/mlgo/activation"
+	. "github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/utils"
+	. "golang.org/x/exp/constraints"
+)
+
+// bidirectional runs two recurrent layers over the sequence in the opposite directions,
+// concatenating their outputs. If the layers return sequences, the outputs are
+// concatenated for each time step, i.e. the output of the time step t is
+//
+//	[forward_t; backward_t]
+type bidirectional[T Float] struct {
+	forward  *recurrent[T]
+	backward *recurrent[T]
+}
+
+func (b *bidirectional[T]) InputSize() [2]int {
+	return b.forward.InputSize()
+}
+
+func (b *bidirectional[T]) OutputSize() [2]int {
+	return [2]int{2 * b.forward.OutputSize()[0], 1}
+}
+
+func (b *bidirectional[T]) IsTraining() bool {
+	return false
+}
+
+// Weights returns nil, as the parameters belong to the wrapped layers.
+func (b *bidirectional[T]) Weights() Matrix[T] {
+	return nil
+}
+
+// Bias returns nil, as the parameters belong to the wrapped layers.
+func (b *bidirectional[T]) Bias() Matrix[T] {
+	return nil
+}
+
+func (b *bidirectional[T]) Activation() activation.ActivationFunction[T] {
+	return nil
+}
+
+// State returns the state of the forward and the backward layers.
+func (b *bidirectional[T]) State() []*Matrix[T] {
+	return append(b.forward.State(), b.backward.State()...)
+}
+
+/****************************************************************************/
+
+// blockSize returns the number of rows of a single direction output per time step.
+func (b *bidirectional[T]) blockSize() (size, steps int) {
+	if b.forward.parameters.ReturnSequences {
+		return b.forward.parameters.HiddenSize, b.forward.parameters.TimeSteps
+	}
+	return b.forward.parameters.HiddenSize, 1
+}
+
+func (b *bidirectional[T]) ForwardPropagate(X Matrix[T]) (Y [2]Matrix[T], err error) {
+	forward, err := b.forward.ForwardPropagate(X)
+	if err != nil {
+		return Y, err
+	}
+	backward, _ := b.backward.ForwardPropagate(X)
+
+	size, steps := b.blockSize()
+	blocks := make([]Matrix[T], 0, 2*steps)
+	for t := 0; t < steps; t++ {
+		blocks = append(
+			blocks,
+			rowRange(forward[1], t*size, (t+1)*size),
+			rowRange(backward[1], t*size, (t+1)*size),
+		)
+	}
+	output := stackRows(blocks...)
+
+	Y = [2]Matrix[T]{output, output}
+	return Y, nil
+}
+
+// BackPropagate splits the gradient between the directions, and sums their propagations.
+func (b *bidirectional[T]) BackPropagate(nextLayerPropagation, X Matrix[T], A [2]Matrix[T], parameters utils.NeuralNetworkParameters[T]) Matrix[T] {
+	size, steps := b.blockSize()
+	forward := make([]Matrix[T], steps)
+	backward := make([]Matrix[T], steps)
+	for t := 0; t < steps; t++ {
+		forward[t] = rowRange(nextLayerPropagation, 2*t*size, (2*t+1)*size)
+		backward[t] = rowRange(nextLayerPropagation, (2*t+1)*size, (2*t+2)*size)
+	}
+
+	forwardPropagation := b.forward.BackPropagate(stackRows(forward...), X, [2]Matrix[T]{}, parameters)
+	backwardPropagation := b.backward.BackPropagate(stackRows(backward...), X, [2]Matrix[T]{}, parameters)
+	result, _ := forwardPropagation.Add(backwardPropagation)
+	return result
+}
+
+/****************************************************************************/
+
+func (b *bidirectional[T]) String() string {
+	return fmt.Sprintf("Bidirectional{%s, %s}", b.forward, b.backward)
+}
+
+func (b *bidirectional[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Forward  *recurrent[T]
+		Backward *recurrent[T]
+		Type     string
+	}{
+		Forward:  b.forward,
+		Backward: b.backward,
+		Type:     "Bidirectional",
+	})
+}
+
+func (b *bidirectional[T]) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Forward  *recurrent[T]
+		Backward *recurrent[T]
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return errors.Join(errors.New("invalid bidirectional layer"), err)
+	}
+	if v.Forward == nil || v.Backward == nil {
+		return errors.New("invalid bidirectional layer: missing direction")
+	}
+	b.forward, b.backward = v.Forward, v.Backward
+	return nil
+}
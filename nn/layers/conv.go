@@ -0,0 +1,312 @@
+package layers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Hukyl/mlgo/activation"
+	. "github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/utils"
+	. "golang.org/x/exp/constraints"
+)
+
+// Conv2DParameters contains the hyperparameters of a 2D convolutional layer.
+//
+// Filters is the number of the output channels.
+//
+// KernelSize is the size of each filter in format {height, width}.
+//
+// Stride is the step of the kernel along each dimension. If not set, initialized to {1, 1}.
+//
+// Padding is the number of zeros added to both sides of the input along each dimension.
+//
+// Dilation is the spacing between the kernel elements. If not set, initialized to {1, 1}.
+type Conv2DParameters struct {
+	Filters    int
+	KernelSize [2]int
+	Stride     [2]int
+	Padding    [2]int
+	Dilation   [2]int
+}
+
+type conv2D[T Float] struct {
+	inputShape Shape
+	parameters Conv2DParameters
+	weights    Matrix[T] // Filters x (Channels*KernelHeight*KernelWidth)
+	bias       Matrix[T] // Filters x 1
+	activation activation.ActivationFunction[T]
+}
+
+func (c *conv2D[T]) InputShape() Shape {
+	return c.inputShape
+}
+
+func (c *conv2D[T]) OutputShape() Shape {
+	p := c.parameters
+	return Shape{
+		Channels: p.Filters,
+		Height:   windowOutputSize(c.inputShape.Height, p.KernelSize[0], p.Stride[0], p.Padding[0], p.Dilation[0]),
+		Width:    windowOutputSize(c.inputShape.Width, p.KernelSize[1], p.Stride[1], p.Padding[1], p.Dilation[1]),
+	}
+}
+
+func (c *conv2D[T]) InputSize() [2]int {
+	return [2]int{c.InputShape().Size(), 1}
+}
+
+func (c *conv2D[T]) OutputSize() [2]int {
+	return [2]int{c.OutputShape().Size(), 1}
+}
+
+func (c *conv2D[T]) Weights() Matrix[T] {
+	return c.weights
+}
+
+func (c *conv2D[T]) Bias() Matrix[T] {
+	return c.bias
+}
+
+func (c *conv2D[T]) Activation() activation.ActivationFunction[T] {
+	return c.activation
+}
+
+// State returns the filters, the bias and the parameters of the activation function.
+func (c *conv2D[T]) State() []*Matrix[T] {
+	return append([]*Matrix[T]{&c.weights, &c.bias}, activationState(c.activation)...)
+}
+
+func (c *conv2D[T]) IsTraining() bool {
+	return false
+}
+
+/****************************************************************************/
+
+// im2col unfolds the input patches, so that the convolution becomes a matrix
+// multiplication. Each column of the result is a patch for a single output position
+// of a single sample, i.e. the result is (Channels*KernelHeight*KernelWidth)x(L*N),
+// where L is the number of output positions and N is the number of samples.
+func (c *conv2D[T]) im2col(X Matrix[T]) Matrix[T] {
+	in, out, p := c.inputShape, c.OutputShape(), c.parameters
+	positions := out.Height * out.Width
+	result := NewZeroMatrix[T](in.Channels*p.KernelSize[0]*p.KernelSize[1], positions*X.ColumnCount())
+
+	for n := 0; n < X.ColumnCount(); n++ {
+		sample, _ := X.Col(n)
+		patches, _ := result.Slice(0, result.RowCount(), n*positions, (n+1)*positions)
+		c.forEachPatchElement(func(row, position, inputRow int) {
+			v, _ := sample.At(inputRow, 0)
+			patches.Set(row, position, v)
+		})
+	}
+	return result
+}
+
+// col2im is the adjoint of im2col, summing the patch values back into the
+// input positions they were taken from.
+func (c *conv2D[T]) col2im(cols Matrix[T], sampleCount int) Matrix[T] {
+	positions := c.OutputShape().Height * c.OutputShape().Width
+	result := NewZeroMatrix[T](c.inputShape.Size(), sampleCount)
+
+	for n := 0; n < sampleCount; n++ {
+		patches, _ := cols.Slice(0, cols.RowCount(), n*positions, (n+1)*positions)
+		sample, _ := result.Col(n)
+		c.forEachPatchElement(func(row, position, inputRow int) {
+			v, _ := patches.At(row, position)
+			current, _ := sample.At(inputRow, 0)
+			sample.Set(inputRow, 0, current+v)
+		})
+	}
+	return result
+}
+
+// forEachPatchElement calls f for each element of each patch, which is not in the padding,
+// with the row of the element in the patch, the output position and the row in the input.
+func (c *conv2D[T]) forEachPatchElement(f func(row, position, inputRow int)) {
+	in, out, p := c.inputShape, c.OutputShape(), c.parameters
+	kh, kw := p.KernelSize[0], p.KernelSize[1]
+
+	for ch := 0; ch < in.Channels; ch++ {
+		for i := 0; i < kh; i++ {
+			for j := 0; j < kw; j++ {
+				row := (ch*kh+i)*kw + j
+				for oh := 0; oh < out.Height; oh++ {
+					h := oh*p.Stride[0] - p.Padding[0] + i*p.Dilation[0]
+					if h < 0 || h >= in.Height {
+						continue
+					}
+					for ow := 0; ow < out.Width; ow++ {
+						w := ow*p.Stride[1] - p.Padding[1] + j*p.Dilation[1]
+						if w < 0 || w >= in.Width {
+							continue
+						}
+						f(row, oh*out.Width+ow, in.index(ch, h, w))
+					}
+				}
+			}
+		}
+	}
+}
+
+// toSamples rearranges Filters x (L*N) matrix into (Filters*L) x N matrix, i.e.
+// each sample becomes a column, adding the bias for each filter.
+func (c *conv2D[T]) toSamples(M Matrix[T], sampleCount int, bias Matrix[T]) Matrix[T] {
+	positions := M.ColumnCount() / sampleCount
+	result := NewZeroMatrix[T](M.RowCount()*positions, sampleCount)
+	for f := 0; f < M.RowCount(); f++ {
+		var b T
+		if bias != nil {
+			b, _ = bias.At(f, 0)
+		}
+		for n := 0; n < sampleCount; n++ {
+			for l := 0; l < positions; l++ {
+				v, _ := M.At(f, n*positions+l)
+				result.Set(f*positions+l, n, v+b)
+			}
+		}
+	}
+	return result
+}
+
+// fromSamples is the inverse of toSamples (without the bias).
+func (c *conv2D[T]) fromSamples(M Matrix[T]) Matrix[T] {
+	filters := c.parameters.Filters
+	positions := M.RowCount() / filters
+	sampleCount := M.ColumnCount()
+	result := NewZeroMatrix[T](filters, positions*sampleCount)
+	for f := 0; f < filters; f++ {
+		for n := 0; n < sampleCount; n++ {
+			for l := 0; l < positions; l++ {
+				v, _ := M.At(f*positions+l, n)
+				result.Set(f, n*positions+l, v)
+			}
+		}
+	}
+	return result
+}
+
+// ForwardPropagate produces two matrices as a result of the convolution of X:
+//   - A linear combination of the filters with the input patches, and biases
+//   - The actual output of the layer, which is activated linear combination
+//
+// Input has to be of c.InputSize() size, otherwise error is returned.
+func (c *conv2D[T]) ForwardPropagate(X Matrix[T]) (output [2]Matrix[T], err error) {
+	if X.RowCount() != c.inputShape.Size() {
+		return output, errors.New("invalid input size")
+	}
+	Z, err := c.weights.Multiply(c.im2col(X))
+	if err != nil {
+		return output, err
+	}
+	output[0] = c.toSamples(Z, X.ColumnCount(), c.bias)
+
+	activatedLinearCombination := output[0].DeepCopy()
+	c.Activation().ApplyMatrix(activatedLinearCombination)
+	output[1] = activatedLinearCombination
+
+	return output, nil
+}
+
+// BackPropagate works the same way as for the dense layer, with the input patches
+// used instead of the input itself:
+//
+//	dLdZ = nextLayerPropagation * dAdZ
+//	dLdW = dLdZ @ im2col(X).T()
+//	dLdb = sum(dLdZ) over positions
+//	thisLayerPropagation = col2im(W.T() @ dLdZ)
+func (c *conv2D[T]) BackPropagate(nextLayerPropagation, X Matrix[T], A [2]Matrix[T], parameters utils.NeuralNetworkParameters[T]) Matrix[T] {
+	dLdZ := activation.Backward(c.Activation(), A[0], A[1], nextLayerPropagation)
+	updateActivation(c.Activation(), A[0], nextLayerPropagation, X.ColumnCount(), parameters)
+	dLdZ = c.fromSamples(dLdZ)
+
+	cols := c.im2col(X)
+	dCols, _ := c.weights.T().Multiply(dLdZ)
+	result := c.col2im(dCols, X.ColumnCount())
+	c.updateWeights(dLdZ, cols, parameters)
+
+	return result
+}
+
+func (c *conv2D[T]) updateWeights(dLdZ, cols Matrix[T], parameters utils.NeuralNetworkParameters[T]) {
+	positions := c.OutputShape().Height * c.OutputShape().Width
+	samples := T(cols.ColumnCount() / positions)
+
+	db, _ := dLdZ.Multiply(NewOnesMatrix[T](cols.ColumnCount(), 1))
+	dW, _ := dLdZ.Multiply(cols.T())
+
+	updateParameter(&c.weights, dW.MultiplyByScalar(1/samples), parameters)
+	updateParameter(&c.bias, db.MultiplyByScalar(1/samples), parameters)
+}
+
+/****************************************************************************/
+
+func (c conv2D[T]) String() string {
+	return fmt.Sprintf(
+		"Conv2D{%s -> %s, kernel: %dx%d, activation: %s}",
+		c.InputShape(),
+		c.OutputShape(),
+		c.parameters.KernelSize[0],
+		c.parameters.KernelSize[1],
+		activation.Name(c.activation),
+	)
+}
+
+func (c *conv2D[T]) MarshalJSON() ([]byte, error) {
+	activationJSON, err := activation.MarshalActivation(c.activation)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&struct {
+		InputShape Shape
+		Parameters Conv2DParameters
+		Weights    Matrix[T]
+		Bias       Matrix[T]
+		Activation json.RawMessage
+		Type       string
+	}{
+		InputShape: c.inputShape,
+		Parameters: c.parameters,
+		Weights:    c.weights,
+		Bias:       c.bias,
+		Activation: activationJSON,
+		Type:       "Conv2D",
+	})
+}
+
+func (c *conv2D[T]) UnmarshalJSON(data []byte) error {
+	var err error
+	var v map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(v["InputShape"], &c.inputShape); err != nil {
+		return errors.Join(errors.New("invalid input shape"), err)
+	}
+	if err := json.Unmarshal(v["Parameters"], &c.parameters); err != nil {
+		return errors.Join(errors.New("invalid convolution parameters"), err)
+	}
+
+	w, _ := NewMatrix([][]T{{}})
+	if err := w.UnmarshalJSON(v["Weights"]); err != nil {
+		return errors.Join(
+			errors.New("invalid weight initializing"),
+			err,
+		)
+	}
+	c.weights = w
+
+	b, _ := NewMatrix([][]T{{}})
+	if err := b.UnmarshalJSON(v["Bias"]); err != nil {
+		return errors.Join(
+			errors.New("invalid bias initializing"),
+			err,
+		)
+	}
+	c.bias = b
+
+	if c.activation, err = activation.UnmarshalActivation[T](v["Activation"]); err != nil {
+		return err
+	}
+	return validateActivation(c.activation, c.OutputSize()[0])
+}
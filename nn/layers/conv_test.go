@@ -0,0 +1,271 @@
+package layers_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/Hukyl/mlgo/activation"
+	"github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/nn/layers"
+	"github.com/Hukyl/mlgo/utils"
+)
+
+func randomMatrix(rows, columns int) matrix.Matrix[float64] {
+	m := matrix.NewZeroMatrix[float64](rows, columns)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < columns; j++ {
+			m.Set(i, j, rand.NormFloat64())
+		}
+	}
+	return m
+}
+
+// weightedSum computes sum(R * Y), which is used as a scalar objective in the gradient checks.
+func weightedSum(R, Y matrix.Matrix[float64]) float64 {
+	product, _ := R.MultiplyElementwise(Y)
+	sum := 0.0
+	for i := 0; i < product.RowCount(); i++ {
+		for j := 0; j < product.ColumnCount(); j++ {
+			v, _ := product.At(i, j)
+			sum += v
+		}
+	}
+	return sum
+}
+
+// checkInputGradient compares the propagation of the layer with the numerical derivative
+// of sum(R * layer(X)) with respect to X.
+func checkInputGradient(t *testing.T, layer layers.Layer[float64], X matrix.Matrix[float64]) {
+	t.Helper()
+	output, err := layer.ForwardPropagate(X)
+	if err != nil {
+		t.Fatalf("ForwardPropagate error: %v", err)
+	}
+	R := randomMatrix(output[1].RowCount(), output[1].ColumnCount())
+	params := utils.NeuralNetworkParameters[float64]{InitialLearningRate: 1e-300}
+	got := layer.BackPropagate(R, X, output, params)
+
+	const h = 1e-6
+	for i := 0; i < X.RowCount(); i++ {
+		for j := 0; j < X.ColumnCount(); j++ {
+			v, _ := X.At(i, j)
+			X.Set(i, j, v+h)
+			plus, _ := layer.ForwardPropagate(X)
+			X.Set(i, j, v-h)
+			minus, _ := layer.ForwardPropagate(X)
+			X.Set(i, j, v)
+
+			want := (weightedSum(R, plus[1]) - weightedSum(R, minus[1])) / (2 * h)
+			if g, _ := got.At(i, j); math.Abs(g-want) > 1e-5 {
+				t.Errorf("dX(%d,%d) = %v, want %v", i, j, g, want)
+			}
+		}
+	}
+}
+
+func TestConv2D_ForwardPropagate(t *testing.T) {
+	// Arrange
+	shape := layers.Shape{Channels: 1, Height: 3, Width: 3}
+	conv, _ := layers.NewConv2D(
+		shape,
+		layers.Conv2DParameters{Filters: 1, KernelSize: [2]int{2, 2}},
+		activation.Linear[float64]{},
+		layers.RandomInitialization{Min: 1, Max: 1},
+	)
+	conv.Bias().Set(0, 0, 0.5)
+	// 1 2 3
+	// 4 5 6
+	// 7 8 9
+	X, _ := matrix.NewMatrix([][]float64{{1}, {2}, {3}, {4}, {5}, {6}, {7}, {8}, {9}})
+
+	// Act
+	output, err := conv.ForwardPropagate(X)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("ForwardPropagate error: %v", err)
+	}
+	want := []float64{12.5, 16.5, 24.5, 28.5}
+	if output[1].RowCount() != len(want) {
+		t.Fatalf("output rows = %d, want %d", output[1].RowCount(), len(want))
+	}
+	for i, w := range want {
+		if got, _ := output[1].At(i, 0); got != w {
+			t.Errorf("At(%d,0) = %v, want %v", i, got, w)
+		}
+	}
+}
+
+func TestConv2D_OutputShape(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		parameters layers.Conv2DParameters
+		want       layers.Shape
+	}{
+		{
+			desc:       "valid",
+			parameters: layers.Conv2DParameters{Filters: 4, KernelSize: [2]int{3, 3}},
+			want:       layers.Shape{Channels: 4, Height: 26, Width: 26},
+		},
+		{
+			desc:       "same-padding",
+			parameters: layers.Conv2DParameters{Filters: 2, KernelSize: [2]int{3, 3}, Padding: [2]int{1, 1}},
+			want:       layers.Shape{Channels: 2, Height: 28, Width: 28},
+		},
+		{
+			desc:       "stride",
+			parameters: layers.Conv2DParameters{Filters: 1, KernelSize: [2]int{2, 2}, Stride: [2]int{2, 2}},
+			want:       layers.Shape{Channels: 1, Height: 14, Width: 14},
+		},
+		{
+			desc:       "dilation",
+			parameters: layers.Conv2DParameters{Filters: 1, KernelSize: [2]int{3, 3}, Dilation: [2]int{2, 2}},
+			want:       layers.Shape{Channels: 1, Height: 24, Width: 24},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Act
+			conv, err := layers.NewConv2D(
+				layers.Shape{Channels: 1, Height: 28, Width: 28},
+				tC.parameters,
+				activation.ReLU[float64]{},
+				layers.HeInitialization{},
+			)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("NewConv2D error: %v", err)
+			}
+			if got := conv.(layers.SpatialLayer[float64]).OutputShape(); got != tC.want {
+				t.Errorf("OutputShape = %v, want %v", got, tC.want)
+			}
+			if conv.OutputSize()[0] != tC.want.Size() {
+				t.Errorf("OutputSize = %v, want %v", conv.OutputSize()[0], tC.want.Size())
+			}
+		})
+	}
+}
+
+func TestConv2D_BackPropagate(t *testing.T) {
+	// Arrange
+	shape := layers.Shape{Channels: 2, Height: 5, Width: 4}
+	parameters := layers.Conv2DParameters{
+		Filters:    3,
+		KernelSize: [2]int{3, 2},
+		Stride:     [2]int{2, 1},
+		Padding:    [2]int{1, 1},
+		Dilation:   [2]int{1, 2},
+	}
+	conv, _ := layers.NewConv2D(shape, parameters, activation.Sigmoid[float64]{}, layers.XavierNormalInitialization{})
+	X := randomMatrix(shape.Size(), 3)
+
+	// Act & Assert
+	checkInputGradient(t, conv, X)
+}
+
+func TestConv2D_WeightGradient(t *testing.T) {
+	// Arrange
+	shape := layers.Shape{Channels: 2, Height: 4, Width: 4}
+	parameters := layers.Conv2DParameters{Filters: 2, KernelSize: [2]int{2, 2}, Padding: [2]int{1, 0}}
+	conv, _ := layers.NewConv2D(shape, parameters, activation.Linear[float64]{}, layers.XavierNormalInitialization{})
+	X := randomMatrix(shape.Size(), 2)
+	output, _ := conv.ForwardPropagate(X)
+	R := randomMatrix(output[1].RowCount(), output[1].ColumnCount())
+
+	W := conv.Weights().DeepCopy()
+	numerical := matrix.NewZeroMatrix[float64](W.RowCount(), W.ColumnCount())
+	const h = 1e-6
+	for i := 0; i < W.RowCount(); i++ {
+		for j := 0; j < W.ColumnCount(); j++ {
+			v, _ := W.At(i, j)
+			conv.Weights().Set(i, j, v+h)
+			plus, _ := conv.ForwardPropagate(X)
+			conv.Weights().Set(i, j, v-h)
+			minus, _ := conv.ForwardPropagate(X)
+			conv.Weights().Set(i, j, v)
+			// gradients are averaged over the samples
+			numerical.Set(i, j, (weightedSum(R, plus[1])-weightedSum(R, minus[1]))/(2*h)/2)
+		}
+	}
+
+	// Act - with SGD and learning rate of 1, the weight change is the gradient itself
+	conv.BackPropagate(R, X, output, utils.NeuralNetworkParameters[float64]{InitialLearningRate: 1})
+
+	// Assert
+	for i := 0; i < W.RowCount(); i++ {
+		for j := 0; j < W.ColumnCount(); j++ {
+			before, _ := W.At(i, j)
+			after, _ := conv.Weights().At(i, j)
+			want, _ := numerical.At(i, j)
+			if math.Abs((before-after)-want) > 1e-5 {
+				t.Errorf("dW(%d,%d) = %v, want %v", i, j, before-after, want)
+			}
+		}
+	}
+}
+
+func TestPooling(t *testing.T) {
+	// 1 2 3 4
+	// 5 6 7 8
+	input := [][]float64{{1}, {2}, {3}, {4}, {5}, {6}, {7}, {8}}
+	shape := layers.Shape{Channels: 1, Height: 2, Width: 4}
+	maxPool, _ := layers.NewMaxPool2D[float64](shape, [2]int{2, 2}, [2]int{})
+	averagePool, _ := layers.NewAveragePool2D[float64](shape, [2]int{2, 2}, [2]int{})
+
+	testCases := []struct {
+		desc         string
+		layer        layers.Layer[float64]
+		wantOutput   []float64
+		wantGradient []float64
+	}{
+		{
+			desc:         "max",
+			layer:        maxPool,
+			wantOutput:   []float64{6, 8},
+			wantGradient: []float64{0, 0, 0, 0, 0, 1, 0, 2},
+		},
+		{
+			desc:         "average",
+			layer:        averagePool,
+			wantOutput:   []float64{3.5, 5.5},
+			wantGradient: []float64{0.25, 0.25, 0.5, 0.5, 0.25, 0.25, 0.5, 0.5},
+		},
+		{
+			desc:         "global-average",
+			layer:        layers.NewGlobalAveragePool[float64](shape),
+			wantOutput:   []float64{4.5},
+			wantGradient: []float64{0.125, 0.125, 0.125, 0.125, 0.125, 0.125, 0.125, 0.125},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			X, _ := matrix.NewMatrix(input)
+			upstream := matrix.NewZeroMatrix[float64](len(tC.wantOutput), 1)
+			for i := range tC.wantOutput {
+				upstream.Set(i, 0, float64(i+1))
+			}
+
+			// Act
+			output, err := tC.layer.ForwardPropagate(X)
+			gradient := tC.layer.BackPropagate(upstream, X, output, utils.NeuralNetworkParameters[float64]{})
+
+			// Assert
+			if err != nil {
+				t.Fatalf("ForwardPropagate error: %v", err)
+			}
+			for i, want := range tC.wantOutput {
+				if got, _ := output[1].At(i, 0); got != want {
+					t.Errorf("output(%d) = %v, want %v", i, got, want)
+				}
+			}
+			for i, want := range tC.wantGradient {
+				if got, _ := gradient.At(i, 0); got != want {
+					t.Errorf("gradient(%d) = %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
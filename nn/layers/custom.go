@@ -0,0 +1,198 @@
+package layers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/Hukyl/mlgo/activation"
+	"github.com/Hukyl/mlgo/autograd"
+	. "github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/utils"
+	. "golang.org/x/exp/constraints"
+)
+
+// CustomFunction produces the output of a custom layer for the batch X, using
+// the trainable parameters in the order they were given to NewCustom.
+type CustomFunction func(X *autograd.Variable, parameters []*autograd.Variable) *autograd.Variable
+
+// custom is a layer, defined by an arbitrary composition of the autograd operations,
+// the gradients of which are computed automatically. As autograd works with float64,
+// the input and the parameters are converted at the boundary of the layer.
+type custom[T Float] struct {
+	name       string
+	inputSize  int
+	outputSize int
+	function   CustomFunction
+	parameters []Matrix[T]
+	graph      *customGraph[T]
+}
+
+// customGraph is the computation graph, built by the last ForwardPropagate, which
+// is reused by BackPropagate for the output it produced.
+type customGraph[T Float] struct {
+	output     Matrix[T]
+	input      *autograd.Variable
+	parameters []*autograd.Variable
+	result     *autograd.Variable
+}
+
+func (c *custom[T]) InputSize() [2]int {
+	return [2]int{c.inputSize, 1}
+}
+
+func (c *custom[T]) OutputSize() [2]int {
+	return [2]int{c.outputSize, 1}
+}
+
+func (c *custom[T]) IsTraining() bool {
+	return false
+}
+
+// Weights returns the first parameter, or nil if there are no parameters.
+func (c *custom[T]) Weights() Matrix[T] {
+	if len(c.parameters) < 1 {
+		return nil
+	}
+	return c.parameters[0]
+}
+
+// Bias returns the second parameter, or nil if there are less than two parameters.
+func (c *custom[T]) Bias() Matrix[T] {
+	if len(c.parameters) < 2 {
+		return nil
+	}
+	return c.parameters[1]
+}
+
+// Activation returns nil, as the activation is a part of the function.
+func (c *custom[T]) Activation() activation.ActivationFunction[T] {
+	return nil
+}
+
+// State returns the parameters in the order they were given to NewCustom.
+func (c *custom[T]) State() []*Matrix[T] {
+	result := make([]*Matrix[T], len(c.parameters))
+	for i := range c.parameters {
+		result[i] = &c.parameters[i]
+	}
+	return result
+}
+
+/****************************************************************************/
+
+// apply builds the computation graph of the function. Panics of the operations,
+// e.g. on non-conformable matrices, are returned as errors.
+func (c *custom[T]) apply(X *autograd.Variable, parameters []*autograd.Variable) (Y *autograd.Variable, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("layer %s: %v", c.name, r)
+		}
+	}()
+	Y = c.function(X, parameters)
+	if Y.Value().RowCount() != c.outputSize || Y.Value().ColumnCount() != X.Value().ColumnCount() {
+		return nil, fmt.Errorf("layer %s: invalid output size %v", c.name, Y.Value().Size())
+	}
+	return Y, nil
+}
+
+// ForwardPropagate produces the output of the function twice, as the activation
+// is a part of the function. The computation graph is kept for BackPropagate.
+//
+// Input has to be of c.InputSize() size, otherwise error is returned.
+func (c *custom[T]) ForwardPropagate(X Matrix[T]) (Y [2]Matrix[T], err error) {
+	if X.RowCount() != c.inputSize {
+		return Y, errors.New("invalid input size")
+	}
+	input := autograd.NewVariable(Convert[float64](X))
+	parameters := make([]*autograd.Variable, len(c.parameters))
+	for i, p := range c.parameters {
+		parameters[i] = autograd.NewVariable(Convert[float64](p))
+	}
+	result, err := c.apply(input, parameters)
+	if err != nil {
+		return Y, err
+	}
+	value := Convert[T](result.Value())
+	c.graph = &customGraph[T]{output: value, input: input, parameters: parameters, result: result}
+	Y = [2]Matrix[T]{value, value}
+	return Y, nil
+}
+
+// BackPropagate differentiates the function, starting from the gradient of the next layer,
+// updates the parameters with their gradients averaged over the batch, and returns
+// the gradient with respect to the input.
+//
+// The graph of the last ForwardPropagate is used if it has produced A, otherwise it is
+// rebuilt for X. If X fails to propagate (which is reported by ForwardPropagate), or
+// the gradient of the next layer does not fit the output (which is logged),
+// the parameters are left intact and the zero gradient is returned.
+func (c *custom[T]) BackPropagate(nextLayerPropagation, X Matrix[T], A [2]Matrix[T], parameters utils.NeuralNetworkParameters[T]) Matrix[T] {
+	graph := c.graph
+	if graph == nil || A[1] == nil || graph.output != A[1] {
+		if _, err := c.ForwardPropagate(X); err != nil {
+			return NewZeroMatrix[T](X.RowCount(), X.ColumnCount())
+		}
+		graph = c.graph
+	}
+	c.graph = nil
+	if err := graph.result.BackwardWith(Convert[float64](nextLayerPropagation)); err != nil {
+		log.Printf("layer %s: %s", c.name, err)
+		return NewZeroMatrix[T](X.RowCount(), X.ColumnCount())
+	}
+
+	columns := T(X.ColumnCount())
+	for i, v := range graph.parameters {
+		if v.Gradient() != nil {
+			updateParameter(&c.parameters[i], Convert[T](v.Gradient()).MultiplyByScalar(1/columns), parameters)
+		}
+	}
+	if graph.input.Gradient() == nil {
+		return NewZeroMatrix[T](X.RowCount(), X.ColumnCount())
+	}
+	return Convert[T](graph.input.Gradient())
+}
+
+/****************************************************************************/
+
+func (c *custom[T]) String() string {
+	return fmt.Sprintf("%s{%d -> %d, parameters: %d}", c.name, c.inputSize, c.outputSize, len(c.parameters))
+}
+
+// MarshalJSON stores the parameters of the layer. As the function cannot be stored,
+// the layer has to be registered by RegisterLayer under its name to be loaded from JSON.
+func (c *custom[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		InputSize  int
+		OutputSize int
+		Parameters []Matrix[T]
+		Type       string
+	}{
+		InputSize:  c.inputSize,
+		OutputSize: c.outputSize,
+		Parameters: c.parameters,
+		Type:       c.name,
+	})
+}
+
+// UnmarshalJSON restores the parameters of the layer, keeping its function.
+func (c *custom[T]) UnmarshalJSON(data []byte) error {
+	var v struct {
+		InputSize  int
+		OutputSize int
+		Parameters []json.RawMessage
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return errors.Join(fmt.Errorf("invalid layer %s", c.name), err)
+	}
+	parameters := make([]Matrix[T], len(v.Parameters))
+	for i, p := range v.Parameters {
+		parameters[i], _ = NewMatrix([][]T{{}})
+		if err := parameters[i].UnmarshalJSON(p); err != nil {
+			return errors.Join(fmt.Errorf("invalid parameter #%d of layer %s", i+1, c.name), err)
+		}
+	}
+	c.inputSize, c.outputSize, c.parameters = v.InputSize, v.OutputSize, parameters
+	return nil
+}
@@ -0,0 +1,142 @@
+package layers_test
+
+import (
+	"testing"
+
+	"github.com/Hukyl/mlgo/activation"
+	"github.com/Hukyl/mlgo/autograd"
+	"github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/nn/layers"
+	"github.com/Hukyl/mlgo/utils"
+)
+
+func swishLayer(t *testing.T) layers.Layer[float64] {
+	t.Helper()
+	layer, err := layers.NewCustom("Swish", 3, 2,
+		func(X *autograd.Variable, p []*autograd.Variable) *autograd.Variable {
+			Z := p[0].MatMul(X).Add(p[1])
+			return Z.Mul(Z.Sigmoid())
+		},
+		randomMatrix(2, 3), randomMatrix(2, 1),
+	)
+	if err != nil {
+		t.Fatalf("NewCustom() error = %v", err)
+	}
+	return layer
+}
+
+func TestCustom_ForwardPropagate(t *testing.T) {
+	// Arrange
+	W, b := randomMatrix(2, 3), randomMatrix(2, 1)
+	custom, _ := layers.NewCustom("Sigmoid", 3, 2,
+		func(X *autograd.Variable, p []*autograd.Variable) *autograd.Variable {
+			return p[0].MatMul(X).Add(p[1]).Sigmoid()
+		},
+		W, b,
+	)
+	dense, _ := layers.NewDense(W, b, activation.Sigmoid[float64]{})
+	X := randomMatrix(3, 4)
+
+	// Act
+	got, err := custom.ForwardPropagate(X)
+	want, _ := dense.ForwardPropagate(X)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("ForwardPropagate() error = %v", err)
+	}
+	if !got[1].Equals(want[1]) {
+		t.Errorf("ForwardPropagate() = %v, want %v", got[1], want[1])
+	}
+}
+
+func TestCustom_BackPropagate(t *testing.T) {
+	t.Run("input", func(t *testing.T) {
+		checkInputGradient(t, swishLayer(t), randomMatrix(3, 4))
+	})
+	t.Run("parameters", func(t *testing.T) {
+		checkParameterGradients(t, swishLayer(t), randomMatrix(3, 4))
+	})
+}
+
+func TestCustom_InvalidOutput(t *testing.T) {
+	testCases := []struct {
+		desc string
+		f    layers.CustomFunction
+	}{
+		{
+			desc: "wrong-size",
+			f: func(X *autograd.Variable, p []*autograd.Variable) *autograd.Variable {
+				return X
+			},
+		},
+		{
+			desc: "non-conformable",
+			f: func(X *autograd.Variable, p []*autograd.Variable) *autograd.Variable {
+				return X.MatMul(X)
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			layer, _ := layers.NewCustom[float64]("Invalid", 3, 2, tC.f)
+
+			// Act
+			_, err := layer.ForwardPropagate(randomMatrix(3, 4))
+
+			// Assert
+			if err == nil {
+				t.Error("ForwardPropagate() error = nil")
+			}
+		})
+	}
+}
+
+func TestCustom_BackPropagate_ReusesGraph(t *testing.T) {
+	// Arrange
+	calls := 0
+	layer, _ := layers.NewCustom("Counted", 3, 2,
+		func(X *autograd.Variable, p []*autograd.Variable) *autograd.Variable {
+			calls++
+			return p[0].MatMul(X).Tanh()
+		},
+		randomMatrix(2, 3),
+	)
+	X := randomMatrix(3, 4)
+	output, _ := layer.ForwardPropagate(X)
+
+	// Act
+	layer.BackPropagate(randomMatrix(2, 4), X, output, utils.NeuralNetworkParameters[float64]{})
+
+	// Assert
+	if calls != 1 {
+		t.Errorf("function called %d times, want 1", calls)
+	}
+}
+
+func TestCustom_BackPropagate_InvalidGradient(t *testing.T) {
+	// Arrange
+	W := randomMatrix(2, 3)
+	layer, _ := layers.NewCustom("Linear", 3, 2,
+		func(X *autograd.Variable, p []*autograd.Variable) *autograd.Variable {
+			return p[0].MatMul(X)
+		},
+		W.DeepCopy(),
+	)
+	X := randomMatrix(3, 4)
+	output, _ := layer.ForwardPropagate(X)
+	parameters := utils.NeuralNetworkParameters[float64]{}
+	parameters.Validate()
+
+	// Act
+	got := layer.BackPropagate(randomMatrix(2, 3), X, output, parameters)
+
+	// Assert
+	if !got.AreSameSize(X) || !got.Equals(matrix.NewZeroMatrix[float64](3, 4)) {
+		t.Errorf("BackPropagate() = %v, want zero gradient of size %v", got, X.Size())
+	}
+	if !layer.Weights().Equals(W) {
+		t.Errorf("Weights() = %v, want %v (intact)", layer.Weights(), W)
+	}
+}
@@ -6,41 +6,45 @@ import (
 	"fmt"
 	"log"
 	"math"
-	"reflect"
-	"strconv"
 
 	"github.com/Hukyl/mlgo/activation"
 	. "github.com/Hukyl/mlgo/matrix"
 	"github.com/Hukyl/mlgo/utils"
+	. "golang.org/x/exp/constraints"
 )
 
-type dense struct {
-	weights    Matrix[float64]
-	bias       Matrix[float64]
-	activation activation.ActivationFunction
+type dense[T Float] struct {
+	weights    Matrix[T]
+	bias       Matrix[T]
+	activation activation.ActivationFunction[T]
 }
 
-func (d *dense) InputSize() [2]int {
+func (d *dense[T]) InputSize() [2]int {
 	return [2]int{d.Weights().ColumnCount(), 1}
 }
 
-func (d *dense) OutputSize() [2]int {
+func (d *dense[T]) OutputSize() [2]int {
 	return [2]int{d.Weights().RowCount(), 1}
 }
 
-func (d *dense) Weights() Matrix[float64] {
+func (d *dense[T]) Weights() Matrix[T] {
 	return d.weights
 }
 
-func (d *dense) Bias() Matrix[float64] {
+func (d *dense[T]) Bias() Matrix[T] {
 	return d.bias
 }
 
-func (d *dense) Activation() activation.ActivationFunction {
+func (d *dense[T]) Activation() activation.ActivationFunction[T] {
 	return d.activation
 }
 
-func (d *dense) IsTraining() bool {
+// State returns the weights, the bias and the parameters of the activation function.
+func (d *dense[T]) State() []*Matrix[T] {
+	return append([]*Matrix[T]{&d.weights, &d.bias}, activationState(d.activation)...)
+}
+
+func (d *dense[T]) IsTraining() bool {
 	return false
 }
 
@@ -49,12 +53,12 @@ func (d *dense) IsTraining() bool {
 //   - The actual output of the layer, which is activated linear combination (i.e. activation(W*X + b))
 //
 // Input has to be of d.InputSize() size, otherwise error is returned.
-func (d *dense) ForwardPropagate(X Matrix[float64]) (output [2]Matrix[float64], err error) {
+func (d *dense[T]) ForwardPropagate(X Matrix[T]) (output [2]Matrix[T], err error) {
 	linearCombination, err := d.Weights().Multiply(X)
 	if err != nil {
 		return output, err
 	}
-	broadcastedBias, _ := d.Bias().Multiply(NewOnesMatrix(1, X.ColumnCount()))
+	broadcastedBias, _ := d.Bias().Multiply(NewOnesMatrix[T](1, X.ColumnCount()))
 	linearCombination, err = linearCombination.Add(broadcastedBias)
 	if err != nil {
 		return output, err
@@ -97,11 +101,16 @@ func (d *dense) ForwardPropagate(X Matrix[float64]) (output [2]Matrix[float64],
 //
 //   - produce the partial derivative for this layer.
 //
-//     dLdZ = dLdA * dAdZ = nextLayerPropagation * layer.Activation.BackPropagateMatrix()
+//     dLdZ = dLdA * dAdZ = activation.Backward(layer.Activation, Z, A, nextLayerPropagation)
+//     i.e. nextLayerPropagation * layer.Activation.DerivativeMatrix(Z) elementwise, or
+//     the Jacobian product for the vector activation functions (e.g. Softmax)
 //     dLdb = dLdZ * dZdb = dLdZ * 1
 //     dLdW = dLdZ * dZdW = dLdZ * X.T()
 //
-//   - update the weights and bias as the main goal of backpropagation.
+//   - update the trainable parameters of the activation function, if any (e.g. PReLU).
+//
+//   - update the weights and bias as the main goal of backpropagation, using
+//     the optimizer from the parameters (e.g. for SGD):
 //
 //     W = W - learningRate * dLdW
 //     b = b - learningRate * dLdb
@@ -109,10 +118,9 @@ func (d *dense) ForwardPropagate(X Matrix[float64]) (output [2]Matrix[float64],
 //   - produce the propagation base for the next layer.
 //
 //     thisLayerPropagation = W.T() @ dLdZ
-func (d *dense) BackPropagate(nextLayerPropagation, X Matrix[float64], A [2]Matrix[float64], parameters utils.NeuralNetworkParameters) Matrix[float64] {
-	dAdZ := d.Activation().DerivativeMatrix(A[0]) // derivative for activation function
-
-	dLdZ, _ := nextLayerPropagation.MultiplyElementwise(dAdZ)
+func (d *dense[T]) BackPropagate(nextLayerPropagation, X Matrix[T], A [2]Matrix[T], parameters utils.NeuralNetworkParameters[T]) Matrix[T] {
+	dLdZ := activation.Backward(d.Activation(), A[0], A[1], nextLayerPropagation)
+	updateActivation(d.Activation(), A[0], nextLayerPropagation, X.ColumnCount(), parameters)
 	result, _ := d.Weights().T().Multiply(dLdZ)
 	d.updateWeights(dLdZ, X, parameters)
 
@@ -124,58 +132,52 @@ func (d *dense) BackPropagate(nextLayerPropagation, X Matrix[float64], A [2]Matr
 //
 // Method is based on that for each next dense, derivative is going to be based on the next dense's
 // backpropagation derivative.
-func (d *dense) updateWeights(dLdZ, input Matrix[float64], parameters utils.NeuralNetworkParameters) {
-	db, _ := dLdZ.Multiply(NewOnesMatrix(input.ColumnCount(), 1))
+func (d *dense[T]) updateWeights(dLdZ, input Matrix[T], parameters utils.NeuralNetworkParameters[T]) {
+	db, _ := dLdZ.Multiply(NewOnesMatrix[T](input.ColumnCount(), 1))
 	dW, _ := dLdZ.Multiply(input.T())
 
-	columns := float64(input.ColumnCount())
+	columns := T(input.ColumnCount())
 
-	if w, _ := dW.At(0, 0); math.IsNaN(w) {
+	if w, _ := dW.At(0, 0); math.IsNaN(float64(w)) {
 		log.Printf("NaN dW")
 	}
-	decayed_dW, _ := dW.MultiplyByScalar(1 / columns).Add(
-		d.weights.MultiplyByScalar(parameters.WeightDecay),
-	)
-	if w, _ := decayed_dW.At(0, 0); math.IsNaN(w) {
-		log.Printf("NaN decayed_dW")
-	}
-	d.weights, _ = d.Weights().Add(decayed_dW.MultiplyByScalar(-parameters.LearningRate()))
-	if w, _ := d.weights.At(0, 0); math.IsNaN(w) {
+	updateParameter(&d.weights, dW.MultiplyByScalar(1/columns), parameters)
+	if w, _ := d.weights.At(0, 0); math.IsNaN(float64(w)) {
 		log.Printf("NaN weight")
 	}
-
-	decayed_db, _ := db.MultiplyByScalar(1 / columns).Add(
-		d.bias.MultiplyByScalar(parameters.WeightDecay),
-	)
-	d.bias, _ = d.Bias().Add(decayed_db.MultiplyByScalar(-parameters.LearningRate()))
+	updateParameter(&d.bias, db.MultiplyByScalar(1/columns), parameters)
 }
 
 /************************************************************************/
 
-func (d dense) String() string {
+func (d dense[T]) String() string {
 	return fmt.Sprintf(
 		"Dense{%d -> %d, activation: %s}",
 		d.InputSize()[0],
 		d.OutputSize()[0],
-		reflect.TypeOf(d.activation).Name(),
+		activation.Name(d.activation),
 	)
 }
 
-func (d *dense) MarshalJSON() ([]byte, error) {
+func (d *dense[T]) MarshalJSON() ([]byte, error) {
+	activationJSON, err := activation.MarshalActivation(d.activation)
+	if err != nil {
+		return nil, err
+	}
 	return json.Marshal(&struct {
-		Weights    Matrix[float64]
-		Bias       Matrix[float64]
-		Activation string
+		Weights    Matrix[T]
+		Bias       Matrix[T]
+		Activation json.RawMessage
 		Type       string
 	}{
 		Weights:    d.weights,
 		Bias:       d.bias,
-		Activation: reflect.TypeOf(d.activation).Name(),
+		Activation: activationJSON,
 		Type:       "Dense",
 	})
 }
 
-func (d *dense) UnmarshalJSON(data []byte) error {
+func (d *dense[T]) UnmarshalJSON(data []byte) error {
 	var err error
 	var v map[string]json.RawMessage
 
@@ -183,7 +185,7 @@ func (d *dense) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	w, _ := NewMatrix([][]float64{{}})
+	w, _ := NewMatrix([][]T{{}})
 	if err := w.UnmarshalJSON(v["Weights"]); err != nil {
 		return errors.Join(
 			errors.New("invalid weight initializing"),
@@ -192,7 +194,7 @@ func (d *dense) UnmarshalJSON(data []byte) error {
 	}
 	d.weights = w
 
-	b, _ := NewMatrix([][]float64{{}})
+	b, _ := NewMatrix([][]T{{}})
 	if err := b.UnmarshalJSON(v["Bias"]); err != nil {
 		return errors.Join(
 			errors.New("invalid bias initializing"),
@@ -201,7 +203,8 @@ func (d *dense) UnmarshalJSON(data []byte) error {
 	}
 	d.bias = b
 
-	activationLiteral, _ := strconv.Unquote(string(v["Activation"]))
-	d.activation, err = activation.DynamicActivation(activationLiteral)
-	return err // can return either actual error or nil
+	if d.activation, err = activation.UnmarshalActivation[T](v["Activation"]); err != nil {
+		return err
+	}
+	return validateActivation(d.activation, d.OutputSize()[0])
 }
@@ -1,6 +1,7 @@
 package layers_test
 
 import (
+	"encoding/json"
 	"math"
 	"testing"
 
@@ -17,13 +18,13 @@ func TestDenseBackPropagate_UsesOldWeights(t *testing.T) {
 		{0.2, 0.8},
 	})
 	b := matrix.NewZeroMatrix[float64](2, 1)
-	layer, _ := layers.NewDense(W, b, activation.Linear{})
+	layer, _ := layers.NewDense(W, b, activation.Linear[float64]{})
 
 	input, _ := matrix.NewMatrix([][]float64{{1.0}, {1.0}})
 	output := [2]matrix.Matrix[float64]{input, input}
 	dLdA, _ := matrix.NewMatrix([][]float64{{1.0}, {1.0}})
 
-	params := utils.NeuralNetworkParameters{
+	params := utils.NeuralNetworkParameters[float64]{
 		EpochCount:          1,
 		InitialLearningRate: 0.5,
 	}
@@ -51,3 +52,137 @@ func TestDenseBackPropagate_UsesOldWeights(t *testing.T) {
 		t.Errorf("propagation[1] = %v, want %v (old weights used)", gotRow1, wantPropRow1)
 	}
 }
+
+func TestDense_SparseInput(t *testing.T) {
+	// Arrange
+	W, _ := matrix.NewMatrix([][]float64{
+		{0.5, 0.3, -0.1, 0.4},
+		{0.2, 0.8, 0.6, -0.7},
+	})
+	b, _ := matrix.NewMatrix([][]float64{{0.1}, {-0.2}})
+	denseLayer, _ := layers.NewDense(W, b, activation.Sigmoid[float64]{})
+	sparseLayer, _ := layers.NewDense(W.DeepCopy(), b.DeepCopy(), activation.Sigmoid[float64]{})
+
+	// bag-of-words of 3 documents, stored as rows and transposed into columns
+	X, _ := matrix.NewSparseMatrix(3, 4, []int{0, 0, 1, 2}, []int{1, 3, 0, 3}, []float64{2, 1, 1, 3})
+	sparseX := X.T()
+	denseX := matrix.ToDense(sparseX)
+	dLdA := matrix.NewOnesMatrix[float64](2, 3)
+
+	params := utils.NeuralNetworkParameters[float64]{EpochCount: 1, InitialLearningRate: 0.5}
+	params.Validate()
+
+	// Act
+	wantOutput, _ := denseLayer.ForwardPropagate(denseX)
+	gotOutput, err := sparseLayer.ForwardPropagate(sparseX)
+	denseLayer.BackPropagate(dLdA, denseX, wantOutput, params)
+	sparseLayer.BackPropagate(dLdA, sparseX, gotOutput, params)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("ForwardPropagate() error = %v", err)
+	}
+	if !gotOutput[1].Equals(wantOutput[1]) {
+		t.Errorf("output = %v, want %v", gotOutput[1], wantOutput[1])
+	}
+	if !sparseLayer.Weights().Equals(denseLayer.Weights()) {
+		t.Errorf("weights = %v, want %v", sparseLayer.Weights(), denseLayer.Weights())
+	}
+}
+
+func TestDense_ActivationParametersJSON(t *testing.T) {
+	alpha, _ := matrix.NewMatrix([][]float64{{0.1}, {0.4}})
+	testCases := []struct {
+		desc string
+		a    activation.ActivationFunction[float64]
+	}{
+		{desc: "leaky-relu", a: activation.LeakyReLU[float64]{Alpha: 0.3}},
+		{desc: "elu", a: activation.ELU[float64]{Alpha: 0.5}},
+		{desc: "prelu", a: &activation.PReLU[float64]{Alpha: alpha}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			W, _ := matrix.NewMatrix([][]float64{{0.5, -0.3}, {-0.2, 0.8}})
+			b := matrix.NewZeroMatrix[float64](2, 1)
+			layer, _ := layers.NewDense(W, b, tC.a)
+			X, _ := matrix.NewMatrix([][]float64{{1, -2}, {-1, 0.5}})
+
+			// Act
+			data, err := json.Marshal(layer)
+			if err != nil {
+				t.Fatalf("MarshalJSON() error = %v", err)
+			}
+			restored, _ := layers.NewDense(matrix.NewZeroMatrix[float64](1, 1), matrix.NewZeroMatrix[float64](1, 1), activation.ReLU[float64]{})
+			err = json.Unmarshal(data, restored)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("UnmarshalJSON() error = %v", err)
+			}
+			want, _ := layer.ForwardPropagate(X)
+			got, _ := restored.ForwardPropagate(X)
+			if !got[1].Equals(want[1]) {
+				t.Errorf("restored output = %v, want %v (%s)", got[1], want[1], data)
+			}
+		})
+	}
+}
+
+func TestDense_PReLUBackPropagate(t *testing.T) {
+	// Arrange
+	W, _ := matrix.NewMatrix([][]float64{{1, 0}, {0, 1}})
+	b := matrix.NewZeroMatrix[float64](2, 1)
+	prelu := activation.NewPReLU[float64](2, 0)
+	layer, _ := layers.NewDense(W, b, prelu)
+	X, _ := matrix.NewMatrix([][]float64{{-1, -3}, {2, 1}})
+	dLdA := matrix.NewOnesMatrix[float64](2, 2)
+
+	params := utils.NeuralNetworkParameters[float64]{EpochCount: 1, InitialLearningRate: 0.5}
+	params.Validate()
+
+	// Act
+	output, _ := layer.ForwardPropagate(X)
+	layer.BackPropagate(dLdA, X, output, params)
+
+	// Assert
+	// dL/dα_0 = mean(-1, -3) = -2, so α_0 = 0.25 + 0.5*2; the second neuron is positive
+	want, _ := matrix.NewMatrix([][]float64{{1.25}, {0.25}})
+	if !prelu.Alpha.Equals(want) {
+		t.Errorf("alpha = %v, want %v", prelu.Alpha, want)
+	}
+}
+
+func TestDense_PReLUSlopeCount(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		slopes  int
+		wantErr bool
+	}{
+		{desc: "shared", slopes: 1},
+		{desc: "per-neuron", slopes: 2},
+		{desc: "mismatch", slopes: 3, wantErr: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			W := matrix.NewOnesMatrix[float64](2, 2)
+			b := matrix.NewZeroMatrix[float64](2, 1)
+			prelu, _ := activation.MarshalActivation[float64](activation.NewPReLU[float64](tC.slopes, 0))
+			data, _ := json.Marshal(map[string]any{"Weights": W, "Bias": b, "Activation": json.RawMessage(prelu)})
+			restored, _ := layers.NewDense(W, b, activation.ReLU[float64]{})
+
+			// Act
+			_, err := layers.NewDense(W, b, activation.NewPReLU[float64](tC.slopes, 0))
+			unmarshalErr := json.Unmarshal(data, restored)
+
+			// Assert
+			if (err != nil) != tC.wantErr {
+				t.Errorf("NewDense() error = %v, wantErr %v", err, tC.wantErr)
+			}
+			if (unmarshalErr != nil) != tC.wantErr {
+				t.Errorf("UnmarshalJSON() error = %v, wantErr %v", unmarshalErr, tC.wantErr)
+			}
+		})
+	}
+}
@@ -4,47 +4,50 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/rand"
 	"sync"
 
 	"github.com/Hukyl/mlgo/activation"
 	. "github.com/Hukyl/mlgo/matrix"
 	"github.com/Hukyl/mlgo/utils"
+	. "golang.org/x/exp/constraints"
 )
 
-type dropout struct {
+type dropout[T Float] struct {
 	inputSize int
 	rate      float64
+	rand      *rand.Rand
 }
 
-func (d *dropout) InputSize() [2]int {
+func (d *dropout[T]) InputSize() [2]int {
 	return [2]int{d.inputSize, 1}
 }
 
-func (d *dropout) OutputSize() [2]int {
+func (d *dropout[T]) OutputSize() [2]int {
 	return [2]int{d.inputSize, 1}
 }
 
-func (d *dropout) IsTraining() bool {
+func (d *dropout[T]) IsTraining() bool {
 	return true
 }
 
-func (d *dropout) Weights() Matrix[float64] {
+func (d *dropout[T]) Weights() Matrix[T] {
 	return nil
 }
 
-func (d *dropout) Bias() Matrix[float64] {
+func (d *dropout[T]) Bias() Matrix[T] {
 	return nil
 }
 
-func (d *dropout) Activation() activation.ActivationFunction {
+func (d *dropout[T]) Activation() activation.ActivationFunction[T] {
 	return nil
 }
 
 /***************************************************************************/
 
-func (d *dropout) ForwardPropagate(X Matrix[float64]) (Y [2]Matrix[float64], err error) {
-	keepProb := 1.0 - d.rate
-	output := uniformMatrix(X.Size(), 0.0, 1.0)
+func (d *dropout[T]) ForwardPropagate(X Matrix[T]) (Y [2]Matrix[T], err error) {
+	keepProb := T(1.0 - d.rate)
+	output := uniformMatrix[T](X.Size(), 0.0, 1.0, d.rand)
 
 	wg := sync.WaitGroup{}
 	wg.Add(output.ColumnCount())
@@ -64,24 +67,27 @@ func (d *dropout) ForwardPropagate(X Matrix[float64]) (Y [2]Matrix[float64], err
 	}
 	wg.Wait()
 
-	Y = [2]Matrix[float64]{output, output}
+	Y = [2]Matrix[T]{output, output}
 
 	return Y, nil
 }
 
-func (d *dropout) BackPropagate(nextLayerPropagation, X Matrix[float64], A [2]Matrix[float64], parameters utils.NeuralNetworkParameters) Matrix[float64] {
-	return nextLayerPropagation
+// SetRand sets the generator, used to choose the nullified neurons.
+func (d *dropout[T]) SetRand(r *rand.Rand) {
+	d.rand = r
 }
 
-func (d *dropout) updateWeights(_, _ Matrix[float64], _ utils.NeuralNetworkParameters) {}
+func (d *dropout[T]) BackPropagate(nextLayerPropagation, X Matrix[T], A [2]Matrix[T], parameters utils.NeuralNetworkParameters[T]) Matrix[T] {
+	return nextLayerPropagation
+}
 
 /***************************************************************************/
 
-func (d *dropout) String() string {
+func (d *dropout[T]) String() string {
 	return fmt.Sprintf("Dropout{%[1]d -> %[1]d, rate: %v}", d.inputSize, d.rate)
 }
 
-func (d *dropout) MarshalJSON() ([]byte, error) {
+func (d *dropout[T]) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&struct {
 		InputSize int
 		Rate      float64
@@ -93,7 +99,7 @@ func (d *dropout) MarshalJSON() ([]byte, error) {
 	})
 }
 
-func (d *dropout) UnmarshalJSON(data []byte) error {
+func (d *dropout[T]) UnmarshalJSON(data []byte) error {
 	var v struct {
 		InputSize int
 		Rate      float64
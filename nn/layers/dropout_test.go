@@ -2,6 +2,7 @@ package layers_test
 
 import (
 	"math"
+	"math/rand"
 	"testing"
 
 	"github.com/Hukyl/mlgo/matrix"
@@ -11,7 +12,7 @@ import (
 func TestDropout_MasksElements(t *testing.T) {
 	// Arrange
 	rate := 0.5
-	d := layers.NewDropout(4, rate)
+	d := layers.NewDropout[float64](4, rate)
 	input, _ := matrix.NewMatrix([][]float64{
 		{1.0, 1.0, 1.0, 1.0, 1.0, 1.0, 1.0, 1.0, 1.0, 1.0},
 		{1.0, 1.0, 1.0, 1.0, 1.0, 1.0, 1.0, 1.0, 1.0, 1.0},
@@ -47,7 +48,7 @@ func TestDropout_ScalesSurvivingValues(t *testing.T) {
 	// Arrange
 	rate := 0.5
 	keepProb := 1.0 - rate
-	d := layers.NewDropout(4, rate)
+	d := layers.NewDropout[float64](4, rate)
 	input, _ := matrix.NewMatrix([][]float64{
 		{2.0, 2.0, 2.0, 2.0, 2.0, 2.0, 2.0, 2.0, 2.0, 2.0},
 		{2.0, 2.0, 2.0, 2.0, 2.0, 2.0, 2.0, 2.0, 2.0, 2.0},
@@ -76,7 +77,7 @@ func TestDropout_ScalesSurvivingValues(t *testing.T) {
 func TestDropout_PreservesExpectedValue(t *testing.T) {
 	// Arrange
 	rate := 0.3
-	d := layers.NewDropout(100, rate)
+	d := layers.NewDropout[float64](100, rate)
 
 	rows := make([][]float64, 100)
 	for i := range rows {
@@ -112,7 +113,7 @@ func TestDropout_PreservesExpectedValue(t *testing.T) {
 
 func TestDropout_ZeroRate(t *testing.T) {
 	// Arrange
-	d := layers.NewDropout(3, 0.0)
+	d := layers.NewDropout[float64](3, 0.0)
 	input, _ := matrix.NewMatrix([][]float64{
 		{1.0, 2.0},
 		{3.0, 4.0},
@@ -136,3 +137,21 @@ func TestDropout_ZeroRate(t *testing.T) {
 		}
 	}
 }
+
+func TestDropout_SetRand(t *testing.T) {
+	// Arrange
+	first := layers.NewDropout[float64](4, 0.5)
+	second := layers.NewDropout[float64](4, 0.5)
+	first.(layers.RandomLayer).SetRand(rand.New(rand.NewSource(42)))
+	second.(layers.RandomLayer).SetRand(rand.New(rand.NewSource(42)))
+	input := matrix.NewOnesMatrix[float64](4, 64)
+
+	// Act
+	firstOutput, _ := first.ForwardPropagate(input)
+	secondOutput, _ := second.ForwardPropagate(input)
+
+	// Assert
+	if !firstOutput[1].Equals(secondOutput[1]) {
+		t.Error("dropouts with the same seed produced different masks")
+	}
+}
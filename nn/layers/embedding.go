@@ -0,0 +1,206 @@
+package layers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/Hukyl/mlgo/activation"
+	. "github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/utils"
+	. "golang.org/x/exp/constraints"
+)
+
+// EmbeddingParameters contains the hyperparameters of an embedding layer.
+//
+// VocabularySize is the number of distinct tokens, i.e. the token ids are in
+// the range [0, VocabularySize).
+//
+// Dimension is the size of the vector each token is mapped to.
+//
+// TimeSteps is the number of tokens in each sample. If not set, initialized to 1.
+//
+// Frozen defines whether the embeddings are left intact during the training,
+// which is mostly used with pretrained embeddings.
+type EmbeddingParameters struct {
+	VocabularySize int
+	Dimension      int
+	TimeSteps      int
+	Frozen         bool
+}
+
+// embedding maps each token id of the input to its vector. The input is a TxN
+// matrix of the token ids, while the output is (T*Dimension)xN, i.e. the vectors
+// are stored one after another, the same way the recurrent layers expect them.
+type embedding[T Float] struct {
+	parameters EmbeddingParameters
+	weights    Matrix[T] // VocabularySize x Dimension
+}
+
+func (e *embedding[T]) InputSize() [2]int {
+	return [2]int{e.parameters.TimeSteps, 1}
+}
+
+func (e *embedding[T]) OutputSize() [2]int {
+	return [2]int{e.parameters.TimeSteps * e.parameters.Dimension, 1}
+}
+
+func (e *embedding[T]) IsTraining() bool {
+	return false
+}
+
+// Weights returns the embedding matrix, each row of which is a vector of a token.
+func (e *embedding[T]) Weights() Matrix[T] {
+	return e.weights
+}
+
+func (e *embedding[T]) Bias() Matrix[T] {
+	return nil
+}
+
+func (e *embedding[T]) Activation() activation.ActivationFunction[T] {
+	return nil
+}
+
+// State returns the embedding matrix.
+func (e *embedding[T]) State() []*Matrix[T] {
+	return []*Matrix[T]{&e.weights}
+}
+
+/****************************************************************************/
+
+// tokens returns the token ids of the input in the same layout.
+func (e *embedding[T]) tokens(X Matrix[T]) ([][]int, error) {
+	result := make([][]int, X.RowCount())
+	for t := range result {
+		result[t] = make([]int, X.ColumnCount())
+		for n := range result[t] {
+			v, _ := X.At(t, n)
+			if float64(v) != math.Trunc(float64(v)) || v < 0 || int(v) >= e.parameters.VocabularySize {
+				return nil, fmt.Errorf("invalid token id %v", v)
+			}
+			result[t][n] = int(v)
+		}
+	}
+	return result, nil
+}
+
+// ForwardPropagate looks up the vectors of the tokens in X.
+//
+// Input has to be of e.InputSize() size and consist of valid token ids, otherwise
+// error is returned.
+func (e *embedding[T]) ForwardPropagate(X Matrix[T]) (Y [2]Matrix[T], err error) {
+	if X.RowCount() != e.parameters.TimeSteps {
+		return Y, errors.New("invalid input size")
+	}
+	tokens, err := e.tokens(X)
+	if err != nil {
+		return Y, err
+	}
+
+	dimension := e.parameters.Dimension
+	output := NewZeroMatrix[T](e.OutputSize()[0], X.ColumnCount())
+	for t, row := range tokens {
+		for n, token := range row {
+			for d := 0; d < dimension; d++ {
+				v, _ := e.weights.At(token, d)
+				output.Set(t*dimension+d, n, v)
+			}
+		}
+	}
+
+	Y = [2]Matrix[T]{output, output}
+	return Y, nil
+}
+
+// BackPropagate accumulates the gradients of the vectors of the tokens in X, and
+// updates only these rows of the embedding matrix (unless the layer is frozen).
+// The gradients are averaged over the samples.
+//
+// As the token ids are discrete, the propagated gradient is zero. X has to be the input
+// of ForwardPropagate, i.e. consist of valid token ids, otherwise BackPropagate panics.
+func (e *embedding[T]) BackPropagate(nextLayerPropagation, X Matrix[T], A [2]Matrix[T], parameters utils.NeuralNetworkParameters[T]) Matrix[T] {
+	tokens, err := e.tokens(X)
+	if err != nil {
+		panic(fmt.Errorf("layers: embedding: %w", err))
+	}
+	if !e.parameters.Frozen {
+		e.updateWeights(nextLayerPropagation, tokens, parameters)
+	}
+	return NewZeroMatrix[T](X.RowCount(), X.ColumnCount())
+}
+
+// updateWeights updates the vectors of the tokens, using the compact gradient,
+// i.e. the one of the tokens present in the batch only.
+func (e *embedding[T]) updateWeights(nextLayerPropagation Matrix[T], tokens [][]int, parameters utils.NeuralNetworkParameters[T]) {
+	dimension := e.parameters.Dimension
+	indices := make(map[int]int)
+	rows := make([]int, 0)
+	for _, row := range tokens {
+		for _, token := range row {
+			if _, ok := indices[token]; !ok {
+				indices[token] = len(rows)
+				rows = append(rows, token)
+			}
+		}
+	}
+
+	samples := T(nextLayerPropagation.ColumnCount())
+	dW := NewZeroMatrix[T](len(rows), dimension)
+	for t, row := range tokens {
+		for n, token := range row {
+			k := indices[token]
+			for d := 0; d < dimension; d++ {
+				g, _ := nextLayerPropagation.At(t*dimension+d, n)
+				current, _ := dW.At(k, d)
+				dW.Set(k, d, current+g/samples)
+			}
+		}
+	}
+	updateParameterRows(&e.weights, dW, rows, parameters)
+}
+
+/****************************************************************************/
+
+func (e *embedding[T]) String() string {
+	return fmt.Sprintf(
+		"Embedding{%d -> %dx%d, vocabulary: %d, frozen: %t}",
+		e.parameters.TimeSteps,
+		e.parameters.TimeSteps,
+		e.parameters.Dimension,
+		e.parameters.VocabularySize,
+		e.parameters.Frozen,
+	)
+}
+
+func (e *embedding[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Parameters EmbeddingParameters
+		Weights    Matrix[T]
+		Type       string
+	}{
+		Parameters: e.parameters,
+		Weights:    e.weights,
+		Type:       "Embedding",
+	})
+}
+
+func (e *embedding[T]) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Parameters EmbeddingParameters
+		Weights    json.RawMessage
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return errors.Join(errors.New("invalid embedding layer"), err)
+	}
+	w, _ := NewMatrix([][]T{{}})
+	if err := w.UnmarshalJSON(v.Weights); err != nil {
+		return errors.Join(errors.New("invalid weight initializing"), err)
+	}
+	if w.RowCount() != v.Parameters.VocabularySize || w.ColumnCount() != v.Parameters.Dimension {
+		return errors.New("invalid embedding size")
+	}
+	e.parameters, e.weights = v.Parameters, w
+	return nil
+}
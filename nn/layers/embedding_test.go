@@ -0,0 +1,152 @@
+package layers_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/nn/layers"
+	"github.com/Hukyl/mlgo/optimizer"
+	"github.com/Hukyl/mlgo/utils"
+)
+
+func pretrainedEmbedding(t *testing.T, frozen bool) layers.Layer[float64] {
+	t.Helper()
+	W, _ := matrix.NewMatrix([][]float64{{0, 1}, {2, 3}, {4, 5}, {6, 7}})
+	e, err := layers.NewPretrainedEmbedding(W, layers.EmbeddingParameters{TimeSteps: 2, Frozen: frozen})
+	if err != nil {
+		t.Fatalf("NewPretrainedEmbedding error: %v", err)
+	}
+	return e
+}
+
+func TestEmbedding_ForwardPropagate(t *testing.T) {
+	// Arrange
+	e := pretrainedEmbedding(t, false)
+	X, _ := matrix.NewMatrix([][]float64{{1, 3}, {0, 1}})
+
+	// Act
+	output, err := e.ForwardPropagate(X)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("ForwardPropagate error: %v", err)
+	}
+	want := [][]float64{{2, 6}, {3, 7}, {0, 2}, {1, 3}}
+	if !output[1].Equals(mustMatrix(t, want)) {
+		t.Errorf("output = %v, want %v", output[1], want)
+	}
+}
+
+func TestEmbedding_InvalidTokens(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		input [][]float64
+	}{
+		{desc: "out-of-vocabulary", input: [][]float64{{4}, {0}}},
+		{desc: "negative", input: [][]float64{{-1}, {0}}},
+		{desc: "non-integer", input: [][]float64{{0.5}, {0}}},
+		{desc: "wrong-time-steps", input: [][]float64{{0}}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			e := pretrainedEmbedding(t, false)
+
+			// Act
+			_, err := e.ForwardPropagate(mustMatrix(t, tC.input))
+
+			// Assert
+			if err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestEmbedding_BackPropagate(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		frozen      bool
+		optimizer   optimizer.Optimizer[float64]
+		weightDecay float64
+		want        [][]float64
+	}{
+		{
+			// token 1 appears twice, so its gradients are summed, and averaged over 2 samples
+			desc:      "sgd",
+			optimizer: &optimizer.SGD[float64]{},
+			want:      [][]float64{{-0.5, 0}, {0, 0}, {4, 5}, {4.5, 5}},
+		},
+		{
+			// weight decay is applied only to the touched rows
+			desc:        "sgd-weight-decay",
+			optimizer:   &optimizer.SGD[float64]{},
+			weightDecay: 0.5,
+			want:        [][]float64{{-0.5, -0.5}, {-1, -1.5}, {4, 5}, {1.5, 1.5}},
+		},
+		{
+			// the first Adam step moves each touched weight by the learning rate
+			desc:      "adam",
+			optimizer: &optimizer.Adam[float64]{},
+			want:      [][]float64{{-1, 0}, {1, 2}, {4, 5}, {5, 6}},
+		},
+		{
+			desc:      "frozen",
+			frozen:    true,
+			optimizer: &optimizer.Adam[float64]{},
+			want:      [][]float64{{0, 1}, {2, 3}, {4, 5}, {6, 7}},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			e := pretrainedEmbedding(t, tC.frozen)
+			X, _ := matrix.NewMatrix([][]float64{{1, 3}, {0, 1}})
+			output, _ := e.ForwardPropagate(X)
+			upstream, _ := matrix.NewMatrix([][]float64{{1, 3}, {2, 4}, {1, 3}, {2, 4}})
+			parameters := utils.NeuralNetworkParameters[float64]{
+				InitialLearningRate: 1,
+				WeightDecay:         tC.weightDecay,
+				Optimizer:           tC.optimizer,
+			}
+
+			// Act
+			gradient := e.BackPropagate(upstream, X, output, parameters)
+
+			// Assert
+			if gradient.Size() != X.Size() {
+				t.Errorf("gradient size = %v, want %v", gradient.Size(), X.Size())
+			}
+			for i := range tC.want {
+				for j := range tC.want[i] {
+					if got, _ := e.Weights().At(i, j); math.Abs(got-tC.want[i][j]) > 1e-6 {
+						t.Errorf("W(%d,%d) = %v, want %v", i, j, got, tC.want[i][j])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestNewPretrainedEmbedding_InvalidSize(t *testing.T) {
+	// Arrange
+	W := matrix.NewZeroMatrix[float64](4, 2)
+
+	// Act
+	_, err := layers.NewPretrainedEmbedding(W, layers.EmbeddingParameters{VocabularySize: 5})
+
+	// Assert
+	if err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func mustMatrix(t *testing.T, data [][]float64) matrix.Matrix[float64] {
+	t.Helper()
+	m, err := matrix.NewMatrix(data)
+	if err != nil {
+		t.Fatalf("NewMatrix error: %v", err)
+	}
+	return m
+}
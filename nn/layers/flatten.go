@@ -0,0 +1,86 @@
+package layers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Hukyl/mlgo/activation"
+	. "github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/utils"
+	. "golang.org/x/exp/constraints"
+)
+
+// flatten does not change the input, as spatial samples are already stored as
+// flattened columns. It only marks the transition from spatial layers to dense ones.
+type flatten[T Float] struct {
+	inputShape Shape
+}
+
+func (f *flatten[T]) InputShape() Shape {
+	return f.inputShape
+}
+
+func (f *flatten[T]) OutputShape() Shape {
+	return Shape{Channels: f.inputShape.Size(), Height: 1, Width: 1}
+}
+
+func (f *flatten[T]) InputSize() [2]int {
+	return [2]int{f.inputShape.Size(), 1}
+}
+
+func (f *flatten[T]) OutputSize() [2]int {
+	return [2]int{f.inputShape.Size(), 1}
+}
+
+func (f *flatten[T]) IsTraining() bool {
+	return false
+}
+
+func (f *flatten[T]) Weights() Matrix[T] {
+	return nil
+}
+
+func (f *flatten[T]) Bias() Matrix[T] {
+	return nil
+}
+
+func (f *flatten[T]) Activation() activation.ActivationFunction[T] {
+	return nil
+}
+
+func (f *flatten[T]) ForwardPropagate(X Matrix[T]) (Y [2]Matrix[T], err error) {
+	if X.RowCount() != f.inputShape.Size() {
+		return Y, errors.New("invalid input size")
+	}
+	return [2]Matrix[T]{X, X}, nil
+}
+
+func (f *flatten[T]) BackPropagate(nextLayerPropagation, X Matrix[T], A [2]Matrix[T], parameters utils.NeuralNetworkParameters[T]) Matrix[T] {
+	return nextLayerPropagation
+}
+
+func (f *flatten[T]) String() string {
+	return fmt.Sprintf("Flatten{%s -> %d}", f.inputShape, f.inputShape.Size())
+}
+
+func (f *flatten[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		InputShape Shape
+		Type       string
+	}{
+		InputShape: f.inputShape,
+		Type:       "Flatten",
+	})
+}
+
+func (f *flatten[T]) UnmarshalJSON(data []byte) error {
+	var v struct {
+		InputShape Shape
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return errors.Join(errors.New("invalid flatten layer"), err)
+	}
+	f.inputShape = v.InputShape
+	return nil
+}
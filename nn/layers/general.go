@@ -3,13 +3,18 @@ package layers
 import (
 	"encoding/json"
 	"fmt"
+	"math/rand"
 
 	"github.com/Hukyl/mlgo/activation"
+	"github.com/Hukyl/mlgo/internal/registry"
 	. "github.com/Hukyl/mlgo/matrix"
 	"github.com/Hukyl/mlgo/utils"
+	. "golang.org/x/exp/constraints"
 )
 
-type Layer interface {
+// Layer is a layer of the neural network, which computes in the float type T,
+// e.g. float64 or float32.
+type Layer[T Float] interface {
 	fmt.Stringer
 	json.Marshaler
 	json.Unmarshaler
@@ -19,15 +24,145 @@ type Layer interface {
 
 	IsTraining() bool
 
-	Weights() Matrix[float64]
-	Bias() Matrix[float64]
-	Activation() activation.ActivationFunction
+	Weights() Matrix[T]
+	Bias() Matrix[T]
+	Activation() activation.ActivationFunction[T]
 
-	ForwardPropagate(X Matrix[float64]) (Y [2]Matrix[float64], err error)
+	ForwardPropagate(X Matrix[T]) (Y [2]Matrix[T], err error)
 	BackPropagate(
-		nextLayerPropagation, input Matrix[float64],
-		output [2]Matrix[float64],
-		parameters utils.NeuralNetworkParameters,
-	) Matrix[float64]
-	updateWeights(nextLayerPropagation, input Matrix[float64], parameters utils.NeuralNetworkParameters)
+		nextLayerPropagation, input Matrix[T],
+		output [2]Matrix[T],
+		parameters utils.NeuralNetworkParameters[T],
+	) Matrix[T]
+}
+
+// RandomLayer is implemented by the layers, which use randomness during the training
+// (e.g. dropout). SetRand sets the generator to be used, so that the training is
+// reproducible for a fixed seed. If it is nil, the global source of math/rand is used.
+type RandomLayer interface {
+	SetRand(r *rand.Rand)
+}
+
+// InferenceLayer is implemented by the layers, which behave differently during
+// training and inference (e.g. batch normalization). ForwardPropagate is used
+// during the training, while Infer is used for the predictions.
+//
+// Infer produces the output of the layer for X, i.e. the counterpart of
+// ForwardPropagate(X)[1].
+type InferenceLayer[T Float] interface {
+	Layer[T]
+
+	Infer(X Matrix[T]) (Matrix[T], error)
+}
+
+// StatefulLayer is implemented by the layers with the matrices, which change during
+// the training, i.e. the trainable parameters (including the ones of the activation
+// function) and the statistics (e.g. of batch normalization).
+//
+// State returns the pointers to these matrices, so that they can be saved and restored
+// in place, e.g. to restore the best weights at the end of the training.
+type StatefulLayer[T Float] interface {
+	Layer[T]
+
+	State() []*Matrix[T]
+}
+
+/****************************************************************************/
+
+// registeredLayers contains the layers, registered by RegisterLayer.
+var registeredLayers registry.Registry
+
+// RegisterLayer makes a layer, defined outside the package, available by its name in
+// DynamicLayer, so that a model with it can be loaded from JSON. The name has to be
+// the same as the "Type" field, produced by MarshalJSON of the layer. The factory
+// produces a new layer, which is then initialized by its UnmarshalJSON, e.g.
+//
+//	layers.RegisterLayer("Swish", func() layers.Layer[float64] {
+//		layer, _ := layers.NewCustom[float64]("Swish", 1, 1, swish)
+//		return layer
+//	})
+//
+// The layer is registered only for the float type T. Panics if the name is already
+// used for T, or the factory is nil.
+func RegisterLayer[T Float](name string, factory func() Layer[T]) {
+	if factory == nil {
+		panic("layers: RegisterLayer factory is nil")
+	}
+	if builtinLayer[T](name) != nil || !registry.Register[T](&registeredLayers, name, factory) {
+		panic(fmt.Sprintf("layers: RegisterLayer called twice for %s", name))
+	}
+}
+
+// DynamicLayer returns a new layer of the type with the given name, which is either
+// one of the layers of the package, or registered by RegisterLayer. The layer is
+// meant to be initialized by its UnmarshalJSON.
+//
+// Returns error if the name is unknown.
+func DynamicLayer[T Float](name string) (Layer[T], error) {
+	if layer := builtinLayer[T](name); layer != nil {
+		return layer, nil
+	}
+	factory, ok := registry.Lookup[T, func() Layer[T]](&registeredLayers, name)
+	if !ok {
+		return nil, fmt.Errorf("unknown layer type: %s", name)
+	}
+	return factory(), nil
+}
+
+// builtinLayer returns a new layer of the package by its type name, or nil if there
+// is no such layer.
+func builtinLayer[T Float](name string) Layer[T] {
+	var layer Layer[T]
+	switch name {
+	case "Dense":
+		W, _ := NewMatrix([][]T{{}})
+		b, _ := NewMatrix([][]T{{}})
+		layer, _ = NewDense(W, b, activation.Linear[T]{})
+	case "Dropout":
+		layer = NewDropout[T](0, 0)
+	case "Conv2D":
+		layer, _ = NewConv2D(
+			Shape{Channels: 1, Height: 1, Width: 1},
+			Conv2DParameters{Filters: 1, KernelSize: [2]int{1, 1}},
+			activation.Linear[T]{},
+			RandomInitialization{},
+		)
+	case "MaxPool2D", "AveragePool2D":
+		layer, _ = NewMaxPool2D[T](Shape{Channels: 1, Height: 1, Width: 1}, [2]int{1, 1}, [2]int{})
+	case "GlobalAveragePool":
+		layer = NewGlobalAveragePool[T](Shape{})
+	case "Flatten":
+		layer = NewFlatten[T](Shape{})
+	case "BatchNorm":
+		layer = NewBatchNorm[T](0, 0, 0)
+	case "LayerNorm":
+		layer = NewLayerNorm[T](0, 0)
+	case "SimpleRNN", "LSTM", "GRU":
+		layer, _ = NewSimpleRNN[T](
+			RecurrentParameters{TimeSteps: 1, InputSize: 1, HiddenSize: 1},
+			RandomInitialization{},
+		)
+	case "Embedding":
+		layer, _ = NewEmbedding[T](EmbeddingParameters{VocabularySize: 1, Dimension: 1}, RandomInitialization{})
+	case "MultiHeadAttention":
+		layer, _ = NewMultiHeadAttention[T](
+			AttentionParameters{TimeSteps: 1, ModelSize: 1, Heads: 1},
+			RandomInitialization{},
+		)
+	case "TransformerEncoderBlock":
+		layer, _ = NewTransformerEncoderBlock(
+			AttentionParameters{TimeSteps: 1, ModelSize: 1, Heads: 1},
+			1,
+			activation.Linear[T]{},
+			RandomInitialization{},
+		)
+	case "PositionalEncoding":
+		layer = NewPositionalEncoding[T](0, 0)
+	case "Bidirectional":
+		parameters := RecurrentParameters{TimeSteps: 1, InputSize: 1, HiddenSize: 1}
+		forward, _ := NewSimpleRNN[T](parameters, RandomInitialization{})
+		backward, _ := NewSimpleRNN[T](parameters, RandomInitialization{})
+		layer, _ = NewBidirectional(forward, backward)
+	}
+	return layer
 }
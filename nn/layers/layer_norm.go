@@ -0,0 +1,207 @@
+package layers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Hukyl/mlgo/activation"
+	. "github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/utils"
+	. "golang.org/x/exp/constraints"
+)
+
+// layerNorm normalizes the features of each sample separately. If the samples are
+// sequences of timeSteps feature vectors (stored one after another), each vector
+// is normalized on its own, sharing gamma and beta between the time steps.
+type layerNorm[T Float] struct {
+	featureSize int
+	timeSteps   int
+	epsilon     float64
+	gamma       Matrix[T] // featureSize x 1
+	beta        Matrix[T] // featureSize x 1
+}
+
+func (l *layerNorm[T]) InputSize() [2]int {
+	return [2]int{l.featureSize * l.timeSteps, 1}
+}
+
+func (l *layerNorm[T]) OutputSize() [2]int {
+	return [2]int{l.featureSize * l.timeSteps, 1}
+}
+
+func (l *layerNorm[T]) IsTraining() bool {
+	return false
+}
+
+// Weights returns the scale (gamma) of the normalized features.
+func (l *layerNorm[T]) Weights() Matrix[T] {
+	return l.gamma
+}
+
+// Bias returns the shift (beta) of the normalized features.
+func (l *layerNorm[T]) Bias() Matrix[T] {
+	return l.beta
+}
+
+func (l *layerNorm[T]) Activation() activation.ActivationFunction[T] {
+	return nil
+}
+
+// State returns gamma and beta.
+func (l *layerNorm[T]) State() []*Matrix[T] {
+	return []*Matrix[T]{&l.gamma, &l.beta}
+}
+
+/****************************************************************************/
+
+// gather returns the features of the time step t of the sample n.
+func (l *layerNorm[T]) gather(M Matrix[T], t, n int) []float64 {
+	values := make([]float64, l.featureSize)
+	for d := range values {
+		v, _ := M.At(t*l.featureSize+d, n)
+		values[d] = float64(v)
+	}
+	return values
+}
+
+// scatter is the inverse of gather.
+func (l *layerNorm[T]) scatter(M Matrix[T], t, n int, values []float64) {
+	for d, v := range values {
+		M.Set(t*l.featureSize+d, n, T(v))
+	}
+}
+
+// ForwardPropagate produces two matrices:
+//   - The normalized input x̂
+//   - The actual output of the layer, which is gamma*x̂ + beta
+//
+// As the statistics are computed for each sample separately, the layer behaves
+// the same way during the training and inference.
+//
+// Input has to be of l.InputSize() size, otherwise error is returned.
+func (l *layerNorm[T]) ForwardPropagate(X Matrix[T]) (Y [2]Matrix[T], err error) {
+	if X.RowCount() != l.InputSize()[0] {
+		return Y, errors.New("invalid input size")
+	}
+	normalized := NewZeroMatrix[T](X.RowCount(), X.ColumnCount())
+	output := NewZeroMatrix[T](X.RowCount(), X.ColumnCount())
+
+	for n := 0; n < X.ColumnCount(); n++ {
+		for t := 0; t < l.timeSteps; t++ {
+			values := l.gather(X, t, n)
+			standardize(values, l.epsilon)
+			l.scatter(normalized, t, n, values)
+			for d, v := range values {
+				gamma, _ := l.gamma.At(d, 0)
+				beta, _ := l.beta.At(d, 0)
+				values[d] = float64(gamma)*v + float64(beta)
+			}
+			l.scatter(output, t, n, values)
+		}
+	}
+
+	Y = [2]Matrix[T]{normalized, output}
+	return Y, nil
+}
+
+// BackPropagate computes the gradients of gamma and beta, averaged over the samples
+//
+//	dLdGamma = sum(nextLayerPropagation * x̂) / N
+//	dLdBeta = sum(nextLayerPropagation) / N
+//
+// and propagates the gradient through the normalization of each feature vector.
+func (l *layerNorm[T]) BackPropagate(nextLayerPropagation, X Matrix[T], A [2]Matrix[T], parameters utils.NeuralNetworkParameters[T]) Matrix[T] {
+	result := NewZeroMatrix[T](X.RowCount(), X.ColumnCount())
+	dGamma := NewZeroMatrix[T](l.featureSize, 1)
+	dBeta := NewZeroMatrix[T](l.featureSize, 1)
+	samples := float64(X.ColumnCount())
+
+	for n := 0; n < X.ColumnCount(); n++ {
+		for t := 0; t < l.timeSteps; t++ {
+			xhat := l.gather(X, t, n)
+			_, _, invStd := standardize(xhat, l.epsilon)
+			gradient := l.gather(nextLayerPropagation, t, n)
+			for d := range gradient {
+				currentGamma, _ := dGamma.At(d, 0)
+				currentBeta, _ := dBeta.At(d, 0)
+				dGamma.Set(d, 0, currentGamma+T(gradient[d]*xhat[d]/samples))
+				dBeta.Set(d, 0, currentBeta+T(gradient[d]/samples))
+
+				gamma, _ := l.gamma.At(d, 0)
+				gradient[d] *= float64(gamma)
+			}
+			standardizationGradient(gradient, xhat, invStd)
+			l.scatter(result, t, n, gradient)
+		}
+	}
+	l.updateWeights(dGamma, dBeta, parameters)
+
+	return result
+}
+
+// updateWeights updates gamma and beta. Weight decay is not applied, as the scale
+// and the shift are not regularized.
+func (l *layerNorm[T]) updateWeights(dGamma, dBeta Matrix[T], parameters utils.NeuralNetworkParameters[T]) {
+	parameters.WeightDecay = 0
+	updateParameter(&l.gamma, dGamma, parameters)
+	updateParameter(&l.beta, dBeta, parameters)
+}
+
+/****************************************************************************/
+
+func (l *layerNorm[T]) String() string {
+	if l.timeSteps > 1 {
+		return fmt.Sprintf("LayerNorm{%dx%d}", l.timeSteps, l.featureSize)
+	}
+	return fmt.Sprintf("LayerNorm{%d}", l.featureSize)
+}
+
+func (l *layerNorm[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		FeatureSize int
+		TimeSteps   int
+		Epsilon     float64
+		Gamma       Matrix[T]
+		Beta        Matrix[T]
+		Type        string
+	}{
+		FeatureSize: l.featureSize,
+		TimeSteps:   l.timeSteps,
+		Epsilon:     l.epsilon,
+		Gamma:       l.gamma,
+		Beta:        l.beta,
+		Type:        "LayerNorm",
+	})
+}
+
+func (l *layerNorm[T]) UnmarshalJSON(data []byte) error {
+	var v struct {
+		FeatureSize int
+		TimeSteps   int
+		Epsilon     float64
+		Gamma       json.RawMessage
+		Beta        json.RawMessage
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return errors.Join(errors.New("invalid layer normalization"), err)
+	}
+	if v.FeatureSize <= 0 || v.TimeSteps <= 0 {
+		return errors.New("invalid layer normalization size")
+	}
+	l.featureSize, l.timeSteps, l.epsilon = v.FeatureSize, v.TimeSteps, v.Epsilon
+
+	gamma, _ := NewMatrix([][]T{{}})
+	if err := gamma.UnmarshalJSON(v.Gamma); err != nil {
+		return errors.Join(errors.New("invalid gamma initializing"), err)
+	}
+	beta, _ := NewMatrix([][]T{{}})
+	if err := beta.UnmarshalJSON(v.Beta); err != nil {
+		return errors.Join(errors.New("invalid beta initializing"), err)
+	}
+	if gamma.RowCount() != l.featureSize || beta.RowCount() != l.featureSize {
+		return errors.New("invalid gamma or beta size")
+	}
+	l.gamma, l.beta = gamma, beta
+	return nil
+}
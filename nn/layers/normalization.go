@@ -0,0 +1,51 @@
+package layers
+
+import (
+	"math"
+)
+
+// moments computes the mean and the (biased) variance of the values.
+func moments(values []float64) (mean, variance float64) {
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(values))
+	return mean, variance
+}
+
+// standardize replaces the values with their standardized counterparts
+//
+//	x̂ = (x - mean) / sqrt(variance + epsilon)
+//
+// returning the statistics used, as well as the inverse standard deviation.
+func standardize(values []float64, epsilon float64) (mean, variance, invStd float64) {
+	mean, variance = moments(values)
+	invStd = 1 / math.Sqrt(variance+epsilon)
+	for i, v := range values {
+		values[i] = (v - mean) * invStd
+	}
+	return mean, variance, invStd
+}
+
+// standardizationGradient propagates the gradient with respect to the standardized
+// values x̂ to the gradient with respect to the original ones, considering that
+// the mean and the variance depend on all of them:
+//
+//	dLdX = invStd / m * (m*dLdX̂ - sum(dLdX̂) - x̂*sum(dLdX̂*x̂))
+//
+// The result is written into dXhat.
+func standardizationGradient(dXhat, xhat []float64, invStd float64) {
+	m := float64(len(xhat))
+	sum, dot := 0.0, 0.0
+	for i := range xhat {
+		sum += dXhat[i]
+		dot += dXhat[i] * xhat[i]
+	}
+	for i := range xhat {
+		dXhat[i] = invStd / m * (m*dXhat[i] - sum - xhat[i]*dot)
+	}
+}
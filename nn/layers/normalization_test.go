@@ -0,0 +1,132 @@
+package layers_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/nn/layers"
+	"github.com/Hukyl/mlgo/utils"
+)
+
+func TestBatchNorm_ForwardPropagate(t *testing.T) {
+	// Arrange
+	bn := layers.NewBatchNorm[float64](2, 0, 1e-12)
+	X, _ := matrix.NewMatrix([][]float64{
+		{1, 2, 3, 6},
+		{-4, 0, 4, 0},
+	})
+
+	// Act
+	output, err := bn.ForwardPropagate(X)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("ForwardPropagate error: %v", err)
+	}
+	for i := 0; i < output[1].RowCount(); i++ {
+		mean, variance := 0.0, 0.0
+		for j := 0; j < output[1].ColumnCount(); j++ {
+			v, _ := output[1].At(i, j)
+			mean += v / 4
+			variance += v * v / 4
+		}
+		if math.Abs(mean) > 1e-9 || math.Abs(variance-1) > 1e-9 {
+			t.Errorf("feature %d: mean = %v, variance = %v, want 0 and 1", i, mean, variance)
+		}
+	}
+}
+
+func TestBatchNorm_Infer(t *testing.T) {
+	// Arrange
+	bn := layers.NewBatchNorm[float64](1, 0.5, 1e-12)
+	X, _ := matrix.NewMatrix([][]float64{{1, 3}})
+	params := utils.NeuralNetworkParameters[float64]{EpochCount: 1}
+	params.Validate()
+	// batch mean is 2 and unbiased variance is 2, so the running statistics become 1 and 1.5
+	output, _ := bn.ForwardPropagate(X)
+	bn.BackPropagate(matrix.NewZeroMatrix[float64](1, 2), X, output, params)
+	// the extra forward passes do not change the running statistics
+	bn.ForwardPropagate(X)
+	sample, _ := matrix.NewMatrix([][]float64{{1, 4}})
+
+	// Act
+	inferred, err := bn.(layers.InferenceLayer[float64]).Infer(sample)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Infer error: %v", err)
+	}
+	want := []float64{0, 3 / math.Sqrt(1.5)}
+	for j, w := range want {
+		if got, _ := inferred.At(0, j); math.Abs(got-w) > 1e-9 {
+			t.Errorf("At(0,%d) = %v, want %v", j, got, w)
+		}
+	}
+}
+
+func TestNormalization_BackPropagate(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		layer layers.Layer[float64]
+	}{
+		{
+			desc:  "batch-norm",
+			layer: layers.NewBatchNorm[float64](3, 0, 0),
+		},
+		{
+			desc:  "spatial-batch-norm",
+			layer: layers.NewSpatialBatchNorm[float64](layers.Shape{Channels: 2, Height: 2, Width: 3}, 0, 0),
+		},
+		{
+			desc:  "layer-norm",
+			layer: layers.NewLayerNorm[float64](4, 0),
+		},
+		{
+			desc:  "sequence-layer-norm",
+			layer: layers.NewSequenceLayerNorm[float64](3, 2, 0),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			tC.layer.Weights().Set(0, 0, 1.5)
+			tC.layer.Bias().Set(1, 0, -0.5)
+			X := randomMatrix(tC.layer.InputSize()[0], 4)
+
+			// Act & Assert
+			checkInputGradient(t, tC.layer, X)
+		})
+	}
+}
+
+func TestLayerNorm_GammaGradient(t *testing.T) {
+	// Arrange
+	ln := layers.NewSequenceLayerNorm[float64](2, 3, 0)
+	X := randomMatrix(ln.InputSize()[0], 3)
+	output, _ := ln.ForwardPropagate(X)
+	R := randomMatrix(output[1].RowCount(), output[1].ColumnCount())
+
+	numerical := make([]float64, 3)
+	const h = 1e-6
+	for i := range numerical {
+		ln.Weights().Set(i, 0, 1+h)
+		plus, _ := ln.ForwardPropagate(X)
+		ln.Weights().Set(i, 0, 1-h)
+		minus, _ := ln.ForwardPropagate(X)
+		ln.Weights().Set(i, 0, 1)
+		// gradients are averaged over the samples
+		numerical[i] = (weightedSum(R, plus[1]) - weightedSum(R, minus[1])) / (2 * h) / 3
+	}
+
+	// Act - with SGD and learning rate of 1, the weight change is the gradient itself
+	ln.BackPropagate(R, X, output, utils.NeuralNetworkParameters[float64]{InitialLearningRate: 1})
+
+	// Assert
+	for i, want := range numerical {
+		after, _ := ln.Weights().At(i, 0)
+		if math.Abs((1-after)-want) > 1e-5 {
+			t.Errorf("dGamma(%d) = %v, want %v", i, 1-after, want)
+		}
+	}
+}
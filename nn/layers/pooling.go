@@ -0,0 +1,282 @@
+package layers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/Hukyl/mlgo/activation"
+	. "github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/utils"
+	. "golang.org/x/exp/constraints"
+)
+
+type pool2D[T Float] struct {
+	inputShape Shape
+	poolSize   [2]int
+	stride     [2]int
+	average    bool
+}
+
+func (p *pool2D[T]) InputShape() Shape {
+	return p.inputShape
+}
+
+func (p *pool2D[T]) OutputShape() Shape {
+	return Shape{
+		Channels: p.inputShape.Channels,
+		Height:   windowOutputSize(p.inputShape.Height, p.poolSize[0], p.stride[0], 0, 1),
+		Width:    windowOutputSize(p.inputShape.Width, p.poolSize[1], p.stride[1], 0, 1),
+	}
+}
+
+func (p *pool2D[T]) InputSize() [2]int {
+	return [2]int{p.InputShape().Size(), 1}
+}
+
+func (p *pool2D[T]) OutputSize() [2]int {
+	return [2]int{p.OutputShape().Size(), 1}
+}
+
+func (p *pool2D[T]) IsTraining() bool {
+	return false
+}
+
+func (p *pool2D[T]) Weights() Matrix[T] {
+	return nil
+}
+
+func (p *pool2D[T]) Bias() Matrix[T] {
+	return nil
+}
+
+func (p *pool2D[T]) Activation() activation.ActivationFunction[T] {
+	return nil
+}
+
+/****************************************************************************/
+
+// forEachWindow calls f for each output element of a sample with the output row
+// and the input rows of the pooling window.
+func (p *pool2D[T]) forEachWindow(f func(outputRow int, window []int)) {
+	in, out := p.inputShape, p.OutputShape()
+	window := make([]int, 0, p.poolSize[0]*p.poolSize[1])
+
+	for c := 0; c < out.Channels; c++ {
+		for oh := 0; oh < out.Height; oh++ {
+			for ow := 0; ow < out.Width; ow++ {
+				window = window[:0]
+				for i := 0; i < p.poolSize[0]; i++ {
+					for j := 0; j < p.poolSize[1]; j++ {
+						window = append(window, in.index(c, oh*p.stride[0]+i, ow*p.stride[1]+j))
+					}
+				}
+				f(out.index(c, oh, ow), window)
+			}
+		}
+	}
+}
+
+func (p *pool2D[T]) ForwardPropagate(X Matrix[T]) (Y [2]Matrix[T], err error) {
+	if X.RowCount() != p.inputShape.Size() {
+		return Y, errors.New("invalid input size")
+	}
+	output := NewZeroMatrix[T](p.OutputShape().Size(), X.ColumnCount())
+
+	for n := 0; n < X.ColumnCount(); n++ {
+		p.forEachWindow(func(outputRow int, window []int) {
+			value := T(math.Inf(-1))
+			if p.average {
+				value = 0
+			}
+			for _, row := range window {
+				v, _ := X.At(row, n)
+				if p.average {
+					value += v / T(len(window))
+				} else {
+					value = max(value, v)
+				}
+			}
+			output.Set(outputRow, n, value)
+		})
+	}
+
+	Y = [2]Matrix[T]{output, output}
+	return Y, nil
+}
+
+// BackPropagate distributes the gradient of each output element to its pooling window.
+// For max pooling, the whole gradient goes to the (first) maximum element of the window,
+// while for average pooling it is split equally between the window elements.
+func (p *pool2D[T]) BackPropagate(nextLayerPropagation, X Matrix[T], A [2]Matrix[T], parameters utils.NeuralNetworkParameters[T]) Matrix[T] {
+	result := NewZeroMatrix[T](p.inputShape.Size(), X.ColumnCount())
+
+	for n := 0; n < X.ColumnCount(); n++ {
+		p.forEachWindow(func(outputRow int, window []int) {
+			gradient, _ := nextLayerPropagation.At(outputRow, n)
+			if p.average {
+				for _, row := range window {
+					current, _ := result.At(row, n)
+					result.Set(row, n, current+gradient/T(len(window)))
+				}
+				return
+			}
+			maxRow := window[0]
+			maxValue, _ := X.At(maxRow, n)
+			for _, row := range window[1:] {
+				if v, _ := X.At(row, n); v > maxValue {
+					maxRow, maxValue = row, v
+				}
+			}
+			current, _ := result.At(maxRow, n)
+			result.Set(maxRow, n, current+gradient)
+		})
+	}
+	return result
+}
+
+/****************************************************************************/
+
+func (p *pool2D[T]) typeName() string {
+	if p.average {
+		return "AveragePool2D"
+	}
+	return "MaxPool2D"
+}
+
+func (p *pool2D[T]) String() string {
+	return fmt.Sprintf(
+		"%s{%s -> %s, pool: %dx%d}",
+		p.typeName(), p.InputShape(), p.OutputShape(), p.poolSize[0], p.poolSize[1],
+	)
+}
+
+func (p *pool2D[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		InputShape Shape
+		PoolSize   [2]int
+		Stride     [2]int
+		Type       string
+	}{
+		InputShape: p.inputShape,
+		PoolSize:   p.poolSize,
+		Stride:     p.stride,
+		Type:       p.typeName(),
+	})
+}
+
+func (p *pool2D[T]) UnmarshalJSON(data []byte) error {
+	var v struct {
+		InputShape Shape
+		PoolSize   [2]int
+		Stride     [2]int
+		Type       string
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return errors.Join(errors.New("invalid pooling layer"), err)
+	}
+	p.inputShape = v.InputShape
+	p.poolSize = v.PoolSize
+	p.stride = v.Stride
+	p.average = v.Type == "AveragePool2D"
+	return nil
+}
+
+/****************************************************************************/
+
+type globalAveragePool[T Float] struct {
+	inputShape Shape
+}
+
+func (g *globalAveragePool[T]) InputShape() Shape {
+	return g.inputShape
+}
+
+func (g *globalAveragePool[T]) OutputShape() Shape {
+	return Shape{Channels: g.inputShape.Channels, Height: 1, Width: 1}
+}
+
+func (g *globalAveragePool[T]) InputSize() [2]int {
+	return [2]int{g.inputShape.Size(), 1}
+}
+
+func (g *globalAveragePool[T]) OutputSize() [2]int {
+	return [2]int{g.inputShape.Channels, 1}
+}
+
+func (g *globalAveragePool[T]) IsTraining() bool {
+	return false
+}
+
+func (g *globalAveragePool[T]) Weights() Matrix[T] {
+	return nil
+}
+
+func (g *globalAveragePool[T]) Bias() Matrix[T] {
+	return nil
+}
+
+func (g *globalAveragePool[T]) Activation() activation.ActivationFunction[T] {
+	return nil
+}
+
+// ForwardPropagate averages each channel of the input over all its positions.
+func (g *globalAveragePool[T]) ForwardPropagate(X Matrix[T]) (Y [2]Matrix[T], err error) {
+	if X.RowCount() != g.inputShape.Size() {
+		return Y, errors.New("invalid input size")
+	}
+	positions := g.inputShape.Height * g.inputShape.Width
+	output := NewZeroMatrix[T](g.inputShape.Channels, X.ColumnCount())
+	for n := 0; n < X.ColumnCount(); n++ {
+		for c := 0; c < g.inputShape.Channels; c++ {
+			var sum T
+			for l := 0; l < positions; l++ {
+				v, _ := X.At(c*positions+l, n)
+				sum += v
+			}
+			output.Set(c, n, sum/T(positions))
+		}
+	}
+	Y = [2]Matrix[T]{output, output}
+	return Y, nil
+}
+
+func (g *globalAveragePool[T]) BackPropagate(nextLayerPropagation, X Matrix[T], A [2]Matrix[T], parameters utils.NeuralNetworkParameters[T]) Matrix[T] {
+	positions := g.inputShape.Height * g.inputShape.Width
+	result := NewZeroMatrix[T](g.inputShape.Size(), X.ColumnCount())
+	for n := 0; n < X.ColumnCount(); n++ {
+		for c := 0; c < g.inputShape.Channels; c++ {
+			gradient, _ := nextLayerPropagation.At(c, n)
+			for l := 0; l < positions; l++ {
+				result.Set(c*positions+l, n, gradient/T(positions))
+			}
+		}
+	}
+	return result
+}
+
+func (g *globalAveragePool[T]) String() string {
+	return fmt.Sprintf("GlobalAveragePool{%s -> %d}", g.inputShape, g.inputShape.Channels)
+}
+
+func (g *globalAveragePool[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		InputShape Shape
+		Type       string
+	}{
+		InputShape: g.inputShape,
+		Type:       "GlobalAveragePool",
+	})
+}
+
+func (g *globalAveragePool[T]) UnmarshalJSON(data []byte) error {
+	var v struct {
+		InputShape Shape
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return errors.Join(errors.New("invalid global average pooling layer"), err)
+	}
+	g.inputShape = v.InputShape
+	return nil
+}
@@ -0,0 +1,111 @@
+package layers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/Hukyl/mlgo/activation"
+	. "github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/utils"
+	. "golang.org/x/exp/constraints"
+)
+
+// positionalEncoding adds the sinusoidal encoding of the position to each time step
+// of the sequence, so that the attention layers are able to distinguish the time steps:
+//
+//	PE(t, 2i) = sin(t / 10000^(2i/dimension))
+//	PE(t, 2i+1) = cos(t / 10000^(2i/dimension))
+type positionalEncoding[T Float] struct {
+	timeSteps int
+	dimension int
+}
+
+func (p *positionalEncoding[T]) InputSize() [2]int {
+	return [2]int{p.timeSteps * p.dimension, 1}
+}
+
+func (p *positionalEncoding[T]) OutputSize() [2]int {
+	return p.InputSize()
+}
+
+func (p *positionalEncoding[T]) IsTraining() bool {
+	return false
+}
+
+func (p *positionalEncoding[T]) Weights() Matrix[T] {
+	return nil
+}
+
+func (p *positionalEncoding[T]) Bias() Matrix[T] {
+	return nil
+}
+
+func (p *positionalEncoding[T]) Activation() activation.ActivationFunction[T] {
+	return nil
+}
+
+/****************************************************************************/
+
+// encoding returns the positional encoding of the element d of the time step t.
+func (p *positionalEncoding[T]) encoding(t, d int) float64 {
+	angle := float64(t) / math.Pow(10000, float64(d-d%2)/float64(p.dimension))
+	if d%2 == 0 {
+		return math.Sin(angle)
+	}
+	return math.Cos(angle)
+}
+
+func (p *positionalEncoding[T]) ForwardPropagate(X Matrix[T]) (Y [2]Matrix[T], err error) {
+	if X.RowCount() != p.InputSize()[0] {
+		return Y, errors.New("invalid input size")
+	}
+	output := X.DeepCopy()
+	for t := 0; t < p.timeSteps; t++ {
+		for d := 0; d < p.dimension; d++ {
+			e := p.encoding(t, d)
+			for n := 0; n < X.ColumnCount(); n++ {
+				v, _ := output.At(t*p.dimension+d, n)
+				output.Set(t*p.dimension+d, n, v+T(e))
+			}
+		}
+	}
+	Y = [2]Matrix[T]{output, output}
+	return Y, nil
+}
+
+// BackPropagate returns the gradient as is, as the encoding is constant.
+func (p *positionalEncoding[T]) BackPropagate(nextLayerPropagation, X Matrix[T], A [2]Matrix[T], parameters utils.NeuralNetworkParameters[T]) Matrix[T] {
+	return nextLayerPropagation
+}
+
+/****************************************************************************/
+
+func (p *positionalEncoding[T]) String() string {
+	return fmt.Sprintf("PositionalEncoding{%dx%d}", p.timeSteps, p.dimension)
+}
+
+func (p *positionalEncoding[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		TimeSteps int
+		Dimension int
+		Type      string
+	}{
+		TimeSteps: p.timeSteps,
+		Dimension: p.dimension,
+		Type:      "PositionalEncoding",
+	})
+}
+
+func (p *positionalEncoding[T]) UnmarshalJSON(data []byte) error {
+	var v struct {
+		TimeSteps int
+		Dimension int
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return errors.Join(errors.New("invalid positional encoding"), err)
+	}
+	p.timeSteps, p.dimension = v.TimeSteps, v.Dimension
+	return nil
+}
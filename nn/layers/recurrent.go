@@ -0,0 +1,365 @@
+package layers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/Hukyl/mlgo/activation"
+	. "github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/utils"
+	. "golang.org/x/exp/constraints"
+)
+
+// RecurrentParameters contains the hyperparameters of a recurrent layer.
+//
+// Each sample is a sequence of TimeSteps vectors of InputSize features, stored
+// one after another, i.e. the element f of the time step t is stored in the row
+//
+//	t*InputSize + f
+//
+// HiddenSize is the size of the hidden state of the layer.
+//
+// ReturnSequences defines whether the layer outputs the hidden states for all the
+// time steps (in the same layout as the input), or only the last one.
+type RecurrentParameters struct {
+	TimeSteps       int
+	InputSize       int
+	HiddenSize      int
+	ReturnSequences bool
+}
+
+// cellState is the state of a recurrent cell between the time steps. The cell
+// state c is used only by LSTM, and is nil for the other cells.
+type cellState[T Float] struct {
+	h Matrix[T]
+	c Matrix[T]
+}
+
+// recurrentCell computes a single time step of a recurrent layer for the whole batch.
+//
+// The parameters of the cell are stored as a single weight matrix [Wx | Wh] of size
+// (gateCount*HiddenSize)x(InputSize+HiddenSize) and a bias of (gateCount*HiddenSize)x1.
+//
+// step computes the next state from the input x and the previous state, and returns
+// the cache, which is then used by stepBack.
+//
+// stepBack propagates the gradient of the next state to the input and the previous
+// state, returning the gradients of the weights and bias for this time step as well.
+type recurrentCell[T Float] interface {
+	name() string
+	gateCount() int
+	step(W, b, x Matrix[T], previous cellState[T]) (next cellState[T], cache any)
+	stepBack(W Matrix[T], cache any, dNext cellState[T]) (dx Matrix[T], dPrevious cellState[T], dW, db Matrix[T])
+}
+
+// newRecurrentCell returns the cell with the given name, or false if it is unknown.
+func newRecurrentCell[T Float](name string) (recurrentCell[T], bool) {
+	switch name {
+	case "SimpleRNN":
+		return simpleRNNCell[T]{}, true
+	case "LSTM":
+		return lstmCell[T]{}, true
+	case "GRU":
+		return gruCell[T]{}, true
+	}
+	return nil, false
+}
+
+// recurrent unrolls a recurrent cell over the time steps of the input. If reverse
+// is set, the time steps are processed from the last one, though the output is
+// still stored in the original time order.
+type recurrent[T Float] struct {
+	parameters RecurrentParameters
+	cell       recurrentCell[T]
+	reverse    bool
+	weights    Matrix[T] // (gateCount*HiddenSize)x(InputSize+HiddenSize)
+	bias       Matrix[T] // (gateCount*HiddenSize)x1
+}
+
+func (r *recurrent[T]) InputSize() [2]int {
+	return [2]int{r.parameters.TimeSteps * r.parameters.InputSize, 1}
+}
+
+func (r *recurrent[T]) OutputSize() [2]int {
+	if r.parameters.ReturnSequences {
+		return [2]int{r.parameters.TimeSteps * r.parameters.HiddenSize, 1}
+	}
+	return [2]int{r.parameters.HiddenSize, 1}
+}
+
+func (r *recurrent[T]) IsTraining() bool {
+	return false
+}
+
+func (r *recurrent[T]) Weights() Matrix[T] {
+	return r.weights
+}
+
+func (r *recurrent[T]) Bias() Matrix[T] {
+	return r.bias
+}
+
+func (r *recurrent[T]) Activation() activation.ActivationFunction[T] {
+	return nil
+}
+
+// State returns the weights and the bias of the gates.
+func (r *recurrent[T]) State() []*Matrix[T] {
+	return []*Matrix[T]{&r.weights, &r.bias}
+}
+
+/****************************************************************************/
+
+// timeOrder returns the time steps in the order of processing.
+func (r *recurrent[T]) timeOrder() []int {
+	order := make([]int, r.parameters.TimeSteps)
+	for i := range order {
+		if r.reverse {
+			order[i] = len(order) - 1 - i
+		} else {
+			order[i] = i
+		}
+	}
+	return order
+}
+
+// unroll runs the cell over the time steps, returning the state and the cache
+// after each time step (in the original time order).
+func (r *recurrent[T]) unroll(X Matrix[T]) (states []cellState[T], caches []any) {
+	p := r.parameters
+	states = make([]cellState[T], p.TimeSteps)
+	caches = make([]any, p.TimeSteps)
+
+	state := cellState[T]{
+		h: NewZeroMatrix[T](p.HiddenSize, X.ColumnCount()),
+		c: NewZeroMatrix[T](p.HiddenSize, X.ColumnCount()),
+	}
+	for _, t := range r.timeOrder() {
+		x := rowRange(X, t*p.InputSize, (t+1)*p.InputSize)
+		state, caches[t] = r.cell.step(r.weights, r.bias, x, state)
+		states[t] = state
+	}
+	return states, caches
+}
+
+// ForwardPropagate unrolls the layer over the time steps of X, producing either
+// the hidden states for all time steps, or only the last one. As the activations
+// are a part of the cell, both output matrices are the same.
+//
+// Input has to be of r.InputSize() size, otherwise error is returned.
+func (r *recurrent[T]) ForwardPropagate(X Matrix[T]) (Y [2]Matrix[T], err error) {
+	if X.RowCount() != r.InputSize()[0] {
+		return Y, errors.New("invalid input size")
+	}
+	states, _ := r.unroll(X)
+
+	var output Matrix[T]
+	if r.parameters.ReturnSequences {
+		hidden := make([]Matrix[T], len(states))
+		for t, state := range states {
+			hidden[t] = state.h
+		}
+		output = stackRows(hidden...)
+	} else {
+		order := r.timeOrder()
+		output = states[order[len(order)-1]].h
+	}
+
+	Y = [2]Matrix[T]{output, output}
+	return Y, nil
+}
+
+// BackPropagate performs backpropagation through time. The gradient of the hidden
+// state at each time step is the sum of the gradient of the layer output (if the
+// state is a part of it) and the gradient from the next time step. The gradients
+// carried between the time steps are clipped by parameters.ClipValue to prevent
+// them from exploding.
+//
+// The gradients of the weights and bias are summed over the time steps and averaged
+// over the samples.
+func (r *recurrent[T]) BackPropagate(nextLayerPropagation, X Matrix[T], A [2]Matrix[T], parameters utils.NeuralNetworkParameters[T]) Matrix[T] {
+	p := r.parameters
+	_, caches := r.unroll(X)
+	order := r.timeOrder()
+
+	result := NewZeroMatrix[T](X.RowCount(), X.ColumnCount())
+	dW := NewZeroMatrix[T](r.weights.RowCount(), r.weights.ColumnCount())
+	db := NewZeroMatrix[T](r.bias.RowCount(), 1)
+	dState := cellState[T]{
+		h: NewZeroMatrix[T](p.HiddenSize, X.ColumnCount()),
+		c: NewZeroMatrix[T](p.HiddenSize, X.ColumnCount()),
+	}
+
+	for i := len(order) - 1; i >= 0; i-- {
+		t := order[i]
+		if p.ReturnSequences {
+			dState.h, _ = dState.h.Add(rowRange(nextLayerPropagation, t*p.HiddenSize, (t+1)*p.HiddenSize))
+		} else if i == len(order)-1 {
+			dState.h, _ = dState.h.Add(nextLayerPropagation)
+		}
+
+		dx, dPrevious, dWt, dbt := r.cell.stepBack(r.weights, caches[t], dState)
+		setRowRange(result, t*p.InputSize, dx)
+		dW, _ = dW.Add(dWt)
+		db, _ = db.Add(dbt)
+
+		dState.h = clipGradient(dPrevious.h, parameters)
+		if dPrevious.c != nil {
+			dState.c = clipGradient(dPrevious.c, parameters)
+		}
+	}
+
+	samples := T(X.ColumnCount())
+	r.updateWeights(dW.MultiplyByScalar(1/samples), db.MultiplyByScalar(1/samples), parameters)
+
+	return result
+}
+
+func (r *recurrent[T]) updateWeights(dW, db Matrix[T], parameters utils.NeuralNetworkParameters[T]) {
+	updateParameter(&r.weights, dW, parameters)
+	updateParameter(&r.bias, db, parameters)
+}
+
+/****************************************************************************/
+
+func (r *recurrent[T]) String() string {
+	return fmt.Sprintf(
+		"%s{%dx%d -> %d, return sequences: %t}",
+		r.cell.name(),
+		r.parameters.TimeSteps,
+		r.parameters.InputSize,
+		r.parameters.HiddenSize,
+		r.parameters.ReturnSequences,
+	)
+}
+
+func (r *recurrent[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Parameters RecurrentParameters
+		Reverse    bool
+		Weights    Matrix[T]
+		Bias       Matrix[T]
+		Type       string
+	}{
+		Parameters: r.parameters,
+		Reverse:    r.reverse,
+		Weights:    r.weights,
+		Bias:       r.bias,
+		Type:       r.cell.name(),
+	})
+}
+
+func (r *recurrent[T]) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Parameters RecurrentParameters
+		Reverse    bool
+		Weights    json.RawMessage
+		Bias       json.RawMessage
+		Type       string
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return errors.Join(errors.New("invalid recurrent layer"), err)
+	}
+	cell, ok := newRecurrentCell[T](v.Type)
+	if !ok {
+		return fmt.Errorf("unknown recurrent layer type: %s", v.Type)
+	}
+	r.parameters, r.reverse, r.cell = v.Parameters, v.Reverse, cell
+
+	w, _ := NewMatrix([][]T{{}})
+	if err := w.UnmarshalJSON(v.Weights); err != nil {
+		return errors.Join(errors.New("invalid weight initializing"), err)
+	}
+	b, _ := NewMatrix([][]T{{}})
+	if err := b.UnmarshalJSON(v.Bias); err != nil {
+		return errors.Join(errors.New("invalid bias initializing"), err)
+	}
+	rows := r.cell.gateCount() * r.parameters.HiddenSize
+	columns := r.parameters.InputSize + r.parameters.HiddenSize
+	if w.RowCount() != rows || w.ColumnCount() != columns || b.RowCount() != rows {
+		return errors.New("invalid weight or bias size")
+	}
+	r.weights, r.bias = w, b
+	return nil
+}
+
+/****************************************************************************/
+
+// rowRange returns the rows [from, to) of the matrix, sharing the elements with it.
+func rowRange[T Float](M Matrix[T], from, to int) Matrix[T] {
+	result, _ := M.Slice(from, to, 0, M.ColumnCount())
+	return result
+}
+
+// setRowRange copies the block into the matrix, starting from the given row.
+func setRowRange[T Float](M Matrix[T], from int, block Matrix[T]) {
+	for i := 0; i < block.RowCount(); i++ {
+		for j := 0; j < block.ColumnCount(); j++ {
+			v, _ := block.At(i, j)
+			M.Set(from+i, j, v)
+		}
+	}
+}
+
+// stackRows concatenates the matrices with the same number of columns vertically.
+func stackRows[T Float](ms ...Matrix[T]) Matrix[T] {
+	result, _ := VStack(ms...)
+	return result
+}
+
+// elementwise produces a matrix, each element of which is f applied to the
+// corresponding elements of the matrices of the same size.
+func elementwise[T Float](f func(v ...float64) float64, ms ...Matrix[T]) Matrix[T] {
+	result := NewZeroMatrix[T](ms[0].RowCount(), ms[0].ColumnCount())
+	values := make([]float64, len(ms))
+	for i := 0; i < result.RowCount(); i++ {
+		for j := 0; j < result.ColumnCount(); j++ {
+			for k, m := range ms {
+				v, _ := m.At(i, j)
+				values[k] = float64(v)
+			}
+			result.Set(i, j, T(f(values...)))
+		}
+	}
+	return result
+}
+
+// affine computes W @ x + b, where b is broadcasted over the columns.
+func affine[T Float](W, b, x Matrix[T]) Matrix[T] {
+	result, _ := W.Multiply(x)
+	for i := 0; i < result.RowCount(); i++ {
+		bias, _ := b.At(i, 0)
+		for j := 0; j < result.ColumnCount(); j++ {
+			v, _ := result.At(i, j)
+			result.Set(i, j, v+bias)
+		}
+	}
+	return result
+}
+
+// affineBack propagates the gradient dZ of affine(W, b, x), returning the gradients
+// with respect to x, W and b.
+func affineBack[T Float](W, x, dZ Matrix[T]) (dx, dW, db Matrix[T]) {
+	dx, _ = W.T().Multiply(dZ)
+	dW, _ = dZ.Multiply(x.T())
+	db, _ = dZ.Multiply(NewOnesMatrix[T](dZ.ColumnCount(), 1))
+	return dx, dW, db
+}
+
+func sigmoid[T Float](x T) T {
+	return T(1 / (1 + math.Exp(-float64(x))))
+}
+
+func tanh[T Float](x T) T {
+	return T(math.Tanh(float64(x)))
+}
+
+// clipGradient clips the gradient by parameters.ClipValue, if it is set.
+func clipGradient[T Float](gradient Matrix[T], parameters utils.NeuralNetworkParameters[T]) Matrix[T] {
+	if parameters.ClipValue <= 0 || math.IsInf(parameters.ClipValue, 1) {
+		return gradient
+	}
+	return Clip(gradient, -T(parameters.ClipValue), T(parameters.ClipValue))
+}
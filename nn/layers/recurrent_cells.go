@@ -0,0 +1,185 @@
+package layers
+
+import (
+	. "github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
+)
+
+// simpleRNNCell is the fully-connected recurrent cell
+//
+//	h' = tanh(Wx @ x + Wh @ h + b)
+type simpleRNNCell[T Float] struct{}
+
+type simpleRNNCache[T Float] struct {
+	input Matrix[T] // [x; h]
+	next  Matrix[T] // h'
+}
+
+func (simpleRNNCell[T]) name() string {
+	return "SimpleRNN"
+}
+
+func (simpleRNNCell[T]) gateCount() int {
+	return 1
+}
+
+func (simpleRNNCell[T]) step(W, b, x Matrix[T], previous cellState[T]) (cellState[T], any) {
+	input := stackRows(x, previous.h)
+	next := affine(W, b, input)
+	ApplyByElement(next, tanh[T])
+	return cellState[T]{h: next}, simpleRNNCache[T]{input: input, next: next}
+}
+
+func (simpleRNNCell[T]) stepBack(W Matrix[T], cache any, dNext cellState[T]) (Matrix[T], cellState[T], Matrix[T], Matrix[T]) {
+	c := cache.(simpleRNNCache[T])
+	dZ := elementwise(func(v ...float64) float64 { return v[0] * (1 - v[1]*v[1]) }, dNext.h, c.next)
+	dInput, dW, db := affineBack(W, c.input, dZ)
+
+	inputSize := dInput.RowCount() - dNext.h.RowCount()
+	dx := rowRange(dInput, 0, inputSize)
+	dh := rowRange(dInput, inputSize, dInput.RowCount())
+	return dx, cellState[T]{h: dh}, dW, db
+}
+
+/****************************************************************************/
+
+// lstmCell is the long short-term memory cell with the input (i), forget (f),
+// candidate (g) and output (o) gates, computed in this order:
+//
+//	[i; f; g; o] = [σ; σ; tanh; σ](W @ [x; h] + b)
+//	c' = f * c + i * g
+//	h' = o * tanh(c')
+type lstmCell[T Float] struct{}
+
+type lstmCache[T Float] struct {
+	input      Matrix[T] // [x; h]
+	previous   Matrix[T] // c
+	i, f, g, o Matrix[T]
+	tanhC      Matrix[T] // tanh(c')
+}
+
+func (lstmCell[T]) name() string {
+	return "LSTM"
+}
+
+func (lstmCell[T]) gateCount() int {
+	return 4
+}
+
+func (lstmCell[T]) step(W, b, x Matrix[T], previous cellState[T]) (cellState[T], any) {
+	hiddenSize := previous.h.RowCount()
+	input := stackRows(x, previous.h)
+	Z := affine(W, b, input)
+
+	cache := lstmCache[T]{
+		input:    input,
+		previous: previous.c,
+		i:        rowRange(Z, 0, hiddenSize),
+		f:        rowRange(Z, hiddenSize, 2*hiddenSize),
+		g:        rowRange(Z, 2*hiddenSize, 3*hiddenSize),
+		o:        rowRange(Z, 3*hiddenSize, 4*hiddenSize),
+	}
+	ApplyByElement(cache.i, sigmoid[T])
+	ApplyByElement(cache.f, sigmoid[T])
+	ApplyByElement(cache.g, tanh[T])
+	ApplyByElement(cache.o, sigmoid[T])
+
+	c := elementwise(func(v ...float64) float64 { return v[0]*v[1] + v[2]*v[3] }, cache.f, previous.c, cache.i, cache.g)
+	cache.tanhC = c.DeepCopy()
+	ApplyByElement(cache.tanhC, tanh[T])
+	h, _ := cache.o.MultiplyElementwise(cache.tanhC)
+
+	return cellState[T]{h: h, c: c}, cache
+}
+
+func (lstmCell[T]) stepBack(W Matrix[T], cache any, dNext cellState[T]) (Matrix[T], cellState[T], Matrix[T], Matrix[T]) {
+	c := cache.(lstmCache[T])
+	// total gradient of c' = dc' + dh' * o * (1 - tanh(c')^2)
+	dC := elementwise(
+		func(v ...float64) float64 { return v[0] + v[1]*v[2]*(1-v[3]*v[3]) },
+		dNext.c, dNext.h, c.o, c.tanhC,
+	)
+	sigmoidGradient := func(v ...float64) float64 { return v[0] * v[1] * v[2] * (1 - v[2]) }
+	dZ := stackRows(
+		elementwise(sigmoidGradient, dC, c.g, c.i),
+		elementwise(sigmoidGradient, dC, c.previous, c.f),
+		elementwise(func(v ...float64) float64 { return v[0] * v[1] * (1 - v[2]*v[2]) }, dC, c.i, c.g),
+		elementwise(sigmoidGradient, dNext.h, c.tanhC, c.o),
+	)
+	dInput, dW, db := affineBack(W, c.input, dZ)
+	dPrevious, _ := dC.MultiplyElementwise(c.f)
+
+	inputSize := dInput.RowCount() - dNext.h.RowCount()
+	dx := rowRange(dInput, 0, inputSize)
+	dh := rowRange(dInput, inputSize, dInput.RowCount())
+	return dx, cellState[T]{h: dh, c: dPrevious}, dW, db
+}
+
+/****************************************************************************/
+
+// gruCell is the gated recurrent unit with the update (z) and reset (r) gates,
+// and the candidate state (n):
+//
+//	[z; r] = σ(Wzr @ [x; h] + bzr)
+//	n = tanh(Wn @ [x; r * h] + bn)
+//	h' = (1 - z) * n + z * h
+type gruCell[T Float] struct{}
+
+type gruCache[T Float] struct {
+	input      Matrix[T] // [x; h]
+	resetInput Matrix[T] // [x; r * h]
+	previous   Matrix[T] // h
+	z, r, n    Matrix[T]
+}
+
+func (gruCell[T]) name() string {
+	return "GRU"
+}
+
+func (gruCell[T]) gateCount() int {
+	return 3
+}
+
+func (gruCell[T]) step(W, b, x Matrix[T], previous cellState[T]) (cellState[T], any) {
+	hiddenSize := previous.h.RowCount()
+	input := stackRows(x, previous.h)
+	gates := affine(rowRange(W, 0, 2*hiddenSize), rowRange(b, 0, 2*hiddenSize), input)
+	ApplyByElement(gates, sigmoid[T])
+
+	cache := gruCache[T]{
+		input:    input,
+		previous: previous.h,
+		z:        rowRange(gates, 0, hiddenSize),
+		r:        rowRange(gates, hiddenSize, 2*hiddenSize),
+	}
+	resetState, _ := cache.r.MultiplyElementwise(previous.h)
+	cache.resetInput = stackRows(x, resetState)
+	cache.n = affine(rowRange(W, 2*hiddenSize, 3*hiddenSize), rowRange(b, 2*hiddenSize, 3*hiddenSize), cache.resetInput)
+	ApplyByElement(cache.n, tanh[T])
+
+	h := elementwise(func(v ...float64) float64 { return (1-v[0])*v[1] + v[0]*v[2] }, cache.z, cache.n, previous.h)
+	return cellState[T]{h: h}, cache
+}
+
+func (gruCell[T]) stepBack(W Matrix[T], cache any, dNext cellState[T]) (Matrix[T], cellState[T], Matrix[T], Matrix[T]) {
+	c := cache.(gruCache[T])
+	hiddenSize := dNext.h.RowCount()
+	inputSize := c.input.RowCount() - hiddenSize
+
+	dZn := elementwise(func(v ...float64) float64 { return v[0] * (1 - v[1]) * (1 - v[2]*v[2]) }, dNext.h, c.z, c.n)
+	dResetInput, dWn, dbn := affineBack(rowRange(W, 2*hiddenSize, 3*hiddenSize), c.resetInput, dZn)
+	dResetState := rowRange(dResetInput, inputSize, dResetInput.RowCount())
+
+	sigmoidGradient := func(v ...float64) float64 { return v[0] * v[1] * (1 - v[1]) }
+	dUpdate := elementwise(func(v ...float64) float64 { return v[0] * (v[1] - v[2]) }, dNext.h, c.previous, c.n)
+	dReset, _ := dResetState.MultiplyElementwise(c.previous)
+	dGates := stackRows(elementwise(sigmoidGradient, dUpdate, c.z), elementwise(sigmoidGradient, dReset, c.r))
+	dInput, dWzr, dbzr := affineBack(rowRange(W, 0, 2*hiddenSize), c.input, dGates)
+
+	dx, _ := rowRange(dInput, 0, inputSize).Add(rowRange(dResetInput, 0, inputSize))
+	dh := elementwise(
+		func(v ...float64) float64 { return v[0]*v[1] + v[2]*v[3] + v[4] },
+		dNext.h, c.z, dResetState, c.r, rowRange(dInput, inputSize, dInput.RowCount()),
+	)
+	return dx, cellState[T]{h: dh}, stackRows(dWzr, dWn), stackRows(dbzr, dbn)
+}
@@ -0,0 +1,186 @@
+package layers_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/nn/layers"
+	"github.com/Hukyl/mlgo/utils"
+)
+
+// checkParameterGradients compares the weight and bias updates of the layer (with SGD
+// and learning rate of 1) with the numerical derivatives of sum(R * layer(X)), averaged
+// over the samples.
+func checkParameterGradients(t *testing.T, layer layers.Layer[float64], X matrix.Matrix[float64]) {
+	t.Helper()
+	output, _ := layer.ForwardPropagate(X)
+	R := randomMatrix(output[1].RowCount(), output[1].ColumnCount())
+	samples := float64(X.ColumnCount())
+
+	parameters := []matrix.Matrix[float64]{layer.Weights(), layer.Bias()}
+	before := make([]matrix.Matrix[float64], len(parameters))
+	numerical := make([]matrix.Matrix[float64], len(parameters))
+	const h = 1e-6
+	for k, P := range parameters {
+		before[k] = P.DeepCopy()
+		numerical[k] = matrix.NewZeroMatrix[float64](P.RowCount(), P.ColumnCount())
+		for i := 0; i < P.RowCount(); i++ {
+			for j := 0; j < P.ColumnCount(); j++ {
+				v, _ := P.At(i, j)
+				P.Set(i, j, v+h)
+				plus, _ := layer.ForwardPropagate(X)
+				P.Set(i, j, v-h)
+				minus, _ := layer.ForwardPropagate(X)
+				P.Set(i, j, v)
+				numerical[k].Set(i, j, (weightedSum(R, plus[1])-weightedSum(R, minus[1]))/(2*h)/samples)
+			}
+		}
+	}
+
+	layer.BackPropagate(R, X, output, utils.NeuralNetworkParameters[float64]{InitialLearningRate: 1})
+
+	after := []matrix.Matrix[float64]{layer.Weights(), layer.Bias()}
+	for k := range parameters {
+		for i := 0; i < before[k].RowCount(); i++ {
+			for j := 0; j < before[k].ColumnCount(); j++ {
+				b, _ := before[k].At(i, j)
+				a, _ := after[k].At(i, j)
+				want, _ := numerical[k].At(i, j)
+				if math.Abs((b-a)-want) > 1e-5 {
+					t.Errorf("parameter %d: gradient(%d,%d) = %v, want %v", k, i, j, b-a, want)
+				}
+			}
+		}
+	}
+}
+
+type recurrentConstructor func(layers.RecurrentParameters, layers.WeightInitialization) (layers.Layer[float64], error)
+
+var recurrentConstructors = []struct {
+	name string
+	new  recurrentConstructor
+}{
+	{"simple-rnn", layers.NewSimpleRNN[float64]},
+	{"lstm", layers.NewLSTM[float64]},
+	{"gru", layers.NewGRU[float64]},
+}
+
+func TestSimpleRNN_ForwardPropagate(t *testing.T) {
+	// Arrange
+	parameters := layers.RecurrentParameters{TimeSteps: 2, InputSize: 1, HiddenSize: 1, ReturnSequences: true}
+	rnn, _ := layers.NewSimpleRNN[float64](parameters, layers.RandomInitialization{Min: 1, Max: 1})
+	X, _ := matrix.NewMatrix([][]float64{{0.5}, {-1}})
+
+	// Act
+	output, err := rnn.ForwardPropagate(X)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("ForwardPropagate error: %v", err)
+	}
+	h1 := math.Tanh(0.5)
+	want := []float64{h1, math.Tanh(-1 + h1)}
+	for i, w := range want {
+		if got, _ := output[1].At(i, 0); math.Abs(got-w) > 1e-12 {
+			t.Errorf("h(%d) = %v, want %v", i, got, w)
+		}
+	}
+}
+
+func TestRecurrent_OutputSize(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		returnSequences bool
+		bidirectional   bool
+		want            int
+	}{
+		{desc: "last-state", want: 3},
+		{desc: "sequences", returnSequences: true, want: 4 * 3},
+		{desc: "bidirectional-last-state", bidirectional: true, want: 2 * 3},
+		{desc: "bidirectional-sequences", returnSequences: true, bidirectional: true, want: 2 * 4 * 3},
+	}
+	for _, c := range recurrentConstructors {
+		for _, tC := range testCases {
+			t.Run(c.name+"/"+tC.desc, func(t *testing.T) {
+				// Arrange
+				parameters := layers.RecurrentParameters{
+					TimeSteps: 4, InputSize: 2, HiddenSize: 3, ReturnSequences: tC.returnSequences,
+				}
+				layer, _ := c.new(parameters, layers.XavierNormalInitialization{})
+				if tC.bidirectional {
+					backward, _ := c.new(parameters, layers.XavierNormalInitialization{})
+					layer, _ = layers.NewBidirectional(layer, backward)
+				}
+
+				// Act
+				output, err := layer.ForwardPropagate(randomMatrix(8, 5))
+
+				// Assert
+				if err != nil {
+					t.Fatalf("ForwardPropagate error: %v", err)
+				}
+				if got := output[1].Size(); got != [2]int{tC.want, 5} {
+					t.Errorf("output size = %v, want %v", got, [2]int{tC.want, 5})
+				}
+				if got := layer.OutputSize()[0]; got != tC.want {
+					t.Errorf("OutputSize = %v, want %v", got, tC.want)
+				}
+			})
+		}
+	}
+}
+
+func TestRecurrent_BackPropagate(t *testing.T) {
+	for _, c := range recurrentConstructors {
+		for _, returnSequences := range []bool{false, true} {
+			parameters := layers.RecurrentParameters{
+				TimeSteps: 3, InputSize: 2, HiddenSize: 3, ReturnSequences: returnSequences,
+			}
+			desc := c.name + "/last-state"
+			if returnSequences {
+				desc = c.name + "/sequences"
+			}
+			t.Run(desc, func(t *testing.T) {
+				// Arrange
+				layer, _ := c.new(parameters, layers.XavierNormalInitialization{})
+				X := randomMatrix(layer.InputSize()[0], 2)
+
+				// Act & Assert
+				checkInputGradient(t, layer, X)
+				checkParameterGradients(t, layer, X)
+			})
+			t.Run(desc+"/bidirectional", func(t *testing.T) {
+				// Arrange
+				forward, _ := c.new(parameters, layers.XavierNormalInitialization{})
+				backward, _ := c.new(parameters, layers.XavierNormalInitialization{})
+				layer, _ := layers.NewBidirectional(forward, backward)
+				X := randomMatrix(layer.InputSize()[0], 2)
+
+				// Act & Assert
+				checkInputGradient(t, layer, X)
+			})
+		}
+	}
+}
+
+func TestRecurrent_ClipValue(t *testing.T) {
+	// Arrange
+	parameters := layers.RecurrentParameters{TimeSteps: 2, InputSize: 1, HiddenSize: 1}
+	rnn, _ := layers.NewSimpleRNN[float64](parameters, layers.RandomInitialization{Min: 1, Max: 1})
+	X, _ := matrix.NewMatrix([][]float64{{0.1}, {0.1}})
+	output, _ := rnn.ForwardPropagate(X)
+	upstream, _ := matrix.NewMatrix([][]float64{{10}})
+
+	// Act
+	gradient := rnn.BackPropagate(upstream, X, output, utils.NeuralNetworkParameters[float64]{ClipValue: 0.01})
+
+	// Assert
+	// the gradient of the last step is not clipped, while the one carried to the first step is
+	if got, _ := gradient.At(1, 0); got < 1 {
+		t.Errorf("last step gradient = %v, want the unclipped one", got)
+	}
+	if got, _ := gradient.At(0, 0); math.Abs(got) > 0.01 {
+		t.Errorf("first step gradient = %v, want at most 0.01", got)
+	}
+}
@@ -0,0 +1,65 @@
+package layers
+
+import (
+	"fmt"
+	. "golang.org/x/exp/constraints"
+)
+
+// Shape describes the dimensions of a single sample for spatial layers, such as
+// convolutional and pooling ones.
+//
+// As each sample is a column of the input matrix, spatial samples are flattened
+// in channel-major order, i.e. the element (c, h, w) is stored in the row
+//
+//	c*Height*Width + h*Width + w
+//
+// So, for example, a batch of N grayscale MNIST images is a (1*28*28)xN matrix.
+type Shape struct {
+	Channels int
+	Height   int
+	Width    int
+}
+
+// Size returns the number of elements in a sample of such shape, i.e. the number
+// of rows in the input matrix.
+func (s Shape) Size() int {
+	return s.Channels * s.Height * s.Width
+}
+
+func (s Shape) String() string {
+	return fmt.Sprintf("%dx%dx%d", s.Channels, s.Height, s.Width)
+}
+
+// index returns the row of the element (c, h, w) in the flattened sample.
+func (s Shape) index(c, h, w int) int {
+	return (c*s.Height+h)*s.Width + w
+}
+
+// SpatialLayer is implemented by the layers, which operate on spatial samples.
+//
+// InputShape and OutputShape return the shapes of a single input and output sample.
+// Their sizes correspond to InputSize()[0] and OutputSize()[0] respectively.
+type SpatialLayer[T Float] interface {
+	Layer[T]
+
+	InputShape() Shape
+	OutputShape() Shape
+}
+
+// windowOutputSize computes the size of the output along one dimension, when
+// a window slides over the input.
+//
+//	output = (input + 2*padding - dilation*(window-1) - 1) / stride + 1
+func windowOutputSize(input, window, stride, padding, dilation int) int {
+	return (input+2*padding-dilation*(window-1)-1)/stride + 1
+}
+
+// valueOrDefault returns the value if it is positive, otherwise the default one.
+func valueOrDefault(value [2]int, defaultValue [2]int) [2]int {
+	for i := range value {
+		if value[i] <= 0 {
+			value[i] = defaultValue[i]
+		}
+	}
+	return value
+}
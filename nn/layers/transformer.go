@@ -0,0 +1,213 @@
+package layers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Hukyl/mlgo/activation"
+	. "github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/utils"
+	. "golang.org/x/exp/constraints"
+)
+
+// transformerEncoderBlock is the (post-normalization) encoder block of the Transformer:
+//
+//	H = LayerNorm(X + MultiHeadAttention(X))
+//	Y = LayerNorm(H + Dense(Dense(H)))
+//
+// where the feed-forward dense layers are applied to each time step separately,
+// the first one with the given activation function, and the second one with the linear one.
+type transformerEncoderBlock[T Float] struct {
+	attention     *multiHeadAttention[T]
+	attentionNorm *layerNorm[T]
+	hidden        *dense[T]
+	output        *dense[T]
+	outputNorm    *layerNorm[T]
+}
+
+func (b *transformerEncoderBlock[T]) InputSize() [2]int {
+	return b.attention.InputSize()
+}
+
+func (b *transformerEncoderBlock[T]) OutputSize() [2]int {
+	return b.attention.OutputSize()
+}
+
+func (b *transformerEncoderBlock[T]) IsTraining() bool {
+	return false
+}
+
+// Weights returns nil, as the parameters belong to the inner layers.
+func (b *transformerEncoderBlock[T]) Weights() Matrix[T] {
+	return nil
+}
+
+// Bias returns nil, as the parameters belong to the inner layers.
+func (b *transformerEncoderBlock[T]) Bias() Matrix[T] {
+	return nil
+}
+
+// Activation returns the activation function of the feed-forward network.
+func (b *transformerEncoderBlock[T]) Activation() activation.ActivationFunction[T] {
+	return b.hidden.activation
+}
+
+// State returns the state of the inner layers.
+func (b *transformerEncoderBlock[T]) State() []*Matrix[T] {
+	var result []*Matrix[T]
+	for _, l := range []StatefulLayer[T]{b.attention, b.attentionNorm, b.hidden, b.output, b.outputNorm} {
+		result = append(result, l.State()...)
+	}
+	return result
+}
+
+/****************************************************************************/
+
+// transformerCache contains the outputs of the inner layers.
+type transformerCache[T Float] struct {
+	attention      Matrix[T]    // X + MultiHeadAttention(X)
+	normalized     Matrix[T]    // H
+	tokens         Matrix[T]    // H, with each time step as a column
+	hidden, output [2]Matrix[T] // feed-forward outputs, with each time step as a column
+	residual       Matrix[T]    // H + feed-forward output
+	result         [2]Matrix[T]
+}
+
+func (b *transformerEncoderBlock[T]) forward(X Matrix[T]) (c transformerCache[T], err error) {
+	attention, err := b.attention.ForwardPropagate(X)
+	if err != nil {
+		return c, err
+	}
+	c.attention, _ = X.Add(attention[1])
+	normalized, _ := b.attentionNorm.ForwardPropagate(c.attention)
+	c.normalized = normalized[1]
+
+	size := b.attention.parameters.ModelSize
+	c.tokens = tokenColumns(c.normalized, size)
+	c.hidden, _ = b.hidden.ForwardPropagate(c.tokens)
+	c.output, _ = b.output.ForwardPropagate(c.hidden[1])
+
+	c.residual, _ = c.normalized.Add(tokenRows(c.output[1], b.attention.parameters.TimeSteps))
+	c.result, _ = b.outputNorm.ForwardPropagate(c.residual)
+	return c, nil
+}
+
+// ForwardPropagate produces the output of the block twice, as the activation
+// function is a part of the feed-forward network.
+//
+// Input has to be of b.InputSize() size, otherwise error is returned.
+func (b *transformerEncoderBlock[T]) ForwardPropagate(X Matrix[T]) (Y [2]Matrix[T], err error) {
+	c, err := b.forward(X)
+	if err != nil {
+		return Y, err
+	}
+	Y = [2]Matrix[T]{c.result[1], c.result[1]}
+	return Y, nil
+}
+
+// BackPropagate propagates the gradient through the inner layers in the reverse order,
+// adding the gradients of the residual connections.
+//
+// As the dense layers average their gradients over the columns, which are the time
+// steps of all samples, their gradient is scaled by the number of the time steps,
+// so that all the gradients of the block are averaged over the samples.
+func (b *transformerEncoderBlock[T]) BackPropagate(nextLayerPropagation, X Matrix[T], A [2]Matrix[T], parameters utils.NeuralNetworkParameters[T]) Matrix[T] {
+	c, _ := b.forward(X)
+	steps := T(b.attention.parameters.TimeSteps)
+	size := b.attention.parameters.ModelSize
+
+	dResidual := b.outputNorm.BackPropagate(nextLayerPropagation, c.residual, c.result, parameters)
+	dOutput := tokenColumns(dResidual, size).MultiplyByScalar(steps)
+	dHidden := b.output.BackPropagate(dOutput, c.hidden[1], c.output, parameters)
+	dTokens := b.hidden.BackPropagate(dHidden, c.tokens, c.hidden, parameters)
+	dNormalized, _ := dResidual.Add(tokenRows(dTokens.MultiplyByScalar(1/steps), int(steps)))
+
+	dAttention := b.attentionNorm.BackPropagate(dNormalized, c.attention, [2]Matrix[T]{}, parameters)
+	dX := b.attention.BackPropagate(dAttention, X, [2]Matrix[T]{}, parameters)
+	result, _ := dX.Add(dAttention)
+	return result
+}
+
+/****************************************************************************/
+
+func (b *transformerEncoderBlock[T]) String() string {
+	return fmt.Sprintf(
+		"TransformerEncoderBlock{%dx%d, heads: %d, feed-forward: %d, activation: %s}",
+		b.attention.parameters.TimeSteps,
+		b.attention.parameters.ModelSize,
+		b.attention.parameters.Heads,
+		b.hidden.OutputSize()[0],
+		activation.Name(b.hidden.activation),
+	)
+}
+
+func (b *transformerEncoderBlock[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Attention     *multiHeadAttention[T]
+		AttentionNorm *layerNorm[T]
+		Hidden        *dense[T]
+		Output        *dense[T]
+		OutputNorm    *layerNorm[T]
+		Type          string
+	}{
+		Attention:     b.attention,
+		AttentionNorm: b.attentionNorm,
+		Hidden:        b.hidden,
+		Output:        b.output,
+		OutputNorm:    b.outputNorm,
+		Type:          "TransformerEncoderBlock",
+	})
+}
+
+func (b *transformerEncoderBlock[T]) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Attention     *multiHeadAttention[T]
+		AttentionNorm *layerNorm[T]
+		Hidden        *dense[T]
+		Output        *dense[T]
+		OutputNorm    *layerNorm[T]
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return errors.Join(errors.New("invalid transformer encoder block"), err)
+	}
+	if v.Attention == nil || v.AttentionNorm == nil || v.Hidden == nil || v.Output == nil || v.OutputNorm == nil {
+		return errors.New("invalid transformer encoder block: missing layer")
+	}
+	b.attention, b.attentionNorm = v.Attention, v.AttentionNorm
+	b.hidden, b.output, b.outputNorm = v.Hidden, v.Output, v.OutputNorm
+	return nil
+}
+
+/****************************************************************************/
+
+// tokenColumns rearranges the (TimeSteps*size) x N sequence batch into size x (N*TimeSteps)
+// matrix, so that each time step of each sample becomes a column.
+func tokenColumns[T Float](X Matrix[T], size int) Matrix[T] {
+	steps := X.RowCount() / size
+	result := NewZeroMatrix[T](size, steps*X.ColumnCount())
+	for n := 0; n < X.ColumnCount(); n++ {
+		for t := 0; t < steps; t++ {
+			for d := 0; d < size; d++ {
+				v, _ := X.At(t*size+d, n)
+				result.Set(d, n*steps+t, v)
+			}
+		}
+	}
+	return result
+}
+
+// tokenRows is the inverse of tokenColumns.
+func tokenRows[T Float](M Matrix[T], steps int) Matrix[T] {
+	size := M.RowCount()
+	result := NewZeroMatrix[T](steps*size, M.ColumnCount()/steps)
+	for n := 0; n < result.ColumnCount(); n++ {
+		for t := 0; t < steps; t++ {
+			for d := 0; d < size; d++ {
+				v, _ := M.At(d, n*steps+t)
+				result.Set(t*size+d, n, v)
+			}
+		}
+	}
+	return result
+}
@@ -2,22 +2,29 @@ package layers
 
 import (
 	"errors"
+	"fmt"
 	"math"
 	"math/rand"
 	"sync"
 
 	"github.com/Hukyl/mlgo/activation"
 	. "github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/optimizer"
+	"github.com/Hukyl/mlgo/utils"
+	. "golang.org/x/exp/constraints"
 )
 
 // NewDense produces a new fully-connected layer of neurons using given weights and biases.
 //
 // Returns error if weights and biases sizes are non-conformable.
-func NewDense(W, b Matrix[float64], a activation.ActivationFunction) (Layer, error) {
-	l := &dense{weights: W, bias: b, activation: a}
+func NewDense[T Float](W, b Matrix[T], a activation.ActivationFunction[T]) (Layer[T], error) {
+	l := &dense[T]{weights: W, bias: b, activation: a}
 	if b.Size()[0] != l.OutputSize()[0] {
 		return nil, errors.New("invalid bias size")
 	}
+	if err := validateActivation(a, l.OutputSize()[0]); err != nil {
+		return nil, err
+	}
 	return l, nil
 }
 
@@ -29,15 +36,15 @@ func NewDense(W, b Matrix[float64], a activation.ActivationFunction) (Layer, err
 //   - Sigmoid - XavierUniformInitializations
 //
 // and so on.
-func NewRandomDense(weightSize [2]int, a activation.ActivationFunction, wi WeightInitialization) Layer {
-	W := NewZeroMatrix[float64](weightSize[1], weightSize[0])
+func NewRandomDense[T Float](weightSize [2]int, a activation.ActivationFunction[T], wi WeightInitialization) Layer[T] {
+	W := NewZeroMatrix[T](weightSize[1], weightSize[0])
 	for i := 0; i < weightSize[1]; i++ {
 		for j := 0; j < weightSize[0]; j++ {
-			W.Set(i, j, wi.Generate(weightSize))
+			W.Set(i, j, T(wi.Generate(weightSize)))
 		}
 	}
-	b := NewZeroMatrix[float64](weightSize[1], 1)
-	return &dense{weights: W, bias: b, activation: a}
+	b := NewZeroMatrix[T](weightSize[1], 1)
+	return &dense[T]{weights: W, bias: b, activation: a}
 }
 
 // NewDropout produces a dropout layer, which nullifies random neurons to reduce
@@ -45,25 +52,388 @@ func NewRandomDense(weightSize [2]int, a activation.ActivationFunction, wi Weigh
 //
 // Neurons are nullified at random with different neurons being deactivated in
 // different samples with some `rate`.
-func NewDropout(inputSize int, rate float64) Layer {
-	return &dropout{inputSize: inputSize, rate: rate}
+func NewDropout[T Float](inputSize int, rate float64) Layer[T] {
+	return &dropout[T]{inputSize: inputSize, rate: rate}
+}
+
+// NewConv2D produces a 2D convolutional layer for the samples of the given shape,
+// initializing the filters with the given weight initialization method.
+//
+// Returns error if the hyperparameters are invalid, or produce an empty output.
+func NewConv2D[T Float](inputShape Shape, parameters Conv2DParameters, a activation.ActivationFunction[T], wi WeightInitialization) (Layer[T], error) {
+	parameters.Stride = valueOrDefault(parameters.Stride, [2]int{1, 1})
+	parameters.Dilation = valueOrDefault(parameters.Dilation, [2]int{1, 1})
+	if parameters.Filters <= 0 || parameters.KernelSize[0] <= 0 || parameters.KernelSize[1] <= 0 {
+		return nil, errors.New("filters and kernel size must be positive")
+	}
+	if parameters.Padding[0] < 0 || parameters.Padding[1] < 0 {
+		return nil, errors.New("padding must not be negative")
+	}
+	c := &conv2D[T]{inputShape: inputShape, parameters: parameters, activation: a}
+	if out := c.OutputShape(); out.Height <= 0 || out.Width <= 0 {
+		return nil, errors.New("kernel does not fit the input")
+	}
+	if err := validateActivation(a, c.OutputSize()[0]); err != nil {
+		return nil, err
+	}
+
+	patchSize := inputShape.Channels * parameters.KernelSize[0] * parameters.KernelSize[1]
+	receptiveField := parameters.KernelSize[0] * parameters.KernelSize[1]
+	layerSize := [2]int{patchSize, parameters.Filters * receptiveField}
+	c.weights = NewZeroMatrix[T](parameters.Filters, patchSize)
+	for i := 0; i < parameters.Filters; i++ {
+		for j := 0; j < patchSize; j++ {
+			c.weights.Set(i, j, T(wi.Generate(layerSize)))
+		}
+	}
+	c.bias = NewZeroMatrix[T](parameters.Filters, 1)
+	return c, nil
+}
+
+// NewMaxPool2D produces a pooling layer, which takes the maximum value of each
+// poolSize window for each channel of the spatial input.
+//
+// If stride is not set, it is initialized to poolSize, i.e. the windows do not overlap.
+func NewMaxPool2D[T Float](inputShape Shape, poolSize, stride [2]int) (Layer[T], error) {
+	return newPool2D[T](inputShape, poolSize, stride, false)
+}
+
+// NewAveragePool2D produces a pooling layer, which averages each poolSize window
+// for each channel of the spatial input.
+//
+// If stride is not set, it is initialized to poolSize, i.e. the windows do not overlap.
+func NewAveragePool2D[T Float](inputShape Shape, poolSize, stride [2]int) (Layer[T], error) {
+	return newPool2D[T](inputShape, poolSize, stride, true)
+}
+
+func newPool2D[T Float](inputShape Shape, poolSize, stride [2]int, average bool) (Layer[T], error) {
+	if poolSize[0] <= 0 || poolSize[1] <= 0 {
+		return nil, errors.New("pool size must be positive")
+	}
+	p := &pool2D[T]{
+		inputShape: inputShape,
+		poolSize:   poolSize,
+		stride:     valueOrDefault(stride, poolSize),
+		average:    average,
+	}
+	if out := p.OutputShape(); out.Height <= 0 || out.Width <= 0 {
+		return nil, errors.New("pool does not fit the input")
+	}
+	return p, nil
+}
+
+// NewGlobalAveragePool produces a layer, which averages each channel of the spatial
+// input over all the positions, i.e. produces Channels outputs for each sample.
+func NewGlobalAveragePool[T Float](inputShape Shape) Layer[T] {
+	return &globalAveragePool[T]{inputShape: inputShape}
+}
+
+// NewFlatten produces a layer, which marks the transition from spatial layers
+// to the dense ones. As the samples are already stored flattened, the input is not changed.
+func NewFlatten[T Float](inputShape Shape) Layer[T] {
+	return &flatten[T]{inputShape: inputShape}
+}
+
+// NewBatchNorm produces a batch normalization layer, which normalizes each of inputSize
+// features using the mean and the variance over the batch, and then scales and shifts
+// them with learnable gamma and beta (initialized to 1 and 0 respectively).
+//
+// During the inference, the running statistics are used instead, which are updated
+// after each training batch with the given momentum. If momentum is not set, it is
+// initialized to 0.99. Epsilon is added to the variance for numerical stability, and
+// if not set, it is initialized to 1e-5.
+func NewBatchNorm[T Float](inputSize int, momentum, epsilon float64) Layer[T] {
+	return NewSpatialBatchNorm[T](Shape{Channels: inputSize, Height: 1, Width: 1}, momentum, epsilon)
+}
+
+// NewSpatialBatchNorm produces a batch normalization layer for the spatial inputs,
+// which normalizes each channel over the batch and all its positions.
+//
+// See NewBatchNorm for the details on the parameters.
+func NewSpatialBatchNorm[T Float](inputShape Shape, momentum, epsilon float64) Layer[T] {
+	if momentum <= 0 {
+		momentum = 0.99
+	}
+	if epsilon <= 0 {
+		epsilon = 1e-5
+	}
+	return &batchNorm[T]{
+		inputShape:      inputShape,
+		momentum:        momentum,
+		epsilon:         epsilon,
+		gamma:           NewOnesMatrix[T](inputShape.Channels, 1),
+		beta:            NewZeroMatrix[T](inputShape.Channels, 1),
+		runningMean:     NewZeroMatrix[T](inputShape.Channels, 1),
+		runningVariance: NewOnesMatrix[T](inputShape.Channels, 1),
+	}
+}
+
+// NewLayerNorm produces a layer normalization layer, which normalizes the featureSize
+// features of each sample using their own mean and variance, and then scales and shifts
+// them with learnable gamma and beta (initialized to 1 and 0 respectively).
+//
+// Unlike batch normalization, it does not depend on the batch, so it behaves the same
+// way during the training and inference. If epsilon is not set, it is initialized to 1e-5.
+func NewLayerNorm[T Float](featureSize int, epsilon float64) Layer[T] {
+	return NewSequenceLayerNorm[T](1, featureSize, epsilon)
+}
+
+// NewSequenceLayerNorm produces a layer normalization layer for the sequences of timeSteps
+// feature vectors, stored one after another, i.e. the input is (timeSteps*featureSize)xN.
+// Each vector is normalized on its own, with gamma and beta shared between the time steps.
+func NewSequenceLayerNorm[T Float](timeSteps, featureSize int, epsilon float64) Layer[T] {
+	if epsilon <= 0 {
+		epsilon = 1e-5
+	}
+	return &layerNorm[T]{
+		featureSize: featureSize,
+		timeSteps:   timeSteps,
+		epsilon:     epsilon,
+		gamma:       NewOnesMatrix[T](featureSize, 1),
+		beta:        NewZeroMatrix[T](featureSize, 1),
+	}
+}
+
+// NewSimpleRNN produces a fully-connected recurrent layer with tanh activation,
+// initializing the weights with the given weight initialization method.
+//
+// Returns error if the sizes are not positive.
+func NewSimpleRNN[T Float](parameters RecurrentParameters, wi WeightInitialization) (Layer[T], error) {
+	return newRecurrent(parameters, simpleRNNCell[T]{}, wi)
+}
+
+// NewLSTM produces a long short-term memory layer, initializing the weights with
+// the given weight initialization method. The bias of the forget gate is initialized
+// to 1, so that the layer remembers the state at the start of the training.
+//
+// Returns error if the sizes are not positive.
+func NewLSTM[T Float](parameters RecurrentParameters, wi WeightInitialization) (Layer[T], error) {
+	l, err := newRecurrent(parameters, lstmCell[T]{}, wi)
+	if err != nil {
+		return nil, err
+	}
+	for i := parameters.HiddenSize; i < 2*parameters.HiddenSize; i++ {
+		l.bias.Set(i, 0, 1)
+	}
+	return l, nil
+}
+
+// NewGRU produces a gated recurrent unit layer, initializing the weights with
+// the given weight initialization method.
+//
+// Returns error if the sizes are not positive.
+func NewGRU[T Float](parameters RecurrentParameters, wi WeightInitialization) (Layer[T], error) {
+	return newRecurrent(parameters, gruCell[T]{}, wi)
+}
+
+func newRecurrent[T Float](parameters RecurrentParameters, cell recurrentCell[T], wi WeightInitialization) (*recurrent[T], error) {
+	if parameters.TimeSteps <= 0 || parameters.InputSize <= 0 || parameters.HiddenSize <= 0 {
+		return nil, errors.New("time steps, input and hidden sizes must be positive")
+	}
+	rows := cell.gateCount() * parameters.HiddenSize
+	columns := parameters.InputSize + parameters.HiddenSize
+	layerSize := [2]int{columns, parameters.HiddenSize}
+
+	W := NewZeroMatrix[T](rows, columns)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < columns; j++ {
+			W.Set(i, j, T(wi.Generate(layerSize)))
+		}
+	}
+	return &recurrent[T]{
+		parameters: parameters,
+		cell:       cell,
+		weights:    W,
+		bias:       NewZeroMatrix[T](rows, 1),
+	}, nil
+}
+
+// NewBidirectional wraps two recurrent layers (produced by NewSimpleRNN, NewLSTM or NewGRU),
+// so that the first one processes the sequence forwards and the second one backwards.
+// The outputs of the layers are concatenated, so the output size is doubled.
+//
+// Returns error if the layers are not recurrent, or have different parameters.
+func NewBidirectional[T Float](forward, backward Layer[T]) (Layer[T], error) {
+	f, ok := forward.(*recurrent[T])
+	if !ok {
+		return nil, errors.New("forward layer is not recurrent")
+	}
+	b, ok := backward.(*recurrent[T])
+	if !ok {
+		return nil, errors.New("backward layer is not recurrent")
+	}
+	if f.parameters != b.parameters {
+		return nil, errors.New("recurrent layers have different parameters")
+	}
+	f.reverse, b.reverse = false, true
+	return &bidirectional[T]{forward: f, backward: b}, nil
+}
+
+// NewEmbedding produces a layer, which maps each token id of the input to a vector
+// of the given dimension, initializing the vectors with the given weight initialization
+// method. Each sample is a column of TimeSteps token ids.
+//
+// Only the vectors of the tokens present in the batch are updated during the training.
+//
+// Returns error if the sizes are not positive.
+func NewEmbedding[T Float](parameters EmbeddingParameters, wi WeightInitialization) (Layer[T], error) {
+	if parameters.TimeSteps == 0 {
+		parameters.TimeSteps = 1
+	}
+	if parameters.VocabularySize <= 0 || parameters.Dimension <= 0 || parameters.TimeSteps < 0 {
+		return nil, errors.New("vocabulary size, dimension and time steps must be positive")
+	}
+	layerSize := [2]int{parameters.VocabularySize, parameters.Dimension}
+	W := NewZeroMatrix[T](parameters.VocabularySize, parameters.Dimension)
+	for i := 0; i < parameters.VocabularySize; i++ {
+		for j := 0; j < parameters.Dimension; j++ {
+			W.Set(i, j, T(wi.Generate(layerSize)))
+		}
+	}
+	return &embedding[T]{parameters: parameters, weights: W}, nil
+}
+
+// NewPretrainedEmbedding produces an embedding layer using the given matrix, each row
+// of which is a vector of a token. If the vocabulary size and the dimension are not set,
+// they are taken from the matrix. Usually, pretrained embeddings are frozen.
+//
+// Returns error if the parameters do not correspond to the matrix size.
+func NewPretrainedEmbedding[T Float](W Matrix[T], parameters EmbeddingParameters) (Layer[T], error) {
+	if parameters.VocabularySize == 0 {
+		parameters.VocabularySize = W.RowCount()
+	}
+	if parameters.Dimension == 0 {
+		parameters.Dimension = W.ColumnCount()
+	}
+	if parameters.TimeSteps == 0 {
+		parameters.TimeSteps = 1
+	}
+	if W.RowCount() != parameters.VocabularySize || W.ColumnCount() != parameters.Dimension {
+		return nil, errors.New("invalid embedding matrix size")
+	}
+	if parameters.TimeSteps < 0 || W.RowCount() == 0 || W.ColumnCount() == 0 {
+		return nil, errors.New("vocabulary size, dimension and time steps must be positive")
+	}
+	return &embedding[T]{parameters: parameters, weights: W}, nil
+}
+
+// NewMultiHeadAttention produces a multi-head self-attention layer, initializing
+// the projections with the given weight initialization method.
+//
+// Returns error if the parameters are invalid, e.g. the model size is not divisible
+// by the number of heads, or the mask does not allow some time step to attend to anything.
+func NewMultiHeadAttention[T Float](parameters AttentionParameters, wi WeightInitialization) (Layer[T], error) {
+	return newMultiHeadAttention[T](parameters, wi)
+}
+
+func newMultiHeadAttention[T Float](parameters AttentionParameters, wi WeightInitialization) (*multiHeadAttention[T], error) {
+	if err := parameters.validate(); err != nil {
+		return nil, err
+	}
+	size := parameters.ModelSize
+	W := NewZeroMatrix[T](4*size, size)
+	for i := 0; i < 4*size; i++ {
+		for j := 0; j < size; j++ {
+			W.Set(i, j, T(wi.Generate([2]int{size, size})))
+		}
+	}
+	return &multiHeadAttention[T]{
+		parameters: parameters,
+		weights:    W,
+		bias:       NewZeroMatrix[T](4*size, 1),
+	}, nil
+}
+
+// NewTransformerEncoderBlock produces an encoder block of the Transformer, which consists
+// of the multi-head self-attention and a feed-forward network of two dense layers (with
+// feedForwardSize hidden neurons, activated by the given function), each followed by
+// a residual connection and layer normalization.
+//
+// Returns error if the attention parameters are invalid, or feedForwardSize is not positive.
+func NewTransformerEncoderBlock[T Float](
+	parameters AttentionParameters,
+	feedForwardSize int,
+	a activation.ActivationFunction[T],
+	wi WeightInitialization,
+) (Layer[T], error) {
+	if feedForwardSize <= 0 {
+		return nil, errors.New("feed-forward size must be positive")
+	}
+	attention, err := newMultiHeadAttention[T](parameters, wi)
+	if err != nil {
+		return nil, err
+	}
+	size := parameters.ModelSize
+	return &transformerEncoderBlock[T]{
+		attention:     attention,
+		attentionNorm: NewSequenceLayerNorm[T](parameters.TimeSteps, size, 0).(*layerNorm[T]),
+		hidden:        NewRandomDense([2]int{size, feedForwardSize}, a, wi).(*dense[T]),
+		output:        NewRandomDense([2]int{feedForwardSize, size}, activation.Linear[T]{}, wi).(*dense[T]),
+		outputNorm:    NewSequenceLayerNorm[T](parameters.TimeSteps, size, 0).(*layerNorm[T]),
+	}, nil
+}
+
+// NewPositionalEncoding produces a layer, which adds the sinusoidal positional encoding
+// to each of timeSteps vectors of the given dimension.
+func NewPositionalEncoding[T Float](timeSteps, dimension int) Layer[T] {
+	return &positionalEncoding[T]{timeSteps: timeSteps, dimension: dimension}
 }
 
 /**********************************************************************/
 
-func uniformMatrix(size [2]int, min, max float64) Matrix[float64] {
-	m := NewZeroMatrix[float64](size[0], size[1])
+// NewCustom produces a layer, the output of which is computed by the given function
+// of the autograd variables, so that its gradients are obtained automatically, e.g.
+//
+//	layer, _ := layers.NewCustom("Swish", 3, 2,
+//		func(X *autograd.Variable, p []*autograd.Variable) *autograd.Variable {
+//			Z := p[0].MatMul(X).Add(p[1])
+//			return Z.Mul(Z.Sigmoid())
+//		},
+//		W, b,
+//	)
+//
+// The parameters are trainable and given to the function in the same order. The first two
+// are returned as Weights() and Bias() respectively. The name is used as the layer type,
+// by which the layer may be registered with RegisterLayer.
+//
+// Returns error if the sizes are not positive, or the function is nil.
+func NewCustom[T Float](name string, inputSize, outputSize int, f CustomFunction, parameters ...Matrix[T]) (Layer[T], error) {
+	if inputSize <= 0 || outputSize <= 0 {
+		return nil, errors.New("sizes must be positive")
+	}
+	if f == nil {
+		return nil, errors.New("function must not be nil")
+	}
+	return &custom[T]{
+		name:       name,
+		inputSize:  inputSize,
+		outputSize: outputSize,
+		function:   f,
+		parameters: parameters,
+	}, nil
+}
+
+// uniformMatrix produces a matrix of the values, uniformly distributed in [min, max].
+// Each column is generated concurrently by its own generator, seeded from r in order,
+// so that the result does not depend on the goroutine scheduling.
+func uniformMatrix[T Float](size [2]int, min, max float64, r *rand.Rand) Matrix[T] {
+	m := NewZeroMatrix[T](size[0], size[1])
+	seeds := make([]int64, m.ColumnCount())
+	for j := range seeds {
+		seeds[j] = randOrGlobal(r).Int63()
+	}
 
 	wg := sync.WaitGroup{}
 	wg.Add(m.ColumnCount())
 	for j := 0; j < m.ColumnCount(); j++ {
 		go func(j int) {
 			defer wg.Done()
+			columnRand := rand.New(rand.NewSource(seeds[j]))
 			for i := 0; i < m.RowCount(); i++ {
-				f := rand.NormFloat64()
+				f := columnRand.NormFloat64()
 				normalized := 0.5 * (1 + math.Erf(f/math.Sqrt2))
 				projected := min + normalized*(max-min)
-				m.Set(i, j, projected)
+				m.Set(i, j, T(projected))
 			}
 		}(j)
 	}
@@ -71,3 +441,72 @@ func uniformMatrix(size [2]int, min, max float64) Matrix[float64] {
 
 	return m
 }
+
+// updateParameter updates the trainable parameter using the optimizer from the parameters.
+// If the optimizer is not set, vanilla SGD is used.
+func updateParameter[T Float](parameter *Matrix[T], gradient Matrix[T], parameters utils.NeuralNetworkParameters[T]) {
+	o := parameters.Optimizer
+	if o == nil {
+		o = &optimizer.SGD[T]{}
+	}
+	o.Update(parameter, gradient, parameters.LearningRate(), parameters.WeightDecay)
+}
+
+// updateParameterRows updates only the given rows of the parameter, using the compact
+// gradient, i.e. its k-th row is the gradient of the rows[k]-th row of the parameter.
+// If the optimizer does not support sparse updates, the whole parameter is updated
+// with the gradient of the rest of the rows being zero.
+func updateParameterRows[T Float](parameter *Matrix[T], gradient Matrix[T], rows []int, parameters utils.NeuralNetworkParameters[T]) {
+	o, ok := parameters.Optimizer.(optimizer.SparseOptimizer[T])
+	if parameters.Optimizer == nil {
+		o, ok = &optimizer.SGD[T]{}, true
+	}
+	if ok {
+		o.UpdateRows(parameter, gradient, rows, parameters.LearningRate(), parameters.WeightDecay)
+		return
+	}
+	full := NewZeroMatrix[T]((*parameter).RowCount(), (*parameter).ColumnCount())
+	for k, i := range rows {
+		for j := 0; j < full.ColumnCount(); j++ {
+			g, _ := gradient.At(k, j)
+			full.Set(i, j, g)
+		}
+	}
+	updateParameter(parameter, full, parameters)
+}
+
+// validateActivation checks that the activation function fits the layer with the given
+// number of neurons, i.e. that PReLU has either a single slope or a slope for each neuron.
+func validateActivation[T Float](a activation.ActivationFunction[T], neurons int) error {
+	p, ok := a.(*activation.PReLU[T])
+	if !ok {
+		return nil
+	}
+	if p.Alpha == nil || p.Alpha.RowCount() != 1 && p.Alpha.RowCount() != neurons {
+		return fmt.Errorf("PReLU must have either 1 or %d slopes", neurons)
+	}
+	return nil
+}
+
+// activationState returns the trainable parameters of the activation function (if any).
+func activationState[T Float](a activation.ActivationFunction[T]) []*Matrix[T] {
+	if l, ok := a.(activation.LearnableActivation[T]); ok {
+		return l.Parameters()
+	}
+	return nil
+}
+
+// updateActivation updates the trainable parameters of the activation function (if any),
+// given its input Z and the gradient dA of its output for the batch of the given size.
+// The parameters are not regularized by the weight decay.
+func updateActivation[T Float](a activation.ActivationFunction[T], Z, dA Matrix[T], samples int, parameters utils.NeuralNetworkParameters[T]) {
+	l, ok := a.(activation.LearnableActivation[T])
+	if !ok {
+		return
+	}
+	parameters.WeightDecay = 0
+	gradients := l.ParameterGradients(Z, dA)
+	for k, p := range l.Parameters() {
+		updateParameter(p, gradients[k].MultiplyByScalar(1/T(samples)), parameters)
+	}
+}
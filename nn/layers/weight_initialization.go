@@ -11,6 +11,12 @@ import (
 // Generate is used to generate a float value as a weight for the layer.
 // Note, that some weight initialization techniques may utilize only one
 // layer dimension, like He initialization.
+// The value is converted to the float type of the layer.
+//
+// All the initializations take an optional Rand, so that the weights are
+// reproducible for a fixed seed. If Rand is not set, the global source of
+// math/rand is used. The same generator may be shared with the training
+// (see utils.NeuralNetworkParameters.Rand), so that one seed drives the whole run.
 type WeightInitialization interface {
 	Generate(layerSize [2]int) float64
 }
@@ -21,45 +27,70 @@ type WeightInitialization interface {
 //
 //	weight := r.Min + rand.Float64()*(r.Max-r.Min)
 type RandomInitialization struct {
-	Min float64
-	Max float64
+	Min  float64
+	Max  float64
+	Rand *rand.Rand
 }
 
 func (r RandomInitialization) Generate(layerSize [2]int) float64 {
-	return r.Min + rand.Float64()*(r.Max-r.Min)
+	return r.Min + randOrGlobal(r.Rand).Float64()*(r.Max-r.Min)
 }
 
 // XavierNormalInitialization (also known as Glorot) is a weight initialization technique
 // based on the normal distribution.
 //
 // Mainly used for tanh activation function.
-type XavierNormalInitialization struct{}
+type XavierNormalInitialization struct {
+	Rand *rand.Rand
+}
 
 func (x XavierNormalInitialization) Generate(layerSize [2]int) float64 {
-	return rand.NormFloat64() * math.Sqrt(float64(2)/float64(layerSize[0]+layerSize[1]))
+	return randOrGlobal(x.Rand).NormFloat64() * math.Sqrt(float64(2)/float64(layerSize[0]+layerSize[1]))
 }
 
 // XavierNormalInitialization (also known as Glorot) is a weight initialization technique
 // based on the uniform distribution.
 //
 // Mainly used for sigmoid activation function.
-type XavierUniformInitialization struct{}
+type XavierUniformInitialization struct {
+	Rand *rand.Rand
+}
 
 func (x XavierUniformInitialization) Generate(layerSize [2]int) float64 {
 	limit := math.Sqrt(float64(6) / float64(layerSize[0]+layerSize[1]))
 
 	// Generate a random number in the range [-x, x]
-	return (rand.Float64()*2 - 1.0) * limit
+	return (randOrGlobal(x.Rand).Float64()*2 - 1.0) * limit
 }
 
 // HeInitialization is a weight initialization technique, which uses the normal distribution
 // with a standard deviation using the previous layer size.
 //
 // Mainly used with ReLU activation function to account for the zeros in (-inf;0] range.
-type HeInitialization struct{}
+type HeInitialization struct {
+	Rand *rand.Rand
+}
 
 func (h HeInitialization) Generate(layerSize [2]int) float64 {
-	return rand.NormFloat64() * math.Sqrt(float64(2)/float64(layerSize[0]))
+	return randOrGlobal(h.Rand).NormFloat64() * math.Sqrt(float64(2)/float64(layerSize[0]))
 }
 
 /******************************************************/
+
+// globalSource draws from the global source of math/rand, which is safe
+// for the concurrent use, unlike the sources produced by rand.NewSource.
+type globalSource struct{}
+
+func (globalSource) Int63() int64    { return rand.Int63() }
+func (globalSource) Uint64() uint64  { return rand.Uint64() }
+func (globalSource) Seed(seed int64) {}
+
+var globalRand = rand.New(globalSource{})
+
+// randOrGlobal returns r, or the generator using the global source if r is nil.
+func randOrGlobal(r *rand.Rand) *rand.Rand {
+	if r == nil {
+		return globalRand
+	}
+	return r
+}
@@ -9,24 +9,35 @@ import (
 	"log"
 	"math"
 	"path/filepath"
-	"reflect"
-	"regexp"
 	"strings"
 
 	"github.com/Hukyl/mlgo/activation"
 	. "github.com/Hukyl/mlgo/loss"
 	. "github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/metric"
 	. "github.com/Hukyl/mlgo/nn/layers"
 	"github.com/Hukyl/mlgo/utils"
+	. "golang.org/x/exp/constraints"
 )
 
-// NeuralNetwork is an interface for a general ANN.
+// Model is the part of the NeuralNetwork, which does not depend on its float type,
+// e.g. to be passed to the callbacks.
 //
 // InputSize returns the dimensions of the input to the first layer.
 // Returned as [2]{rowCount, 1}, but in practice columnCount can also differ.
 //
 // OutputSize returns the dimensions of the output of the last layer.
 // Returned as [2]{rowCount, 1}, but in practice columnCount can also differ.
+type Model interface {
+	json.Marshaler
+	json.Unmarshaler
+
+	InputSize() [2]int
+	OutputSize() [2]int
+}
+
+// NeuralNetwork is an interface for a general ANN, which computes in the float type T,
+// e.g. float64 or float32.
 //
 // ComputeCost uses the LossFunction of the last layer to compute cost, i.e. the error
 // of the prediction. Separate columns of y and yHat represent different samples,
@@ -43,46 +54,55 @@ import (
 // BackPropagate uses the inputCache, produced by ForwardPropagate, to update the layers.
 // Utilizes parameters for the neural network.
 //
-// Predict produces ANN prediction for the given data. Equivalent to ForwardPropagate()[-1][1].
+// Predict produces ANN prediction for the given data. Equivalent to ForwardPropagate()[-1][1],
+// except that training-only layers are skipped, and layers implementing InferenceLayer
+// are used in the inference mode.
+//
+// Evaluate computes the cost and the given metrics of the predictions for the batches,
+// averaged over the batches.
 //
 // Train uses training functions (ComputeCost, ForwardPropagate, BackPropagate) to update
-// the weights of the layers to decrease the cost and loss.
-type NeuralNetwork interface {
-	json.Marshaler
-	json.Unmarshaler
-
-	InputSize() [2]int
-	OutputSize() [2]int
+// the weights of the layers to decrease the cost and loss. Callbacks are notified
+// of the training progress, and may stop the training early. Returns the history
+// of the training and validation logs for each epoch passed, even if an error occured.
+type NeuralNetwork[T Float] interface {
+	Model
 
 	// Training functions
-	ComputeCost(yHat, Y Matrix[float64]) float64
-	ForwardPropagate(X Matrix[float64]) (inputCache [][2]Matrix[float64])
-	BackPropagate(Y Matrix[float64], inputCache [][2]Matrix[float64], parameters utils.NeuralNetworkParameters)
+	ComputeCost(yHat, Y Matrix[T]) float64
+	ForwardPropagate(X Matrix[T]) (inputCache [][2]Matrix[T])
+	BackPropagate(Y Matrix[T], inputCache [][2]Matrix[T], parameters utils.NeuralNetworkParameters[T])
 
 	// Prediction functions
-	Predict(X Matrix[float64]) (Y Matrix[float64])
-	Train(X, Y []Matrix[float64], parameters utils.NeuralNetworkParameters) error
+	Predict(X Matrix[T]) (Y Matrix[T])
+	Evaluate(X, Y []Matrix[T], metrics ...metric.NamedMetric[T]) (Logs, error)
+	Train(X, Y []Matrix[T], parameters utils.NeuralNetworkParameters[T], callbacks ...Callback) (History, error)
 }
 
 /************************************************************************/
 
-type nn struct {
-	layers       []Layer
-	LossFunction LossFunction[float64]
+type nn[T Float] struct {
+	layers       []Layer[T]
+	LossFunction LossFunction[T]
 }
 
-func (n *nn) InputSize() [2]int {
+func (n *nn[T]) InputSize() [2]int {
 	return n.layers[0].InputSize()
 }
 
-func (n *nn) OutputSize() [2]int {
+func (n *nn[T]) OutputSize() [2]int {
 	return n.layers[len(n.layers)-1].OutputSize()
 }
 
-func (n *nn) Predict(X Matrix[float64]) Matrix[float64] {
+func (n *nn[T]) Predict(X Matrix[T]) Matrix[T] {
 	Y := X
 	for _, l := range n.layers {
-		if !l.IsTraining() {
+		if l.IsTraining() {
+			continue
+		}
+		if il, ok := l.(InferenceLayer[T]); ok {
+			Y, _ = il.Infer(Y)
+		} else {
 			output, _ := l.ForwardPropagate(Y)
 			Y = output[1]
 		}
@@ -90,15 +110,20 @@ func (n *nn) Predict(X Matrix[float64]) Matrix[float64] {
 	return Y
 }
 
-func (n *nn) validateTrainSamples(X, Y []Matrix[float64]) error {
+func (n *nn[T]) validateTrainSamples(X, Y []Matrix[T]) error {
 	var errorText string
 
+	if len(X) != len(Y) {
+		return errors.New("incosistent batch count")
+	}
+
+	outputSize := n.labelRows()
 	for i := 0; i < len(X); i++ {
 		X_batch, Y_batch := X[i], Y[i]
 		switch {
 		case X_batch.RowCount() != n.InputSize()[0]:
 			errorText = "invalid input size"
-		case Y_batch.RowCount() != n.OutputSize()[0]:
+		case Y_batch.RowCount() != outputSize:
 			errorText = "invalid output size"
 		case X_batch.ColumnCount() != Y_batch.ColumnCount():
 			errorText = "incosistent sample count"
@@ -110,19 +135,121 @@ func (n *nn) validateTrainSamples(X, Y []Matrix[float64]) error {
 	return nil
 }
 
+// labelRows returns the number of rows of the labels, which is the output size, unless
+// the loss function uses another layout of the labels (see SparseLabels).
+func (n *nn[T]) labelRows() int {
+	outputSize := n.OutputSize()[0]
+	if l, ok := n.LossFunction.(SparseLabels[T]); ok {
+		return l.LabelRows(outputSize)
+	}
+	return outputSize
+}
+
+// validateMetrics checks that the labels fit each of the metrics, either as they are,
+// or converted to the layout of the prediction by the loss function (see SparseLabels).
+func (n *nn[T]) validateMetrics(metrics []metric.NamedMetric[T]) error {
+	outputSize := n.OutputSize()[0]
+	_, sparse := n.LossFunction.(SparseLabels[T])
+	for _, m := range metrics {
+		rows := metricLabelRows(m, outputSize)
+		if rows != n.labelRows() && !(sparse && rows == outputSize) {
+			return fmt.Errorf("labels of %d rows do not fit metric %s", n.labelRows(), m.Name)
+		}
+	}
+	return nil
+}
+
+// computeMetrics calculates each of the metrics for the prediction. The labels are
+// converted to the layout of the prediction for the metrics, which require it.
+func (n *nn[T]) computeMetrics(metrics []metric.NamedMetric[T], Y, prediction Matrix[T]) Logs {
+	logs := make(Logs, len(metrics)+1)
+	var dense Matrix[T]
+	for _, m := range metrics {
+		labels := Y
+		if l, ok := n.LossFunction.(SparseLabels[T]); ok && metricLabelRows(m, prediction.RowCount()) != Y.RowCount() {
+			if dense == nil {
+				dense = l.DenseLabels(Y, prediction.RowCount())
+			}
+			labels = dense
+		}
+		logs[m.Name] = m.Calculate(labels, prediction)
+	}
+	return logs
+}
+
+// snapshotter is implemented by the models, the state of which can be saved and
+// restored in place.
+type snapshotter interface {
+	snapshot() (restore func())
+}
+
+// snapshot copies the state of the layers (see StatefulLayer), returning the function,
+// which restores it. The matrices are restored through the same pointers, so that the
+// layers, as well as the state of the optimizer kept for them, stay valid.
+func (n *nn[T]) snapshot() (restore func()) {
+	var pointers []*Matrix[T]
+	var saved []Matrix[T]
+	for _, l := range n.layers {
+		if sl, ok := l.(StatefulLayer[T]); ok {
+			for _, p := range sl.State() {
+				pointers = append(pointers, p)
+				saved = append(saved, (*p).DeepCopy())
+			}
+		}
+	}
+	return func() {
+		for i, p := range pointers {
+			*p = saved[i].DeepCopy()
+		}
+	}
+}
+
+// validateOutput checks that the ANN has layers, and that SoftmaxWithCCE and
+// CCELossWithSoftmax are used only together, as they rely on each other's derivatives.
+// Hence, SoftmaxWithCCE can be used only by the last layer.
+func (n *nn[T]) validateOutput() error {
+	if len(n.layers) == 0 {
+		return errors.New("no layers")
+	}
+	for j, layer := range n.layers[:len(n.layers)-1] {
+		if _, ok := layer.Activation().(activation.SoftmaxWithCCE[T]); ok {
+			return fmt.Errorf("SoftmaxWithCCE activation of layer #%d can be used only by the last layer", j+1)
+		}
+	}
+	_, fusedActivation := n.layers[len(n.layers)-1].Activation().(activation.SoftmaxWithCCE[T])
+	_, fusedLoss := n.LossFunction.(CCELossWithSoftmax[T])
+	switch {
+	case fusedActivation && !fusedLoss:
+		return errors.New("SoftmaxWithCCE activation must be used with CCELossWithSoftmax loss")
+	case fusedLoss && !fusedActivation:
+		return errors.New("CCELossWithSoftmax loss must be used with SoftmaxWithCCE activation")
+	}
+	return nil
+}
+
 // forwardPropagate propagates the input through the layers of the network.
 //
 // Returns slice, with size of (N layers)+1, where [0] is the input to the network,
 // and each element is the result of propagation through the next layer.
-func (n *nn) ForwardPropagate(X Matrix[float64]) [][2]Matrix[float64] {
-	inputCache := make([][2]Matrix[float64], len(n.layers)+1)
-	inputCache[0] = [2]Matrix[float64]{X, X}
+func (n *nn[T]) ForwardPropagate(X Matrix[T]) [][2]Matrix[T] {
+	inputCache, _ := n.forwardPropagate(X)
+	return inputCache
+}
+
+// forwardPropagate works as ForwardPropagate, though stops at the first layer, which
+// fails to propagate its input, returning the error.
+func (n *nn[T]) forwardPropagate(X Matrix[T]) ([][2]Matrix[T], error) {
+	inputCache := make([][2]Matrix[T], len(n.layers)+1)
+	inputCache[0] = [2]Matrix[T]{X, X}
 
 	for j, layer := range n.layers {
-		soFar, _ := layer.ForwardPropagate(inputCache[j][1])
+		soFar, err := layer.ForwardPropagate(inputCache[j][1])
+		if err != nil {
+			return inputCache, errors.Join(fmt.Errorf("layer #%d (%s) failed to propagate", j+1, layer), err)
+		}
 		inputCache[j+1] = soFar
 	}
-	return inputCache
+	return inputCache, nil
 }
 
 // backPropagate propagates the derivatives from the end of the neural network.
@@ -134,10 +261,10 @@ func (n *nn) ForwardPropagate(X Matrix[float64]) [][2]Matrix[float64] {
 //	L1 -> dL/dZ1 = dL/dA3 * dA3/dZ3 * dZ3/dA2 * dA2/dZ2 * dZ2/dA1 * dA1/dZ1
 //	L2 -> dL/dZ2 = dL/dA3 * dA3/dZ3 * dZ3/dA2 * dA2/dZ2
 //	L3 -> dL/dZ3 = dL/dA3 * dA3/dZ3
-func (n *nn) BackPropagate(Y Matrix[float64], inputCache [][2]Matrix[float64], parameters utils.NeuralNetworkParameters) {
+func (n *nn[T]) BackPropagate(Y Matrix[T], inputCache [][2]Matrix[T], parameters utils.NeuralNetworkParameters[T]) {
 	layerCount := len(n.layers)
 
-	var backPropagation Matrix[float64]
+	var backPropagation Matrix[T]
 
 	yHat := inputCache[layerCount][1]
 	dL := n.LossFunction.ApplyDerivativeMatrix(Y, yHat)
@@ -154,79 +281,176 @@ func (n *nn) BackPropagate(Y Matrix[float64], inputCache [][2]Matrix[float64], p
 		)
 		backPropagation = Clip(
 			backPropagation,
-			-parameters.ClipValue,
-			parameters.ClipValue,
+			-T(parameters.ClipValue),
+			T(parameters.ClipValue),
 		)
 	}
 }
 
-func (n *nn) ComputeCost(yHat, Y Matrix[float64]) float64 {
-	cost := float64(0)
-
+func (n *nn[T]) ComputeCost(yHat, Y Matrix[T]) float64 {
 	losses := n.LossFunction.ApplyMatrix(Y, yHat)
-	for column := 0; column < losses.ColumnCount(); column++ {
-		for row := 0; row < losses.RowCount(); row++ {
-			v, _ := losses.At(row, column)
-			cost += v
-		}
-	}
-	return cost / float64(losses.ColumnCount())
+	cost, _ := Sum(losses, AllElements).At(0, 0)
+	return float64(cost) / float64(losses.ColumnCount())
 }
 
-func (n *nn) Train(X, Y []Matrix[float64], parameters utils.NeuralNetworkParameters) error {
+func (n *nn[T]) Train(X, Y []Matrix[T], parameters utils.NeuralNetworkParameters[T], callbacks ...Callback) (History, error) {
+	var history History
+
 	err := n.validateTrainSamples(X, Y)
 	if err != nil {
-		return err
+		return history, err
 	}
 	parameters.Validate()
+	if err = n.validateMetrics(parameters.NamedMetrics()); err != nil {
+		return history, err
+	}
+	X, Y, validationX, validationY, err := splitValidation(X, Y, parameters.Validation)
+	if err != nil {
+		return history, err
+	}
+	if err = n.validateTrainSamples(validationX, validationY); err != nil {
+		return history, errors.Join(errors.New("invalid validation data"), err)
+	}
 	parameters.ResetEpoch()
+	for _, l := range n.layers {
+		if rl, ok := l.(RandomLayer); ok {
+			rl.SetRand(parameters.Rand)
+		}
+	}
+	cl := callbackList(callbacks)
+
+	var logs Logs
+	err = cl.OnTrainBegin(n)
+	for e := 0; err == nil && e < int(parameters.EpochCount); e++ {
+		var epochLogs Logs
+		epochLogs, err = n.trainEpoch(X, Y, validationX, validationY, e+1, &parameters, cl)
+		if epochLogs != nil {
+			logs = epochLogs
+			history.append(e+1, epochLogs)
+		}
+	}
+	if err != nil && !errors.Is(err, ErrStopTraining) {
+		return history, err
+	}
 
-	for e := 0; e < int(parameters.EpochCount); e++ {
-		cost := float64(0.0)
-		accuracy := float64(0.0)
+	parameters.ResetEpoch()
+	if err = cl.OnTrainEnd(n, logs); err != nil && !errors.Is(err, ErrStopTraining) {
+		return history, err
+	}
+	return history, nil
+}
 
-		for i := 0; i < len(X); i++ {
-			X_batch, Y_batch := X[i], Y[i]
+// trainEpoch performs a single training epoch over all the batches, returning
+// the averaged logs for the epoch. If validation batches are given, the ANN is
+// evaluated on them at the end of the epoch.
+func (n *nn[T]) trainEpoch(
+	X, Y, validationX, validationY []Matrix[T],
+	epoch int,
+	parameters *utils.NeuralNetworkParameters[T],
+	cl callbackList,
+) (Logs, error) {
+	if err := cl.OnEpochBegin(n, epoch); err != nil {
+		return nil, err
+	}
+	metrics := parameters.NamedMetrics()
+	logs := Logs{CostKey: 0}
 
-			// Forward propagate and store inputs
-			inputCache := n.ForwardPropagate(X_batch)
+	for i := 0; i < len(X); i++ {
+		if err := cl.OnBatchBegin(n, i); err != nil {
+			return nil, err
+		}
+		X_batch, Y_batch := X[i], Y[i]
 
-			// Calculate cost and accuracy
-			prediction := inputCache[len(inputCache)-1][1]
-			cost += n.ComputeCost(prediction, Y_batch) / float64(len(X))
-			if math.IsNaN(cost) || math.IsInf(cost, 0) || cost == 0.0 {
-				return errors.New("cost is an invalid number")
-			}
-			accuracy += parameters.AccuracyMetric.Calculate(Y_batch, prediction) / float64(len(X))
+		// Forward propagate and store inputs
+		inputCache, err := n.forwardPropagate(X_batch)
+		if err != nil {
+			return nil, err
+		}
 
-			// Updating the weights
-			n.BackPropagate(Y_batch, inputCache, parameters)
+		// Calculate cost and metrics
+		prediction := inputCache[len(inputCache)-1][1]
+		batchLogs := n.computeMetrics(metrics, Y_batch, prediction)
+		batchLogs[CostKey] = n.ComputeCost(prediction, Y_batch)
+		for key, value := range batchLogs {
+			logs[key] += value / float64(len(X))
 		}
-		log.Printf("Epoch %d/%d, avg_cost: %-10.5g avg_accuracy: %-10.5g\n", e+1, parameters.EpochCount, cost, accuracy)
-
-		parameters.IncrementEpoch()
-		if parameters.Backups.ToCreate {
-			err = DumpNeuralNetwork(
-				n,
-				filepath.Join(
-					parameters.Backups.Path,
-					fmt.Sprintf("epoch_%d.json", e+1),
-				),
-			)
-			if err != nil {
-				log.Printf("dump saving error: %s", err)
-			}
+		if cost := logs[CostKey]; math.IsNaN(cost) || math.IsInf(cost, 0) || cost == 0.0 {
+			return nil, errors.New("cost is an invalid number")
+		}
+
+		// Updating the weights
+		n.BackPropagate(Y_batch, inputCache, *parameters)
+		parameters.IncrementBatch()
+
+		if err := cl.OnBatchEnd(n, i, batchLogs); err != nil {
+			return nil, err
 		}
+	}
+	logs[LearningRateKey] = parameters.LearningRate()
+	if len(validationX) > 0 {
+		validationLogs, err := n.Evaluate(validationX, validationY, metrics...)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range validationLogs {
+			logs[ValidationPrefix+key] = value
+		}
+	}
+	log.Printf("Epoch %d/%d, %s\n", epoch, parameters.EpochCount, formatLogs(logs, metrics, len(validationX) > 0))
 
+	parameters.ObserveCost(logs[CostKey])
+	parameters.IncrementEpoch()
+	if parameters.Backups.ToCreate {
+		n.createBackup(*parameters)
 	}
 
-	parameters.ResetEpoch()
-	return nil
+	return logs, cl.OnEpochEnd(n, epoch, logs)
+}
+
+// Evaluate computes the cost and the metrics of the ANN predictions on the batches,
+// averaged over the batches. Metrics are reported under their names.
+func (n *nn[T]) Evaluate(X, Y []Matrix[T], metrics ...metric.NamedMetric[T]) (Logs, error) {
+	if len(X) == 0 {
+		return nil, errors.New("no samples to evaluate")
+	}
+	if err := n.validateTrainSamples(X, Y); err != nil {
+		return nil, err
+	}
+	if err := n.validateMetrics(metrics); err != nil {
+		return nil, err
+	}
+	logs := Logs{CostKey: 0}
+	for i := 0; i < len(X); i++ {
+		prediction := n.Predict(X[i])
+		batchLogs := n.computeMetrics(metrics, Y[i], prediction)
+		batchLogs[CostKey] = n.ComputeCost(prediction, Y[i])
+		for key, value := range batchLogs {
+			logs[key] += value / float64(len(X))
+		}
+	}
+	return logs, nil
+}
+
+// createBackup dumps the ANN together with the training state into the backup folder.
+// Errors are only logged, so as not to interrupt the training.
+func (n *nn[T]) createBackup(parameters utils.NeuralNetworkParameters[T]) {
+	state := parameters.State()
+	err := DumpBackup(
+		n,
+		state,
+		filepath.Join(
+			parameters.Backups.Path,
+			fmt.Sprintf("epoch_%d.json", state.Step.Epoch),
+		),
+	)
+	if err != nil {
+		log.Printf("dump saving error: %s", err)
+	}
 }
 
 /************************************************************************/
 
-func (n nn) String() string {
+func (n nn[T]) String() string {
 	b := strings.Builder{}
 	for i, layer := range n.layers {
 		b.WriteString(fmt.Sprintf("Layer %d: %s\n", i, layer))
@@ -234,42 +458,36 @@ func (n nn) String() string {
 	return b.String()
 }
 
-func (n *nn) MarshalJSON() ([]byte, error) {
-	lossFullName := reflect.TypeOf(n.LossFunction).Name()
-	r := regexp.MustCompile(`^(.+)\[.+\]$`)
-
+func (n *nn[T]) MarshalJSON() ([]byte, error) {
+	lossFunction, err := MarshalLoss(n.LossFunction)
+	if err != nil {
+		return nil, err
+	}
 	return json.Marshal(&struct {
-		Layers       []Layer
-		LossFunction string
+		Layers       []Layer[T]
+		LossFunction json.RawMessage
 	}{
-		Layers: n.layers, LossFunction: r.FindStringSubmatch(lossFullName)[1],
+		Layers: n.layers, LossFunction: lossFunction,
 	})
 }
 
-func (n *nn) UnmarshalJSON(data []byte) error {
+func (n *nn[T]) UnmarshalJSON(data []byte) error {
 	var err error
 	var v struct {
 		Layers       []json.RawMessage
-		LossFunction string
+		LossFunction json.RawMessage
 	}
 	if err := json.Unmarshal(data, &v); err != nil {
 		return errors.New("invalid general NN unmarshalling")
 	}
-	n.layers = make([]Layer, len(v.Layers))
+	n.layers = make([]Layer[T], len(v.Layers))
 	for i, lData := range v.Layers {
-		var layer Layer
 		var layerType struct {
 			Type string
 		}
 		json.Unmarshal(lData, &layerType)
-		switch layerType.Type {
-		case "Dense":
-			W, _ := NewMatrix([][]float64{{}})
-			b, _ := NewMatrix([][]float64{{}})
-			layer, _ = NewDense(W, b, activation.Linear{})
-			err = layer.UnmarshalJSON(lData)
-		case "Dropout":
-			layer = NewDropout(0, 0)
+		layer, err := DynamicLayer[T](layerType.Type)
+		if err == nil {
 			err = layer.UnmarshalJSON(lData)
 		}
 		if err != nil {
@@ -280,9 +498,9 @@ func (n *nn) UnmarshalJSON(data []byte) error {
 		}
 		n.layers[i] = layer
 	}
-	n.LossFunction, err = DynamicLoss[float64](v.LossFunction)
+	n.LossFunction, err = UnmarshalLoss[T](v.LossFunction)
 	if err != nil {
-		return fmt.Errorf("invalid loss name: %s", v.LossFunction)
+		return errors.Join(errors.New("invalid loss function"), err)
 	}
-	return nil
+	return n.validateOutput()
 }
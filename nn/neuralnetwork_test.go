@@ -1,14 +1,22 @@
 package nn_test
 
 import (
+	"encoding/json"
 	"math"
+	"math/rand"
+	"path/filepath"
 	"testing"
 
 	"github.com/Hukyl/mlgo/activation"
+	"github.com/Hukyl/mlgo/autograd"
 	"github.com/Hukyl/mlgo/loss"
 	"github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/metric"
 	"github.com/Hukyl/mlgo/nn"
 	"github.com/Hukyl/mlgo/nn/layers"
+	"github.com/Hukyl/mlgo/optimizer"
+	"github.com/Hukyl/mlgo/scheduler"
+	"github.com/Hukyl/mlgo/utils"
 )
 
 func TestComputeCost_SquareLoss(t *testing.T) {
@@ -49,10 +57,10 @@ func TestComputeCost_SquareLoss(t *testing.T) {
 			// Arrange
 			W, _ := matrix.NewMatrix([][]float64{{1.0}})
 			b := matrix.NewZeroMatrix[float64](1, 1)
-			layer, _ := layers.NewDense(W, b, activation.Linear{})
+			layer, _ := layers.NewDense(W, b, activation.Linear[float64]{})
 
-			model := nn.NewNeuralNetwork(
-				[]layers.Layer{layer},
+			model, _ := nn.NewNeuralNetwork(
+				[]layers.Layer[float64]{layer},
 				loss.SquareLoss[float64]{},
 			)
 
@@ -74,10 +82,10 @@ func TestComputeCost_CCELoss(t *testing.T) {
 	// Arrange
 	W, _ := matrix.NewMatrix([][]float64{{1.0}})
 	b := matrix.NewZeroMatrix[float64](1, 1)
-	layer, _ := layers.NewDense(W, b, activation.Linear{})
+	layer, _ := layers.NewDense(W, b, activation.Linear[float64]{})
 
-	model := nn.NewNeuralNetwork(
-		[]layers.Layer{layer},
+	model, _ := nn.NewNeuralNetwork(
+		[]layers.Layer[float64]{layer},
 		loss.CategoricalCrossEntropyLoss[float64]{Epsilon: 1e-7},
 	)
 
@@ -105,3 +113,825 @@ func TestComputeCost_CCELoss(t *testing.T) {
 		t.Errorf("ComputeCost = %v, want %v", got, want)
 	}
 }
+
+func TestTrain_History(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		validation utils.ValidationParameters[float64]
+		wantKeys   []string
+	}{
+		{
+			desc:     "no-validation",
+			wantKeys: []string{nn.CostKey, nn.AccuracyKey, nn.LearningRateKey},
+		},
+		{
+			desc:       "validation-split",
+			validation: utils.ValidationParameters[float64]{Split: 0.5},
+			wantKeys: []string{
+				nn.CostKey, nn.AccuracyKey, nn.LearningRateKey,
+				nn.ValidationPrefix + nn.CostKey, nn.ValidationPrefix + nn.AccuracyKey,
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			model := newLinearModel(t, 0.5)
+			X, Y := linearData()
+			parameters := utils.NeuralNetworkParameters[float64]{
+				EpochCount:     4,
+				AccuracyMetric: metric.Accuracy[float64]{},
+				Validation:     tC.validation,
+			}
+
+			// Act
+			history, err := model.Train(X, Y, parameters)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Train error: %v", err)
+			}
+			if len(history.Epochs) != 4 || history.Epochs[3] != 4 {
+				t.Errorf("Epochs = %v, want [1 2 3 4]", history.Epochs)
+			}
+			if len(history.Values) != len(tC.wantKeys) {
+				t.Errorf("got %d history keys, want %d", len(history.Values), len(tC.wantKeys))
+			}
+			for _, key := range tC.wantKeys {
+				if len(history.Values[key]) != 4 {
+					t.Errorf("len(Values[%s]) = %d, want 4", key, len(history.Values[key]))
+				}
+			}
+			cost := history.Values[nn.CostKey]
+			if cost[3] >= cost[0] {
+				t.Errorf("cost did not decrease: %v", cost)
+			}
+		})
+	}
+}
+
+func TestTrain_ValidationData(t *testing.T) {
+	// Arrange
+	model := newLinearModel(t, 1.0)
+	X, Y := linearData()
+	validationX, _ := matrix.NewMatrix([][]float64{{1, 2}})
+	validationY, _ := matrix.NewMatrix([][]float64{{2, 4}})
+	parameters := utils.NeuralNetworkParameters[float64]{
+		EpochCount:          1,
+		InitialLearningRate: 1e-10,
+		AccuracyMetric:      metric.Accuracy[float64]{},
+		Validation: utils.ValidationParameters[float64]{
+			X: []matrix.Matrix[float64]{validationX},
+			Y: []matrix.Matrix[float64]{validationY},
+		},
+	}
+
+	// Act
+	history, err := model.Train(X, Y, parameters)
+
+	// Assert - the model predicts x, while validation labels are 2x
+	if err != nil {
+		t.Fatalf("Train error: %v", err)
+	}
+	want := (0.5*1*1 + 0.5*2*2) / 2
+	if got := history.Values[nn.ValidationPrefix+nn.CostKey][0]; math.Abs(got-want) > 1e-6 {
+		t.Errorf("validation cost = %v, want %v", got, want)
+	}
+}
+
+func TestTrain_InvalidValidationSplit(t *testing.T) {
+	// Arrange
+	model := newLinearModel(t, 0.5)
+	X, Y := linearData()
+	parameters := utils.NeuralNetworkParameters[float64]{
+		AccuracyMetric: metric.Accuracy[float64]{},
+		Validation:     utils.ValidationParameters[float64]{Split: 0.9},
+	}
+
+	// Act
+	_, err := model.Train(X, Y, parameters)
+
+	// Assert
+	if err == nil {
+		t.Error("expected error for validation split leaving no training batches")
+	}
+}
+
+func TestTrain_InvalidInput(t *testing.T) {
+	// Arrange
+	embedding, _ := layers.NewEmbedding[float64](
+		layers.EmbeddingParameters{VocabularySize: 10, Dimension: 2, TimeSteps: 1},
+		layers.XavierNormalInitialization{},
+	)
+	model, _ := nn.NewNeuralNetwork(
+		[]layers.Layer[float64]{
+			embedding,
+			layers.NewRandomDense([2]int{2, 1}, activation.Sigmoid[float64]{}, layers.XavierNormalInitialization{}),
+		},
+		loss.SquareLoss[float64]{},
+	)
+	X, _ := matrix.NewMatrix([][]float64{{1, 12}}) // 12 is not a valid token id
+	Y, _ := matrix.NewMatrix([][]float64{{0, 1}})
+
+	// Act
+	_, err := model.Train([]matrix.Matrix[float64]{X}, []matrix.Matrix[float64]{Y}, utils.NeuralNetworkParameters[float64]{})
+
+	// Assert
+	if err == nil {
+		t.Error("expected error for the input the embedding fails to propagate")
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	// Arrange
+	model := newLinearModel(t, 1.0)
+	X1, _ := matrix.NewMatrix([][]float64{{1, 2}})
+	Y1, _ := matrix.NewMatrix([][]float64{{1, 4}})
+	X2, _ := matrix.NewMatrix([][]float64{{3}})
+	Y2, _ := matrix.NewMatrix([][]float64{{3}})
+	metrics := []metric.NamedMetric[float64]{
+		{Name: "exact", Metric: metric.Accuracy[float64]{}},
+		{Name: "close", Metric: metric.Accuracy[float64]{Epsilon: 5}},
+	}
+
+	// Act
+	logs, err := model.Evaluate(
+		[]matrix.Matrix[float64]{X1, X2},
+		[]matrix.Matrix[float64]{Y1, Y2},
+		metrics...,
+	)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Evaluate error: %v", err)
+	}
+	// batch 1: cost = (0 + 0.5*2^2) / 2 = 1, exact = 0.5, close = 1
+	// batch 2: cost = 0, exact = 1, close = 1
+	want := nn.Logs{nn.CostKey: 0.5, "exact": 0.75, "close": 1.0}
+	if len(logs) != len(want) {
+		t.Errorf("got %d log values, want %d", len(logs), len(want))
+	}
+	for key, value := range want {
+		if math.Abs(logs[key]-value) > 1e-10 {
+			t.Errorf("logs[%s] = %v, want %v", key, logs[key], value)
+		}
+	}
+}
+
+func TestTrain_NamedMetrics(t *testing.T) {
+	// Arrange
+	model := newLinearModel(t, 0.5)
+	X, Y := linearData()
+	parameters := utils.NeuralNetworkParameters[float64]{
+		EpochCount: 2,
+		Metrics: []metric.NamedMetric[float64]{
+			{Name: "within_1", Metric: metric.Accuracy[float64]{Epsilon: 1}},
+			{Name: "within_10", Metric: metric.Accuracy[float64]{Epsilon: 10}},
+		},
+		Validation: utils.ValidationParameters[float64]{X: X, Y: Y},
+	}
+
+	// Act
+	history, err := model.Train(X, Y, parameters)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Train error: %v", err)
+	}
+	for _, key := range []string{"within_1", "within_10", nn.ValidationPrefix + "within_10"} {
+		if len(history.Values[key]) != 2 {
+			t.Errorf("len(Values[%s]) = %d, want 2", key, len(history.Values[key]))
+		}
+	}
+	if got := history.Values["within_10"][0]; got != 1.0 {
+		t.Errorf("within_10 = %v, want 1.0", got)
+	}
+	if _, ok := history.Values[nn.AccuracyKey]; ok {
+		t.Errorf("accuracy is reported without AccuracyMetric")
+	}
+}
+
+func TestLoadNeuralNetwork_SpatialLayers(t *testing.T) {
+	// Arrange
+	shape := layers.Shape{Channels: 1, Height: 6, Width: 6}
+	conv, _ := layers.NewConv2D(
+		shape,
+		layers.Conv2DParameters{Filters: 2, KernelSize: [2]int{3, 3}, Padding: [2]int{1, 1}},
+		activation.ReLU[float64]{},
+		layers.HeInitialization{},
+	)
+	pool, _ := layers.NewMaxPool2D[float64](conv.(layers.SpatialLayer[float64]).OutputShape(), [2]int{2, 2}, [2]int{})
+	averagePool, _ := layers.NewAveragePool2D[float64](pool.(layers.SpatialLayer[float64]).OutputShape(), [2]int{1, 1}, [2]int{})
+	flatten := layers.NewFlatten[float64](averagePool.(layers.SpatialLayer[float64]).OutputShape())
+	dense := layers.NewRandomDense([2]int{flatten.OutputSize()[0], 3}, activation.Sigmoid[float64]{}, layers.XavierUniformInitialization{})
+	model, _ := nn.NewNeuralNetwork(
+		[]layers.Layer[float64]{conv, pool, averagePool, flatten, dense},
+		loss.SquareLoss[float64]{},
+	)
+	path := filepath.Join(t.TempDir(), "model.json")
+	X := matrix.NewZeroMatrix[float64](shape.Size(), 2)
+	for i := 0; i < X.RowCount(); i++ {
+		X.Set(i, 0, float64(i%5))
+		X.Set(i, 1, float64(i%7)-3)
+	}
+
+	// Act
+	if err := nn.DumpNeuralNetwork(model, path); err != nil {
+		t.Fatalf("DumpNeuralNetwork error: %v", err)
+	}
+	loaded, err := nn.LoadNeuralNetwork[float64](path)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("LoadNeuralNetwork error: %v", err)
+	}
+	if !loaded.Predict(X).Equals(model.Predict(X)) {
+		t.Error("loaded model predictions differ from the original ones")
+	}
+}
+
+func TestLoadNeuralNetwork_NormalizationLayers(t *testing.T) {
+	// Arrange
+	batchNorm := layers.NewBatchNorm[float64](3, 0.9, 0)
+	model, _ := nn.NewNeuralNetwork(
+		[]layers.Layer[float64]{
+			layers.NewRandomDense([2]int{2, 3}, activation.Linear[float64]{}, layers.XavierNormalInitialization{}),
+			batchNorm,
+			layers.NewLayerNorm[float64](3, 0),
+			layers.NewRandomDense([2]int{3, 1}, activation.Sigmoid[float64]{}, layers.XavierNormalInitialization{}),
+		},
+		loss.SquareLoss[float64]{},
+	)
+	X, _ := matrix.NewMatrix([][]float64{{1, 2, -1}, {0, 3, 1}})
+	// update the running statistics, so that they differ from the initial ones
+	model.ForwardPropagate(X)
+	path := filepath.Join(t.TempDir(), "model.json")
+
+	// Act
+	if err := nn.DumpNeuralNetwork(model, path); err != nil {
+		t.Fatalf("DumpNeuralNetwork error: %v", err)
+	}
+	loaded, err := nn.LoadNeuralNetwork[float64](path)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("LoadNeuralNetwork error: %v", err)
+	}
+	if !loaded.Predict(X).Equals(model.Predict(X)) {
+		t.Error("loaded model predictions differ from the original ones")
+	}
+	if training := model.ForwardPropagate(X); training[len(training)-1][1].Equals(model.Predict(X)) {
+		t.Error("batch normalization uses batch statistics during inference")
+	}
+}
+
+func TestLoadNeuralNetwork_RecurrentLayers(t *testing.T) {
+	// Arrange
+	parameters := layers.RecurrentParameters{TimeSteps: 4, InputSize: 2, HiddenSize: 3, ReturnSequences: true}
+	forward, _ := layers.NewLSTM[float64](parameters, layers.XavierNormalInitialization{})
+	backward, _ := layers.NewLSTM[float64](parameters, layers.XavierNormalInitialization{})
+	lstm, _ := layers.NewBidirectional(forward, backward)
+	gru, _ := layers.NewGRU[float64](
+		layers.RecurrentParameters{TimeSteps: 4, InputSize: 6, HiddenSize: 3, ReturnSequences: true},
+		layers.XavierNormalInitialization{},
+	)
+	rnn, _ := layers.NewSimpleRNN[float64](
+		layers.RecurrentParameters{TimeSteps: 4, InputSize: 3, HiddenSize: 2},
+		layers.XavierNormalInitialization{},
+	)
+	model, _ := nn.NewNeuralNetwork(
+		[]layers.Layer[float64]{
+			lstm,
+			gru,
+			rnn,
+			layers.NewRandomDense([2]int{2, 1}, activation.Sigmoid[float64]{}, layers.XavierNormalInitialization{}),
+		},
+		loss.SquareLoss[float64]{},
+	)
+	X := matrix.NewZeroMatrix[float64](8, 2)
+	for i := 0; i < X.RowCount(); i++ {
+		X.Set(i, 0, float64(i%3)-1)
+		X.Set(i, 1, float64(i%5)/5)
+	}
+	path := filepath.Join(t.TempDir(), "model.json")
+
+	// Act
+	if err := nn.DumpNeuralNetwork(model, path); err != nil {
+		t.Fatalf("DumpNeuralNetwork error: %v", err)
+	}
+	loaded, err := nn.LoadNeuralNetwork[float64](path)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("LoadNeuralNetwork error: %v", err)
+	}
+	if !loaded.Predict(X).Equals(model.Predict(X)) {
+		t.Error("loaded model predictions differ from the original ones")
+	}
+}
+
+func TestLoadNeuralNetwork_Embedding(t *testing.T) {
+	// Arrange
+	embedding, _ := layers.NewEmbedding[float64](
+		layers.EmbeddingParameters{VocabularySize: 10, Dimension: 4, TimeSteps: 3, Frozen: true},
+		layers.XavierNormalInitialization{},
+	)
+	rnn, _ := layers.NewSimpleRNN[float64](
+		layers.RecurrentParameters{TimeSteps: 3, InputSize: 4, HiddenSize: 2},
+		layers.XavierNormalInitialization{},
+	)
+	model, _ := nn.NewNeuralNetwork(
+		[]layers.Layer[float64]{
+			embedding,
+			rnn,
+			layers.NewRandomDense([2]int{2, 1}, activation.Sigmoid[float64]{}, layers.XavierNormalInitialization{}),
+		},
+		loss.SquareLoss[float64]{},
+	)
+	X, _ := matrix.NewMatrix([][]float64{{1, 9}, {0, 3}, {7, 3}})
+	path := filepath.Join(t.TempDir(), "model.json")
+
+	// Act
+	if err := nn.DumpNeuralNetwork(model, path); err != nil {
+		t.Fatalf("DumpNeuralNetwork error: %v", err)
+	}
+	loaded, err := nn.LoadNeuralNetwork[float64](path)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("LoadNeuralNetwork error: %v", err)
+	}
+	if !loaded.Predict(X).Equals(model.Predict(X)) {
+		t.Error("loaded model predictions differ from the original ones")
+	}
+}
+
+func TestLoadNeuralNetwork_AttentionLayers(t *testing.T) {
+	// Arrange
+	parameters := layers.AttentionParameters{
+		TimeSteps: 3,
+		ModelSize: 4,
+		Heads:     2,
+		Mask:      [][]bool{{true, true, false}, {true, true, true}, {false, true, true}},
+	}
+	attention, _ := layers.NewMultiHeadAttention[float64](parameters, layers.XavierNormalInitialization{})
+	parameters.Causal, parameters.Mask = true, nil
+	block, _ := layers.NewTransformerEncoderBlock(parameters, 8, activation.ReLU[float64]{}, layers.HeInitialization{})
+	model, _ := nn.NewNeuralNetwork(
+		[]layers.Layer[float64]{
+			layers.NewPositionalEncoding[float64](3, 4),
+			attention,
+			block,
+			layers.NewRandomDense([2]int{12, 1}, activation.Sigmoid[float64]{}, layers.XavierNormalInitialization{}),
+		},
+		loss.SquareLoss[float64]{},
+	)
+	X := matrix.NewZeroMatrix[float64](12, 2)
+	for i := 0; i < X.RowCount(); i++ {
+		X.Set(i, 0, float64(i%3)-1)
+		X.Set(i, 1, float64(i%5)/5)
+	}
+	path := filepath.Join(t.TempDir(), "model.json")
+
+	// Act
+	if err := nn.DumpNeuralNetwork(model, path); err != nil {
+		t.Fatalf("DumpNeuralNetwork error: %v", err)
+	}
+	loaded, err := nn.LoadNeuralNetwork[float64](path)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("LoadNeuralNetwork error: %v", err)
+	}
+	if !loaded.Predict(X).Equals(model.Predict(X)) {
+		t.Error("loaded model predictions differ from the original ones")
+	}
+}
+
+func TestTrain_Float32(t *testing.T) {
+	// Arrange
+	hidden := layers.NewRandomDense([2]int{2, 4}, activation.ReLU[float32]{}, layers.HeInitialization{})
+	output := layers.NewRandomDense([2]int{4, 1}, activation.Sigmoid[float32]{}, layers.XavierUniformInitialization{})
+	model, _ := nn.NewNeuralNetwork([]layers.Layer[float32]{hidden, output}, loss.LogLoss[float32]{})
+	X, _ := matrix.NewMatrix([][]float32{{0, 1, 2, 3, -1, -2}, {1, 0, 2, -1, 3, 0}})
+	Y, _ := matrix.NewMatrix([][]float32{{0, 0, 1, 1, 0, 0}})
+	parameters := utils.NeuralNetworkParameters[float32]{
+		EpochCount:          20,
+		InitialLearningRate: 0.1,
+		Optimizer:           &optimizer.Adam[float32]{},
+		AccuracyMetric:      metric.Accuracy[float32]{Epsilon: 0.5},
+	}
+	path := filepath.Join(t.TempDir(), "model.json")
+
+	// Act
+	history, err := model.Train([]matrix.Matrix[float32]{X}, []matrix.Matrix[float32]{Y}, parameters)
+	if err != nil {
+		t.Fatalf("Train error: %v", err)
+	}
+	if err := nn.DumpNeuralNetwork(model, path); err != nil {
+		t.Fatalf("DumpNeuralNetwork error: %v", err)
+	}
+	loaded, err := nn.LoadNeuralNetwork[float32](path)
+	if err != nil {
+		t.Fatalf("LoadNeuralNetwork error: %v", err)
+	}
+	widened, err := nn.LoadNeuralNetwork[float64](path)
+	if err != nil {
+		t.Fatalf("LoadNeuralNetwork[float64] error: %v", err)
+	}
+
+	// Assert
+	cost := history.Values[nn.CostKey]
+	if cost[len(cost)-1] >= cost[0] {
+		t.Errorf("cost did not decrease: %v", cost)
+	}
+	prediction := model.Predict(X)
+	if !loaded.Predict(X).Equals(prediction) {
+		t.Error("loaded model predictions differ from the original ones")
+	}
+	widePrediction := widened.Predict(matrix.Convert[float64](X))
+	for j := 0; j < prediction.ColumnCount(); j++ {
+		want, _ := prediction.At(0, j)
+		got, _ := widePrediction.At(0, j)
+		if math.Abs(got-float64(want)) > 1e-5 {
+			t.Errorf("float64 prediction #%d = %v, want %v", j, got, want)
+		}
+	}
+}
+
+func TestTrain_Seeded(t *testing.T) {
+	train := func(seed int64) []byte {
+		r := rand.New(rand.NewSource(seed))
+		model, _ := nn.NewNeuralNetwork(
+			[]layers.Layer[float64]{
+				layers.NewRandomDense([2]int{2, 8}, activation.ReLU[float64]{}, layers.HeInitialization{Rand: r}),
+				layers.NewDropout[float64](8, 0.3),
+				layers.NewRandomDense([2]int{8, 1}, activation.Sigmoid[float64]{}, layers.XavierUniformInitialization{Rand: r}),
+			},
+			loss.LogLoss[float64]{},
+		)
+		X, _ := matrix.NewMatrix([][]float64{{0, 1, 2, 3, -1, -2}, {1, 0, 2, -1, 3, 0}})
+		Y, _ := matrix.NewMatrix([][]float64{{0, 0, 1, 1, 0, 0}})
+		parameters := utils.NeuralNetworkParameters[float64]{
+			EpochCount:     5,
+			AccuracyMetric: metric.Accuracy[float64]{Epsilon: 0.5},
+			Rand:           r,
+		}
+		history, err := model.Train([]matrix.Matrix[float64]{X, X}, []matrix.Matrix[float64]{Y, Y}, parameters)
+		if err != nil {
+			t.Fatalf("Train error: %v", err)
+		}
+		result, _ := json.Marshal(struct {
+			Model   nn.NeuralNetwork[float64]
+			History nn.History
+		}{model, history})
+		return result
+	}
+
+	// Act
+	first, second, other := train(7), train(7), train(8)
+
+	// Assert
+	if string(first) != string(second) {
+		t.Error("training runs with the same seed produced different models")
+	}
+	if string(first) == string(other) {
+		t.Error("training runs with different seeds produced the same model")
+	}
+}
+
+func TestNewNeuralNetwork_OutputPairing(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		activation activation.ActivationFunction[float64]
+		loss       loss.LossFunction[float64]
+		wantErr    bool
+	}{
+		{desc: "fused-pair", activation: activation.SoftmaxWithCCE[float64]{}, loss: loss.CCELossWithSoftmax[float64]{}},
+		{desc: "softmax-square", activation: activation.Softmax[float64]{}, loss: loss.SquareLoss[float64]{}},
+		{desc: "softmax-cce", activation: activation.Softmax[float64]{}, loss: loss.CategoricalCrossEntropyLoss[float64]{}},
+		{desc: "fused-activation-only", activation: activation.SoftmaxWithCCE[float64]{}, loss: loss.SquareLoss[float64]{}, wantErr: true},
+		{desc: "fused-loss-only", activation: activation.Softmax[float64]{}, loss: loss.CCELossWithSoftmax[float64]{}, wantErr: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			layer := layers.NewRandomDense([2]int{2, 3}, tC.activation, layers.XavierUniformInitialization{})
+
+			// Act
+			_, err := nn.NewNeuralNetwork([]layers.Layer[float64]{layer}, tC.loss)
+
+			// Assert
+			if (err != nil) != tC.wantErr {
+				t.Errorf("NewNeuralNetwork() error = %v, wantErr %v", err, tC.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewNeuralNetwork_HiddenFusedSoftmax(t *testing.T) {
+	// Arrange
+	hidden := layers.NewRandomDense([2]int{2, 3}, activation.SoftmaxWithCCE[float64]{}, layers.XavierUniformInitialization{})
+	output := layers.NewRandomDense([2]int{3, 3}, activation.SoftmaxWithCCE[float64]{}, layers.XavierUniformInitialization{})
+
+	// Act
+	_, err := nn.NewNeuralNetwork([]layers.Layer[float64]{hidden, output}, loss.CCELossWithSoftmax[float64]{})
+
+	// Assert
+	if err == nil {
+		t.Error("expected error for SoftmaxWithCCE used by a hidden layer")
+	}
+}
+
+func TestTrain_SoftmaxWithSquareLoss(t *testing.T) {
+	// Arrange
+	model, err := nn.NewNeuralNetwork(
+		[]layers.Layer[float64]{
+			layers.NewRandomDense([2]int{2, 3}, activation.Softmax[float64]{}, layers.XavierUniformInitialization{}),
+		},
+		loss.SquareLoss[float64]{},
+	)
+	if err != nil {
+		t.Fatalf("NewNeuralNetwork error: %v", err)
+	}
+	X, _ := matrix.NewMatrix([][]float64{{1, 0, -1, 2}, {0, 1, -1, 0}})
+	Y, _ := matrix.NewMatrix([][]float64{{1, 0, 0, 1}, {0, 1, 0, 0}, {0, 0, 1, 0}})
+	parameters := utils.NeuralNetworkParameters[float64]{
+		EpochCount:          10,
+		InitialLearningRate: 1,
+		AccuracyMetric:      metric.CategoricalAccuracy[float64]{},
+	}
+
+	// Act
+	history, err := model.Train([]matrix.Matrix[float64]{X}, []matrix.Matrix[float64]{Y}, parameters)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Train error: %v", err)
+	}
+	if cost := history.Values[nn.CostKey]; cost[len(cost)-1] >= cost[0] {
+		t.Errorf("cost did not decrease: %v", cost)
+	}
+}
+
+// cube is an activation function, defined outside the activation package.
+type cube struct {
+	Scale float64
+}
+
+func (c cube) Apply(x float64) float64              { return c.Scale * x * x * x }
+func (c cube) ApplyMatrix(M matrix.Matrix[float64]) { matrix.ApplyByElement(M, c.Apply) }
+func (c cube) Derivative(x float64) float64         { return 3 * c.Scale * x * x }
+func (c cube) DerivativeMatrix(M matrix.Matrix[float64]) matrix.Matrix[float64] {
+	return matrix.Map(M, c.Derivative)
+}
+
+// halfSquareLoss is a loss function, defined outside the loss package.
+type halfSquareLoss struct {
+	loss.SquareLoss[float64]
+}
+
+func doubleLayer() layers.Layer[float64] {
+	layer, _ := layers.NewCustom[float64]("Double", 2, 2,
+		func(X *autograd.Variable, p []*autograd.Variable) *autograd.Variable {
+			return X.Mul(p[0])
+		},
+		matrix.NewOnesMatrix[float64](2, 1).MultiplyByScalar(2),
+	)
+	return layer
+}
+
+func init() {
+	layers.RegisterLayer("Double", doubleLayer)
+	activation.RegisterActivation("cube", func() activation.ActivationFunction[float64] { return cube{Scale: 1} })
+	loss.RegisterLoss("halfSquareLoss", func() loss.LossFunction[float64] { return halfSquareLoss{} })
+}
+
+func TestLoadNeuralNetwork_RegisteredComponents(t *testing.T) {
+	// Arrange
+	W, _ := matrix.NewMatrix([][]float64{{0.5, -0.2}})
+	b, _ := matrix.NewMatrix([][]float64{{0.1}})
+	output, _ := layers.NewDense[float64](W, b, cube{Scale: 0.5})
+	model, _ := nn.NewNeuralNetwork([]layers.Layer[float64]{doubleLayer(), output}, halfSquareLoss{})
+	X, _ := matrix.NewMatrix([][]float64{{1, -1, 0.5}, {2, 0, -3}})
+	path := filepath.Join(t.TempDir(), "model.json")
+
+	// Act
+	if err := nn.DumpNeuralNetwork(model, path); err != nil {
+		t.Fatalf("DumpNeuralNetwork error: %v", err)
+	}
+	loaded, err := nn.LoadNeuralNetwork[float64](path)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("LoadNeuralNetwork error: %v", err)
+	}
+	if !loaded.Predict(X).Equals(model.Predict(X)) {
+		t.Errorf("loaded predictions = %v, want %v", loaded.Predict(X), model.Predict(X))
+	}
+	if _, err := nn.LoadNeuralNetwork[float32](path); err == nil {
+		t.Error("LoadNeuralNetwork[float32] error = nil, want error for the components registered for float64")
+	}
+}
+
+// mustLoss returns the loss function produced by a constructor, panicking on its error.
+func mustLoss(l loss.LossFunction[float64], err error) loss.LossFunction[float64] {
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
+func TestLoadNeuralNetwork_LossParameters(t *testing.T) {
+	testCases := []struct {
+		desc string
+		l    loss.LossFunction[float64]
+	}{
+		{desc: "huber", l: mustLoss(loss.NewHuberLoss[float64](0.3))},
+		{desc: "quantile", l: mustLoss(loss.NewQuantileLoss[float64](0.9))},
+		{desc: "quantile-zero", l: mustLoss(loss.NewQuantileLoss[float64](0))},
+		{desc: "percentage", l: loss.MeanAbsolutePercentageError[float64]{Epsilon: 0.5}},
+		{desc: "categorical-cross-entropy", l: loss.CategoricalCrossEntropyLoss[float64]{Epsilon: 0.1}},
+		{desc: "focal-zero-gamma", l: loss.NewFocalLoss[float64](0, 0.4)},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			model, _ := nn.NewNeuralNetwork(
+				[]layers.Layer[float64]{
+					layers.NewRandomDense([2]int{2, 2}, activation.Linear[float64]{}, layers.XavierUniformInitialization{}),
+				},
+				tC.l,
+			)
+			yHat, _ := matrix.NewMatrix([][]float64{{2, -1}, {0.5, 0.1}})
+			Y, _ := matrix.NewMatrix([][]float64{{0, 0.2}, {1, 0}})
+			path := filepath.Join(t.TempDir(), "model.json")
+
+			// Act
+			if err := nn.DumpNeuralNetwork(model, path); err != nil {
+				t.Fatalf("DumpNeuralNetwork error: %v", err)
+			}
+			loaded, err := nn.LoadNeuralNetwork[float64](path)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("LoadNeuralNetwork error: %v", err)
+			}
+			if got, want := loaded.ComputeCost(yHat, Y), model.ComputeCost(yHat, Y); got != want {
+				t.Errorf("loaded ComputeCost() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestTrain_SparseLabels(t *testing.T) {
+	// Arrange
+	model, _ := nn.NewNeuralNetwork(
+		[]layers.Layer[float64]{
+			layers.NewRandomDense([2]int{2, 3}, activation.Softmax[float64]{}, layers.XavierUniformInitialization{}),
+		},
+		loss.SparseCategoricalCrossEntropy[float64]{},
+	)
+	X, _ := matrix.NewMatrix([][]float64{{2, 0, -2, 2, 0, -2}, {0, 2, 0, 0, 2, 0}})
+	Y, _ := matrix.NewMatrix([][]float64{{0, 1, 2, 0, 1, 2}})
+	parameters := utils.NeuralNetworkParameters[float64]{
+		EpochCount:          30,
+		InitialLearningRate: 0.5,
+		Metrics: []metric.NamedMetric[float64]{
+			{Name: "categorical", Metric: metric.CategoricalAccuracy[float64]{}},
+			{Name: "sparse", Metric: metric.SparseCategoricalAccuracy[float64]{}},
+		},
+		Validation: utils.ValidationParameters[float64]{Split: 0.5},
+	}
+
+	// Act
+	history, err := model.Train([]matrix.Matrix[float64]{X, X}, []matrix.Matrix[float64]{Y, Y}, parameters)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Train error: %v", err)
+	}
+	cost := history.Values[nn.CostKey]
+	if cost[len(cost)-1] >= cost[0] {
+		t.Errorf("cost did not decrease: %v", cost)
+	}
+	// both metrics see the same labels, either converted to one-hot or as is
+	categorical, sparse := history.Values[nn.ValidationPrefix+"categorical"], history.Values[nn.ValidationPrefix+"sparse"]
+	if len(categorical) == 0 || categorical[len(categorical)-1] != sparse[len(sparse)-1] {
+		t.Errorf("validation accuracies differ: %v and %v", categorical, sparse)
+	}
+}
+
+func TestTrain_MetricLabels(t *testing.T) {
+	// Arrange - the one-hot labels cannot be given to a metric of the class indices
+	model, _ := nn.NewNeuralNetwork(
+		[]layers.Layer[float64]{
+			layers.NewRandomDense([2]int{2, 3}, activation.Softmax[float64]{}, layers.XavierUniformInitialization{}),
+		},
+		loss.CategoricalCrossEntropyLoss[float64]{},
+	)
+	X, _ := matrix.NewMatrix([][]float64{{2, 0, -2}, {0, 2, 0}})
+	Y, _ := matrix.NewMatrix([][]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}})
+	parameters := utils.NeuralNetworkParameters[float64]{AccuracyMetric: metric.SparseCategoricalAccuracy[float64]{}}
+
+	// Act
+	_, err := model.Train([]matrix.Matrix[float64]{X}, []matrix.Matrix[float64]{Y}, parameters)
+
+	// Assert
+	if err == nil {
+		t.Error("expected error for the labels, which do not fit the metric")
+	}
+}
+
+func TestLoadBackup_KeepsSchedulerState(t *testing.T) {
+	// Arrange
+	s := &scheduler.ReduceLROnPlateau{Factor: 0.5, Patience: 1}
+	for _, cost := range []float64{1, 0.9, 0.95, 0.97, 0.99} {
+		s.Observe(cost)
+	}
+	model, _ := nn.NewNeuralNetwork(
+		[]layers.Layer[float64]{
+			layers.NewRandomDense([2]int{2, 1}, activation.Linear[float64]{}, layers.XavierUniformInitialization{}),
+		},
+		loss.SquareLoss[float64]{},
+	)
+	state := utils.TrainingState{Step: scheduler.Step{Epoch: 5, Batch: 20}, Scheduler: s}
+	path := filepath.Join(t.TempDir(), "backup.json")
+
+	// Act
+	if err := nn.DumpBackup(model, state, path); err != nil {
+		t.Fatalf("DumpBackup error: %v", err)
+	}
+	_, loaded, err := nn.LoadBackup[float64](path)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("LoadBackup error: %v", err)
+	}
+	if loaded.Step != state.Step {
+		t.Errorf("loaded Step = %v, want %v", loaded.Step, state.Step)
+	}
+	if got, want := loaded.Scheduler.LearningRate(1, scheduler.Step{}), s.LearningRate(1, scheduler.Step{}); got != want || want == 1 {
+		t.Errorf("loaded LearningRate() = %v, want %v (reduced)", got, want)
+	}
+	loaded.Scheduler.(scheduler.CostObserver).Observe(1)
+	s.Observe(1)
+	if got, want := loaded.Scheduler.LearningRate(1, scheduler.Step{}), s.LearningRate(1, scheduler.Step{}); got != want {
+		t.Errorf("LearningRate() after Observe = %v, want %v", got, want)
+	}
+	if _, err := nn.LoadNeuralNetwork[float64](path); err != nil {
+		t.Errorf("LoadNeuralNetwork error on backup: %v", err)
+	}
+}
+
+func TestTrain_ResumesFromBackup(t *testing.T) {
+	// Arrange
+	model, _ := nn.NewNeuralNetwork(
+		[]layers.Layer[float64]{
+			layers.NewRandomDense([2]int{2, 1}, activation.Linear[float64]{}, layers.XavierUniformInitialization{}),
+		},
+		loss.SquareLoss[float64]{},
+	)
+	X, _ := matrix.NewMatrix([][]float64{{1, -1, 0.5}, {2, 0, -3}})
+	Y, _ := matrix.NewMatrix([][]float64{{1, 0, -1}})
+	dir := t.TempDir()
+	parameters := utils.NeuralNetworkParameters[float64]{
+		EpochCount:            2,
+		InitialLearningRate:   0.01,
+		LearningRateScheduler: scheduler.StepDecay{StepSize: 1, Gamma: 0.5},
+		Backups:               utils.BackupParameters{ToCreate: true, Path: dir},
+	}
+	if _, err := model.Train([]matrix.Matrix[float64]{X, X}, []matrix.Matrix[float64]{Y, Y}, parameters); err != nil {
+		t.Fatalf("Train error: %v", err)
+	}
+
+	// Act
+	loaded, state, err := nn.LoadBackup[float64](filepath.Join(dir, "epoch_2.json"))
+	if err != nil {
+		t.Fatalf("LoadBackup error: %v", err)
+	}
+	resumed := utils.NeuralNetworkParameters[float64]{
+		EpochCount:          1,
+		InitialLearningRate: 0.01,
+		Backups:             utils.BackupParameters{ToCreate: true, Path: dir},
+	}
+	resumed.Resume(state)
+	history, err := loaded.Train([]matrix.Matrix[float64]{X, X}, []matrix.Matrix[float64]{Y, Y}, resumed)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Train error after resume: %v", err)
+	}
+	if want := (scheduler.Step{Epoch: 2, Batch: 4}); state.Step != want {
+		t.Errorf("backup Step = %v, want %v", state.Step, want)
+	}
+	if got, want := resumed.LearningRate(), 0.01*0.25; math.Abs(got-want) > 1e-12 {
+		t.Errorf("resumed LearningRate() = %v, want %v", got, want)
+	}
+	if got := history.Logs(0)[nn.LearningRateKey]; math.Abs(got-0.01*0.25) > 1e-12 {
+		t.Errorf("learning rate of the resumed epoch = %v, want %v", got, 0.01*0.25)
+	}
+	if _, _, err := nn.LoadBackup[float64](filepath.Join(dir, "epoch_3.json")); err != nil {
+		t.Errorf("LoadBackup error for the resumed epoch: %v", err)
+	}
+}
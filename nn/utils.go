@@ -2,12 +2,23 @@ package nn
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"os"
+	"strings"
 
 	"github.com/Hukyl/mlgo/loss"
+	"github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/metric"
 	"github.com/Hukyl/mlgo/nn/layers"
+	"github.com/Hukyl/mlgo/utils"
+	. "golang.org/x/exp/constraints"
 )
 
+// trainingStateKey is the key of the training state in the backups of the ANN.
+const trainingStateKey = "TrainingState"
+
 func jsonifyObject(obj interface{}, path string) error {
 	f1, err := os.Create(path)
 	if err != nil {
@@ -23,23 +34,75 @@ func jsonifyObject(obj interface{}, path string) error {
 	return nil
 }
 
+// metricLabelRows returns the number of rows of the labels of the metric for the prediction
+// with the given number of rows (see metric.SparseLabels).
+func metricLabelRows[T Float](m metric.NamedMetric[T], outputRows int) int {
+	if s, ok := m.Metric.(metric.SparseLabels); ok {
+		return s.LabelRows(outputRows)
+	}
+	return outputRows
+}
+
+// formatLogs formats the epoch logs for printing, with the cost going first
+// and the metrics following in the given order.
+func formatLogs[T Float](logs Logs, metrics []metric.NamedMetric[T], withValidation bool) string {
+	b := strings.Builder{}
+	b.WriteString(fmt.Sprintf("avg_cost: %-10.5g", logs[CostKey]))
+	for _, m := range metrics {
+		b.WriteString(fmt.Sprintf(" avg_%s: %-10.5g", m.Name, logs[m.Name]))
+	}
+	if withValidation {
+		b.WriteString(fmt.Sprintf(" %s%s: %-10.5g", ValidationPrefix, CostKey, logs[ValidationPrefix+CostKey]))
+		for _, m := range metrics {
+			b.WriteString(fmt.Sprintf(" %s%s: %-10.5g", ValidationPrefix, m.Name, logs[ValidationPrefix+m.Name]))
+		}
+	}
+	return b.String()
+}
+
+// splitValidation separates the validation batches from the training ones.
+//
+// If the validation data is provided, it is returned as is. Otherwise, if the validation
+// split is set, the last batches of the training data are used for validation.
+func splitValidation[T Float](X, Y []matrix.Matrix[T], v utils.ValidationParameters[T]) (
+	trainX, trainY, validationX, validationY []matrix.Matrix[T],
+	err error,
+) {
+	if len(v.X) > 0 || v.Split == 0 {
+		return X, Y, v.X, v.Y, nil
+	}
+	if v.Split < 0 || v.Split >= 1 {
+		return nil, nil, nil, nil, errors.New("validation split must be in range [0;1)")
+	}
+	count := int(math.Ceil(v.Split * float64(len(X))))
+	if count >= len(X) {
+		return nil, nil, nil, nil, errors.New("not enough batches for validation split")
+	}
+	split := len(X) - count
+	return X[:split], Y[:split], X[split:], Y[split:], nil
+}
+
 // DumpNeuralNetwork dumps the JSON represantation to a file given by a path.
 //
 // The creating of the file is managed by os.Create function.
-func DumpNeuralNetwork(nn NeuralNetwork, path string) error {
+//
+// Only the ANN itself is dumped. To resume the training later, DumpBackup has to be used
+// instead, which also dumps the training state.
+func DumpNeuralNetwork(nn Model, path string) error {
 	return jsonifyObject(nn, path)
 }
 
-// LoadNeuralNetwork loads ANN from a JSON file given by path.
+// LoadNeuralNetwork loads ANN from a JSON file given by path. The float type of
+// the ANN does not have to match the one it was dumped with.
 //
 // Errors are returned due to any errors in the unmarshalling of any of the layers.
-func LoadNeuralNetwork(path string) (NeuralNetwork, error) {
+func LoadNeuralNetwork[T Float](path string) (NeuralNetwork[T], error) {
 	fileContent, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
 
-	nn := new(nn)
+	nn := new(nn[T])
 
 	// Unmarshal JSON data into the struct
 	err = json.Unmarshal(fileContent, nn)
@@ -50,8 +113,58 @@ func LoadNeuralNetwork(path string) (NeuralNetwork, error) {
 	return nn, nil
 }
 
+// DumpBackup dumps the JSON represantation of the ANN together with the training state
+// (see utils.TrainingState) to a file given by a path. The file can be loaded either
+// by LoadBackup to resume the training, or by LoadNeuralNetwork.
+func DumpBackup(nn Model, state utils.TrainingState, path string) error {
+	data, err := json.Marshal(nn)
+	if err != nil {
+		return err
+	}
+	var backup map[string]json.RawMessage
+	if err = json.Unmarshal(data, &backup); err != nil {
+		return err
+	}
+	if backup[trainingStateKey], err = json.Marshal(state); err != nil {
+		return err
+	}
+	return jsonifyObject(backup, path)
+}
+
+// LoadBackup loads ANN and the training state from a JSON file given by path, dumped
+// either by DumpBackup or during the training (see utils.BackupParameters). To resume
+// the training, the state has to be passed to utils.NeuralNetworkParameters.Resume.
+func LoadBackup[T Float](path string) (NeuralNetwork[T], utils.TrainingState, error) {
+	var state utils.TrainingState
+	nn, err := LoadNeuralNetwork[T](path)
+	if err != nil {
+		return nil, state, err
+	}
+	fileContent, err := os.ReadFile(path)
+	if err != nil {
+		return nil, state, err
+	}
+	var backup map[string]json.RawMessage
+	if err = json.Unmarshal(fileContent, &backup); err != nil {
+		return nil, state, err
+	}
+	if data, ok := backup[trainingStateKey]; ok {
+		if err = json.Unmarshal(data, &state); err != nil {
+			return nil, state, err
+		}
+	}
+	return nn, state, nil
+}
+
 // NewNeuralNetwork produces an ANN based on layer slice and loss function applied to the \
 // last layer.
-func NewNeuralNetwork(layers []layers.Layer, lossFunction loss.LossFunction[float64]) NeuralNetwork {
-	return &nn{layers: layers, LossFunction: lossFunction}
+//
+// Returns error if there are no layers, or the activation of the last layer and the loss
+// function are mismatched, i.e. only one of SoftmaxWithCCE and CCELossWithSoftmax is used.
+func NewNeuralNetwork[T Float](layers []layers.Layer[T], lossFunction loss.LossFunction[T]) (NeuralNetwork[T], error) {
+	n := &nn[T]{layers: layers, LossFunction: lossFunction}
+	if err := n.validateOutput(); err != nil {
+		return nil, err
+	}
+	return n, nil
 }
@@ -0,0 +1,64 @@
+package optimizer
+
+import (
+	"math"
+
+	. "github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
+)
+
+// Adagrad is an optimizer, which adapts the learning rate for each parameter
+// by dividing it by the root of the sum of all past squared gradients.
+//
+//	s = s + dW^2
+//	W = W - learningRate * dW / (sqrt(s) + Epsilon)
+//
+// InitialAccumulator is the starting value of s. If not set, s starts from 0.
+//
+// Epsilon prevents the division by zero. If nil, it is initialized to 1e-8.
+type Adagrad[T Float] struct {
+	InitialAccumulator float64
+	Epsilon            *float64
+
+	accumulators map[*Matrix[T]]Matrix[T]
+}
+
+// NewAdagrad produces an Adagrad optimizer with the given hyperparameters.
+func NewAdagrad[T Float](initialAccumulator, epsilon float64) *Adagrad[T] {
+	return &Adagrad[T]{InitialAccumulator: initialAccumulator, Epsilon: &epsilon}
+}
+
+func (a *Adagrad[T]) Update(parameter *Matrix[T], gradient Matrix[T], learningRate, weightDecay float64) {
+	updated := (*parameter).DeepCopy()
+	a.step(updated, gradient, parameter, nil, learningRate, weightDecay)
+	*parameter = updated
+}
+
+func (a *Adagrad[T]) UpdateRows(parameter *Matrix[T], gradient Matrix[T], rows []int, learningRate, weightDecay float64) {
+	a.step(*parameter, gradient, parameter, rows, learningRate, weightDecay)
+}
+
+// step performs a single Adagrad step on the given rows of the parameter in place,
+// keeping the accumulator by the key.
+func (a *Adagrad[T]) step(parameter, gradient Matrix[T], key *Matrix[T], rows []int, learningRate, weightDecay float64) {
+	epsilon := valueOr(a.Epsilon, defaultEpsilon)
+	if a.accumulators == nil {
+		a.accumulators = make(map[*Matrix[T]]Matrix[T])
+	}
+	s, ok := a.accumulators[key]
+	if !ok || !s.AreSameSize(parameter) {
+		s = NewZeroMatrix[T](parameter.RowCount(), parameter.ColumnCount())
+		s = s.AddScalar(T(a.InitialAccumulator))
+		a.accumulators[key] = s
+	}
+
+	forEachElement(parameter, rows, func(i, k, j int) {
+		g := gradientAt(parameter, gradient, i, k, j, weightDecay)
+		sValue, _ := s.At(i, j)
+		sValue += T(g * g)
+		s.Set(i, j, sValue)
+
+		w, _ := parameter.At(i, j)
+		parameter.Set(i, j, w-T(learningRate*g/(math.Sqrt(float64(sValue))+epsilon)))
+	})
+}
@@ -0,0 +1,130 @@
+package optimizer
+
+import (
+	"math"
+
+	. "github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
+)
+
+const (
+	defaultBeta1   = 0.9
+	defaultBeta2   = 0.999
+	defaultEpsilon = 1e-8
+)
+
+// Adam (adaptive moment estimation) is an optimizer, which keeps exponentially
+// decaying averages of the past gradients (first moment) and of the past squared
+// gradients (second moment), correcting them for the bias towards zero.
+//
+//	m = Beta1*m + (1-Beta1)*dW
+//	v = Beta2*v + (1-Beta2)*dW^2
+//	mHat = m / (1 - Beta1^t)
+//	vHat = v / (1 - Beta2^t)
+//	W = W - learningRate * mHat / (sqrt(vHat) + Epsilon)
+//
+// Weight decay is applied as L2 regularization, i.e. added to the gradient.
+// If nil, Beta1, Beta2 and Epsilon are initialized to 0.9, 0.999 and 1e-8 respectively.
+type Adam[T Float] struct {
+	Beta1   *float64
+	Beta2   *float64
+	Epsilon *float64
+
+	moments map[*Matrix[T]]*adamMoments[T]
+}
+
+// NewAdam produces an Adam optimizer with the given hyperparameters.
+func NewAdam[T Float](beta1, beta2, epsilon float64) *Adam[T] {
+	return &Adam[T]{Beta1: &beta1, Beta2: &beta2, Epsilon: &epsilon}
+}
+
+type adamMoments[T Float] struct {
+	m, v Matrix[T]
+	t    int
+}
+
+func (a *Adam[T]) Update(parameter *Matrix[T], gradient Matrix[T], learningRate, weightDecay float64) {
+	updated := (*parameter).DeepCopy()
+	a.step(updated, gradient, parameter, nil, learningRate, weightDecay)
+	*parameter = updated
+}
+
+// UpdateRows updates only the given rows of the parameter. The step count, used
+// for the bias correction, is shared by all the rows, as in lazy Adam.
+func (a *Adam[T]) UpdateRows(parameter *Matrix[T], gradient Matrix[T], rows []int, learningRate, weightDecay float64) {
+	a.step(*parameter, gradient, parameter, rows, learningRate, weightDecay)
+}
+
+// step performs a single Adam step on the given rows of the parameter in place,
+// keeping the moments by the key. The weight decay is added to the gradient.
+func (a *Adam[T]) step(parameter, gradient Matrix[T], key *Matrix[T], rows []int, learningRate, weightDecay float64) {
+	beta1 := valueOr(a.Beta1, defaultBeta1)
+	beta2 := valueOr(a.Beta2, defaultBeta2)
+	epsilon := valueOr(a.Epsilon, defaultEpsilon)
+
+	if a.moments == nil {
+		a.moments = make(map[*Matrix[T]]*adamMoments[T])
+	}
+	state, ok := a.moments[key]
+	if !ok || !state.m.AreSameSize(parameter) {
+		state = &adamMoments[T]{
+			m: NewZeroMatrix[T](parameter.RowCount(), parameter.ColumnCount()),
+			v: NewZeroMatrix[T](parameter.RowCount(), parameter.ColumnCount()),
+		}
+		a.moments[key] = state
+	}
+	state.t++
+	correction1 := 1 - math.Pow(beta1, float64(state.t))
+	correction2 := 1 - math.Pow(beta2, float64(state.t))
+
+	forEachElement(parameter, rows, func(i, k, j int) {
+		g := gradientAt(parameter, gradient, i, k, j, weightDecay)
+		mValue, _ := state.m.At(i, j)
+		vValue, _ := state.v.At(i, j)
+		m := beta1*float64(mValue) + (1-beta1)*g
+		v := beta2*float64(vValue) + (1-beta2)*g*g
+		state.m.Set(i, j, T(m))
+		state.v.Set(i, j, T(v))
+
+		w, _ := parameter.At(i, j)
+		w -= T(learningRate * (m / correction1) / (math.Sqrt(v/correction2) + epsilon))
+		parameter.Set(i, j, w)
+	})
+}
+
+// AdamW is an Adam optimizer with decoupled weight decay, i.e. the weight decay
+// is not added to the gradient, but applied directly to the parameter.
+//
+//	W = W - learningRate * weightDecay * W
+//	W = Adam(W, dW)
+type AdamW[T Float] struct {
+	Adam[T]
+}
+
+// NewAdamW produces an AdamW optimizer with the given hyperparameters.
+func NewAdamW[T Float](beta1, beta2, epsilon float64) *AdamW[T] {
+	return &AdamW[T]{Adam: *NewAdam[T](beta1, beta2, epsilon)}
+}
+
+func (a *AdamW[T]) Update(parameter *Matrix[T], gradient Matrix[T], learningRate, weightDecay float64) {
+	updated := (*parameter).DeepCopy()
+	a.decay(updated, nil, learningRate, weightDecay)
+	a.step(updated, gradient, parameter, nil, learningRate, 0)
+	*parameter = updated
+}
+
+func (a *AdamW[T]) UpdateRows(parameter *Matrix[T], gradient Matrix[T], rows []int, learningRate, weightDecay float64) {
+	a.decay(*parameter, rows, learningRate, weightDecay)
+	a.step(*parameter, gradient, parameter, rows, learningRate, 0)
+}
+
+// decay applies the decoupled weight decay to the given rows of the parameter in place.
+func (a *AdamW[T]) decay(parameter Matrix[T], rows []int, learningRate, weightDecay float64) {
+	if weightDecay == 0 {
+		return
+	}
+	forEachElement(parameter, rows, func(i, _, j int) {
+		w, _ := parameter.At(i, j)
+		parameter.Set(i, j, w*T(1-learningRate*weightDecay))
+	})
+}
@@ -0,0 +1,102 @@
+// Package optimizer contains optimization algorithms, which are used by
+// the trainable layers to update their parameters using computed gradients.
+//
+// Optimizers are stateful, i.e. they keep some state (velocity, moments, etc.)
+// for each of the parameters they update, and therefore have to be used by pointer.
+package optimizer
+
+import (
+	. "github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
+)
+
+// Optimizer is an interface for the algorithms, which update the trainable
+// parameters of the layers. The hyperparameters are float64 regardless of T.
+//
+// Update replaces the matrix the parameter points to with the updated one,
+// based on the gradient of the parameter. The pointer itself is used as a key
+// to identify the parameter, so the per-parameter state is kept between the calls.
+// Weight decay is applied by the optimizer itself, either as L2 regularization
+// added to the gradient, or decoupled from it (as in AdamW).
+type Optimizer[T Float] interface {
+	Update(parameter *Matrix[T], gradient Matrix[T], learningRate, weightDecay float64)
+}
+
+// SparseOptimizer is implemented by the optimizers, which are able to update only
+// some rows of the parameter, e.g. the embeddings of the tokens present in the batch.
+//
+// UpdateRows works as Update, though only the given rows of the parameter, as well as
+// their state, are updated in place, so the cost does not depend on the parameter size.
+// This includes the weight decay, which is applied only to the given rows.
+// The gradient is compact, i.e. its k-th row is the gradient of the rows[k]-th row
+// of the parameter, hence the rows have to be distinct.
+type SparseOptimizer[T Float] interface {
+	Optimizer[T]
+
+	UpdateRows(parameter *Matrix[T], gradient Matrix[T], rows []int, learningRate, weightDecay float64)
+}
+
+/****************************************************************************/
+
+// valueOr returns the value the hyperparameter points to, or the default value if it is nil.
+func valueOr(value *float64, defaultValue float64) float64 {
+	if value == nil {
+		return defaultValue
+	}
+	return *value
+}
+
+// decayedGradient returns the gradient with the L2 regularization term added to it.
+//
+//	g = dW + weightDecay*W
+func decayedGradient[T Float](parameter, gradient Matrix[T], weightDecay float64) Matrix[T] {
+	if weightDecay == 0 {
+		return gradient
+	}
+	result, _ := gradient.Add(parameter.MultiplyByScalar(T(weightDecay)))
+	return result
+}
+
+// stateMatrix returns the state matrix stored by the key, creating a zero matrix
+// if it does not exist or its size does not correspond to the parameter anymore.
+func stateMatrix[T Float](
+	state map[*Matrix[T]]Matrix[T],
+	key *Matrix[T],
+) Matrix[T] {
+	m, ok := state[key]
+	if !ok || !m.AreSameSize(*key) {
+		m = NewZeroMatrix[T]((*key).RowCount(), (*key).ColumnCount())
+		state[key] = m
+	}
+	return m
+}
+
+// gradientAt returns the (k, j) element of the gradient with the L2 regularization
+// term of the (i, j) element of the parameter added to it.
+func gradientAt[T Float](parameter, gradient Matrix[T], i, k, j int, weightDecay float64) float64 {
+	g, _ := gradient.At(k, j)
+	if weightDecay == 0 {
+		return float64(g)
+	}
+	w, _ := parameter.At(i, j)
+	return float64(g) + weightDecay*float64(w)
+}
+
+// forEachElement calls f for each element of the given rows of the parameter, where
+// i and k are the indices of the row in the parameter and in the gradient respectively.
+// If rows is nil, all the rows are used, i.e. i equals k.
+func forEachElement[T Float](parameter Matrix[T], rows []int, f func(i, k, j int)) {
+	if rows == nil {
+		for i := 0; i < parameter.RowCount(); i++ {
+			for j := 0; j < parameter.ColumnCount(); j++ {
+				f(i, i, j)
+			}
+		}
+		return
+	}
+	for k, i := range rows {
+		for j := 0; j < parameter.ColumnCount(); j++ {
+			f(i, k, j)
+		}
+	}
+}
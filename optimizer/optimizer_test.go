@@ -0,0 +1,236 @@
+package optimizer_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Hukyl/mlgo/matrix"
+	"github.com/Hukyl/mlgo/optimizer"
+)
+
+func assertMatrixClose(t *testing.T, got matrix.Matrix[float64], want [][]float64) {
+	t.Helper()
+	for i := range want {
+		for j := range want[i] {
+			v, _ := got.At(i, j)
+			if math.Abs(v-want[i][j]) > 1e-6 {
+				t.Errorf("At(%d,%d) = %v, want %v", i, j, v, want[i][j])
+			}
+		}
+	}
+}
+
+func TestOptimizerFirstStep(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		optimizer   optimizer.Optimizer[float64]
+		weightDecay float64
+		want        [][]float64
+	}{
+		{
+			desc:      "sgd",
+			optimizer: &optimizer.SGD[float64]{},
+			want:      [][]float64{{1.0 - 0.1*0.5, -2.0 + 0.1*2.0}},
+		},
+		{
+			desc:        "sgd-weight-decay",
+			optimizer:   &optimizer.SGD[float64]{},
+			weightDecay: 0.1,
+			want:        [][]float64{{1.0 - 0.1*(0.5+0.1), -2.0 + 0.1*(2.0+0.1*2)}},
+		},
+		{
+			// first step of Adam moves each weight by learningRate in the opposite
+			// direction of the gradient sign
+			desc:      "adam",
+			optimizer: &optimizer.Adam[float64]{},
+			want:      [][]float64{{1.0 - 0.1, -2.0 + 0.1}},
+		},
+		{
+			desc:        "adamw",
+			optimizer:   &optimizer.AdamW[float64]{},
+			weightDecay: 0.5,
+			want:        [][]float64{{1.0*0.95 - 0.1, -2.0*0.95 + 0.1}},
+		},
+		{
+			desc:      "rmsprop",
+			optimizer: &optimizer.RMSprop[float64]{},
+			want:      [][]float64{{1.0 - 0.1/math.Sqrt(0.1), -2.0 + 0.1/math.Sqrt(0.1)}},
+		},
+		{
+			desc:      "adagrad",
+			optimizer: &optimizer.Adagrad[float64]{},
+			want:      [][]float64{{1.0 - 0.1, -2.0 + 0.1}},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			W, _ := matrix.NewMatrix([][]float64{{1.0, -2.0}})
+			dW, _ := matrix.NewMatrix([][]float64{{0.5, -2.0}})
+
+			// Act
+			tC.optimizer.Update(&W, dW, 0.1, tC.weightDecay)
+
+			// Assert
+			assertMatrixClose(t, W, tC.want)
+		})
+	}
+}
+
+func TestOptimizer_ExplicitZeroHyperparameters(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		optimizer optimizer.Optimizer[float64]
+		gradients []float64
+		want      [][]float64
+	}{
+		{
+			// s = dW^2, so the weight moves by learningRate (not 0.1/sqrt(0.1) of Rho=0.9)
+			desc:      "rmsprop",
+			optimizer: optimizer.NewRMSprop[float64](0, 0),
+			gradients: []float64{0.5},
+			want:      [][]float64{{0.9}},
+		},
+		{
+			// m = dW and v = dW^2, so the moments of the first step are forgotten
+			desc:      "adam",
+			optimizer: optimizer.NewAdam[float64](0, 0, 0),
+			gradients: []float64{1, -1},
+			want:      [][]float64{{1.0}},
+		},
+		{
+			desc:      "adamw",
+			optimizer: optimizer.NewAdamW[float64](0, 0, 0),
+			gradients: []float64{1, -1},
+			want:      [][]float64{{1.0}},
+		},
+		{
+			desc:      "adagrad",
+			optimizer: optimizer.NewAdagrad[float64](0, 0),
+			gradients: []float64{2},
+			want:      [][]float64{{0.9}},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			W, _ := matrix.NewMatrix([][]float64{{1.0}})
+
+			// Act
+			for _, g := range tC.gradients {
+				dW, _ := matrix.NewMatrix([][]float64{{g}})
+				tC.optimizer.Update(&W, dW, 0.1, 0)
+			}
+
+			// Assert
+			assertMatrixClose(t, W, tC.want)
+		})
+	}
+}
+
+func TestSGD_Momentum(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		nesterov bool
+		want     [][]float64
+	}{
+		{
+			// v1 = -0.1, W1 = 0.9; v2 = 0.9*(-0.1) - 0.1 = -0.19, W2 = 0.71
+			desc: "classic",
+			want: [][]float64{{0.71}},
+		},
+		{
+			// v1 = -0.1, W1 = 1 + 0.9*(-0.1) - 0.1 = 0.81
+			// v2 = -0.19, W2 = 0.81 + 0.9*(-0.19) - 0.1 = 0.539
+			desc:     "nesterov",
+			nesterov: true,
+			want:     [][]float64{{0.539}},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			o := &optimizer.SGD[float64]{Momentum: 0.9, Nesterov: tC.nesterov}
+			W, _ := matrix.NewMatrix([][]float64{{1.0}})
+			dW, _ := matrix.NewMatrix([][]float64{{1.0}})
+
+			// Act
+			o.Update(&W, dW, 0.1, 0)
+			o.Update(&W, dW, 0.1, 0)
+
+			// Assert
+			assertMatrixClose(t, W, tC.want)
+		})
+	}
+}
+
+func TestOptimizer_KeepsSeparateStatePerParameter(t *testing.T) {
+	// Arrange
+	o := &optimizer.SGD[float64]{Momentum: 0.9}
+	W, _ := matrix.NewMatrix([][]float64{{1.0}})
+	b, _ := matrix.NewMatrix([][]float64{{1.0}})
+	g, _ := matrix.NewMatrix([][]float64{{1.0}})
+
+	// Act
+	o.Update(&W, g, 0.1, 0)
+	o.Update(&W, g, 0.1, 0)
+	o.Update(&b, g, 0.1, 0)
+
+	// Assert - b is updated only once, so its velocity must not include W's
+	assertMatrixClose(t, W, [][]float64{{0.71}})
+	assertMatrixClose(t, b, [][]float64{{0.9}})
+}
+
+func TestAdam_MinimizesQuadratic(t *testing.T) {
+	// Arrange
+	o := &optimizer.Adam[float64]{}
+	W, _ := matrix.NewMatrix([][]float64{{5.0, -3.0}})
+
+	// Act - minimize 0.5*||W||^2, whose gradient is W itself
+	for i := 0; i < 500; i++ {
+		o.Update(&W, W.DeepCopy(), 0.1, 0)
+	}
+
+	// Assert
+	for j := 0; j < W.ColumnCount(); j++ {
+		v, _ := W.At(0, j)
+		if math.Abs(v) > 1e-2 {
+			t.Errorf("At(0,%d) = %v, want close to 0", j, v)
+		}
+	}
+}
+
+func TestSparseOptimizer_UpdateRows(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		optimizer optimizer.SparseOptimizer[float64]
+		reference optimizer.Optimizer[float64]
+	}{
+		{desc: "sgd", optimizer: &optimizer.SGD[float64]{}, reference: &optimizer.SGD[float64]{}},
+		{desc: "sgd-momentum", optimizer: &optimizer.SGD[float64]{Momentum: 0.9}, reference: &optimizer.SGD[float64]{Momentum: 0.9}},
+		{desc: "adam", optimizer: &optimizer.Adam[float64]{}, reference: &optimizer.Adam[float64]{}},
+		{desc: "adamw", optimizer: &optimizer.AdamW[float64]{}, reference: &optimizer.AdamW[float64]{}},
+		{desc: "rmsprop", optimizer: &optimizer.RMSprop[float64]{}, reference: &optimizer.RMSprop[float64]{}},
+		{desc: "adagrad", optimizer: &optimizer.Adagrad[float64]{}, reference: &optimizer.Adagrad[float64]{}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			W, _ := matrix.NewMatrix([][]float64{{3.0, 4.0}, {1.0, -2.0}})
+			row, _ := matrix.NewMatrix([][]float64{{1.0, -2.0}})
+			dRow, _ := matrix.NewMatrix([][]float64{{0.5, -2.0}})
+
+			// Act - the first row must stay intact, while the second one is updated as a whole
+			// parameter using the compact gradient
+			for i := 0; i < 2; i++ {
+				tC.optimizer.UpdateRows(&W, dRow, []int{1}, 0.1, 0.5)
+				tC.reference.Update(&row, dRow, 0.1, 0.5)
+			}
+
+			// Assert
+			first, _ := row.At(0, 0)
+			second, _ := row.At(0, 1)
+			assertMatrixClose(t, W, [][]float64{{3.0, 4.0}, {first, second}})
+		})
+	}
+}
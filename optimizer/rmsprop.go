@@ -0,0 +1,60 @@
+package optimizer
+
+import (
+	"math"
+
+	. "github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
+)
+
+const defaultRho = 0.9
+
+// RMSprop is an optimizer, which divides the gradient by the root of
+// the exponentially decaying average of the squared gradients.
+//
+//	s = Rho*s + (1-Rho)*dW^2
+//	W = W - learningRate * dW / (sqrt(s) + Epsilon)
+//
+// If nil, Rho and Epsilon are initialized to 0.9 and 1e-8 respectively.
+type RMSprop[T Float] struct {
+	Rho     *float64
+	Epsilon *float64
+
+	averages map[*Matrix[T]]Matrix[T]
+}
+
+// NewRMSprop produces an RMSprop optimizer with the given hyperparameters.
+func NewRMSprop[T Float](rho, epsilon float64) *RMSprop[T] {
+	return &RMSprop[T]{Rho: &rho, Epsilon: &epsilon}
+}
+
+func (r *RMSprop[T]) Update(parameter *Matrix[T], gradient Matrix[T], learningRate, weightDecay float64) {
+	updated := (*parameter).DeepCopy()
+	r.step(updated, gradient, parameter, nil, learningRate, weightDecay)
+	*parameter = updated
+}
+
+func (r *RMSprop[T]) UpdateRows(parameter *Matrix[T], gradient Matrix[T], rows []int, learningRate, weightDecay float64) {
+	r.step(*parameter, gradient, parameter, rows, learningRate, weightDecay)
+}
+
+// step performs a single RMSprop step on the given rows of the parameter in place,
+// keeping the average by the key.
+func (r *RMSprop[T]) step(parameter, gradient Matrix[T], key *Matrix[T], rows []int, learningRate, weightDecay float64) {
+	rho := valueOr(r.Rho, defaultRho)
+	epsilon := valueOr(r.Epsilon, defaultEpsilon)
+	if r.averages == nil {
+		r.averages = make(map[*Matrix[T]]Matrix[T])
+	}
+	s := stateMatrix(r.averages, key)
+
+	forEachElement(parameter, rows, func(i, k, j int) {
+		g := gradientAt(parameter, gradient, i, k, j, weightDecay)
+		sValue, _ := s.At(i, j)
+		average := rho*float64(sValue) + (1-rho)*g*g
+		s.Set(i, j, T(average))
+
+		w, _ := parameter.At(i, j)
+		parameter.Set(i, j, w-T(learningRate*g/(math.Sqrt(average)+epsilon)))
+	})
+}
@@ -0,0 +1,67 @@
+package optimizer
+
+import (
+	. "github.com/Hukyl/mlgo/matrix"
+	. "golang.org/x/exp/constraints"
+)
+
+// SGD is a stochastic gradient descent optimizer with optional momentum.
+//
+// If Momentum is 0, the vanilla gradient descent is performed:
+//
+//	W = W - learningRate * dW
+//
+// Otherwise the velocity is accumulated for each parameter:
+//
+//	v = Momentum*v - learningRate*dW
+//	W = W + v
+//
+// If Nesterov is set, Nesterov accelerated gradient is used instead:
+//
+//	v = Momentum*v - learningRate*dW
+//	W = W + Momentum*v - learningRate*dW
+type SGD[T Float] struct {
+	Momentum float64
+	Nesterov bool
+
+	velocities map[*Matrix[T]]Matrix[T]
+}
+
+func (s *SGD[T]) Update(parameter *Matrix[T], gradient Matrix[T], learningRate, weightDecay float64) {
+	if s.Momentum == 0 {
+		g := decayedGradient(*parameter, gradient, weightDecay)
+		*parameter, _ = (*parameter).Add(g.MultiplyByScalar(T(-learningRate)))
+		return
+	}
+	updated := (*parameter).DeepCopy()
+	s.step(updated, gradient, parameter, nil, learningRate, weightDecay)
+	*parameter = updated
+}
+
+func (s *SGD[T]) UpdateRows(parameter *Matrix[T], gradient Matrix[T], rows []int, learningRate, weightDecay float64) {
+	s.step(*parameter, gradient, parameter, rows, learningRate, weightDecay)
+}
+
+// step performs a single SGD step on the given rows of the parameter in place,
+// keeping the velocity by the key.
+func (s *SGD[T]) step(parameter, gradient Matrix[T], key *Matrix[T], rows []int, learningRate, weightDecay float64) {
+	if s.velocities == nil {
+		s.velocities = make(map[*Matrix[T]]Matrix[T])
+	}
+	v := stateMatrix(s.velocities, key)
+
+	forEachElement(parameter, rows, func(i, k, j int) {
+		g := gradientAt(parameter, gradient, i, k, j, weightDecay)
+		vValue, _ := v.At(i, j)
+		velocity := s.Momentum*float64(vValue) - learningRate*g
+		v.Set(i, j, T(velocity))
+
+		w, _ := parameter.At(i, j)
+		if s.Nesterov {
+			w += T(s.Momentum*velocity - learningRate*g)
+		} else {
+			w += T(velocity)
+		}
+		parameter.Set(i, j, w)
+	})
+}
@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// CosineAnnealingWarmRestarts (also known as SGDR) anneals the learning rate using
+// the cosine function from the initial learning rate to MinLearningRate, and
+// restarts it after Period steps. After each restart, the period is multiplied by
+// PeriodMultiplier.
+//
+//	lr = MinLearningRate + (initialLearningRate - MinLearningRate) * (1 + cos(pi * t / T)) / 2
+//
+// Where t is the number of steps since the last restart, and T is the current period.
+//
+// If PeriodMultiplier is not set, it is initialized to 1. If Period is 0, the
+// learning rate is not changed.
+type CosineAnnealingWarmRestarts struct {
+	Period           uint64
+	PeriodMultiplier uint64
+	MinLearningRate  float64
+	PerBatch         bool
+}
+
+func (s CosineAnnealingWarmRestarts) LearningRate(initialLearningRate float64, step Step) float64 {
+	if s.Period == 0 {
+		return initialLearningRate
+	}
+	multiplier := s.PeriodMultiplier
+	if multiplier == 0 {
+		multiplier = 1
+	}
+
+	t, period := step.count(s.PerBatch), s.Period
+	if multiplier == 1 {
+		t %= period
+	} else {
+		for t >= period {
+			t -= period
+			period *= multiplier
+		}
+	}
+	cosine := (1 + math.Cos(math.Pi*float64(t)/float64(period))) / 2
+	return s.MinLearningRate + (initialLearningRate-s.MinLearningRate)*cosine
+}
+
+func (s CosineAnnealingWarmRestarts) MarshalJSON() ([]byte, error) {
+	type alias CosineAnnealingWarmRestarts
+	return json.Marshal(&struct {
+		alias
+		Type string
+	}{alias(s), "CosineAnnealingWarmRestarts"})
+}
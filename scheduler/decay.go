@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// InverseTimeDecay decays the learning rate by the inverse of the steps passed.
+//
+//	lr = initialLearningRate / (1 + Decay*step)
+//
+// This is the default behaviour of the ANN parameters, when no scheduler is set.
+type InverseTimeDecay struct {
+	Decay    float64
+	PerBatch bool
+}
+
+func (s InverseTimeDecay) LearningRate(initialLearningRate float64, step Step) float64 {
+	return initialLearningRate / (1 + s.Decay*float64(step.count(s.PerBatch)))
+}
+
+func (s InverseTimeDecay) MarshalJSON() ([]byte, error) {
+	type alias InverseTimeDecay
+	return json.Marshal(&struct {
+		alias
+		Type string
+	}{alias(s), "InverseTimeDecay"})
+}
+
+// StepDecay multiplies the learning rate by Gamma every StepSize steps.
+//
+//	lr = initialLearningRate * Gamma^floor(step / StepSize)
+//
+// If StepSize is 0, the learning rate is not changed.
+type StepDecay struct {
+	StepSize uint64
+	Gamma    float64
+	PerBatch bool
+}
+
+func (s StepDecay) LearningRate(initialLearningRate float64, step Step) float64 {
+	if s.StepSize == 0 {
+		return initialLearningRate
+	}
+	return initialLearningRate * math.Pow(s.Gamma, float64(step.count(s.PerBatch)/s.StepSize))
+}
+
+func (s StepDecay) MarshalJSON() ([]byte, error) {
+	type alias StepDecay
+	return json.Marshal(&struct {
+		alias
+		Type string
+	}{alias(s), "StepDecay"})
+}
+
+// ExponentialDecay multiplies the learning rate by Gamma every step.
+//
+//	lr = initialLearningRate * Gamma^step
+type ExponentialDecay struct {
+	Gamma    float64
+	PerBatch bool
+}
+
+func (s ExponentialDecay) LearningRate(initialLearningRate float64, step Step) float64 {
+	return initialLearningRate * math.Pow(s.Gamma, float64(step.count(s.PerBatch)))
+}
+
+func (s ExponentialDecay) MarshalJSON() ([]byte, error) {
+	type alias ExponentialDecay
+	return json.Marshal(&struct {
+		alias
+		Type string
+	}{alias(s), "ExponentialDecay"})
+}
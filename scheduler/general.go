@@ -0,0 +1,88 @@
+// Package scheduler contains learning rate schedulers, which change the learning
+// rate of the ANN during the training.
+//
+// Schedulers can be stepped either per epoch or per batch, and can be serialized
+// to JSON (together with their state) to continue the training later.
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// LRScheduler is an interface for learning rate schedulers.
+//
+// LearningRate returns the learning rate for the training step given, based on
+// the initial learning rate of the ANN. Depending on the scheduler configuration,
+// either the epoch or the batch counter of the step is used.
+//
+// Schedulers are serialized with a "Type" discriminator, which is used by
+// UnmarshalLRScheduler to restore them.
+type LRScheduler interface {
+	json.Marshaler
+
+	LearningRate(initialLearningRate float64, step Step) float64
+}
+
+// CostObserver is implemented by the schedulers which depend on the training cost,
+// e.g. ReduceLROnPlateau.
+//
+// Observe is called once at the end of each epoch with the average epoch cost.
+type CostObserver interface {
+	Observe(cost float64)
+}
+
+// Step describes the progress of the training.
+//
+// Epoch is the number of the epochs passed.
+//
+// Batch is the total number of the batches passed during the whole training.
+type Step struct {
+	Epoch uint64
+	Batch uint64
+}
+
+// count returns the step counter used by the scheduler.
+func (s Step) count(perBatch bool) uint64 {
+	if perBatch {
+		return s.Batch
+	}
+	return s.Epoch
+}
+
+/****************************************************************************/
+
+var schedulerMap = map[string]func([]byte) (LRScheduler, error){
+	"InverseTimeDecay":            unmarshalAs[InverseTimeDecay],
+	"StepDecay":                   unmarshalAs[StepDecay],
+	"ExponentialDecay":            unmarshalAs[ExponentialDecay],
+	"CosineAnnealingWarmRestarts": unmarshalAs[CosineAnnealingWarmRestarts],
+	"LinearWarmup":                unmarshalAs[LinearWarmup],
+	"OneCycle":                    unmarshalAs[OneCycle],
+	"ReduceLROnPlateau":           unmarshalAs[*ReduceLROnPlateau],
+}
+
+func unmarshalAs[S LRScheduler](data []byte) (LRScheduler, error) {
+	var s S
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// UnmarshalLRScheduler restores the scheduler from its JSON representation,
+// produced by its MarshalJSON method.
+func UnmarshalLRScheduler(data []byte) (LRScheduler, error) {
+	var v struct {
+		Type string
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, errors.Join(errors.New("invalid scheduler"), err)
+	}
+	f, ok := schedulerMap[v.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown scheduler: %s", v.Type)
+	}
+	return f(data)
+}
@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// OneCycle is a one-cycle learning rate policy, which uses initial learning rate
+// as the maximum one. The learning rate is annealed using the cosine function from
+// initialLearningRate/DivFactor up to initialLearningRate during the first
+// WarmupFraction of TotalSteps, and then down to initialLearningRate/(DivFactor*FinalDivFactor)
+// during the remaining steps.
+//
+// If not set, WarmupFraction, DivFactor and FinalDivFactor are initialized to
+// 0.3, 25 and 1e4 respectively. If TotalSteps is 0, the learning rate is not changed.
+type OneCycle struct {
+	TotalSteps     uint64
+	WarmupFraction float64
+	DivFactor      float64
+	FinalDivFactor float64
+	PerBatch       bool
+}
+
+func (s OneCycle) LearningRate(initialLearningRate float64, step Step) float64 {
+	if s.TotalSteps == 0 {
+		return initialLearningRate
+	}
+	warmupFraction, divFactor, finalDivFactor := s.WarmupFraction, s.DivFactor, s.FinalDivFactor
+	if warmupFraction == 0 {
+		warmupFraction = 0.3
+	}
+	if divFactor == 0 {
+		divFactor = 25
+	}
+	if finalDivFactor == 0 {
+		finalDivFactor = 1e4
+	}
+	startLearningRate := initialLearningRate / divFactor
+	finalLearningRate := startLearningRate / finalDivFactor
+
+	t := float64(step.count(s.PerBatch))
+	total := float64(s.TotalSteps)
+	warmupSteps := warmupFraction * total
+
+	switch {
+	case t >= total:
+		return finalLearningRate
+	case t < warmupSteps:
+		return cosineInterpolation(startLearningRate, initialLearningRate, t/warmupSteps)
+	default:
+		return cosineInterpolation(initialLearningRate, finalLearningRate, (t-warmupSteps)/(total-warmupSteps))
+	}
+}
+
+func (s OneCycle) MarshalJSON() ([]byte, error) {
+	type alias OneCycle
+	return json.Marshal(&struct {
+		alias
+		Type string
+	}{alias(s), "OneCycle"})
+}
+
+// cosineInterpolation interpolates between start and end using the cosine function,
+// where progress is in range [0;1].
+func cosineInterpolation(start, end, progress float64) float64 {
+	return end + (start-end)*(1+math.Cos(math.Pi*progress))/2
+}
@@ -0,0 +1,131 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+)
+
+// ReduceLROnPlateau reduces the learning rate by Factor when the epoch cost has
+// stopped improving for more than Patience epochs. The cost is considered improved,
+// if it decreased by more than MinDelta compared to the best one.
+//
+// After the reduction, the scheduler waits for Cooldown epochs before resuming
+// normal operation. The learning rate is never reduced below MinLearningRate.
+//
+// If not set, Factor is initialized to 0.1.
+//
+// As ReduceLROnPlateau is stateful, it has to be used by pointer.
+type ReduceLROnPlateau struct {
+	Factor          float64
+	Patience        uint64
+	MinDelta        float64
+	Cooldown        uint64
+	MinLearningRate float64
+
+	initialized       bool
+	best              float64
+	wait              uint64
+	cooldownRemaining uint64
+	scale             float64
+}
+
+func (s *ReduceLROnPlateau) init() {
+	if !s.initialized {
+		s.initialized = true
+		s.best = math.Inf(1)
+		s.scale = 1
+	}
+}
+
+func (s *ReduceLROnPlateau) LearningRate(initialLearningRate float64, _ Step) float64 {
+	s.init()
+	return math.Max(initialLearningRate*s.scale, s.MinLearningRate)
+}
+
+// Observe updates the state of the scheduler with the epoch cost.
+func (s *ReduceLROnPlateau) Observe(cost float64) {
+	s.init()
+	factor := s.Factor
+	if factor == 0 {
+		factor = 0.1
+	}
+
+	if s.cooldownRemaining > 0 {
+		s.cooldownRemaining--
+		s.wait = 0
+	}
+	if cost < s.best-s.MinDelta {
+		s.best = cost
+		s.wait = 0
+		return
+	}
+	if s.cooldownRemaining > 0 {
+		return
+	}
+	s.wait++
+	if s.wait > s.Patience {
+		s.scale *= factor
+		s.cooldownRemaining = s.Cooldown
+		s.wait = 0
+	}
+}
+
+type reduceLROnPlateauJSON struct {
+	Factor          float64
+	Patience        uint64
+	MinDelta        float64
+	Cooldown        uint64
+	MinLearningRate float64
+
+	Best              *float64 // nil for +inf
+	Wait              uint64
+	CooldownRemaining uint64
+	Scale             float64
+}
+
+func (s *ReduceLROnPlateau) MarshalJSON() ([]byte, error) {
+	s.init()
+	v := reduceLROnPlateauJSON{
+		Factor:            s.Factor,
+		Patience:          s.Patience,
+		MinDelta:          s.MinDelta,
+		Cooldown:          s.Cooldown,
+		MinLearningRate:   s.MinLearningRate,
+		Wait:              s.wait,
+		CooldownRemaining: s.cooldownRemaining,
+		Scale:             s.scale,
+	}
+	if !math.IsInf(s.best, 1) {
+		v.Best = &s.best
+	}
+	return json.Marshal(&struct {
+		reduceLROnPlateauJSON
+		Type string
+	}{v, "ReduceLROnPlateau"})
+}
+
+func (s *ReduceLROnPlateau) UnmarshalJSON(data []byte) error {
+	var v reduceLROnPlateauJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return errors.Join(errors.New("invalid plateau scheduler"), err)
+	}
+	s.Factor = v.Factor
+	s.Patience = v.Patience
+	s.MinDelta = v.MinDelta
+	s.Cooldown = v.Cooldown
+	s.MinLearningRate = v.MinLearningRate
+
+	s.initialized = true
+	s.best = math.Inf(1)
+	if v.Best != nil {
+		s.best = *v.Best
+	}
+	s.wait = v.Wait
+	s.cooldownRemaining = v.CooldownRemaining
+	s.scale = v.Scale
+	if s.scale == 0 {
+		s.scale = 1
+	}
+	return nil
+}
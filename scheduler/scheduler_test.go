@@ -0,0 +1,193 @@
+package scheduler_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/Hukyl/mlgo/scheduler"
+)
+
+func TestLearningRate(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		scheduler scheduler.LRScheduler
+		step      scheduler.Step
+		want      float64
+	}{
+		{
+			desc:      "inverse-time",
+			scheduler: scheduler.InverseTimeDecay{Decay: 0.5},
+			step:      scheduler.Step{Epoch: 2},
+			want:      0.5,
+		},
+		{
+			desc:      "step-decay",
+			scheduler: scheduler.StepDecay{StepSize: 2, Gamma: 0.1},
+			step:      scheduler.Step{Epoch: 5},
+			want:      0.01,
+		},
+		{
+			desc:      "step-decay-per-batch",
+			scheduler: scheduler.StepDecay{StepSize: 10, Gamma: 0.5, PerBatch: true},
+			step:      scheduler.Step{Epoch: 0, Batch: 25},
+			want:      0.25,
+		},
+		{
+			desc:      "exponential",
+			scheduler: scheduler.ExponentialDecay{Gamma: 0.5},
+			step:      scheduler.Step{Epoch: 3},
+			want:      0.125,
+		},
+		{
+			desc:      "cosine-half-period",
+			scheduler: scheduler.CosineAnnealingWarmRestarts{Period: 4},
+			step:      scheduler.Step{Epoch: 2},
+			want:      0.5,
+		},
+		{
+			desc:      "cosine-restart",
+			scheduler: scheduler.CosineAnnealingWarmRestarts{Period: 4},
+			step:      scheduler.Step{Epoch: 4},
+			want:      1.0,
+		},
+		{
+			// periods are 2, 4, 8, ...; step 3 is the 2nd step of the 4-long period
+			desc:      "cosine-multiplier",
+			scheduler: scheduler.CosineAnnealingWarmRestarts{Period: 2, PeriodMultiplier: 2, MinLearningRate: 0.2},
+			step:      scheduler.Step{Epoch: 3},
+			want:      0.2 + 0.8*(1+math.Cos(math.Pi/4))/2,
+		},
+		{
+			desc:      "warmup",
+			scheduler: scheduler.LinearWarmup{WarmupSteps: 4},
+			step:      scheduler.Step{Epoch: 1},
+			want:      0.5,
+		},
+		{
+			desc: "warmup-then-exponential",
+			scheduler: scheduler.LinearWarmup{
+				WarmupSteps: 4,
+				After:       scheduler.ExponentialDecay{Gamma: 0.5},
+			},
+			step: scheduler.Step{Epoch: 6},
+			want: 0.25,
+		},
+		{
+			desc:      "one-cycle-start",
+			scheduler: scheduler.OneCycle{TotalSteps: 10, DivFactor: 10},
+			step:      scheduler.Step{},
+			want:      0.1,
+		},
+		{
+			desc:      "one-cycle-peak",
+			scheduler: scheduler.OneCycle{TotalSteps: 10},
+			step:      scheduler.Step{Epoch: 3},
+			want:      1.0,
+		},
+		{
+			desc:      "one-cycle-end",
+			scheduler: scheduler.OneCycle{TotalSteps: 10, DivFactor: 10, FinalDivFactor: 10},
+			step:      scheduler.Step{Epoch: 10},
+			want:      0.01,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Act
+			got := tC.scheduler.LearningRate(1.0, tC.step)
+
+			// Assert
+			if math.Abs(got-tC.want) > 1e-10 {
+				t.Errorf("LearningRate = %v, want %v", got, tC.want)
+			}
+		})
+	}
+}
+
+func TestReduceLROnPlateau(t *testing.T) {
+	// Arrange
+	s := &scheduler.ReduceLROnPlateau{Factor: 0.5, Patience: 1, MinLearningRate: 0.2}
+	costs := []float64{1.0, 0.9, 0.95, 0.92, 0.91, 0.93, 0.94, 0.95, 0.96}
+	want := []float64{1.0, 1.0, 1.0, 0.5, 0.5, 0.25, 0.25, 0.2, 0.2}
+
+	for i, cost := range costs {
+		// Act
+		s.Observe(cost)
+		got := s.LearningRate(1.0, scheduler.Step{})
+
+		// Assert
+		if math.Abs(got-want[i]) > 1e-10 {
+			t.Errorf("after epoch %d LearningRate = %v, want %v", i, got, want[i])
+		}
+	}
+}
+
+func TestLinearWarmup_ForwardsCost(t *testing.T) {
+	// Arrange
+	var s scheduler.LRScheduler = scheduler.LinearWarmup{
+		WarmupSteps: 1,
+		After:       &scheduler.ReduceLROnPlateau{Factor: 0.5},
+	}
+	observer, ok := s.(scheduler.CostObserver)
+	if !ok {
+		t.Fatal("LinearWarmup does not implement CostObserver")
+	}
+
+	// Act
+	observer.Observe(1.0)
+	observer.Observe(2.0)
+	got := s.LearningRate(1.0, scheduler.Step{Epoch: 3})
+
+	// Assert
+	if math.Abs(got-0.5) > 1e-10 {
+		t.Errorf("LearningRate = %v, want %v", got, 0.5)
+	}
+}
+
+func TestUnmarshalLRScheduler(t *testing.T) {
+	plateau := &scheduler.ReduceLROnPlateau{Factor: 0.5}
+	plateau.Observe(1.0)
+	plateau.Observe(2.0) // reduces the learning rate once
+
+	testCases := []struct {
+		desc      string
+		scheduler scheduler.LRScheduler
+	}{
+		{desc: "step-decay", scheduler: scheduler.StepDecay{StepSize: 3, Gamma: 0.5, PerBatch: true}},
+		{desc: "cosine", scheduler: scheduler.CosineAnnealingWarmRestarts{Period: 3, MinLearningRate: 0.1}},
+		{desc: "one-cycle", scheduler: scheduler.OneCycle{TotalSteps: 20}},
+		{
+			desc: "nested-warmup",
+			scheduler: scheduler.LinearWarmup{
+				WarmupSteps: 2,
+				After:       scheduler.StepDecay{StepSize: 1, Gamma: 0.5},
+			},
+		},
+		{desc: "plateau-state", scheduler: plateau},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// Arrange
+			data, err := json.Marshal(tC.scheduler)
+			if err != nil {
+				t.Fatalf("MarshalJSON error: %v", err)
+			}
+
+			// Act
+			got, err := scheduler.UnmarshalLRScheduler(data)
+			if err != nil {
+				t.Fatalf("UnmarshalLRScheduler error: %v", err)
+			}
+
+			// Assert
+			for e := uint64(0); e < 6; e++ {
+				step := scheduler.Step{Epoch: e, Batch: e}
+				want := tC.scheduler.LearningRate(1.0, step)
+				if lr := got.LearningRate(1.0, step); math.Abs(lr-want) > 1e-10 {
+					t.Errorf("epoch %d: LearningRate = %v, want %v", e, lr, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// LinearWarmup linearly increases the learning rate from initialLearningRate/WarmupSteps
+// up to the initialLearningRate during the first WarmupSteps steps.
+//
+//	lr = initialLearningRate * (step + 1) / WarmupSteps
+//
+// After the warmup, the After scheduler is used (if set), receiving the step
+// shifted by WarmupSteps. Only the counter of the same unit (epoch or batch)
+// is shifted. If After is not set, the initial learning rate is kept.
+//
+// LinearWarmup implements CostObserver, forwarding the cost to After (if it implements
+// CostObserver as well), including during the warmup.
+type LinearWarmup struct {
+	WarmupSteps uint64
+	After       LRScheduler
+	PerBatch    bool
+}
+
+func (s LinearWarmup) LearningRate(initialLearningRate float64, step Step) float64 {
+	t := step.count(s.PerBatch)
+	if t < s.WarmupSteps {
+		return initialLearningRate * float64(t+1) / float64(s.WarmupSteps)
+	}
+	if s.After == nil {
+		return initialLearningRate
+	}
+	if s.PerBatch {
+		step.Batch -= s.WarmupSteps
+	} else {
+		step.Epoch -= s.WarmupSteps
+	}
+	return s.After.LearningRate(initialLearningRate, step)
+}
+
+func (s LinearWarmup) Observe(cost float64) {
+	if observer, ok := s.After.(CostObserver); ok {
+		observer.Observe(cost)
+	}
+}
+
+func (s LinearWarmup) MarshalJSON() ([]byte, error) {
+	type alias LinearWarmup
+	return json.Marshal(&struct {
+		alias
+		Type string
+	}{alias(s), "LinearWarmup"})
+}
+
+func (s *LinearWarmup) UnmarshalJSON(data []byte) error {
+	var v struct {
+		WarmupSteps uint64
+		After       json.RawMessage
+		PerBatch    bool
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return errors.Join(errors.New("invalid linear warmup scheduler"), err)
+	}
+	s.WarmupSteps = v.WarmupSteps
+	s.PerBatch = v.PerBatch
+	s.After = nil
+	if len(v.After) > 0 && string(v.After) != "null" {
+		after, err := UnmarshalLRScheduler(v.After)
+		if err != nil {
+			return err
+		}
+		s.After = after
+	}
+	return nil
+}
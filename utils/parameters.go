@@ -1,35 +1,98 @@
 package utils
 
 import (
+	"encoding/json"
+	"errors"
 	"math"
+	"math/rand"
 
+	"github.com/Hukyl/mlgo/matrix"
 	"github.com/Hukyl/mlgo/metric"
+	"github.com/Hukyl/mlgo/optimizer"
+	"github.com/Hukyl/mlgo/scheduler"
+	. "golang.org/x/exp/constraints"
 )
 
 const defaultEpochCount = 5
 const defaultLearningRate = 0.01
+const accuracyMetricName = "accuracy"
 
 // BackupParameters manages data associated to creating the dumps for an ANN.
 //
 // ToCreate determines whether to create the dumps at all.
 //
-// Path specifies the folder, where the dumps should be stored.
+// Path specifies the folder, where the dumps should be stored. Each dump "epoch_N.json"
+// contains the ANN together with the training state (see TrainingState), so it
+// can be loaded either to resume the training (see nn.LoadBackup) or as a plain
+// ANN (see nn.LoadNeuralNetwork).
 type BackupParameters struct {
 	ToCreate bool
 	Path     string
 }
 
+// ValidationParameters manages data used to validate an ANN at the end of each
+// epoch. Validation data is never used to update the weights.
+//
+// X and Y are the validation batches, in the same format as the training ones.
+//
+// Split is the fraction of the training batches to be used for validation,
+// if X and Y are not provided. Last batches of the training data are used.
+type ValidationParameters[T Float] struct {
+	X, Y  []matrix.Matrix[T]
+	Split float64
+}
+
+// TrainingState is the state of the training, which is dumped with the backups of the ANN
+// to resume the training later.
+//
+// Step is the number of the epochs and batches passed.
+//
+// Scheduler is the learning rate scheduler together with its state, e.g. the best
+// cost of ReduceLROnPlateau. It is nil if the scheduler is not set.
+type TrainingState struct {
+	Step      scheduler.Step
+	Scheduler scheduler.LRScheduler
+}
+
+func (ts *TrainingState) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Step      scheduler.Step
+		Scheduler json.RawMessage
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return errors.Join(errors.New("invalid training state"), err)
+	}
+	ts.Step = v.Step
+	ts.Scheduler = nil
+	if len(v.Scheduler) > 0 && string(v.Scheduler) != "null" {
+		s, err := scheduler.UnmarshalLRScheduler(v.Scheduler)
+		if err != nil {
+			return err
+		}
+		ts.Scheduler = s
+	}
+	return nil
+}
+
 // NeuralNetworkParameters containes some parameters to be applied to an ANN
-// during its training.
+// during its training. T is the element type of the ANN, while the hyperparameters
+// are float64 regardless of it.
 //
 // EpochCount specifies the total epochs for the ANN to train. If not set, initialized
 // to 5.
 //
 // LearningRateDecay is used to degrade the learning rate after some epochs. Algorithm
-// is based on the inverse of epochs passed during training.
+// is based on the inverse of epochs passed during training. Ignored if
+// LearningRateScheduler is set.
 //
 // InitialLearningRate is the starting value for the learning rate. If LearningRateDecay
-// is 0, this learning rate is kept during the whole training.
+// is 0 and LearningRateScheduler is not set, this learning rate is kept during
+// the whole training.
+//
+// LearningRateScheduler changes the learning rate during the training, based on
+// the epochs and batches passed. If the scheduler implements scheduler.CostObserver,
+// it is also notified of the epoch cost. The scheduler is dumped with the backups
+// of the ANN, together with the current step (see TrainingState and Resume).
 //
 // WeightDecay is a L2 reguralization technique to enforce the model to improve weights
 // using smaller absolute values. This helps in preventing gradient exploding and
@@ -38,28 +101,65 @@ type BackupParameters struct {
 // ClipValue is the absolute value by which the gradient must be clipped to reduce
 // the sudden changes in the weights.
 //
+// Optimizer is the algorithm used by trainable layers to update their parameters.
+// If not set, vanilla stochastic gradient descent is used.
+//
 // AccuracyMetric is a metric of calculating how many correct outputs were guessed during
 // training. Output for this function is usually used in the logs for the epoch summary.
+// It is reported under the "accuracy" name.
+//
+// Metrics are additional named metrics, computed both during training and validation.
+//
+// Rand is the generator of the randomness during the training, e.g. for dropout.
+// If not set, the global source of math/rand is used. The weights are initialized
+// before the training, so Rand does not affect them: the weight initializations take
+// their own generator (see layers.WeightInitialization). For a single seed to make
+// the whole run bit-identical, the same generator has to be given both to the weight
+// initializations and to Rand, e.g.
+//
+//	r := rand.New(rand.NewSource(seed))
+//	layer := layers.NewRandomDense(size, a, layers.HeInitialization{Rand: r})
+//	parameters := utils.NeuralNetworkParameters[float64]{Rand: r}
+//
+// Validation is a struct containing validation data for the ANN.
 //
 // Backups is a struct containing backup variables to manages ANN dumps.
-type NeuralNetworkParameters struct {
+type NeuralNetworkParameters[T Float] struct {
 	currentEpoch uint64
+	currentBatch uint64
+	initialStep  scheduler.Step
 	EpochCount   uint64
 
-	LearningRateDecay   float64
-	InitialLearningRate float64
-	WeightDecay         float64
-	ClipValue           float64
+	LearningRateDecay     float64
+	InitialLearningRate   float64
+	LearningRateScheduler scheduler.LRScheduler
+
+	WeightDecay float64
+	ClipValue   float64
 
-	AccuracyMetric metric.Metric
+	Optimizer optimizer.Optimizer[T]
 
-	Backups BackupParameters
+	AccuracyMetric metric.Metric[T]
+	Metrics        []metric.NamedMetric[T]
+
+	Rand *rand.Rand
+
+	Validation ValidationParameters[T]
+	Backups    BackupParameters
 }
 
 // LearningRate returns the current learning rate of the ANN. The return value
-// of this funciton may depend on the epoch passed and learning rate decay value.
-func (nnp NeuralNetworkParameters) LearningRate() float64 {
-	return nnp.InitialLearningRate / (1 + nnp.LearningRateDecay*float64(nnp.currentEpoch))
+// of this funciton may depend on the epochs and batches passed, and either
+// the learning rate scheduler or learning rate decay value.
+func (nnp NeuralNetworkParameters[T]) LearningRate() float64 {
+	s := nnp.LearningRateScheduler
+	if s == nil {
+		s = scheduler.InverseTimeDecay{Decay: nnp.LearningRateDecay}
+	}
+	return s.LearningRate(
+		nnp.InitialLearningRate,
+		scheduler.Step{Epoch: nnp.currentEpoch, Batch: nnp.currentBatch},
+	)
 }
 
 // Validate updates the values of the hyperparameters to be valid.
@@ -67,7 +167,8 @@ func (nnp NeuralNetworkParameters) LearningRate() float64 {
 //   - InitialLearningRate: set to 0.01
 //   - EpochCount: set to 5
 //   - ClipValue: if not provided, set to +inf
-func (nnp *NeuralNetworkParameters) Validate() {
+//   - Optimizer: if not provided, set to SGD
+func (nnp *NeuralNetworkParameters[T]) Validate() {
 	if nnp.InitialLearningRate == 0 {
 		nnp.InitialLearningRate = defaultLearningRate
 	}
@@ -77,16 +178,66 @@ func (nnp *NeuralNetworkParameters) Validate() {
 	if nnp.ClipValue == 0 {
 		nnp.ClipValue = math.Inf(1)
 	}
+	if nnp.Optimizer == nil {
+		nnp.Optimizer = &optimizer.SGD[T]{}
+	}
 }
 
-// ResetEpoch resets current epoch count to 0. Epoch count may influence
-// the learning rate, based on learning rate decay.
-func (nnp *NeuralNetworkParameters) ResetEpoch() {
-	nnp.currentEpoch = 0
+// NamedMetrics returns all the metrics to be computed for the ANN, i.e. AccuracyMetric
+// (if set) named "accuracy", followed by Metrics.
+func (nnp NeuralNetworkParameters[T]) NamedMetrics() []metric.NamedMetric[T] {
+	var metrics []metric.NamedMetric[T]
+	if nnp.AccuracyMetric != nil {
+		metrics = append(metrics, metric.NamedMetric[T]{Name: accuracyMetricName, Metric: nnp.AccuracyMetric})
+	}
+	return append(metrics, nnp.Metrics...)
+}
+
+// ResetEpoch resets current epoch and batch count to 0, or to the step the training
+// is resumed from (see Resume). Epoch count may influence the learning rate, based
+// on learning rate decay.
+func (nnp *NeuralNetworkParameters[T]) ResetEpoch() {
+	nnp.currentEpoch = nnp.initialStep.Epoch
+	nnp.currentBatch = nnp.initialStep.Batch
+}
+
+// State returns the current state of the training, i.e. the learning rate scheduler
+// and the epochs and batches passed.
+func (nnp NeuralNetworkParameters[T]) State() TrainingState {
+	return TrainingState{
+		Step:      scheduler.Step{Epoch: nnp.currentEpoch, Batch: nnp.currentBatch},
+		Scheduler: nnp.LearningRateScheduler,
+	}
+}
+
+// Resume sets the training to continue from the state given, e.g. loaded from
+// a backup. The scheduler of the state replaces LearningRateScheduler (if set),
+// and the step counting starts from the step of the state instead of 0.
+// EpochCount is the number of epochs to train further.
+func (nnp *NeuralNetworkParameters[T]) Resume(state TrainingState) {
+	if state.Scheduler != nil {
+		nnp.LearningRateScheduler = state.Scheduler
+	}
+	nnp.initialStep = state.Step
+	nnp.ResetEpoch()
 }
 
 // IncrementEpoch increments current epoch count by 1. Epoch count may influence
 // the learning rate, based on learning rate decay.
-func (nnp *NeuralNetworkParameters) IncrementEpoch() {
+func (nnp *NeuralNetworkParameters[T]) IncrementEpoch() {
 	nnp.currentEpoch++
 }
+
+// IncrementBatch increments current batch count by 1. Batch count may influence
+// the learning rate, if the scheduler is stepped per batch.
+func (nnp *NeuralNetworkParameters[T]) IncrementBatch() {
+	nnp.currentBatch++
+}
+
+// ObserveCost notifies the learning rate scheduler of the epoch cost,
+// if it implements scheduler.CostObserver.
+func (nnp *NeuralNetworkParameters[T]) ObserveCost(cost float64) {
+	if observer, ok := nnp.LearningRateScheduler.(scheduler.CostObserver); ok {
+		observer.Observe(cost)
+	}
+}